@@ -0,0 +1,142 @@
+// Package addressbook reads contacts from sources outside WhatsApp (CSV files,
+// CardDAV servers) and matches them to WhatsApp users by phone number.
+package addressbook
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// minSuffixDigits is the shortest number that may match by suffix. Shorter
+// numbers (extensions, short codes) only match exactly.
+const minSuffixDigits = 7
+
+// Entry is a single contact with one or more phone numbers.
+type Entry struct {
+	Name   string   `json:"name"`
+	Phones []string `json:"phones"`
+}
+
+// NormalizePhone reduces a phone number to comparable digits: formatting,
+// "+", the "00" international prefix and national trunk zeros are dropped.
+func NormalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimLeft(b.String(), "0")
+}
+
+// Index looks up contact names by phone number.
+type Index struct {
+	byPhone map[string]string
+	// keys sorted longest first so suffix matches prefer the most specific number
+	keys []string
+}
+
+// NewIndex builds an Index from entries. When two entries share a number the
+// first one wins.
+func NewIndex(entries []Entry) *Index {
+	idx := &Index{byPhone: make(map[string]string)}
+	for _, e := range entries {
+		name := strings.TrimSpace(e.Name)
+		if name == "" {
+			continue
+		}
+		for _, p := range e.Phones {
+			key := NormalizePhone(p)
+			if key == "" {
+				continue
+			}
+			if _, exists := idx.byPhone[key]; !exists {
+				idx.byPhone[key] = name
+				idx.keys = append(idx.keys, key)
+			}
+		}
+	}
+	sort.Slice(idx.keys, func(i, j int) bool {
+		if len(idx.keys[i]) != len(idx.keys[j]) {
+			return len(idx.keys[i]) > len(idx.keys[j])
+		}
+		return idx.keys[i] < idx.keys[j]
+	})
+	return idx
+}
+
+// Len returns the number of distinct phone numbers in the index.
+func (idx *Index) Len() int {
+	if idx == nil {
+		return 0
+	}
+	return len(idx.byPhone)
+}
+
+// Lookup returns the contact name for phone, or "" if there is no match.
+// Numbers stored without a country code match by suffix.
+func (idx *Index) Lookup(phone string) string {
+	if idx == nil {
+		return ""
+	}
+	key := NormalizePhone(phone)
+	if key == "" {
+		return ""
+	}
+	if name, ok := idx.byPhone[key]; ok {
+		return name
+	}
+	for _, k := range idx.keys {
+		if len(k) < minSuffixDigits || len(key) < minSuffixDigits {
+			continue
+		}
+		if strings.HasSuffix(key, k) || strings.HasSuffix(k, key) {
+			return idx.byPhone[k]
+		}
+	}
+	return ""
+}
+
+// LoadCSV reads "phone,name" rows from path. A header row is skipped when
+// its first column contains no digits.
+func LoadCSV(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening contacts CSV: %w", err)
+	}
+	defer f.Close()
+	return ReadCSV(f)
+}
+
+// ReadCSV parses "phone,name" rows from r.
+func ReadCSV(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var entries []Entry
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading contacts CSV line %d: %w", line, err)
+		}
+		if len(record) < 2 {
+			continue
+		}
+		if NormalizePhone(record[0]) == "" {
+			continue // header or blank row
+		}
+		entries = append(entries, Entry{
+			Name:   strings.TrimSpace(record[1]),
+			Phones: []string{record[0]},
+		})
+	}
+	return entries, nil
+}
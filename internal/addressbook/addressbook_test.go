@@ -0,0 +1,39 @@
+package addressbook
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	assert.Equal(t, "34612345678", NormalizePhone("+34 612 34 56 78"))
+	assert.Equal(t, "34612345678", NormalizePhone("0034 612-345-678"))
+	assert.Equal(t, "612345678", NormalizePhone("0612 345 678"))
+	assert.Equal(t, "", NormalizePhone("Phone"))
+}
+
+func TestIndexLookupMatchesExactAndBySuffix(t *testing.T) {
+	idx := NewIndex([]Entry{
+		{Name: "Alice", Phones: []string{"+1 (555) 123-4567"}},
+		{Name: "Bob", Phones: []string{"0612 345 678"}}, // stored without country code
+		{Name: "Short", Phones: []string{"112"}},
+	})
+
+	assert.Equal(t, 3, idx.Len())
+	assert.Equal(t, "Alice", idx.Lookup("15551234567"))
+	assert.Equal(t, "Bob", idx.Lookup("31612345678"))
+	assert.Equal(t, "Short", idx.Lookup("112"))
+	assert.Equal(t, "", idx.Lookup("49112"), "short numbers must not match by suffix")
+	assert.Equal(t, "", idx.Lookup("999999999"))
+}
+
+func TestReadCSVSkipsHeaderAndBlankRows(t *testing.T) {
+	entries, err := ReadCSV(strings.NewReader("phone,name\n+15551234567, Alice Smith\n\n34612345678,Bob\n"))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Alice Smith", entries[0].Name)
+	assert.Equal(t, []string{"34612345678"}, entries[1].Phones)
+}
@@ -0,0 +1,185 @@
+package addressbook
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const addressbookQuery = `<?xml version="1.0" encoding="utf-8"?>
+<C:addressbook-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:carddav">
+  <D:prop>
+    <D:getetag/>
+    <C:address-data/>
+  </D:prop>
+</C:addressbook-query>`
+
+// CardDAVClient fetches contacts from a CardDAV address book collection.
+type CardDAVClient struct {
+	URL      string
+	Username string
+	Password string
+	// Token, when set, is sent as a Bearer token instead of basic auth.
+	Token      string
+	HTTPClient *http.Client
+}
+
+type multistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Propstats []struct {
+			Status      string `xml:"DAV: status"`
+			AddressData string `xml:"prop>address-data"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// FetchEntries runs an addressbook-query REPORT and returns every contact
+// that has a name and at least one phone number.
+func (c *CardDAVClient) FetchEntries(ctx context.Context) ([]Entry, error) {
+	if strings.TrimSpace(c.URL) == "" {
+		return nil, fmt.Errorf("carddav URL is required")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "REPORT", c.URL, strings.NewReader(addressbookQuery))
+	if err != nil {
+		return nil, fmt.Errorf("building carddav request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Depth", "1")
+	switch {
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	case c.Username != "":
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 60 * time.Second}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("querying carddav server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("carddav server returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("parsing carddav response: %w", err)
+	}
+
+	var entries []Entry
+	for _, r := range ms.Responses {
+		for _, ps := range r.Propstats {
+			if ps.Status != "" && !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+			for _, card := range ParseVCards(ps.AddressData) {
+				if card.Name != "" && len(card.Phones) > 0 {
+					entries = append(entries, card)
+				}
+			}
+		}
+	}
+	return entries, nil
+}
+
+// ParseVCards extracts the display name and phone numbers from one or more
+// vCards. Unknown properties are ignored.
+func ParseVCards(data string) []Entry {
+	var entries []Entry
+	var current *Entry
+	var structuredName string
+
+	for _, line := range unfoldVCardLines(data) {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		// Drop parameters ("TEL;TYPE=cell") and group prefixes ("item1.TEL").
+		prop, _, _ := strings.Cut(name, ";")
+		if i := strings.LastIndex(prop, "."); i >= 0 {
+			prop = prop[i+1:]
+		}
+
+		switch strings.ToUpper(prop) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VCARD") {
+				current = &Entry{}
+				structuredName = ""
+			}
+		case "END":
+			if current != nil && strings.EqualFold(value, "VCARD") {
+				if current.Name == "" {
+					current.Name = structuredName
+				}
+				entries = append(entries, *current)
+				current = nil
+			}
+		case "FN":
+			if current != nil {
+				current.Name = strings.TrimSpace(unescapeVCard(value))
+			}
+		case "N":
+			if current != nil {
+				structuredName = nameFromN(value)
+			}
+		case "TEL":
+			if current != nil {
+				phone := strings.TrimPrefix(strings.TrimSpace(value), "tel:")
+				if phone != "" {
+					current.Phones = append(current.Phones, phone)
+				}
+			}
+		}
+	}
+	return entries
+}
+
+// unfoldVCardLines joins folded continuation lines (RFC 6350 §3.2).
+func unfoldVCardLines(data string) []string {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// nameFromN turns "Family;Given;Additional;Prefix;Suffix" into "Given Family".
+func nameFromN(value string) string {
+	parts := strings.Split(value, ";")
+	var given, family string
+	if len(parts) > 0 {
+		family = strings.TrimSpace(unescapeVCard(parts[0]))
+	}
+	if len(parts) > 1 {
+		given = strings.TrimSpace(unescapeVCard(parts[1]))
+	}
+	return strings.TrimSpace(given + " " + family)
+}
+
+var vcardUnescaper = strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`)
+
+func unescapeVCard(s string) string {
+	return vcardUnescaper.Replace(s)
+}
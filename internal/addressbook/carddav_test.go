@@ -0,0 +1,88 @@
+package addressbook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const multistatusBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:card="urn:ietf:params:xml:ns:carddav">
+  <d:response>
+    <d:href>/addressbooks/me/contacts/alice.vcf</d:href>
+    <d:propstat>
+      <d:prop>
+        <d:getetag>"1"</d:getetag>
+        <card:address-data>BEGIN:VCARD
+VERSION:3.0
+FN:Alice Smith
+item1.TEL;TYPE=cell:+1 555 123
+ 4567
+END:VCARD
+</card:address-data>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+  <d:response>
+    <d:href>/addressbooks/me/contacts/nophone.vcf</d:href>
+    <d:propstat>
+      <d:prop>
+        <card:address-data>BEGIN:VCARD
+VERSION:3.0
+FN:No Phone
+END:VCARD
+</card:address-data>
+      </d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`
+
+func TestCardDAVClientFetchEntries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "REPORT", r.Method)
+		assert.Equal(t, "1", r.Header.Get("Depth"))
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "me", user)
+		assert.Equal(t, "secret", pass)
+		body, _ := io.ReadAll(r.Body)
+		assert.Contains(t, string(body), "addressbook-query")
+
+		w.WriteHeader(http.StatusMultiStatus)
+		io.WriteString(w, multistatusBody)
+	}))
+	defer srv.Close()
+
+	c := &CardDAVClient{URL: srv.URL, Username: "me", Password: "secret"}
+	entries, err := c.FetchEntries(context.Background())
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Alice Smith", entries[0].Name)
+	assert.Equal(t, []string{"+1 555 1234567"}, entries[0].Phones)
+}
+
+func TestCardDAVClientReportsHTTPErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c := &CardDAVClient{URL: srv.URL}
+	_, err := c.FetchEntries(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
+
+func TestParseVCardsFallsBackToStructuredName(t *testing.T) {
+	entries := ParseVCards("BEGIN:VCARD\r\nN:Doe;Jane;;;\r\nTEL:tel:+34612345678\r\nEND:VCARD\r\n")
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Jane Doe", entries[0].Name)
+	assert.Equal(t, []string{"+34612345678"}, entries[0].Phones)
+}
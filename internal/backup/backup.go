@@ -0,0 +1,301 @@
+// Package backup reads the message databases WhatsApp keeps on the phone:
+// Android's msgstore.db (plain or as a crypt15 backup) and iOS's
+// ChatStorage.sqlite. They hold history that linked devices never receive.
+package backup
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Backup formats recognized by Open.
+const (
+	FormatAndroid       = "android"
+	FormatAndroidLegacy = "android-legacy"
+	FormatIOS           = "ios"
+)
+
+var sqliteMagic = []byte("SQLite format 3\x00")
+
+// Chat is a conversation found in a backup.
+type Chat struct {
+	JID  string
+	Name string
+}
+
+// Message is a message found in a backup. Sender is the participant JID in
+// groups and the chat JID otherwise, like messages from history sync.
+type Message struct {
+	ID        string
+	ChatJID   string
+	Sender    string
+	Content   string
+	Timestamp time.Time
+	IsFromMe  bool
+	MediaType string
+}
+
+// Reader iterates over the chats and messages of an opened backup.
+type Reader struct {
+	db     *sql.DB
+	format string
+	tmp    string
+}
+
+// Open reads a backup file. Encrypted Android backups (.crypt15) need the
+// root key from LoadKey; plain SQLite databases ignore it. An encrypted
+// backup is decrypted into a file in workDir readable only by the user,
+// which Close removes; pass a private directory such as the store.
+func Open(path string, key []byte, workDir string) (*Reader, error) {
+	plain, err := isSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dbPath := path
+	tmp := ""
+	if !plain {
+		if key == nil {
+			return nil, fmt.Errorf("%s is not a SQLite database; encrypted backups need --key", filepath.Base(path))
+		}
+		if tmp, err = decryptToTemp(path, key, workDir); err != nil {
+			return nil, err
+		}
+		dbPath = tmp
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		if tmp != "" {
+			os.Remove(tmp)
+		}
+		return nil, err
+	}
+	r := &Reader{db: db, tmp: tmp}
+	if r.format, err = detectFormat(db); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func isSQLite(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	header := make([]byte, len(sqliteMagic))
+	if _, err := io.ReadFull(f, header); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	return bytes.Equal(header, sqliteMagic), nil
+}
+
+// decryptToTemp decrypts a crypt15 backup into a temporary SQLite file in
+// dir and returns its path. The file is created with mode 0600.
+func decryptToTemp(path string, key []byte, dir string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	plain, err := decryptCrypt15(data, key)
+	if err != nil {
+		return "", err
+	}
+	if !bytes.HasPrefix(plain, sqliteMagic) {
+		return "", errors.New("decrypted backup is not a SQLite database")
+	}
+	f, err := os.CreateTemp(dir, ".backup-*.db")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(plain)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func detectFormat(db *sql.DB) (string, error) {
+	tables := map[string]bool{}
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table'`)
+	if err != nil {
+		return "", fmt.Errorf("reading backup schema: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return "", err
+		}
+		tables[name] = true
+	}
+	switch {
+	case tables["message"] && tables["chat"] && tables["jid"]:
+		return FormatAndroid, nil
+	case tables["messages"] && tables["chat_list"]:
+		return FormatAndroidLegacy, nil
+	case tables["ZWAMESSAGE"] && tables["ZWACHATSESSION"]:
+		return FormatIOS, nil
+	}
+	return "", errors.New("not a WhatsApp message database (expected msgstore.db or ChatStorage.sqlite)")
+}
+
+// Format returns which kind of database the backup holds.
+func (r *Reader) Format() string {
+	return r.format
+}
+
+// Close releases the database and removes any decrypted copy.
+func (r *Reader) Close() error {
+	err := r.db.Close()
+	if r.tmp != "" {
+		os.Remove(r.tmp)
+	}
+	return err
+}
+
+// Chats lists the backup's conversations. Names are only known for groups
+// on Android; iOS also keeps contact names.
+func (r *Reader) Chats() ([]Chat, error) {
+	var query string
+	switch r.format {
+	case FormatAndroid:
+		query = `SELECT j.raw_string, COALESCE(c.subject, '') FROM chat c JOIN jid j ON c.jid_row_id = j._id`
+	case FormatAndroidLegacy:
+		query = `SELECT key_remote_jid, COALESCE(subject, '') FROM chat_list`
+	case FormatIOS:
+		query = `SELECT ZCONTACTJID, COALESCE(ZPARTNERNAME, '') FROM ZWACHATSESSION`
+	}
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Chat
+	for rows.Next() {
+		var c Chat
+		var jid sql.NullString
+		if err := rows.Scan(&jid, &c.Name); err != nil {
+			return nil, err
+		}
+		c.JID = jid.String
+		if skipChat(c.JID) {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// Messages calls fn for each message in the backup, oldest first. System
+// messages (group changes, security notices) are skipped.
+func (r *Reader) Messages(fn func(Message) error) error {
+	switch r.format {
+	case FormatAndroid:
+		return r.scan(`
+			SELECT m.key_id, cj.raw_string, COALESCE(sj.raw_string, ''), m.from_me,
+			       m.timestamp, COALESCE(m.text_data, ''), m.message_type
+			FROM message m
+			JOIN chat c ON m.chat_row_id = c._id
+			JOIN jid cj ON c.jid_row_id = cj._id
+			LEFT JOIN jid sj ON m.sender_jid_row_id = sj._id
+			WHERE m.key_id IS NOT NULL AND m.key_id != '' AND m.message_type != 7
+			ORDER BY m.timestamp`, androidTime, androidMediaTypes, fn)
+	case FormatAndroidLegacy:
+		return r.scan(`
+			SELECT key_id, key_remote_jid, COALESCE(remote_resource, ''), key_from_me,
+			       timestamp, COALESCE(data, ''), CAST(COALESCE(media_wa_type, '0') AS INTEGER)
+			FROM messages
+			WHERE key_id IS NOT NULL AND key_remote_jid != '-1' AND status != 6
+			ORDER BY timestamp`, androidTime, androidMediaTypes, fn)
+	case FormatIOS:
+		return r.scan(`
+			SELECT m.ZSTANZAID, s.ZCONTACTJID, COALESCE(g.ZMEMBERJID, ''), m.ZISFROMME,
+			       m.ZMESSAGEDATE, COALESCE(m.ZTEXT, ''), m.ZMESSAGETYPE
+			FROM ZWAMESSAGE m
+			JOIN ZWACHATSESSION s ON m.ZCHATSESSION = s.Z_PK
+			LEFT JOIN ZWAGROUPMEMBER g ON m.ZGROUPMEMBER = g.Z_PK
+			WHERE m.ZSTANZAID IS NOT NULL AND m.ZMESSAGETYPE != 6
+			ORDER BY m.ZMESSAGEDATE`, iosTime, iosMediaTypes, fn)
+	}
+	return fmt.Errorf("unsupported backup format %q", r.format)
+}
+
+func (r *Reader) scan(query string, toTime func(float64) time.Time, mediaTypes map[int]string, fn func(Message) error) error {
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m Message
+		var chatJID sql.NullString
+		var ts float64
+		var msgType int
+		if err := rows.Scan(&m.ID, &chatJID, &m.Sender, &m.IsFromMe, &ts, &m.Content, &msgType); err != nil {
+			return err
+		}
+		m.ChatJID = chatJID.String
+		if skipChat(m.ChatJID) {
+			continue
+		}
+		if m.Sender == "" {
+			m.Sender = m.ChatJID
+		}
+		m.Timestamp = toTime(ts)
+		m.MediaType = mediaTypes[msgType]
+		if m.Content == "" {
+			m.Content = placeholders[m.MediaType]
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// skipChat drops status updates, which expire after a day and never show
+// up in synced history either.
+func skipChat(jid string) bool {
+	return jid == "" || strings.HasPrefix(jid, "status@")
+}
+
+// androidTime converts Android's millisecond timestamps.
+func androidTime(ms float64) time.Time {
+	return time.UnixMilli(int64(ms))
+}
+
+// appleEpoch is the Unix time of 2001-01-01, the reference date of Core
+// Data timestamps on iOS.
+const appleEpoch = 978307200
+
+func iosTime(seconds float64) time.Time {
+	return time.Unix(appleEpoch, 0).Add(time.Duration(seconds * float64(time.Second)))
+}
+
+// Message types mapped onto the media types used by sync. GIFs are videos,
+// as they are on the wire.
+var (
+	androidMediaTypes = map[int]string{1: "image", 2: "audio", 3: "video", 9: "document", 13: "video", 20: "sticker"}
+	iosMediaTypes     = map[int]string{1: "image", 2: "video", 3: "audio", 8: "document", 11: "video", 15: "sticker"}
+)
+
+// placeholders stand in for content-less messages, matching sync.
+var placeholders = map[string]string{"audio": "[Audio]", "sticker": "[Sticker]"}
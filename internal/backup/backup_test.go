@@ -0,0 +1,202 @@
+package backup
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/md5"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func createDB(t *testing.T, schema string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "backup.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(schema)
+	require.NoError(t, err)
+	return path
+}
+
+const androidSchema = `
+	CREATE TABLE jid (_id INTEGER PRIMARY KEY, user TEXT, server TEXT, raw_string TEXT);
+	CREATE TABLE chat (_id INTEGER PRIMARY KEY, jid_row_id INTEGER, subject TEXT);
+	CREATE TABLE message (_id INTEGER PRIMARY KEY, chat_row_id INTEGER, from_me INTEGER, key_id TEXT,
+		sender_jid_row_id INTEGER, timestamp INTEGER, message_type INTEGER, text_data TEXT);
+	INSERT INTO jid VALUES (1, '34611111111', 's.whatsapp.net', '34611111111@s.whatsapp.net'),
+		(2, '1203630001', 'g.us', '1203630001@g.us'),
+		(3, '34622222222', 's.whatsapp.net', '34622222222@s.whatsapp.net'),
+		(4, 'status', 'broadcast', 'status@broadcast');
+	INSERT INTO chat VALUES (1, 1, NULL), (2, 2, 'Family'), (3, 4, NULL);
+	INSERT INTO message VALUES
+		(1, 1, 0, 'A1', 0, 1500000000000, 0, 'hello'),
+		(2, 1, 1, 'A2', 0, 1500000060000, 1, 'look'),
+		(3, 2, 0, 'G1', 3, 1500000120000, 2, NULL),
+		(4, 2, 0, 'SYS', 0, 1500000130000, 7, NULL),
+		(5, 3, 0, 'S1', 1, 1500000140000, 0, 'status');
+`
+
+// TestOpenReadsAndroidDatabase verifies chats and messages are read from a
+// plain msgstore.db, skipping system messages and statuses.
+func TestOpenReadsAndroidDatabase(t *testing.T) {
+	r, err := Open(createDB(t, androidSchema), nil, t.TempDir())
+	require.NoError(t, err)
+	defer r.Close()
+	assert.Equal(t, FormatAndroid, r.Format())
+
+	chats, err := r.Chats()
+	require.NoError(t, err)
+	assert.Equal(t, []Chat{{JID: "34611111111@s.whatsapp.net"}, {JID: "1203630001@g.us", Name: "Family"}}, chats)
+
+	var messages []Message
+	require.NoError(t, r.Messages(func(m Message) error {
+		messages = append(messages, m)
+		return nil
+	}))
+	require.Len(t, messages, 3)
+	assert.Equal(t, Message{ID: "A1", ChatJID: "34611111111@s.whatsapp.net", Sender: "34611111111@s.whatsapp.net",
+		Content: "hello", Timestamp: time.UnixMilli(1500000000000)}, messages[0])
+	assert.True(t, messages[1].IsFromMe)
+	assert.Equal(t, "image", messages[1].MediaType)
+	assert.Equal(t, "34622222222@s.whatsapp.net", messages[2].Sender)
+	assert.Equal(t, "[Audio]", messages[2].Content)
+}
+
+// TestOpenReadsIOSDatabase verifies ChatStorage.sqlite timestamps, names
+// and group senders.
+func TestOpenReadsIOSDatabase(t *testing.T) {
+	path := createDB(t, `
+		CREATE TABLE ZWACHATSESSION (Z_PK INTEGER PRIMARY KEY, ZCONTACTJID TEXT, ZPARTNERNAME TEXT);
+		CREATE TABLE ZWAGROUPMEMBER (Z_PK INTEGER PRIMARY KEY, ZMEMBERJID TEXT);
+		CREATE TABLE ZWAMESSAGE (Z_PK INTEGER PRIMARY KEY, ZCHATSESSION INTEGER, ZGROUPMEMBER INTEGER,
+			ZISFROMME INTEGER, ZMESSAGEDATE REAL, ZTEXT TEXT, ZMESSAGETYPE INTEGER, ZSTANZAID TEXT);
+		INSERT INTO ZWACHATSESSION VALUES (1, '1203630001@g.us', 'Family');
+		INSERT INTO ZWAGROUPMEMBER VALUES (1, '34622222222@s.whatsapp.net');
+		INSERT INTO ZWAMESSAGE VALUES (1, 1, 1, 0, 600000000.5, 'hi all', 0, 'I1'),
+			(2, 1, NULL, 0, 600000001, NULL, 6, 'I2');
+	`)
+	r, err := Open(path, nil, t.TempDir())
+	require.NoError(t, err)
+	defer r.Close()
+	assert.Equal(t, FormatIOS, r.Format())
+
+	chats, err := r.Chats()
+	require.NoError(t, err)
+	assert.Equal(t, []Chat{{JID: "1203630001@g.us", Name: "Family"}}, chats)
+
+	var messages []Message
+	require.NoError(t, r.Messages(func(m Message) error {
+		messages = append(messages, m)
+		return nil
+	}))
+	require.Len(t, messages, 1)
+	assert.Equal(t, "34622222222@s.whatsapp.net", messages[0].Sender)
+	assert.Equal(t, time.Date(2020, 1, 6, 10, 40, 0, 500000000, time.UTC), messages[0].Timestamp.UTC())
+}
+
+// writeCrypt15 encrypts plain the way WhatsApp does, with a trailing MD5
+// checksum.
+func writeCrypt15(t *testing.T, plain, rootKey []byte) string {
+	t.Helper()
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, err := zw.Write(plain)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	iv := bytes.Repeat([]byte{7}, 16)
+	var header []byte
+	header = protowire.AppendTag(header, 1, protowire.VarintType)
+	header = protowire.AppendVarint(header, 1)
+	ivMsg := protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), iv)
+	header = protowire.AppendTag(header, 3, protowire.BytesType)
+	header = protowire.AppendBytes(header, ivMsg)
+
+	key, err := hkdf.Key(sha256.New, rootKey, make([]byte, 32), "backup encryption", 32)
+	require.NoError(t, err)
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	require.NoError(t, err)
+
+	data := append([]byte{byte(len(header)), 0x01}, header...)
+	data = gcm.Seal(data, iv, compressed.Bytes(), nil)
+	sum := md5.Sum(data)
+	data = append(data, sum[:]...)
+
+	path := filepath.Join(t.TempDir(), "msgstore.db.crypt15")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+// TestOpenDecryptsCrypt15 verifies an encrypted backup is decrypted with
+// its root key and rejected with any other.
+func TestOpenDecryptsCrypt15(t *testing.T) {
+	plain, err := os.ReadFile(createDB(t, androidSchema))
+	require.NoError(t, err)
+	rootKey := bytes.Repeat([]byte{0xab}, KeySize)
+	path := writeCrypt15(t, plain, rootKey)
+
+	workDir := t.TempDir()
+	_, err = Open(path, nil, workDir)
+	require.ErrorContains(t, err, "need --key")
+
+	_, err = Open(path, bytes.Repeat([]byte{1}, KeySize), workDir)
+	require.ErrorContains(t, err, "wrong key")
+
+	r, err := Open(path, rootKey, workDir)
+	require.NoError(t, err)
+	assert.Equal(t, workDir, filepath.Dir(r.tmp), "decrypted into the work directory")
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(r.tmp)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+	assert.Equal(t, FormatAndroid, r.Format())
+	count := 0
+	require.NoError(t, r.Messages(func(Message) error { count++; return nil }))
+	assert.Equal(t, 3, count)
+
+	tmp := r.tmp
+	require.NoError(t, r.Close())
+	assert.NoFileExists(t, tmp, "the decrypted copy is removed on close")
+}
+
+func TestLoadKeyFormats(t *testing.T) {
+	want := bytes.Repeat([]byte{0xab}, KeySize)
+	hexKey := hex.EncodeToString(want)
+
+	key, err := LoadKey(hexKey)
+	require.NoError(t, err)
+	assert.Equal(t, want, key)
+
+	dir := t.TempDir()
+	spaced := filepath.Join(dir, "key.txt")
+	require.NoError(t, os.WriteFile(spaced, []byte(hexKey[:32]+" "+hexKey[32:]+"\n"), 0600))
+	key, err = LoadKey(spaced)
+	require.NoError(t, err)
+	assert.Equal(t, want, key)
+
+	serialized := filepath.Join(dir, "encrypted_backup.key")
+	require.NoError(t, os.WriteFile(serialized, append([]byte{0xac, 0xed, 0x00, 0x05, 0x75, 0x72}, want...), 0600))
+	key, err = LoadKey(serialized)
+	require.NoError(t, err)
+	assert.Equal(t, want, key)
+
+	_, err = LoadKey(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
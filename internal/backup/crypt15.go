@@ -0,0 +1,147 @@
+package backup
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// KeySize is the length of the root key of an end-to-end encrypted backup.
+const KeySize = 32
+
+// backupKeyInfo is the HKDF info WhatsApp uses to derive the database
+// encryption key from the root key.
+const backupKeyInfo = "backup encryption"
+
+// LoadKey reads a crypt15 root key. It accepts the 64-digit hex key shown
+// when end-to-end encrypted backup is enabled (inline or in a file), a raw
+// 32-byte key file, or the app's encrypted_backup.key, whose serialized
+// form ends with the key.
+func LoadKey(keyOrPath string) ([]byte, error) {
+	if key, ok := parseHexKey(keyOrPath); ok {
+		return key, nil
+	}
+	data, err := os.ReadFile(keyOrPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading key: %w", err)
+	}
+	if key, ok := parseHexKey(string(data)); ok {
+		return key, nil
+	}
+	if len(data) < KeySize {
+		return nil, fmt.Errorf("key file %s is too short (%d bytes)", keyOrPath, len(data))
+	}
+	return data[len(data)-KeySize:], nil
+}
+
+func parseHexKey(s string) ([]byte, bool) {
+	s = strings.Join(strings.Fields(s), "")
+	if len(s) != 2*KeySize {
+		return nil, false
+	}
+	key, err := hex.DecodeString(s)
+	return key, err == nil
+}
+
+// decryptCrypt15 turns a msgstore.db.crypt15 file into the SQLite database
+// it contains. The file is a length-prefixed protobuf header carrying the
+// IV, then the AES-GCM encrypted, zlib-compressed database and its tag,
+// usually followed by an MD5 checksum of everything before it.
+func decryptCrypt15(data, rootKey []byte) ([]byte, error) {
+	if len(rootKey) != KeySize {
+		return nil, fmt.Errorf("crypt15 key must be %d bytes, got %d", KeySize, len(rootKey))
+	}
+	if len(data) < 2 {
+		return nil, errors.New("file too short to be a crypt15 backup")
+	}
+	headerSize := int(data[0])
+	offset := 1
+	// A 0x01 after the size flags a feature table in the header.
+	if data[1] == 0x01 {
+		offset++
+	}
+	if len(data) < offset+headerSize {
+		return nil, errors.New("truncated crypt15 header")
+	}
+	iv, err := crypt15IV(data[offset : offset+headerSize])
+	if err != nil {
+		return nil, err
+	}
+	payload := data[offset+headerSize:]
+
+	if n := len(data); n > 16 {
+		if sum := md5.Sum(data[:n-16]); bytes.Equal(sum[:], data[n-16:]) {
+			payload = payload[:len(payload)-16]
+		}
+	}
+
+	key, err := hkdf.Key(sha256.New, rootKey, make([]byte, 32), backupKeyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := gcm.Open(nil, iv, payload, nil)
+	if err != nil {
+		return nil, errors.New("decryption failed: wrong key or not a crypt15 backup")
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing backup: %w", err)
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// crypt15IV extracts the IV from the backup header (BackupPrefix field 3,
+// C15_IV, whose field 1 holds the IV).
+func crypt15IV(header []byte) ([]byte, error) {
+	iv := protoBytesField(protoBytesField(header, 3), 1)
+	if len(iv) == 0 {
+		return nil, errors.New("crypt15 header has no IV")
+	}
+	return iv, nil
+}
+
+// protoBytesField returns the first length-delimited field num in msg.
+func protoBytesField(msg []byte, num protowire.Number) []byte {
+	for len(msg) > 0 {
+		n, typ, tagLen := protowire.ConsumeTag(msg)
+		if tagLen < 0 {
+			return nil
+		}
+		msg = msg[tagLen:]
+		if n == num && typ == protowire.BytesType {
+			v, l := protowire.ConsumeBytes(msg)
+			if l < 0 {
+				return nil
+			}
+			return v
+		}
+		l := protowire.ConsumeFieldValue(n, typ, msg)
+		if l < 0 {
+			return nil
+		}
+		msg = msg[l:]
+	}
+	return nil
+}
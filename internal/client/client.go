@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"math"
 	"mime"
@@ -13,13 +14,13 @@ import (
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/mdp/qrterminal"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
 	"github.com/vicentereig/whatsapp-cli/internal/types"
 	"go.mau.fi/whatsmeow"
 	waProto "go.mau.fi/whatsmeow/binary/proto"
 	"go.mau.fi/whatsmeow/store/sqlstore"
 	waTypes "go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
-	waLog "go.mau.fi/whatsmeow/util/log"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -29,6 +30,7 @@ type WAClient struct {
 	eventHandler    func(interface{})
 	contactLookup   func(ctx context.Context, user waTypes.JID) (waTypes.ContactInfo, error)
 	groupInfoLookup func(ctx context.Context, jid waTypes.JID) (*waTypes.GroupInfo, error)
+	nameProviders   []NameProvider
 }
 
 type MediaInfo struct {
@@ -45,13 +47,20 @@ type MediaInfo struct {
 }
 
 type MessageDetails struct {
-	ID        string
-	ChatJID   string
-	Sender    string
-	Content   string
-	Timestamp time.Time
-	IsFromMe  bool
-	Media     *MediaInfo
+	ID          string
+	ChatJID     string
+	Sender      string
+	Content     string
+	Timestamp   time.Time
+	IsFromMe    bool
+	Media       *MediaInfo
+	ReplyToID   string
+	Location    *LocationInfo
+	Commerce    *CommerceInfo
+	Interactive *InteractiveInfo
+	// RawProto is the serialized message, archived so later versions can
+	// re-extract fields this version does not understand.
+	RawProto []byte
 }
 
 
@@ -61,7 +70,7 @@ func NewWAClient(storeDir string) (*WAClient, error) {
 		return nil, fmt.Errorf("failed to create store directory: %v", err)
 	}
 
-	dbLog := waLog.Stdout("Database", "ERROR", true)
+	dbLog := newStderrLogger("Database", levelError)
 	ctx := context.Background()
 	container, err := sqlstore.New(ctx, "sqlite3", fmt.Sprintf("file:%s/whatsapp.db?_foreign_keys=on", storeDir), dbLog)
 	if err != nil {
@@ -77,7 +86,7 @@ func NewWAClient(storeDir string) (*WAClient, error) {
 		}
 	}
 
-	logger := waLog.Stdout("Client", "ERROR", true)
+	logger := newStderrLogger("Client", levelError)
 	client := whatsmeow.NewClient(deviceStore, logger)
 
 	return &WAClient{
@@ -107,7 +116,7 @@ func (w *WAClient) Authenticate(ctx context.Context) error {
 			fmt.Fprintln(os.Stderr, "\nScan this QR code with your WhatsApp app:")
 			qrterminal.GenerateHalfBlock(evt.Code, qrterminal.M, os.Stderr)
 		} else if evt.Event == "success" {
-			fmt.Fprintln(os.Stderr, "\n✓ Successfully authenticated!")
+			fmt.Fprintln(output.Stderr, "\n✓ Successfully authenticated!")
 			return nil
 		}
 	}
@@ -196,6 +205,50 @@ func (w *WAClient) SendImageMessage(ctx context.Context, recipient, imagePath, c
 	return sendResp.ID, nil
 }
 
+// SendInteractiveMessage sends a reply-button or list message built from
+// spec. The spec is expected to be validated by the caller.
+func (w *WAClient) SendInteractiveMessage(ctx context.Context, recipient string, spec types.InteractiveSpec) (string, error) {
+	if !w.client.IsConnected() {
+		return "", fmt.Errorf("not connected to WhatsApp")
+	}
+
+	recipientJID, err := parseJID(recipient)
+	if err != nil {
+		return "", fmt.Errorf("parsing recipient: %w", err)
+	}
+
+	resp, err := w.client.SendMessage(ctx, recipientJID, BuildInteractiveMessage(spec))
+	if err != nil {
+		return "", fmt.Errorf("sending interactive message: %w", err)
+	}
+	return resp.ID, nil
+}
+
+// MarkRead sends read receipts for messages in a chat. In group chats all
+// ids must come from sender; call once per sender.
+func (w *WAClient) MarkRead(ctx context.Context, chatJID, sender string, ids []string) error {
+	if !w.client.IsConnected() {
+		return fmt.Errorf("not connected to WhatsApp")
+	}
+
+	chat, err := parseJID(chatJID)
+	if err != nil {
+		return fmt.Errorf("parsing chat: %w", err)
+	}
+	var senderJID waTypes.JID
+	if sender != "" && sender != "me" {
+		if senderJID, err = parseJID(sender); err != nil {
+			return fmt.Errorf("parsing sender: %w", err)
+		}
+	}
+
+	msgIDs := make([]waTypes.MessageID, len(ids))
+	for i, id := range ids {
+		msgIDs[i] = waTypes.MessageID(id)
+	}
+	return w.client.MarkRead(ctx, msgIDs, time.Now(), chat, senderJID)
+}
+
 func mimeTypeFromExtension(path string) string {
 	ext := strings.ToLower(filepath.Ext(path))
 	if mtype := mime.TypeByExtension(ext); mtype != "" {
@@ -263,22 +316,9 @@ func (w *WAClient) ResolveChatName(ctx context.Context, chatJID string, evt inte
 
 	parsed, err := waTypes.ParseJID(chatJID)
 	if err == nil {
-		// Group chats
-		if parsed.Server == waTypes.GroupServer || parsed.IsBroadcastList() {
-			if w.groupInfoLookup != nil {
-				if info, err := w.groupInfoLookup(ctx, parsed); err == nil && info != nil {
-					if name := strings.TrimSpace(info.GroupName.Name); name != "" {
-						return name
-					}
-				}
-			}
-		} else {
-			if w.contactLookup != nil {
-				if info, err := w.contactLookup(ctx, parsed.ToNonAD()); err == nil {
-					if name := bestContactName(info); name != "" {
-						return name
-					}
-				}
+		for _, p := range w.providers() {
+			if name := strings.TrimSpace(p.ResolveName(ctx, parsed)); name != "" {
+				return name
 			}
 		}
 	}
@@ -319,6 +359,51 @@ func parseJID(recipient string) (waTypes.JID, error) {
 }
 
 
+// GetGroupMetadata fetches a group's info and photo. knownAvatarID is the
+// photo the caller already has; when it is unchanged AvatarURL is left
+// empty so the photo isn't downloaded again.
+func (w *WAClient) GetGroupMetadata(ctx context.Context, groupJID, knownAvatarID string) (types.GroupMetadata, error) {
+	if w == nil || w.client == nil {
+		return types.GroupMetadata{}, fmt.Errorf("whatsapp client is not initialized")
+	}
+	jid, err := waTypes.ParseJID(groupJID)
+	if err != nil {
+		return types.GroupMetadata{}, fmt.Errorf("invalid group JID %q: %w", groupJID, err)
+	}
+	info, err := w.client.GetGroupInfo(ctx, jid)
+	if err != nil {
+		return types.GroupMetadata{}, err
+	}
+
+	meta := types.GroupMetadata{
+		JID:      groupJID,
+		Name:     strings.TrimSpace(info.Name),
+		Topic:    info.Topic,
+		AvatarID: knownAvatarID,
+	}
+	for _, p := range info.Participants {
+		role := types.RoleMember
+		if p.IsSuperAdmin {
+			role = types.RoleSuperAdmin
+		} else if p.IsAdmin {
+			role = types.RoleAdmin
+		}
+		meta.Participants = append(meta.Participants, types.GroupParticipant{JID: p.JID.ToNonAD().String(), Role: role})
+	}
+
+	pic, err := w.client.GetProfilePictureInfo(ctx, jid, &whatsmeow.GetProfilePictureParams{ExistingID: knownAvatarID})
+	switch {
+	case errors.Is(err, whatsmeow.ErrProfilePictureNotSet):
+		meta.AvatarID = ""
+	case err != nil:
+		// Keep the photo we have; the next refresh will try again.
+	case pic != nil:
+		meta.AvatarID = pic.ID
+		meta.AvatarURL = pic.URL
+	}
+	return meta, nil
+}
+
 func (w *WAClient) DownloadMediaToFile(ctx context.Context, req types.MediaDownloadRequest, targetPath string) (int64, error) {
 	if w == nil || w.client == nil {
 		return 0, fmt.Errorf("whatsapp client is not initialized")
@@ -406,79 +491,14 @@ func HandleMessage(msg *events.Message) MessageDetails {
 	}
 
 	if msg.Message != nil {
-		switch {
-		case msg.Message.GetConversation() != "":
-			details.Content = msg.Message.GetConversation()
-		case msg.Message.GetExtendedTextMessage() != nil:
-			details.Content = msg.Message.GetExtendedTextMessage().GetText()
-		}
-
-		if img := msg.Message.GetImageMessage(); img != nil {
-			if details.Content == "" {
-				details.Content = img.GetCaption()
-			}
-			details.Media = &MediaInfo{
-				Type:          "image",
-				Filename:      "",
-				URL:           img.GetURL(),
-				DirectPath:    img.GetDirectPath(),
-				MimeType:      img.GetMimetype(),
-				Caption:       img.GetCaption(),
-				MediaKey:      cloneBytes(img.GetMediaKey()),
-				FileSHA256:    cloneBytes(img.GetFileSHA256()),
-				FileEncSHA256: cloneBytes(img.GetFileEncSHA256()),
-				FileLength:    img.GetFileLength(),
-			}
-		} else if video := msg.Message.GetVideoMessage(); video != nil {
-			if details.Content == "" {
-				details.Content = video.GetCaption()
-			}
-			details.Media = &MediaInfo{
-				Type:          "video",
-				Filename:      "",
-				URL:           video.GetURL(),
-				DirectPath:    video.GetDirectPath(),
-				MimeType:      video.GetMimetype(),
-				Caption:       video.GetCaption(),
-				MediaKey:      cloneBytes(video.GetMediaKey()),
-				FileSHA256:    cloneBytes(video.GetFileSHA256()),
-				FileEncSHA256: cloneBytes(video.GetFileEncSHA256()),
-				FileLength:    video.GetFileLength(),
-			}
-		} else if audio := msg.Message.GetAudioMessage(); audio != nil {
-			if details.Content == "" {
-				details.Content = "[Audio]"
-			}
-			details.Media = &MediaInfo{
-				Type:          "audio",
-				Filename:      "",
-				URL:           audio.GetURL(),
-				DirectPath:    audio.GetDirectPath(),
-				MimeType:      audio.GetMimetype(),
-				Caption:       details.Content,
-				MediaKey:      cloneBytes(audio.GetMediaKey()),
-				FileSHA256:    cloneBytes(audio.GetFileSHA256()),
-				FileEncSHA256: cloneBytes(audio.GetFileEncSHA256()),
-				FileLength:    audio.GetFileLength(),
-			}
-		} else if doc := msg.Message.GetDocumentMessage(); doc != nil {
-			if details.Content == "" {
-				details.Content = doc.GetCaption()
-			}
-			filename := doc.GetFileName()
-			details.Media = &MediaInfo{
-				Type:          "document",
-				Filename:      filename,
-				URL:           doc.GetURL(),
-				DirectPath:    doc.GetDirectPath(),
-				MimeType:      doc.GetMimetype(),
-				Caption:       doc.GetCaption(),
-				MediaKey:      cloneBytes(doc.GetMediaKey()),
-				FileSHA256:    cloneBytes(doc.GetFileSHA256()),
-				FileEncSHA256: cloneBytes(doc.GetFileEncSHA256()),
-				FileLength:    doc.GetFileLength(),
-			}
-		}
+		content := ExtractContent(msg.Message)
+		details.Content = content.Content
+		details.Media = content.Media
+		details.ReplyToID = content.ReplyToID
+		details.Location = content.Location
+		details.Commerce = content.Commerce
+		details.Interactive = content.Interactive
+		details.RawProto = MarshalRaw(msg.Message)
 	}
 
 	return details
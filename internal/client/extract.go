@@ -0,0 +1,472 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	"google.golang.org/protobuf/proto"
+)
+
+// MessageContent is everything the CLI extracts from a message proto. The
+// same extraction runs for live messages, history sync and `store
+// reprocess`, so improving it improves all three.
+type MessageContent struct {
+	Content     string
+	Media       *MediaInfo
+	ReplyToID   string
+	Location    *LocationInfo
+	Commerce    *CommerceInfo
+	Interactive *InteractiveInfo
+}
+
+// Business and payment message types recorded in CommerceInfo.Type.
+const (
+	CommerceOrder          = "order"
+	CommerceInvoice        = "invoice"
+	CommerceProduct        = "product"
+	CommercePayment        = "payment"
+	CommercePaymentRequest = "payment_request"
+	CommercePaymentInvite  = "payment_invite"
+)
+
+// Payment statuses set by the payment messages themselves. History sync
+// may report others (e.g. "complete", "refunded") from WhatsApp's records.
+const (
+	PaymentSent      = "sent"
+	PaymentRequested = "requested"
+	PaymentPaid      = "paid"
+	PaymentDeclined  = "declined"
+	PaymentCancelled = "cancelled"
+)
+
+// CommerceInfo holds the structured fields of a WhatsApp Business order,
+// invoice or product message, or of a payment message. Amounts are in
+// thousandths of the currency unit, as WhatsApp sends them; zero means no
+// amount was given.
+type CommerceInfo struct {
+	Type       string
+	Amount1000 int64
+	Currency   string
+	ItemCount  int
+	Title      string
+	Status     string
+	// RequestID is the payment request a payment, decline or cancellation
+	// answers; RequestStatus is the status that request moves to.
+	RequestID     string
+	RequestStatus string
+}
+
+// LocationInfo is a shared position: a pinned location or one point of a
+// live location. Accuracy, speed and heading are zero when not reported.
+type LocationInfo struct {
+	Live      bool
+	Latitude  float64
+	Longitude float64
+	AccuracyM uint32
+	SpeedMps  float32
+	Heading   uint32
+	// Sequence orders the updates of a live location; pinned locations
+	// use 0.
+	Sequence int64
+	Label    string
+}
+
+// unwrapMessage strips container messages (disappearing, view-once,
+// captioned documents, edits) that wrap the real content. Live messages are
+// unwrapped by whatsmeow, but history sync delivers them as-is.
+func unwrapMessage(m *waProto.Message) *waProto.Message {
+	for i := 0; m != nil && i < 5; i++ {
+		var inner *waProto.Message
+		switch {
+		case m.GetEphemeralMessage() != nil:
+			inner = m.GetEphemeralMessage().GetMessage()
+		case m.GetViewOnceMessage() != nil:
+			inner = m.GetViewOnceMessage().GetMessage()
+		case m.GetViewOnceMessageV2() != nil:
+			inner = m.GetViewOnceMessageV2().GetMessage()
+		case m.GetViewOnceMessageV2Extension() != nil:
+			inner = m.GetViewOnceMessageV2Extension().GetMessage()
+		case m.GetDocumentWithCaptionMessage() != nil:
+			inner = m.GetDocumentWithCaptionMessage().GetMessage()
+		case m.GetEditedMessage() != nil:
+			inner = m.GetEditedMessage().GetMessage()
+		}
+		if inner == nil {
+			return m
+		}
+		m = inner
+	}
+	return m
+}
+
+// ExtractContent returns the text, media metadata and reply reference of a
+// message. Unknown message types yield an empty MessageContent.
+func ExtractContent(m *waProto.Message) MessageContent {
+	var out MessageContent
+	m = unwrapMessage(m)
+	if m == nil {
+		return out
+	}
+
+	switch {
+	case m.GetConversation() != "":
+		out.Content = m.GetConversation()
+	case m.GetExtendedTextMessage() != nil:
+		out.Content = m.GetExtendedTextMessage().GetText()
+	}
+
+	if img := m.GetImageMessage(); img != nil {
+		if out.Content == "" {
+			out.Content = img.GetCaption()
+		}
+		out.Media = &MediaInfo{
+			Type:          "image",
+			URL:           img.GetURL(),
+			DirectPath:    img.GetDirectPath(),
+			MimeType:      img.GetMimetype(),
+			Caption:       img.GetCaption(),
+			MediaKey:      cloneBytes(img.GetMediaKey()),
+			FileSHA256:    cloneBytes(img.GetFileSHA256()),
+			FileEncSHA256: cloneBytes(img.GetFileEncSHA256()),
+			FileLength:    img.GetFileLength(),
+		}
+	} else if video := m.GetVideoMessage(); video != nil {
+		if out.Content == "" {
+			out.Content = video.GetCaption()
+		}
+		out.Media = &MediaInfo{
+			Type:          "video",
+			URL:           video.GetURL(),
+			DirectPath:    video.GetDirectPath(),
+			MimeType:      video.GetMimetype(),
+			Caption:       video.GetCaption(),
+			MediaKey:      cloneBytes(video.GetMediaKey()),
+			FileSHA256:    cloneBytes(video.GetFileSHA256()),
+			FileEncSHA256: cloneBytes(video.GetFileEncSHA256()),
+			FileLength:    video.GetFileLength(),
+		}
+	} else if audio := m.GetAudioMessage(); audio != nil {
+		if out.Content == "" {
+			out.Content = "[Audio]"
+		}
+		out.Media = &MediaInfo{
+			Type:          "audio",
+			URL:           audio.GetURL(),
+			DirectPath:    audio.GetDirectPath(),
+			MimeType:      audio.GetMimetype(),
+			Caption:       out.Content,
+			MediaKey:      cloneBytes(audio.GetMediaKey()),
+			FileSHA256:    cloneBytes(audio.GetFileSHA256()),
+			FileEncSHA256: cloneBytes(audio.GetFileEncSHA256()),
+			FileLength:    audio.GetFileLength(),
+		}
+	} else if doc := m.GetDocumentMessage(); doc != nil {
+		if out.Content == "" {
+			out.Content = doc.GetCaption()
+		}
+		out.Media = &MediaInfo{
+			Type:          "document",
+			Filename:      doc.GetFileName(),
+			URL:           doc.GetURL(),
+			DirectPath:    doc.GetDirectPath(),
+			MimeType:      doc.GetMimetype(),
+			Caption:       doc.GetCaption(),
+			MediaKey:      cloneBytes(doc.GetMediaKey()),
+			FileSHA256:    cloneBytes(doc.GetFileSHA256()),
+			FileEncSHA256: cloneBytes(doc.GetFileEncSHA256()),
+			FileLength:    doc.GetFileLength(),
+		}
+	} else if sticker := m.GetStickerMessage(); sticker != nil {
+		if out.Content == "" {
+			out.Content = "[Sticker]"
+		}
+		out.Media = &MediaInfo{
+			Type:          "sticker",
+			URL:           sticker.GetURL(),
+			DirectPath:    sticker.GetDirectPath(),
+			MimeType:      sticker.GetMimetype(),
+			MediaKey:      cloneBytes(sticker.GetMediaKey()),
+			FileSHA256:    cloneBytes(sticker.GetFileSHA256()),
+			FileEncSHA256: cloneBytes(sticker.GetFileEncSHA256()),
+			FileLength:    sticker.GetFileLength(),
+		}
+	} else if loc := m.GetLocationMessage(); loc != nil {
+		out.Content = describeLocation(loc.GetName(), loc.GetAddress(), loc.GetDegreesLatitude(), loc.GetDegreesLongitude())
+		out.Location = &LocationInfo{
+			Latitude:  loc.GetDegreesLatitude(),
+			Longitude: loc.GetDegreesLongitude(),
+			AccuracyM: loc.GetAccuracyInMeters(),
+			SpeedMps:  loc.GetSpeedInMps(),
+			Heading:   loc.GetDegreesClockwiseFromMagneticNorth(),
+			Label:     strings.Join(nonEmpty(loc.GetName(), loc.GetAddress()), ", "),
+		}
+	} else if live := m.GetLiveLocationMessage(); live != nil {
+		out.Content = "[Live location] " + describeCoordinates(live.GetCaption(), live.GetDegreesLatitude(), live.GetDegreesLongitude())
+		out.Location = &LocationInfo{
+			Live:      true,
+			Latitude:  live.GetDegreesLatitude(),
+			Longitude: live.GetDegreesLongitude(),
+			AccuracyM: live.GetAccuracyInMeters(),
+			SpeedMps:  live.GetSpeedInMps(),
+			Heading:   live.GetDegreesClockwiseFromMagneticNorth(),
+			Sequence:  live.GetSequenceNumber(),
+			Label:     strings.TrimSpace(live.GetCaption()),
+		}
+	} else if order := m.GetOrderMessage(); order != nil {
+		out.Commerce = &CommerceInfo{
+			Type:       CommerceOrder,
+			Amount1000: order.GetTotalAmount1000(),
+			Currency:   order.GetTotalCurrencyCode(),
+			ItemCount:  int(order.GetItemCount()),
+			Title:      strings.TrimSpace(order.GetOrderTitle()),
+		}
+		out.Content = describeCommerce("[Order]", out.Commerce, order.GetMessage())
+	} else if invoice := m.GetInvoiceMessage(); invoice != nil {
+		out.Commerce = &CommerceInfo{Type: CommerceInvoice}
+		out.Content = describeCommerce("[Invoice]", out.Commerce, invoice.GetNote())
+	} else if product := m.GetProductMessage(); product != nil {
+		snapshot := product.GetProduct()
+		price := snapshot.GetSalePriceAmount1000()
+		if price <= 0 {
+			price = snapshot.GetPriceAmount1000()
+		}
+		out.Commerce = &CommerceInfo{
+			Type:       CommerceProduct,
+			Amount1000: price,
+			Currency:   snapshot.GetCurrencyCode(),
+			ItemCount:  1,
+			Title:      strings.TrimSpace(snapshot.GetTitle()),
+		}
+		out.Content = describeCommerce("[Product]", out.Commerce, product.GetBody())
+	} else if pay := m.GetSendPaymentMessage(); pay != nil {
+		out.Commerce = &CommerceInfo{Type: CommercePayment, Status: PaymentSent}
+		if id := pay.GetRequestMessageKey().GetID(); id != "" {
+			out.Commerce.RequestID = id
+			out.Commerce.RequestStatus = PaymentPaid
+		}
+		out.Content = describeCommerce("[Payment]", out.Commerce, paymentNote(m))
+	} else if req := m.GetRequestPaymentMessage(); req != nil {
+		out.Commerce = &CommerceInfo{
+			Type:       CommercePaymentRequest,
+			Amount1000: int64(req.GetAmount1000()),
+			Currency:   req.GetCurrencyCodeIso4217(),
+			Status:     PaymentRequested,
+		}
+		if amount := req.GetAmount(); amount != nil {
+			setMoney(out.Commerce, amount.GetValue(), amount.GetOffset(), amount.GetCurrencyCode())
+		}
+		out.Content = describeCommerce("[Payment request]", out.Commerce, paymentNote(m))
+	} else if decline := m.GetDeclinePaymentRequestMessage(); decline != nil {
+		out.Commerce = &CommerceInfo{
+			Type:          CommercePaymentRequest,
+			Status:        PaymentDeclined,
+			RequestID:     decline.GetKey().GetID(),
+			RequestStatus: PaymentDeclined,
+		}
+		out.Content = "[Payment request declined]"
+	} else if cancel := m.GetCancelPaymentRequestMessage(); cancel != nil {
+		out.Commerce = &CommerceInfo{
+			Type:          CommercePaymentRequest,
+			Status:        PaymentCancelled,
+			RequestID:     cancel.GetKey().GetID(),
+			RequestStatus: PaymentCancelled,
+		}
+		out.Content = "[Payment request cancelled]"
+	} else if m.GetPaymentInviteMessage() != nil {
+		out.Commerce = &CommerceInfo{Type: CommercePaymentInvite}
+		out.Content = "[Payment invite]"
+	} else if contact := m.GetContactMessage(); contact != nil {
+		out.Content = "[Contact] " + strings.TrimSpace(contact.GetDisplayName())
+	} else if text, info, ok := extractInteractive(m); ok {
+		out.Content = text
+		out.Interactive = info
+	} else if poll := pollCreation(m); poll != nil {
+		var options []string
+		for _, opt := range poll.GetOptions() {
+			options = append(options, opt.GetOptionName())
+		}
+		out.Content = fmt.Sprintf("[Poll] %s: %s", poll.GetName(), strings.Join(options, " / "))
+	}
+
+	out.ReplyToID = replyToID(m)
+	return out
+}
+
+func pollCreation(m *waProto.Message) *waProto.PollCreationMessage {
+	switch {
+	case m.GetPollCreationMessage() != nil:
+		return m.GetPollCreationMessage()
+	case m.GetPollCreationMessageV2() != nil:
+		return m.GetPollCreationMessageV2()
+	case m.GetPollCreationMessageV3() != nil:
+		return m.GetPollCreationMessageV3()
+	}
+	return nil
+}
+
+func describeLocation(name, address string, lat, lon float64) string {
+	return "[Location] " + describeCoordinates(strings.Join(nonEmpty(name, address), ", "), lat, lon)
+}
+
+// setMoney fills the amount from a value with a decimal offset (value 1050
+// with offset 100 is 10.50), WhatsApp's newer amount encoding.
+func setMoney(c *CommerceInfo, value int64, offset uint32, currency string) {
+	if offset == 0 || value == 0 {
+		return
+	}
+	c.Amount1000 = value * 1000 / int64(offset)
+	if currency != "" {
+		c.Currency = currency
+	}
+}
+
+func paymentNote(m *waProto.Message) string {
+	switch {
+	case m.GetSendPaymentMessage() != nil:
+		return ExtractContent(m.GetSendPaymentMessage().GetNoteMessage()).Content
+	case m.GetRequestPaymentMessage() != nil:
+		return ExtractContent(m.GetRequestPaymentMessage().GetNoteMessage()).Content
+	}
+	return ""
+}
+
+// ExtractHistoryContent is ExtractContent for history sync messages. It also
+// reads the payment record history sync attaches to payment messages, which
+// is the only place the amount of a sent payment and its final status
+// (complete, refunded, ...) appear.
+func ExtractHistoryContent(web *waWeb.WebMessageInfo) MessageContent {
+	out := ExtractContent(web.GetMessage())
+	info := web.GetPaymentInfo()
+	m := unwrapMessage(web.GetMessage())
+	if info == nil || (m.GetSendPaymentMessage() == nil && m.GetRequestPaymentMessage() == nil) {
+		return out
+	}
+	c := out.Commerce
+
+	if c.Amount1000 == 0 {
+		c.Amount1000 = int64(info.GetAmount1000())
+		if amount := info.GetPrimaryAmount(); amount != nil {
+			setMoney(c, amount.GetValue(), amount.GetOffset(), amount.GetCurrencyCode())
+		}
+	}
+	if c.Currency == "" {
+		c.Currency = info.GetCurrency()
+	}
+	if status := info.GetStatus(); status != waWeb.PaymentInfo_UNKNOWN_STATUS {
+		c.Status = strings.ToLower(status.String())
+	}
+	if c.Type == CommercePayment {
+		out.Content = describeCommerce("[Payment]", c, paymentNote(m))
+	} else {
+		out.Content = describeCommerce("[Payment request]", c, paymentNote(m))
+	}
+	return out
+}
+
+// describeCommerce renders a business message as text, e.g.
+// "[Order] Weekly box: 3 items, 42.50 EUR — leave at the door".
+func describeCommerce(tag string, c *CommerceInfo, note string) string {
+	var parts []string
+	switch {
+	case c.Type == CommerceProduct:
+		// A product is always a single item.
+	case c.ItemCount == 1:
+		parts = append(parts, "1 item")
+	case c.ItemCount > 1:
+		parts = append(parts, fmt.Sprintf("%d items", c.ItemCount))
+	}
+	if c.Amount1000 > 0 {
+		parts = append(parts, strings.TrimSpace(fmt.Sprintf("%.2f %s", float64(c.Amount1000)/1000, c.Currency)))
+	}
+
+	text := tag
+	if c.Title != "" {
+		text += " " + c.Title
+	}
+	if len(parts) > 0 {
+		if c.Title != "" {
+			text += ":"
+		}
+		text += " " + strings.Join(parts, ", ")
+	}
+	if note = strings.TrimSpace(note); note != "" {
+		if text == tag {
+			text += " " + note
+		} else {
+			text += " — " + note
+		}
+	}
+	return text
+}
+
+func describeCoordinates(label string, lat, lon float64) string {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return fmt.Sprintf("%.6f, %.6f", lat, lon)
+	}
+	return fmt.Sprintf("%s (%.6f, %.6f)", label, lat, lon)
+}
+
+func nonEmpty(values ...string) []string {
+	var out []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// replyToID returns the ID of the quoted message, if any.
+func replyToID(m *waProto.Message) string {
+	type contextual interface {
+		GetContextInfo() *waProto.ContextInfo
+	}
+	candidates := []contextual{
+		m.GetExtendedTextMessage(),
+		m.GetImageMessage(),
+		m.GetVideoMessage(),
+		m.GetAudioMessage(),
+		m.GetDocumentMessage(),
+		m.GetStickerMessage(),
+		m.GetLocationMessage(),
+		m.GetLiveLocationMessage(),
+		m.GetContactMessage(),
+		m.GetOrderMessage(),
+		m.GetProductMessage(),
+		m.GetButtonsResponseMessage(),
+		m.GetTemplateButtonReplyMessage(),
+		m.GetListResponseMessage(),
+	}
+	for _, c := range candidates {
+		// Typed nil pointers satisfy the interface; their getters are nil-safe.
+		if id := c.GetContextInfo().GetStanzaID(); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// MarshalRaw serializes a message for archival, returning nil on failure so
+// archiving never blocks storing the parsed message.
+func MarshalRaw(m *waProto.Message) []byte {
+	if m == nil {
+		return nil
+	}
+	data, err := proto.Marshal(m)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// UnmarshalRaw parses a blob previously produced by MarshalRaw.
+func UnmarshalRaw(data []byte) (*waProto.Message, error) {
+	var m waProto.Message
+	if err := proto.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
@@ -0,0 +1,225 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/types"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestExtractContentUnwrapsViewOnceImage(t *testing.T) {
+	msg := &waProto.Message{
+		ViewOnceMessageV2: &waProto.FutureProofMessage{Message: &waProto.Message{
+			ImageMessage: &waProto.ImageMessage{
+				Caption:    proto.String("secret"),
+				DirectPath: proto.String("/v/img"),
+				Mimetype:   proto.String("image/jpeg"),
+			},
+		}},
+	}
+
+	got := ExtractContent(msg)
+	assert.Equal(t, "secret", got.Content)
+	require.NotNil(t, got.Media)
+	assert.Equal(t, "image", got.Media.Type)
+	assert.Equal(t, "/v/img", got.Media.DirectPath)
+}
+
+func TestExtractContentReplyAndPoll(t *testing.T) {
+	reply := ExtractContent(&waProto.Message{
+		ExtendedTextMessage: &waProto.ExtendedTextMessage{
+			Text:        proto.String("agreed"),
+			ContextInfo: &waProto.ContextInfo{StanzaID: proto.String("parent-id")},
+		},
+	})
+	assert.Equal(t, "agreed", reply.Content)
+	assert.Equal(t, "parent-id", reply.ReplyToID)
+
+	poll := ExtractContent(&waProto.Message{
+		PollCreationMessageV3: &waProto.PollCreationMessage{
+			Name: proto.String("Lunch?"),
+			Options: []*waProto.PollCreationMessage_Option{
+				{OptionName: proto.String("Yes")},
+				{OptionName: proto.String("No")},
+			},
+		},
+	})
+	assert.Equal(t, "[Poll] Lunch?: Yes / No", poll.Content)
+}
+
+func TestMarshalRawRoundTrip(t *testing.T) {
+	raw := MarshalRaw(&waProto.Message{Conversation: proto.String("hi")})
+	require.NotEmpty(t, raw)
+
+	msg, err := UnmarshalRaw(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "hi", ExtractContent(msg).Content)
+	assert.Nil(t, MarshalRaw(nil))
+}
+
+func TestExtractContentLocations(t *testing.T) {
+	pin := ExtractContent(&waProto.Message{
+		LocationMessage: &waProto.LocationMessage{
+			DegreesLatitude:  proto.Float64(40.4168),
+			DegreesLongitude: proto.Float64(-3.7038),
+			Name:             proto.String("Puerta del Sol"),
+		},
+	})
+	assert.Equal(t, "[Location] Puerta del Sol (40.416800, -3.703800)", pin.Content)
+	require.NotNil(t, pin.Location)
+	assert.False(t, pin.Location.Live)
+
+	live := ExtractContent(&waProto.Message{
+		LiveLocationMessage: &waProto.LiveLocationMessage{
+			DegreesLatitude:  proto.Float64(40.4168),
+			DegreesLongitude: proto.Float64(-3.7038),
+			SpeedInMps:       proto.Float32(1.5),
+			SequenceNumber:   proto.Int64(3),
+		},
+	})
+	assert.Equal(t, "[Live location] 40.416800, -3.703800", live.Content)
+	require.NotNil(t, live.Location)
+	assert.True(t, live.Location.Live)
+	assert.EqualValues(t, 3, live.Location.Sequence)
+	assert.InDelta(t, 1.5, live.Location.SpeedMps, 0.001)
+}
+
+func TestExtractContentBusinessMessages(t *testing.T) {
+	order := ExtractContent(&waProto.Message{
+		OrderMessage: &waProto.OrderMessage{
+			OrderID:           proto.String("ORD-1"),
+			OrderTitle:        proto.String("Weekly box"),
+			ItemCount:         proto.Int32(3),
+			TotalAmount1000:   proto.Int64(42500),
+			TotalCurrencyCode: proto.String("EUR"),
+			Message:           proto.String("leave at the door"),
+		},
+	})
+	assert.Equal(t, "[Order] Weekly box: 3 items, 42.50 EUR — leave at the door", order.Content)
+	require.NotNil(t, order.Commerce)
+	assert.Equal(t, CommerceOrder, order.Commerce.Type)
+	assert.EqualValues(t, 42500, order.Commerce.Amount1000)
+	assert.Equal(t, 3, order.Commerce.ItemCount)
+
+	product := ExtractContent(&waProto.Message{
+		ProductMessage: &waProto.ProductMessage{
+			Product: &waProto.ProductMessage_ProductSnapshot{
+				ProductID:           proto.String("P-9"),
+				Title:               proto.String("Espresso beans"),
+				CurrencyCode:        proto.String("USD"),
+				PriceAmount1000:     proto.Int64(18000),
+				SalePriceAmount1000: proto.Int64(15000),
+			},
+		},
+	})
+	assert.Equal(t, "[Product] Espresso beans: 15.00 USD", product.Content)
+	require.NotNil(t, product.Commerce)
+	assert.EqualValues(t, 15000, product.Commerce.Amount1000, "the sale price wins")
+
+	invoice := ExtractContent(&waProto.Message{
+		InvoiceMessage: &waProto.InvoiceMessage{Note: proto.String("March retainer")},
+	})
+	assert.Equal(t, "[Invoice] March retainer", invoice.Content)
+	require.NotNil(t, invoice.Commerce)
+	assert.Equal(t, CommerceInvoice, invoice.Commerce.Type)
+}
+
+func TestExtractContentPayments(t *testing.T) {
+	request := ExtractContent(&waProto.Message{
+		RequestPaymentMessage: &waProto.RequestPaymentMessage{
+			CurrencyCodeIso4217: proto.String("INR"),
+			Amount1000:          proto.Uint64(250000),
+			NoteMessage:         &waProto.Message{Conversation: proto.String("dinner")},
+		},
+	})
+	assert.Equal(t, "[Payment request] 250.00 INR — dinner", request.Content)
+	require.NotNil(t, request.Commerce)
+	assert.Equal(t, CommercePaymentRequest, request.Commerce.Type)
+	assert.Equal(t, PaymentRequested, request.Commerce.Status)
+
+	newer := ExtractContent(&waProto.Message{
+		RequestPaymentMessage: &waProto.RequestPaymentMessage{
+			Amount: &waProto.Money{Value: proto.Int64(1050), Offset: proto.Uint32(100), CurrencyCode: proto.String("BRL")},
+		},
+	})
+	assert.EqualValues(t, 10500, newer.Commerce.Amount1000)
+	assert.Equal(t, "BRL", newer.Commerce.Currency)
+
+	declined := ExtractContent(&waProto.Message{
+		DeclinePaymentRequestMessage: &waProto.DeclinePaymentRequestMessage{Key: &waCommon.MessageKey{ID: proto.String("REQ1")}},
+	})
+	assert.Equal(t, "[Payment request declined]", declined.Content)
+	assert.Equal(t, "REQ1", declined.Commerce.RequestID)
+	assert.Equal(t, PaymentDeclined, declined.Commerce.RequestStatus)
+
+	paid := ExtractContent(&waProto.Message{
+		SendPaymentMessage: &waProto.SendPaymentMessage{RequestMessageKey: &waCommon.MessageKey{ID: proto.String("REQ2")}},
+	})
+	assert.Equal(t, CommercePayment, paid.Commerce.Type)
+	assert.Equal(t, PaymentPaid, paid.Commerce.RequestStatus)
+}
+
+func TestExtractHistoryContentReadsPaymentInfo(t *testing.T) {
+	got := ExtractHistoryContent(&waWeb.WebMessageInfo{
+		Message: &waProto.Message{
+			SendPaymentMessage: &waProto.SendPaymentMessage{
+				NoteMessage: &waProto.Message{Conversation: proto.String("rent")},
+			},
+		},
+		PaymentInfo: &waWeb.PaymentInfo{
+			Amount1000: proto.Uint64(500000),
+			Currency:   proto.String("INR"),
+			Status:     waWeb.PaymentInfo_COMPLETE.Enum(),
+		},
+	})
+	assert.Equal(t, "[Payment] 500.00 INR — rent", got.Content)
+	require.NotNil(t, got.Commerce)
+	assert.Equal(t, "complete", got.Commerce.Status)
+}
+
+func TestExtractContentInteractive(t *testing.T) {
+	buttons := ExtractContent(BuildInteractiveMessage(types.InteractiveSpec{
+		Type:    types.InteractiveButtons,
+		Header:  "Delivery",
+		Body:    "Is 5pm OK?",
+		Buttons: []types.InteractiveButton{{ID: "yes", Title: "Yes"}, {ID: "no", Title: "No"}},
+	}))
+	assert.Equal(t, "[Buttons] Delivery Is 5pm OK? (Yes / No)", buttons.Content)
+	require.NotNil(t, buttons.Interactive)
+	assert.Equal(t, InteractiveButtons, buttons.Interactive.Kind)
+
+	list := ExtractContent(BuildInteractiveMessage(types.InteractiveSpec{
+		Type:       types.InteractiveList,
+		Body:       "Pick a slot",
+		ButtonText: "Slots",
+		Sections: []types.InteractiveSection{{Rows: []types.InteractiveRow{
+			{ID: "am", Title: "Morning"}, {ID: "pm", Title: "Afternoon"},
+		}}},
+	}))
+	assert.Equal(t, "[List] Pick a slot (Morning / Afternoon)", list.Content)
+
+	reply := ExtractContent(&waProto.Message{
+		ButtonsResponseMessage: &waProto.ButtonsResponseMessage{
+			SelectedButtonID: proto.String("yes"),
+			Response:         &waProto.ButtonsResponseMessage_SelectedDisplayText{SelectedDisplayText: "Yes"},
+			ContextInfo:      &waProto.ContextInfo{StanzaID: proto.String("BTN1")},
+		},
+	})
+	assert.Equal(t, "[Button reply] Yes", reply.Content)
+	assert.Equal(t, "BTN1", reply.ReplyToID)
+	assert.Equal(t, &InteractiveInfo{Kind: InteractiveButtonReply, SelectedID: "yes"}, reply.Interactive)
+
+	listReply := ExtractContent(&waProto.Message{
+		ListResponseMessage: &waProto.ListResponseMessage{
+			Title:             proto.String("Afternoon"),
+			SingleSelectReply: &waProto.ListResponseMessage_SingleSelectReply{SelectedRowID: proto.String("pm")},
+		},
+	})
+	assert.Equal(t, "[List reply] Afternoon", listReply.Content)
+	assert.Equal(t, "pm", listReply.Interactive.SelectedID)
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vicentereig/whatsapp-cli/internal/types"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// Interactive message kinds recorded in InteractiveInfo.Kind: the two
+// message shapes, and the replies a recipient sends by tapping them.
+const (
+	InteractiveButtons     = types.InteractiveButtons
+	InteractiveList        = types.InteractiveList
+	InteractiveButtonReply = "button_reply"
+	InteractiveListReply   = "list_reply"
+)
+
+// InteractiveInfo identifies a reply-button or list message, or a reply to
+// one. SelectedID is the button or row ID the recipient picked; the
+// message being answered is the reply's ReplyToID.
+type InteractiveInfo struct {
+	Kind       string
+	SelectedID string
+}
+
+// BuildInteractiveMessage converts a spec into a buttons or list message.
+func BuildInteractiveMessage(spec types.InteractiveSpec) *waProto.Message {
+	if spec.Type == types.InteractiveList {
+		list := &waProto.ListMessage{
+			Title:       proto.String(spec.Header),
+			Description: proto.String(spec.Body),
+			ButtonText:  proto.String(spec.ButtonText),
+			ListType:    waProto.ListMessage_SINGLE_SELECT.Enum(),
+			FooterText:  proto.String(spec.Footer),
+		}
+		for _, section := range spec.Sections {
+			s := &waProto.ListMessage_Section{Title: proto.String(section.Title)}
+			for _, row := range section.Rows {
+				s.Rows = append(s.Rows, &waProto.ListMessage_Row{
+					RowID:       proto.String(row.ID),
+					Title:       proto.String(row.Title),
+					Description: proto.String(row.Description),
+				})
+			}
+			list.Sections = append(list.Sections, s)
+		}
+		return &waProto.Message{ListMessage: list}
+	}
+
+	buttons := &waProto.ButtonsMessage{
+		ContentText: proto.String(spec.Body),
+		FooterText:  proto.String(spec.Footer),
+		HeaderType:  waProto.ButtonsMessage_EMPTY.Enum(),
+	}
+	if spec.Header != "" {
+		buttons.HeaderType = waProto.ButtonsMessage_TEXT.Enum()
+		buttons.Header = &waProto.ButtonsMessage_Text{Text: spec.Header}
+	}
+	for _, b := range spec.Buttons {
+		buttons.Buttons = append(buttons.Buttons, &waProto.ButtonsMessage_Button{
+			ButtonID:   proto.String(b.ID),
+			ButtonText: &waProto.ButtonsMessage_Button_ButtonText{DisplayText: proto.String(b.Title)},
+			Type:       waProto.ButtonsMessage_Button_RESPONSE.Enum(),
+		})
+	}
+	return &waProto.Message{ButtonsMessage: buttons}
+}
+
+// extractInteractive describes interactive messages and their replies.
+// It returns ok=false for any other message.
+func extractInteractive(m *waProto.Message) (content string, info *InteractiveInfo, ok bool) {
+	switch {
+	case m.GetButtonsMessage() != nil:
+		b := m.GetButtonsMessage()
+		var titles []string
+		for _, button := range b.GetButtons() {
+			titles = append(titles, button.GetButtonText().GetDisplayText())
+		}
+		return describeInteractive("[Buttons]", b.GetText(), b.GetContentText(), titles),
+			&InteractiveInfo{Kind: InteractiveButtons}, true
+
+	case m.GetListMessage() != nil:
+		l := m.GetListMessage()
+		var titles []string
+		for _, section := range l.GetSections() {
+			for _, row := range section.GetRows() {
+				titles = append(titles, row.GetTitle())
+			}
+		}
+		return describeInteractive("[List]", l.GetTitle(), l.GetDescription(), titles),
+			&InteractiveInfo{Kind: InteractiveList}, true
+
+	case m.GetButtonsResponseMessage() != nil:
+		r := m.GetButtonsResponseMessage()
+		return "[Button reply] " + r.GetSelectedDisplayText(),
+			&InteractiveInfo{Kind: InteractiveButtonReply, SelectedID: r.GetSelectedButtonID()}, true
+
+	case m.GetTemplateButtonReplyMessage() != nil:
+		r := m.GetTemplateButtonReplyMessage()
+		return "[Button reply] " + r.GetSelectedDisplayText(),
+			&InteractiveInfo{Kind: InteractiveButtonReply, SelectedID: r.GetSelectedID()}, true
+
+	case m.GetListResponseMessage() != nil:
+		r := m.GetListResponseMessage()
+		return "[List reply] " + r.GetTitle(),
+			&InteractiveInfo{Kind: InteractiveListReply, SelectedID: r.GetSingleSelectReply().GetSelectedRowID()}, true
+	}
+	return "", nil, false
+}
+
+// describeInteractive renders e.g. "[Buttons] Confirm? Delivery at 5pm (Yes / No)".
+func describeInteractive(tag, header, body string, options []string) string {
+	text := strings.Join(append([]string{tag}, nonEmpty(header, body)...), " ")
+	if len(options) > 0 {
+		text += fmt.Sprintf(" (%s)", strings.Join(options, " / "))
+	}
+	return text
+}
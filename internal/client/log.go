@@ -0,0 +1,55 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// Log levels understood by stderrLogger, lowest first.
+const (
+	levelDebug = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+// stderrLogger is a waLog.Logger that writes to output.Stderr. whatsmeow's
+// own waLog.Stdout prints to stdout, which would corrupt the JSON result.
+type stderrLogger struct {
+	module   string
+	minLevel int
+}
+
+func newStderrLogger(module string, minLevel int) waLog.Logger {
+	return &stderrLogger{module: module, minLevel: minLevel}
+}
+
+func (l *stderrLogger) logf(level int, name, msg string, args ...interface{}) {
+	if level < l.minLevel {
+		return
+	}
+	fmt.Fprintf(output.Stderr, "%s [%s %s] %s\n", time.Now().Format("15:04:05.000"), l.module, name, fmt.Sprintf(msg, args...))
+}
+
+func (l *stderrLogger) Errorf(msg string, args ...interface{}) {
+	l.logf(levelError, "ERROR", msg, args...)
+}
+
+func (l *stderrLogger) Warnf(msg string, args ...interface{}) {
+	l.logf(levelWarn, "WARN", msg, args...)
+}
+
+func (l *stderrLogger) Infof(msg string, args ...interface{}) {
+	l.logf(levelInfo, "INFO", msg, args...)
+}
+
+func (l *stderrLogger) Debugf(msg string, args ...interface{}) {
+	l.logf(levelDebug, "DEBUG", msg, args...)
+}
+
+func (l *stderrLogger) Sub(module string) waLog.Logger {
+	return &stderrLogger{module: l.module + "/" + module, minLevel: l.minLevel}
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/addressbook"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	waTypes "go.mau.fi/whatsmeow/types"
+)
+
+// addressBookRefresh is how long an external address book is cached before
+// it is fetched again.
+const addressBookRefresh = time.Hour
+
+// NameProvider resolves a display name for a chat. Providers return "" when
+// they have nothing to offer so the chain falls through to the next one.
+type NameProvider interface {
+	ResolveName(ctx context.Context, jid waTypes.JID) string
+}
+
+// NameProviderFunc adapts a function to NameProvider.
+type NameProviderFunc func(ctx context.Context, jid waTypes.JID) string
+
+func (f NameProviderFunc) ResolveName(ctx context.Context, jid waTypes.JID) string {
+	return f(ctx, jid)
+}
+
+// SetNameProviders replaces the resolution chain used by ResolveChatName.
+func (w *WAClient) SetNameProviders(providers ...NameProvider) {
+	w.nameProviders = providers
+}
+
+// WhatsmeowNameProvider returns the provider backed by whatsmeow's contact
+// store and group metadata.
+func (w *WAClient) WhatsmeowNameProvider() NameProvider {
+	return NameProviderFunc(w.resolveFromWhatsmeow)
+}
+
+func (w *WAClient) providers() []NameProvider {
+	if w.nameProviders != nil {
+		return w.nameProviders
+	}
+	return []NameProvider{w.WhatsmeowNameProvider()}
+}
+
+func (w *WAClient) resolveFromWhatsmeow(ctx context.Context, jid waTypes.JID) string {
+	if jid.Server == waTypes.GroupServer || jid.IsBroadcastList() {
+		if w.groupInfoLookup != nil {
+			if info, err := w.groupInfoLookup(ctx, jid); err == nil && info != nil {
+				return strings.TrimSpace(info.GroupName.Name)
+			}
+		}
+		return ""
+	}
+	if w.contactLookup != nil {
+		if info, err := w.contactLookup(ctx, jid.ToNonAD()); err == nil {
+			return bestContactName(info)
+		}
+	}
+	return ""
+}
+
+// addressBookProvider resolves user JIDs by phone number against an external
+// address book, loading it lazily and refreshing it periodically.
+type addressBookProvider struct {
+	name    string
+	load    func(ctx context.Context) ([]addressbook.Entry, error)
+	refresh time.Duration
+
+	mu       sync.Mutex
+	index    *addressbook.Index
+	loadedAt time.Time
+	// loading is closed when the load in flight finishes; nil when idle.
+	loading chan struct{}
+}
+
+// NewAddressBookNameProvider wraps an address book loader as a NameProvider.
+// Load failures are reported on stderr and the previous index stays in use.
+func NewAddressBookNameProvider(name string, load func(ctx context.Context) ([]addressbook.Entry, error)) NameProvider {
+	return &addressBookProvider{name: name, load: load, refresh: addressBookRefresh}
+}
+
+// NewCSVNameProvider resolves names from a "phone,name" CSV file.
+func NewCSVNameProvider(path string) NameProvider {
+	return NewAddressBookNameProvider("csv", func(ctx context.Context) ([]addressbook.Entry, error) {
+		return addressbook.LoadCSV(path)
+	})
+}
+
+// NewCardDAVNameProvider resolves names from a CardDAV address book.
+func NewCardDAVNameProvider(c *addressbook.CardDAVClient) NameProvider {
+	return NewAddressBookNameProvider("carddav", c.FetchEntries)
+}
+
+func (p *addressBookProvider) ResolveName(ctx context.Context, jid waTypes.JID) string {
+	if jid.Server != waTypes.DefaultUserServer {
+		return "" // groups and LIDs carry no phone number
+	}
+	idx := p.currentIndex(ctx)
+	return idx.Lookup(jid.User)
+}
+
+// currentIndex returns the loaded index, starting a reload when it is due.
+// The load runs without the lock, so one slow fetch never stalls other
+// lookups: they keep using the previous index, and only wait for the first
+// load when there is none yet.
+func (p *addressBookProvider) currentIndex(ctx context.Context) *addressbook.Index {
+	p.mu.Lock()
+	if p.loading == nil && (p.loadedAt.IsZero() || time.Since(p.loadedAt) > p.refresh) {
+		// Record the attempt even on failure so a broken source is not
+		// hammered once per message.
+		p.loadedAt = time.Now()
+		done := make(chan struct{})
+		p.loading = done
+		p.mu.Unlock()
+
+		entries, err := p.load(ctx)
+
+		p.mu.Lock()
+		if err != nil {
+			fmt.Fprintf(output.Stderr, "⚠️  %s name provider: %v\n", p.name, err)
+		} else {
+			p.index = addressbook.NewIndex(entries)
+		}
+		p.loading = nil
+		close(done)
+	}
+	idx, loading := p.index, p.loading
+	p.mu.Unlock()
+
+	if idx == nil && loading != nil {
+		select {
+		case <-loading:
+		case <-ctx.Done():
+			return nil
+		}
+		p.mu.Lock()
+		idx = p.index
+		p.mu.Unlock()
+	}
+	return idx
+}
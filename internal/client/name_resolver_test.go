@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/vicentereig/whatsapp-cli/internal/addressbook"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 )
@@ -66,3 +67,66 @@ func TestResolveChatNameFallsBackToJID(t *testing.T) {
 	name := w.ResolveChatName(context.Background(), "status@broadcast", nil)
 	assert.Equal(t, "status@broadcast", name)
 }
+
+func TestResolveChatNameFollowsProviderOrder(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	provider := func(label, name string) NameProvider {
+		return NameProviderFunc(func(ctx context.Context, jid types.JID) string {
+			calls = append(calls, label)
+			return name
+		})
+	}
+
+	w := &WAClient{}
+	w.SetNameProviders(provider("first", ""), provider("second", "Directory Name"), provider("third", "Never Used"))
+
+	name := w.ResolveChatName(context.Background(), "1234@s.whatsapp.net", nil)
+	assert.Equal(t, "Directory Name", name)
+	assert.Equal(t, []string{"first", "second"}, calls)
+}
+
+func TestAddressBookNameProviderMatchesPhoneNumber(t *testing.T) {
+	t.Parallel()
+
+	loads := 0
+	p := NewAddressBookNameProvider("test", func(ctx context.Context) ([]addressbook.Entry, error) {
+		loads++
+		return []addressbook.Entry{{Name: "Carol", Phones: []string{"+34 612 345 678"}}}, nil
+	})
+
+	assert.Equal(t, "Carol", p.ResolveName(context.Background(), types.NewJID("34612345678", types.DefaultUserServer)))
+	assert.Equal(t, "", p.ResolveName(context.Background(), types.NewJID("34612345678", types.GroupServer)))
+	assert.Equal(t, "", p.ResolveName(context.Background(), types.NewJID("1111111111", types.DefaultUserServer)))
+	assert.Equal(t, 1, loads, "address book should be cached between lookups")
+}
+
+func TestAddressBookNameProviderReloadsWithoutBlockingLookups(t *testing.T) {
+	t.Parallel()
+
+	reloading := make(chan struct{})
+	release := make(chan struct{})
+	loads := 0
+	p := NewAddressBookNameProvider("test", func(ctx context.Context) ([]addressbook.Entry, error) {
+		loads++
+		if loads > 1 {
+			close(reloading)
+			<-release
+		}
+		return []addressbook.Entry{{Name: "Carol", Phones: []string{"34612345678"}}}, nil
+	}).(*addressBookProvider)
+	jid := types.NewJID("34612345678", types.DefaultUserServer)
+
+	assert.Equal(t, "Carol", p.ResolveName(context.Background(), jid))
+	p.refresh = 0
+	reloaded := make(chan string)
+	go func() { reloaded <- p.ResolveName(context.Background(), jid) }()
+	<-reloading
+
+	// The previous index answers while the reload is in flight.
+	assert.Equal(t, "Carol", p.ResolveName(context.Background(), jid))
+	close(release)
+	assert.Equal(t, "Carol", <-reloaded)
+	assert.Equal(t, 2, loads)
+}
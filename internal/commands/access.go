@@ -0,0 +1,21 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+)
+
+// checkChatAllowed rejects commands aimed at a chat outside allowed_chats.
+// Listing and search commands are filtered by the store instead.
+func (a *App) checkChatAllowed(chatJID string) error {
+	if a.cfg == nil || len(a.cfg.AllowedChats) == 0 {
+		return nil
+	}
+	for _, allowed := range a.cfg.AllowedChats {
+		if allowed == chatJID {
+			return nil
+		}
+	}
+	return fmt.Errorf("chat %s is not in allowed_chats (%s)", chatJID, config.FileName)
+}
@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+)
+
+// TestSendMessage_RejectsChatOutsideAllowlist verifies a disallowed send
+// fails before connecting or touching the outbound log.
+func TestSendMessage_RejectsChatOutsideAllowlist(t *testing.T) {
+	connected := false
+	logged := false
+	mockClient := &MockWAClient{
+		ConnectFunc: func(ctx context.Context) error {
+			connected = true
+			return nil
+		},
+	}
+	mockStore := &MockMessageStore{
+		LogOutboundFunc: func(chatJID, kind, content, attachment string, at time.Time) (int64, error) {
+			logged = true
+			return 1, nil
+		},
+	}
+	app := NewAppWithDeps(mockClient, mockStore, t.TempDir(), "test")
+	app.cfg = &config.Config{AllowedChats: []string{"team@g.us"}}
+
+	resp := parseResponse(t, app.SendMessage(context.Background(), "34600000000", "hi", SendOptions{}))
+	require.False(t, resp.Success)
+	require.Contains(t, *resp.Error, "allowed_chats")
+	require.False(t, connected)
+	require.False(t, logged)
+
+	resp = parseResponse(t, app.SendMessage(context.Background(), "team@g.us", "hi", SendOptions{}))
+	require.True(t, resp.Success)
+}
+
+// TestChatSnapshot_RejectsChatOutsideAllowlist verifies chat-targeted
+// reads honor the allowlist too.
+func TestChatSnapshot_RejectsChatOutsideAllowlist(t *testing.T) {
+	app := NewAppWithDeps(&MockWAClient{}, &MockMessageStore{}, t.TempDir(), "test")
+	app.cfg = &config.Config{AllowedChats: []string{"team@g.us"}}
+
+	resp := parseResponse(t, app.ChatSnapshot("other@g.us", "2024-01-01"))
+	require.False(t, resp.Success)
+}
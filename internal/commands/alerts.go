@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+)
+
+// alertSinkTimeout bounds a single webhook call or exec run.
+const alertSinkTimeout = 30 * time.Second
+
+// Alert is an anomaly raised by the monitor and delivered to every sink.
+type Alert struct {
+	Kind      string    `json:"kind"`
+	ChatJID   string    `json:"chat_jid"`
+	ChatName  string    `json:"chat_name,omitempty"`
+	Message   string    `json:"message"`
+	Observed  float64   `json:"observed"`
+	Threshold float64   `json:"threshold"`
+	Time      time.Time `json:"time"`
+}
+
+// alertSink delivers alerts to one configured destination.
+type alertSink struct {
+	kind string
+	send func(ctx context.Context, alert Alert) error
+}
+
+// buildAlertSinks turns sink settings into senders. No sinks means stderr.
+func buildAlertSinks(sinks []config.AlertSink) ([]alertSink, error) {
+	if len(sinks) == 0 {
+		sinks = []config.AlertSink{{Type: config.SinkStderr}}
+	}
+
+	var out []alertSink
+	for _, s := range sinks {
+		switch s.Type {
+		case config.SinkStderr:
+			out = append(out, alertSink{kind: s.Type, send: sendStderrAlert})
+		case config.SinkWebhook:
+			if s.URL == "" {
+				return nil, fmt.Errorf("webhook alert sink requires url")
+			}
+			out = append(out, alertSink{kind: s.Type, send: webhookAlertSender(s.URL, &http.Client{Timeout: alertSinkTimeout})})
+		case config.SinkExec:
+			if len(s.Command) == 0 {
+				return nil, fmt.Errorf("exec alert sink requires command")
+			}
+			out = append(out, alertSink{kind: s.Type, send: execAlertSender(s.Command)})
+		default:
+			return nil, fmt.Errorf("unknown alert sink type %q (use stderr, webhook or exec)", s.Type)
+		}
+	}
+	return out, nil
+}
+
+// sendStderrAlert writes to stderr like other warnings, so --quiet silences
+// it; use a webhook or exec sink for alerts that must always get through.
+func sendStderrAlert(ctx context.Context, alert Alert) error {
+	_, err := fmt.Fprintf(output.Stderr, "\nALERT [%s] %s\n", alert.Kind, alert.Message)
+	return err
+}
+
+func webhookAlertSender(url string, httpClient *http.Client) func(ctx context.Context, alert Alert) error {
+	return func(ctx context.Context, alert Alert) error {
+		return postJSON(ctx, httpClient, url, alert)
+	}
+}
+
+// execAlertSender runs command with the alert JSON on stdin and the kind
+// and chat in WHATSAPP_ALERT_KIND and WHATSAPP_ALERT_CHAT.
+func execAlertSender(command []string) func(ctx context.Context, alert Alert) error {
+	return func(ctx context.Context, alert Alert) error {
+		return runWithJSON(ctx, command, alert,
+			"WHATSAPP_ALERT_KIND="+alert.Kind,
+			"WHATSAPP_ALERT_CHAT="+alert.ChatJID,
+		)
+	}
+}
+
+// postJSON POSTs v to url, treating non-2xx responses as failures.
+func postJSON(ctx context.Context, httpClient *http.Client, url string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// runWithJSON runs command with v as JSON on stdin and env added to the
+// environment, bounded by alertSinkTimeout.
+func runWithJSON(ctx context.Context, command []string, v interface{}, env ...string) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, alertSinkTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(), env...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %v: %s", command[0], err, bytes.TrimSpace(out))
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+)
+
+// TestWebhookAlertSink_PostsAlertJSON verifies webhook sinks POST the alert
+// and treat non-2xx responses as failures.
+func TestWebhookAlertSink_PostsAlertJSON(t *testing.T) {
+	var got Alert
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sinks, err := buildAlertSinks([]config.AlertSink{{Type: config.SinkWebhook, URL: server.URL}})
+	require.NoError(t, err)
+	require.Len(t, sinks, 1)
+
+	alert := Alert{Kind: AlertSilence, ChatJID: "ops@g.us", Message: "quiet", Time: time.Now()}
+	require.NoError(t, sinks[0].send(context.Background(), alert))
+	require.Equal(t, "ops@g.us", got.ChatJID)
+
+	status = http.StatusInternalServerError
+	require.Error(t, sinks[0].send(context.Background(), alert))
+}
+
+// TestBuildAlertSinks_RequiresDestination verifies sinks missing their
+// target are rejected at startup rather than failing on the first alert.
+func TestBuildAlertSinks_RequiresDestination(t *testing.T) {
+	_, err := buildAlertSinks([]config.AlertSink{{Type: config.SinkWebhook}})
+	require.Error(t, err)
+	_, err = buildAlertSinks([]config.AlertSink{{Type: config.SinkExec}})
+	require.Error(t, err)
+}
+
+// TestStderrAlertSink_WritesToOutputStderr verifies stderr alerts go
+// through output.Stderr, so --quiet and --no-emoji apply to them.
+func TestStderrAlertSink_WritesToOutputStderr(t *testing.T) {
+	var buf bytes.Buffer
+	orig := output.Stderr
+	output.Stderr = &buf
+	t.Cleanup(func() { output.Stderr = orig })
+
+	require.NoError(t, sendStderrAlert(context.Background(), Alert{Kind: "spike", Message: "Team is busy"}))
+	require.Equal(t, "\nALERT [spike] Team is busy\n", buf.String())
+}
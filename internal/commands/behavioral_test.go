@@ -9,6 +9,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"github.com/vicentereig/whatsapp-cli/internal/store"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
 )
 
 // Helper to create string pointer
@@ -62,7 +64,7 @@ func TestListMessages_FiltersByChat(t *testing.T) {
 	app := NewAppWithDeps(&MockWAClient{}, mockStore, "/tmp", "test")
 
 	// When: ListMessages called with chat filter
-	result := app.ListMessages(ptr(targetJID), nil, 10, 0)
+	result := app.ListMessages(ptr(targetJID), nil, nil, 10, 0)
 
 	// Then: Only messages from target chat are returned
 	resp := parseResponse(t, result)
@@ -102,7 +104,7 @@ func TestListMessages_RespectsLimit(t *testing.T) {
 	app := NewAppWithDeps(&MockWAClient{}, mockStore, "/tmp", "test")
 
 	// When: ListMessages called with limit=2
-	result := app.ListMessages(nil, nil, 2, 0)
+	result := app.ListMessages(nil, nil, nil, 2, 0)
 
 	// Then: Only 2 messages returned (behavioral - tests output, not internals)
 	resp := parseResponse(t, result)
@@ -212,7 +214,7 @@ func TestListChats_ReturnsChats(t *testing.T) {
 	app := NewAppWithDeps(&MockWAClient{}, mockStore, "/tmp", "test")
 
 	// When: ListChats called
-	result := app.ListChats(nil, 10, 0)
+	result := app.ListChats(nil, 10, 0, false)
 
 	// Then: Returns chats
 	resp := parseResponse(t, result)
@@ -223,3 +225,30 @@ func TestListChats_ReturnsChats(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, chats, 2)
 }
+
+// TestSync_DeleteChatSoftDeletes verifies a chat deleted on the phone is
+// marked deleted in the store rather than removed.
+func TestSync_DeleteChatSoftDeletes(t *testing.T) {
+	deletedAt := time.Unix(1700000000, 0)
+	var marked []string
+	mockStore := &MockMessageStore{
+		MarkChatDeletedFunc: func(jid string, at time.Time) error {
+			require.True(t, at.Equal(deletedAt))
+			marked = append(marked, jid)
+			return nil
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	mockClient := &MockWAClient{
+		StartSyncFunc: func(ctx context.Context, handler func(interface{})) error {
+			handler(&events.DeleteChat{JID: types.NewJID("1234", types.DefaultUserServer), Timestamp: deletedAt})
+			cancel()
+			return nil
+		},
+	}
+
+	app := NewAppWithDeps(mockClient, mockStore, t.TempDir(), "test")
+	resp := parseResponse(t, app.Sync(ctx, SyncOptions{}))
+	require.True(t, resp.Success)
+	require.Equal(t, []string{"1234@s.whatsapp.net"}, marked)
+}
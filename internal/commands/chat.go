@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// ChatOptions configures the interactive chat command.
+type ChatOptions struct {
+	// History is how many recent messages are shown before the prompt.
+	History int
+	Send    SendOptions
+}
+
+// Chat resolves a contact or group by name, phone number or JID, prints
+// the recent conversation to out and sends each line read from in until
+// EOF, /quit or ctx is cancelled. The transcript goes to out (stderr) so
+// stdout still carries a single JSON summary.
+func (a *App) Chat(ctx context.Context, query string, in io.Reader, out io.Writer, opts ChatOptions) string {
+	chatJID, name, err := a.resolveChat(query)
+	if err != nil {
+		return output.Error(err)
+	}
+	if err := a.checkChatAllowed(chatJID); err != nil {
+		return output.Error(err)
+	}
+
+	messages, err := a.store.ListMessages(store.ListMessagesParams{ChatJID: &chatJID, Limit: opts.History})
+	if err != nil {
+		return output.Error(err)
+	}
+	printTranscript(out, chatJID, name, messages)
+	fmt.Fprintln(out, "Type a message and press Enter to send; /quit or Ctrl+D to leave.")
+
+	// Read stdin on its own goroutine so Ctrl+C ends the session even
+	// while waiting for input.
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	sent, failed := 0, 0
+loop:
+	for {
+		fmt.Fprint(out, "> ")
+		var line string
+		var ok bool
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(out)
+			break loop
+		case line, ok = <-lines:
+			if !ok {
+				fmt.Fprintln(out)
+				break loop
+			}
+		}
+
+		line = strings.TrimSpace(line)
+		switch line {
+		case "":
+			continue
+		case "/quit", "/exit":
+			break loop
+		}
+		if _, err := a.sendText(ctx, chatJID, line, opts.Send); err != nil {
+			failed++
+			fmt.Fprintf(out, "✗ Not sent: %v\n", err)
+			continue
+		}
+		sent++
+		fmt.Fprintln(out, "✓ Sent")
+	}
+
+	return output.Success(map[string]interface{}{
+		"chat_jid": chatJID,
+		"name":     name,
+		"shown":    len(messages),
+		"sent":     sent,
+		"failed":   failed,
+	})
+}
+
+// resolveChat finds the chat a name, phone number or JID refers to. An
+// exact (case-insensitive) name match wins over partial matches; anything
+// still ambiguous is an error listing the candidates.
+func (a *App) resolveChat(query string) (jid, name string, err error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", "", fmt.Errorf("chat name, phone number or JID is required")
+	}
+	if strings.Contains(query, "@") {
+		name, err := a.store.GetChatName(query)
+		return query, name, err
+	}
+
+	chats, err := a.store.ListChats(store.ListChatsParams{Query: &query, Limit: 20})
+	if err != nil {
+		return "", "", err
+	}
+	var exact []store.Chat
+	for _, c := range chats {
+		if strings.EqualFold(c.Name, query) {
+			exact = append(exact, c)
+		}
+	}
+	if len(exact) > 0 {
+		chats = exact
+	}
+
+	switch {
+	case len(chats) == 1:
+		return chats[0].JID, chats[0].Name, nil
+	case len(chats) == 0:
+		if phone := strings.TrimPrefix(query, "+"); isDigits(phone) {
+			return recipientToJID(phone), "", nil
+		}
+		return "", "", fmt.Errorf("no chat matches %q", query)
+	}
+
+	candidates := make([]string, 0, len(chats))
+	for _, c := range chats {
+		candidates = append(candidates, fmt.Sprintf("%s (%s)", c.Name, c.JID))
+	}
+	return "", "", fmt.Errorf("%q matches %d chats: %s; use the JID instead", query, len(chats), strings.Join(candidates, ", "))
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// printTranscript renders messages (newest first, as the store returns
+// them) oldest first.
+func printTranscript(w io.Writer, chatJID, name string, messages []store.Message) {
+	fmt.Fprintf(w, "💬 %s\n", chatLabel(chatJID, name))
+	if len(messages) == 0 {
+		fmt.Fprintln(w, "   (no messages yet)")
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		m := messages[i]
+		content := m.Content
+		if m.MediaType != "" {
+			content = strings.TrimSpace(fmt.Sprintf("[%s] %s", m.MediaType, content))
+		}
+		fmt.Fprintf(w, "[%s] %s: %s\n", m.Timestamp.Local().Format("2006-01-02 15:04"), senderLabel(m, name), content)
+	}
+}
+
+func senderLabel(m store.Message, chatName string) string {
+	switch {
+	case m.IsFromMe:
+		return "You"
+	case !strings.HasSuffix(m.ChatJID, "@g.us") && chatName != "":
+		return chatName
+	}
+	user, _, _ := strings.Cut(m.Sender, "@")
+	return user
+}
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// mediaTypeNone in a chat media policy turns off automatic downloads.
+const mediaTypeNone = "none"
+
+// downloadableMediaTypes are the media types a chat media policy can list.
+var downloadableMediaTypes = []string{"image", "video", "audio", "document", "sticker"}
+
+// errMediaPolicyBlocked is returned by background downloads a chat's media
+// policy excludes.
+var errMediaPolicyBlocked = errors.New("excluded by chat media policy")
+
+// parseMediaTypes validates a comma-separated --types value. "all" or an
+// empty value allows every type; "none" disables automatic downloads.
+func parseMediaTypes(value string) ([]string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "all" {
+		return []string{}, nil
+	}
+	if value == mediaTypeNone {
+		return []string{mediaTypeNone}, nil
+	}
+
+	seen := map[string]bool{}
+	var types []string
+	for _, t := range strings.Split(value, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		valid := false
+		for _, known := range downloadableMediaTypes {
+			if t == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown media type %q (use %s, all or none)", t, strings.Join(downloadableMediaTypes, ", "))
+		}
+		seen[t] = true
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+func (a *App) SetChatMediaPolicy(chatJID, types, maxSize string) string {
+	if err := a.checkChatAllowed(chatJID); err != nil {
+		return output.Error(err)
+	}
+	parsedTypes, err := parseMediaTypes(types)
+	if err != nil {
+		return output.Error(err)
+	}
+	size, err := parseByteSize(maxSize)
+	if err != nil {
+		return output.Error(fmt.Errorf("max size: %w", err))
+	}
+	if err := a.store.SetChatMediaPolicy(chatJID, parsedTypes, size); err != nil {
+		return output.Error(err)
+	}
+	return output.Success(map[string]interface{}{
+		"chat_jid": chatJID,
+		"types":    parsedTypes,
+		"max_size": size,
+	})
+}
+
+func (a *App) ClearChatMediaPolicy(chatJID string) string {
+	if err := a.checkChatAllowed(chatJID); err != nil {
+		return output.Error(err)
+	}
+	if err := a.store.ClearChatMediaPolicy(chatJID); err != nil {
+		return output.Error(err)
+	}
+	return output.Success(map[string]interface{}{
+		"chat_jid": chatJID,
+		"cleared":  true,
+	})
+}
+
+func (a *App) ListChatMediaPolicies() string {
+	policies, err := a.store.ListChatMediaPolicies()
+	if err != nil {
+		return output.Error(err)
+	}
+	return output.Success(policies)
+}
+
+// checkChatMediaPolicy decides whether a background download may proceed.
+// Chats without a policy download everything the global limits allow.
+func (a *App) checkChatMediaPolicy(info store.MessageDownloadInfo) error {
+	policy, err := a.store.GetChatMediaPolicy(info.ChatJID)
+	if err != nil {
+		return err
+	}
+	if policy != nil && !policy.Allows(info.MediaType, int64(info.FileLength)) {
+		return errMediaPolicyBlocked
+	}
+	return nil
+}
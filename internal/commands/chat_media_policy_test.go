@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+func TestParseMediaTypes(t *testing.T) {
+	types, err := parseMediaTypes("image, document,image")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"image", "document"}, types)
+
+	types, err = parseMediaTypes("all")
+	require.NoError(t, err)
+	assert.Empty(t, types)
+
+	types, err = parseMediaTypes("none")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"none"}, types)
+
+	_, err = parseMediaTypes("image,gif")
+	assert.Error(t, err)
+}
+
+// TestSetChatMediaPolicy_ParsesFlags verifies --types and --max-size reach
+// the store parsed.
+func TestSetChatMediaPolicy_ParsesFlags(t *testing.T) {
+	var gotTypes []string
+	var gotSize int64
+	mockStore := &MockMessageStore{
+		SetChatMediaPolicyFunc: func(chatJID string, types []string, maxSize int64) error {
+			gotTypes, gotSize = types, maxSize
+			return nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+
+	resp := parseResponse(t, app.SetChatMediaPolicy("work@g.us", "image,document", "5MB"))
+	require.True(t, resp.Success)
+	assert.Equal(t, []string{"image", "document"}, gotTypes)
+	assert.EqualValues(t, 5<<20, gotSize)
+
+	resp = parseResponse(t, app.SetChatMediaPolicy("work@g.us", "image", "lots"))
+	assert.False(t, resp.Success)
+}
+
+// TestProcessMediaJob_RespectsChatMediaPolicy verifies background downloads
+// skip media the chat's policy excludes, before any space is reserved.
+func TestProcessMediaJob_RespectsChatMediaPolicy(t *testing.T) {
+	mockStore := &MockMessageStore{
+		GetMediaForSyncFunc: func(id, chatJID string) (store.MessageDownloadInfo, error) {
+			return store.MessageDownloadInfo{
+				ID: id, ChatJID: chatJID, MediaType: "video",
+				DirectPath: "/v/t62", MediaKey: []byte{1}, FileLength: 50 << 20,
+			}, nil
+		},
+		GetChatMediaPolicyFunc: func(chatJID string) (*store.ChatMediaPolicy, error) {
+			return &store.ChatMediaPolicy{ChatJID: chatJID, Types: []string{"image", "document"}, MaxSize: 5 << 20}, nil
+		},
+		MediaUsageFunc: func(root string) (int64, error) {
+			t.Fatal("quota should not be checked for excluded media")
+			return 0, nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+	app.mediaGuard = newMediaGuard(app, MediaLimits{Quota: 1 << 30, Policy: MediaPolicySkip})
+
+	err := app.processMediaJob(context.Background(), mediaJob{messageID: "m1", chatJID: "family@g.us"})
+	assert.ErrorIs(t, err, errMediaPolicyBlocked)
+}
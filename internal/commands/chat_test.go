@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// TestResolveChat_PrefersExactNameMatch verifies an exact name beats
+// partial matches, ambiguity is an error and bare numbers become JIDs.
+func TestResolveChat_PrefersExactNameMatch(t *testing.T) {
+	chats := []store.Chat{
+		{JID: "1@s.whatsapp.net", Name: "John Doe"},
+		{JID: "2@s.whatsapp.net", Name: "John Doe Jr"},
+		{JID: "3@s.whatsapp.net", Name: "Johnny"},
+	}
+	mockStore := &MockMessageStore{
+		ListChatsFunc: func(params store.ListChatsParams) ([]store.Chat, error) {
+			var out []store.Chat
+			for _, c := range chats {
+				if strings.Contains(strings.ToLower(c.Name), strings.ToLower(*params.Query)) {
+					out = append(out, c)
+				}
+			}
+			return out, nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+
+	jid, name, err := app.resolveChat("john doe")
+	require.NoError(t, err)
+	require.Equal(t, "1@s.whatsapp.net", jid)
+	require.Equal(t, "John Doe", name)
+
+	_, _, err = app.resolveChat("John")
+	require.ErrorContains(t, err, "matches 3 chats")
+
+	jid, _, err = app.resolveChat("+34600000000")
+	require.NoError(t, err)
+	require.Equal(t, "34600000000@s.whatsapp.net", jid)
+
+	_, _, err = app.resolveChat("Nobody")
+	require.Error(t, err)
+}
+
+// TestChat_SendsTypedLinesUntilQuit verifies the transcript is printed
+// oldest first, each non-empty line is sent, and stdout gets one summary.
+func TestChat_SendsTypedLinesUntilQuit(t *testing.T) {
+	now := time.Now()
+	mockStore := &MockMessageStore{
+		GetChatNameFunc: func(jid string) (string, error) { return "John Doe", nil },
+		ListMessagesFunc: func(params store.ListMessagesParams) ([]store.Message, error) {
+			return []store.Message{
+				{ID: "2", ChatJID: *params.ChatJID, Content: "second", Timestamp: now, IsFromMe: true},
+				{ID: "1", ChatJID: *params.ChatJID, Content: "first", Timestamp: now.Add(-time.Minute)},
+			}, nil
+		},
+	}
+	var sent []string
+	mockClient := &MockWAClient{
+		SendMessageFunc: func(ctx context.Context, recipient, message string) (string, error) {
+			sent = append(sent, message)
+			return "id", nil
+		},
+	}
+	app := NewAppWithDeps(mockClient, mockStore, t.TempDir(), "test")
+
+	var transcript bytes.Buffer
+	in := strings.NewReader("hello\n\nhow are you?\n/quit\nnot sent\n")
+	resp := parseResponse(t, app.Chat(context.Background(), "1@s.whatsapp.net", in, &transcript, ChatOptions{History: 20}))
+	require.True(t, resp.Success)
+	require.Equal(t, []string{"hello", "how are you?"}, sent)
+
+	out := transcript.String()
+	require.Less(t, strings.Index(out, "John Doe: first"), strings.Index(out, "You: second"))
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+	require.EqualValues(t, 2, data["sent"])
+	require.EqualValues(t, 2, data["shown"])
+}
@@ -9,14 +9,17 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/vicentereig/whatsapp-cli/internal/client"
+	"github.com/vicentereig/whatsapp-cli/internal/config"
 	"github.com/vicentereig/whatsapp-cli/internal/output"
 	"github.com/vicentereig/whatsapp-cli/internal/store"
 	"github.com/vicentereig/whatsapp-cli/internal/types"
+	"github.com/vicentereig/whatsapp-cli/internal/uri"
 	"go.mau.fi/whatsmeow/types/events"
 )
 
@@ -25,8 +28,11 @@ type App struct {
 	store           MessageStore
 	version         string
 	storeDir        string
+	cfg             *config.Config
 	mediaDownloader func(ctx context.Context, info store.MessageDownloadInfo, targetPath string) (int64, error)
 	mediaWorker     *mediaDownloadWorker
+	mediaGuard      *mediaGuard
+	events          *eventRecorder
 }
 
 // NewApp creates a new App with production dependencies.
@@ -42,13 +48,30 @@ func NewApp(storeDir, version string) (*App, error) {
 		return nil, err
 	}
 
+	cfg, err := config.Load(storeDir)
+	if err != nil {
+		return nil, err
+	}
+	providers, err := buildNameProviders(cfg, cli, st)
+	if err != nil {
+		return nil, err
+	}
+	cli.SetNameProviders(providers...)
+	st.SetAllowedChats(cfg.AllowedChats)
+	limits, err := resolveMediaLimits(cfg, "", "", "")
+	if err != nil {
+		return nil, err
+	}
+
 	app := &App{
 		client:   cli,
 		store:    st,
 		version:  resolveVersion(version, gitDescribe),
 		storeDir: storeDir,
+		cfg:      cfg,
 	}
 	app.mediaDownloader = app.downloadMediaWithClient
+	app.mediaGuard = newMediaGuard(app, limits)
 	return app, nil
 }
 
@@ -59,6 +82,7 @@ func NewAppWithDeps(client WAClient, store MessageStore, storeDir, version strin
 		store:    store,
 		version:  version,
 		storeDir: storeDir,
+		cfg:      &config.Config{},
 	}
 	return app
 }
@@ -93,10 +117,11 @@ func (a *App) Auth(ctx context.Context) string {
 	})
 }
 
-func (a *App) ListMessages(chatJID *string, query *string, limit, page int) string {
+func (a *App) ListMessages(chatJID *string, query *string, msgType *string, limit, page int) string {
 	messages, err := a.store.ListMessages(store.ListMessagesParams{
 		ChatJID: chatJID,
 		Query:   query,
+		Type:    msgType,
 		Limit:   limit,
 		Page:    page,
 	})
@@ -116,11 +141,12 @@ func (a *App) SearchContacts(query string) string {
 	return output.Success(contacts)
 }
 
-func (a *App) ListChats(query *string, limit, page int) string {
+func (a *App) ListChats(query *string, limit, page int, includeDeleted bool) string {
 	chats, err := a.store.ListChats(store.ListChatsParams{
-		Query: query,
-		Limit: limit,
-		Page:  page,
+		Query:          query,
+		Limit:          limit,
+		Page:           page,
+		IncludeDeleted: includeDeleted,
 	})
 	if err != nil {
 		return output.Error(err)
@@ -137,14 +163,38 @@ func recipientToJID(recipient string) string {
 	return recipient + "@s.whatsapp.net"
 }
 
-func (a *App) SendMessage(ctx context.Context, recipient, message string) string {
-	if err := a.client.Connect(ctx); err != nil {
+func (a *App) SendMessage(ctx context.Context, recipient, message string, opts SendOptions) string {
+	msgID, err := a.sendText(ctx, recipient, message, opts)
+	if err != nil {
 		return output.Error(err)
 	}
 
+	return output.Success(map[string]interface{}{
+		"sent":      true,
+		"id":        msgID,
+		"uri":       uri.Message(recipientToJID(recipient), msgID),
+		"recipient": recipient,
+		"message":   message,
+	})
+}
+
+// sendText sends a text message and records it in the local store. It backs
+// both send and the interactive chat prompt.
+func (a *App) sendText(ctx context.Context, recipient, message string, opts SendOptions) (string, error) {
+	if err := a.checkChatAllowed(recipientToJID(recipient)); err != nil {
+		return "", err
+	}
+	logID := a.logOutboundQueued(recipientToJID(recipient), "text", message, "")
+	if err := a.client.Connect(ctx); err != nil {
+		a.logOutboundResult(logID, "", err)
+		return "", err
+	}
+	a.markReadBeforeSend(ctx, recipientToJID(recipient), opts)
+
 	msgID, err := a.client.SendMessage(ctx, recipient, message)
+	a.logOutboundResult(logID, msgID, err)
 	if err != nil {
-		return output.Error(err)
+		return "", err
 	}
 
 	timestamp := time.Now()
@@ -156,30 +206,31 @@ func (a *App) SendMessage(ctx context.Context, recipient, message string) string
 	}
 
 	if err := a.store.StoreChat(chatJID, chatName, timestamp); err != nil {
-		return output.Error(fmt.Errorf("storing chat: %w", err))
+		return "", fmt.Errorf("storing chat: %w", err)
 	}
 	if err := a.store.StoreMessage(
 		msgID, chatJID, "me", message, timestamp, true,
 		"", "", "", "", "",
 		nil, nil, nil, 0,
 	); err != nil {
-		return output.Error(fmt.Errorf("storing message: %w", err))
+		return "", fmt.Errorf("storing message: %w", err)
 	}
-
-	return output.Success(map[string]interface{}{
-		"sent":      true,
-		"id":        msgID,
-		"recipient": recipient,
-		"message":   message,
-	})
+	return msgID, nil
 }
 
-func (a *App) SendImage(ctx context.Context, recipient, imagePath, caption string) string {
+func (a *App) SendImage(ctx context.Context, recipient, imagePath, caption string, opts SendOptions) string {
+	if err := a.checkChatAllowed(recipientToJID(recipient)); err != nil {
+		return output.Error(err)
+	}
+	logID := a.logOutboundQueued(recipientToJID(recipient), "image", caption, imagePath)
 	if err := a.client.Connect(ctx); err != nil {
+		a.logOutboundResult(logID, "", err)
 		return output.Error(err)
 	}
+	a.markReadBeforeSend(ctx, recipientToJID(recipient), opts)
 
 	msgID, err := a.client.SendImageMessage(ctx, recipient, imagePath, caption)
+	a.logOutboundResult(logID, msgID, err)
 	if err != nil {
 		return output.Error(err)
 	}
@@ -211,6 +262,7 @@ func (a *App) SendImage(ctx context.Context, recipient, imagePath, caption strin
 	return output.Success(map[string]interface{}{
 		"sent":      true,
 		"id":        msgID,
+		"uri":       uri.Message(chatJID, msgID),
 		"recipient": recipient,
 		"image":     imagePath,
 		"caption":   caption,
@@ -243,6 +295,7 @@ func (a *App) DownloadMedia(ctx context.Context, messageID string, chatJID *stri
 	response := map[string]interface{}{
 		"message_id":    messageID,
 		"chat_jid":      info.ChatJID,
+		"uri":           uri.Message(info.ChatJID, messageID),
 		"path":          targetPath,
 		"bytes":         bytesWritten,
 		"media_type":    info.MediaType,
@@ -409,13 +462,15 @@ func (a *App) downloadMediaWithClient(ctx context.Context, info store.MessageDow
 	return a.client.DownloadMediaToFile(ctx, req, targetPath)
 }
 
-func (a *App) downloadMediaAndPersist(ctx context.Context, info store.MessageDownloadInfo, requestedPath string) (string, int64, time.Time, error) {
+// downloadMediaFile downloads media to requestedPath, or its default
+// location under the store, without recording it.
+func (a *App) downloadMediaFile(ctx context.Context, info store.MessageDownloadInfo, requestedPath string) (string, int64, error) {
 	finalPath, err := a.resolveOutputPath(info, requestedPath)
 	if err != nil {
-		return "", 0, time.Time{}, err
+		return "", 0, err
 	}
 	if err := os.MkdirAll(filepath.Dir(finalPath), 0755); err != nil {
-		return "", 0, time.Time{}, fmt.Errorf("failed to create destination directory: %w", err)
+		return "", 0, fmt.Errorf("failed to create destination directory: %w", err)
 	}
 
 	downloader := a.mediaDownloader
@@ -424,6 +479,14 @@ func (a *App) downloadMediaAndPersist(ctx context.Context, info store.MessageDow
 	}
 
 	bytesWritten, err := downloader(ctx, info, finalPath)
+	if err != nil {
+		return "", 0, err
+	}
+	return finalPath, bytesWritten, nil
+}
+
+func (a *App) downloadMediaAndPersist(ctx context.Context, info store.MessageDownloadInfo, requestedPath string) (string, int64, time.Time, error) {
+	finalPath, bytesWritten, err := a.downloadMediaFile(ctx, info, requestedPath)
 	if err != nil {
 		return "", 0, time.Time{}, err
 	}
@@ -440,7 +503,7 @@ func (a *App) processMediaJob(ctx context.Context, job mediaJob) error {
 	if a.store == nil {
 		return fmt.Errorf("message store not initialized")
 	}
-	info, err := a.store.GetMessageForDownload(job.messageID, &job.chatJID)
+	info, err := a.store.GetMediaForSync(job.messageID, job.chatJID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil
@@ -455,10 +518,23 @@ func (a *App) processMediaJob(ctx context.Context, job mediaJob) error {
 			return nil
 		}
 	}
+	if err := a.admitMedia(info); err != nil {
+		return err
+	}
 	_, _, _, err = a.downloadMediaAndPersist(ctx, info, "")
 	return err
 }
 
+// admitMedia checks an automatic download against the chat's media policy
+// and reserves space for it under the media limits. Every background
+// download goes through it, whether from the media worker or a pipeline.
+func (a *App) admitMedia(info store.MessageDownloadInfo) error {
+	if err := a.checkChatMediaPolicy(info); err != nil {
+		return err
+	}
+	return a.mediaGuard.Reserve(int64(info.FileLength))
+}
+
 type mediaJob struct {
 	messageID string
 	chatJID   string
@@ -508,14 +584,21 @@ func (w *mediaDownloadWorker) run() {
 		case <-w.ctx.Done():
 			return
 		case job := <-w.jobs:
-			if err := w.app.processMediaJob(w.ctx, job); err != nil {
-				w.trackError(err)
-			}
+			w.app.safely("media download worker", func() {
+				if err := w.app.processMediaJob(w.ctx, job); err != nil {
+					w.trackError(err)
+				}
+			})
 		}
 	}
 }
 
 func (w *mediaDownloadWorker) trackError(err error) {
+	// Space-limit skips are counted and reported by mediaGuard; chat
+	// media policy skips are intended.
+	if errors.Is(err, errMediaSpace) || errors.Is(err, errMediaPolicyBlocked) {
+		return
+	}
 	errStr := err.Error()
 	// Check for expected expired/deleted media errors
 	isExpired := strings.Contains(errStr, "status code 403") ||
@@ -547,15 +630,15 @@ func (w *mediaDownloadWorker) PrintSummary() {
 	w.mu.Unlock()
 
 	if expiredCount > 0 {
-		fmt.Fprintf(os.Stderr, "⚠️  Skipped %d expired/deleted media files (normal for old messages)\n", expiredCount)
+		fmt.Fprintf(output.Stderr, "⚠️  Skipped %d expired/deleted media files (normal for old messages)\n", expiredCount)
 	}
 	if otherErrors > 0 {
-		fmt.Fprintf(os.Stderr, "⚠️  %d media downloads failed:\n", otherErrors)
+		fmt.Fprintf(output.Stderr, "⚠️  %d media downloads failed:\n", otherErrors)
 		for _, msg := range otherErrorMsgs {
-			fmt.Fprintf(os.Stderr, "   - %s\n", msg)
+			fmt.Fprintf(output.Stderr, "   - %s\n", msg)
 		}
 		if otherErrors > len(otherErrorMsgs) {
-			fmt.Fprintf(os.Stderr, "   ... and %d more\n", otherErrors-len(otherErrorMsgs))
+			fmt.Fprintf(output.Stderr, "   ... and %d more\n", otherErrors-len(otherErrorMsgs))
 		}
 	}
 }
@@ -587,15 +670,99 @@ func (w *mediaDownloadWorker) Stop() {
 	w.wg.Wait()
 }
 
+// SyncOptions controls optional behavior of the sync loop.
+type SyncOptions struct {
+	// Daemon enables background maintenance (e.g. retention pruning) and
+	// the anomaly monitor for long-running, unattended syncs.
+	Daemon bool
+
+	// MediaQuota, MediaMinFree and MediaPolicy override the media_quota,
+	// media_min_free and media_policy config settings when non-empty.
+	MediaQuota   string
+	MediaMinFree string
+	MediaPolicy  string
+}
+
 // Sync connects to WhatsApp and continuously syncs messages to the database
-func (a *App) Sync(ctx context.Context) string {
+func (a *App) Sync(ctx context.Context, opts SyncOptions) (result string) {
 	messageCount := 0
 
+	// Panics and fatal connection errors produce a crash report with the
+	// last events seen, so "it crashed during history sync" is actionable.
+	a.events = newEventRecorder(crashEventHistory)
+	defer func() {
+		if r := recover(); r != nil {
+			path := a.reportCrash("sync", r, nil, debug.Stack())
+			result = output.Error(fmt.Errorf("sync crashed: %v (crash report: %s)", r, path))
+		}
+	}()
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	fatal := make(chan error, 1)
+
 	version := a.version
 	if strings.TrimSpace(version) == "" {
 		version = "unknown"
 	}
-	fmt.Fprintf(os.Stderr, "ℹ️  whatsapp-cli version: %s\n", version)
+	fmt.Fprintf(output.Stderr, "ℹ️  whatsapp-cli version: %s\n", version)
+
+	if opts.MediaQuota != "" || opts.MediaMinFree != "" || opts.MediaPolicy != "" {
+		limits, err := resolveMediaLimits(a.cfg, opts.MediaQuota, opts.MediaMinFree, opts.MediaPolicy)
+		if err != nil {
+			return output.Error(err)
+		}
+		a.mediaGuard = newMediaGuard(a, limits)
+	}
+	if a.mediaGuard != nil && a.mediaGuard.limits.enabled() {
+		fmt.Fprintf(output.Stderr, "ℹ️  Media limits: quota %s, min free %s, policy %s\n",
+			formatLimit(a.mediaGuard.limits.Quota), formatLimit(a.mediaGuard.limits.MinFree), a.mediaGuard.limits.Policy)
+	}
+
+	var monitor *anomalyMonitor
+	if opts.Daemon && a.cfg != nil && a.cfg.Monitor.Enabled {
+		settings, err := resolveMonitorSettings(a.cfg.Monitor)
+		if err != nil {
+			return output.Error(err)
+		}
+		monitor = newAnomalyMonitor(a, settings, time.Now())
+		fmt.Fprintf(output.Stderr, "ℹ️  Anomaly monitor: %d critical chats, checking every %s\n", len(settings.critical), settings.interval)
+	}
+
+	var groups *groupRefresher
+	if opts.Daemon && (a.cfg == nil || !a.cfg.GroupRefresh.Disabled) {
+		var groupCfg config.GroupRefreshConfig
+		if a.cfg != nil {
+			groupCfg = a.cfg.GroupRefresh
+		}
+		settings, err := resolveGroupRefreshSettings(groupCfg)
+		if err != nil {
+			return output.Error(err)
+		}
+		groups = newGroupRefresher(a, settings)
+		defer func() {
+			stop()
+			groups.Wait()
+			groups.PrintSummary()
+		}()
+	}
+
+	var pipelineCfgs []config.Pipeline
+	if a.cfg != nil {
+		pipelineCfgs = a.cfg.Pipelines
+	}
+	pipelines, err := newPipelineRunner(a, pipelineCfgs)
+	if err != nil {
+		return output.Error(err)
+	}
+	if pipelines != nil {
+		fmt.Fprintf(output.Stderr, "ℹ️  Pipelines: %d configured\n", len(pipelines.pipelines))
+	}
+	pipelines.Start(ctx)
+	defer func() {
+		stop()
+		pipelines.Wait()
+		pipelines.PrintSummary()
+	}()
 
 	worker := newMediaDownloadWorker(a, 4)
 	worker.Start(ctx)
@@ -603,11 +770,31 @@ func (a *App) Sync(ctx context.Context) string {
 	defer func() {
 		worker.Stop()
 		worker.PrintSummary()
+		a.mediaGuard.PrintSummary()
 		if a.mediaWorker == worker {
 			a.mediaWorker = nil
 		}
 	}()
 
+	// History sync only ingests raw rows; name lookups run in the background
+	// so large backfills don't stall the event handler.
+	enricher := newEnrichWorker(a)
+	enricher.Start(ctx)
+	defer func() {
+		enricher.Wait()
+		enricher.PrintSummary()
+	}()
+
+	// History chunks arrive on the event handler goroutine only, so the
+	// tracker needs no locking.
+	history := &syncProgress{}
+
+	// Participant contact names are looked up in the background too, since
+	// name providers may go over the network.
+	names := newParticipantNames(a)
+	names.Start(ctx)
+	defer names.Wait()
+
 	// Create event handler
 	eventHandler := func(evt interface{}) {
 		switch v := evt.(type) {
@@ -620,6 +807,10 @@ func (a *App) Sync(ctx context.Context) string {
 			content := details.Content
 			msgTime := details.Timestamp
 			isFromMe := details.IsFromMe
+			if details.Location != nil && a.recordLocation(id, chatJID, sender, msgTime, details.Location, true) {
+				// Live location updates extend the original message's track.
+				break
+			}
 			mediaType := ""
 			filename := ""
 			url := ""
@@ -645,42 +836,100 @@ func (a *App) Sync(ctx context.Context) string {
 				chatName = chatJID
 			}
 
-			// Store chat
-			a.store.StoreChat(chatJID, chatName, msgTime)
-
-			// Store message
-			a.store.StoreMessage(
-				id,
-				chatJID,
-				sender,
-				content,
-				msgTime,
-				isFromMe,
-				mediaType,
-				filename,
-				url,
-				directPath,
-				mimeType,
-				mediaKey, fileSHA256, fileEncSHA256, fileLength,
-			)
-
-			if directPath != "" && len(mediaKey) > 0 {
-				worker.Enqueue(mediaJob{messageID: id, chatJID: chatJID})
+			// Pipelines decide where the message goes; those without a
+			// store sink keep it out of messages.db.
+			event := PipelineEvent{
+				ID:         id,
+				URI:        uri.Message(chatJID, id),
+				ChatJID:    chatJID,
+				ChatName:   chatName,
+				Sender:     sender,
+				SenderName: v.Info.PushName,
+				Content:    content,
+				Timestamp:  msgTime,
+				IsFromMe:   isFromMe,
+				Type:       pipelineEventType(mediaType, details.Location != nil),
+				Filename:   filename,
+				MimeType:   mimeType,
+			}
+			matched := pipelines.Match(event)
+			stored := shouldStore(matched)
+			storeMessage := func() {
+				// Store chat
+				a.store.StoreChat(chatJID, chatName, msgTime)
+
+				// Store message
+				a.store.StoreMessage(
+					id,
+					chatJID,
+					sender,
+					content,
+					msgTime,
+					isFromMe,
+					mediaType,
+					filename,
+					url,
+					directPath,
+					mimeType,
+					mediaKey, fileSHA256, fileEncSHA256, fileLength,
+				)
+				a.store.StoreRawMessage(id, chatJID, details.RawProto, details.ReplyToID)
+				if details.Commerce != nil {
+					a.storeCommerce(id, chatJID, details.Commerce)
+				}
+				if details.Interactive != nil {
+					a.store.StoreInteractive(id, chatJID, details.Interactive.Kind, details.Interactive.SelectedID)
+				}
+				if !isFromMe {
+					names.Record(chatJID, v.Info.Sender.ToNonAD().String(), v.Info.PushName)
+				}
+
+				if directPath != "" && len(mediaKey) > 0 && !downloadsMedia(matched) {
+					worker.Enqueue(mediaJob{messageID: id, chatJID: chatJID})
+				}
+			}
+			if stored {
+				storeMessage()
+			}
+			if len(matched) > 0 {
+				job := pipelineJob{event: event, pipelines: matched, stored: stored, storeMessage: storeMessage}
+				if directPath != "" && len(mediaKey) > 0 {
+					job.media = &store.MessageDownloadInfo{
+						ID:            id,
+						ChatJID:       chatJID,
+						MediaType:     mediaType,
+						Filename:      filename,
+						DirectPath:    directPath,
+						MimeType:      mimeType,
+						URL:           url,
+						MediaKey:      mediaKey,
+						FileSHA256:    fileSHA256,
+						FileEncSHA256: fileEncSHA256,
+						FileLength:    fileLength,
+					}
+				}
+				pipelines.Enqueue(job)
+			}
+
+			if !isFromMe && a.autoMarkRead() {
+				if err := a.client.MarkRead(ctx, chatJID, v.Info.Sender.String(), []string{id}); err == nil {
+					a.store.MarkMessagesRead(chatJID, []string{id}, time.Now())
+				}
 			}
 
 			messageCount++
-			fmt.Fprintf(os.Stderr, "\r💬 Synced %d messages...", messageCount)
+			fmt.Fprintf(output.Stderr, "\r💬 Synced %d messages...", messageCount)
 
 		case *events.HistorySync:
-			fmt.Fprintf(os.Stderr, "\n📜 Processing history sync (%d conversations)...\n", len(v.Data.Conversations))
+			fmt.Fprintf(output.Stderr, "\n📜 Processing history sync (%d conversations)...\n", len(v.Data.Conversations))
+			history.SetPercent(time.Now(), v.Data.GetProgress())
 			for _, conv := range v.Data.Conversations {
+				convMessages := 0
 				chatJID := conv.GetID()
 				chatName := conv.GetName()
-				if chatName == "" {
-					chatName = a.client.ResolveChatName(ctx, chatJID, nil)
-					if chatName == "" {
-						chatName = chatJID
-					}
+				unnamed := chatName == ""
+				if unnamed {
+					chatName = chatJID
 				}
 
 				// Process messages in this conversation
@@ -690,6 +939,9 @@ func (a *App) Sync(ctx context.Context) string {
 					}
 
 					histMsg := msg.Message
+					if changes := stubParticipantEvents(chatJID, histMsg); len(changes) > 0 {
+						a.store.RecordParticipantEvents(changes)
+					}
 					msgID := histMsg.Key.GetID()
 					sender := histMsg.Key.GetParticipant()
 					if sender == "" {
@@ -699,7 +951,13 @@ func (a *App) Sync(ctx context.Context) string {
 					msgTimestamp := time.Unix(int64(histMsg.GetMessageTimestamp()), 0)
 
 					// Extract content
-					content := ""
+					extracted := client.ExtractHistoryContent(histMsg)
+					if extracted.Location != nil {
+						// History may arrive newest first, so its points are
+						// kept as they are rather than merged into tracks.
+						a.recordLocation(msgID, chatJID, sender, msgTimestamp, extracted.Location, false)
+					}
+					content := extracted.Content
 					mediaType := ""
 					filename := ""
 					url := ""
@@ -708,59 +966,17 @@ func (a *App) Sync(ctx context.Context) string {
 					var mediaKey, fileSHA256, fileEncSHA256 []byte
 					var fileLength uint64
 
-					switch {
-					case histMsg.Message.GetConversation() != "":
-						content = histMsg.Message.GetConversation()
-					case histMsg.Message.GetExtendedTextMessage() != nil:
-						extText := histMsg.Message.GetExtendedTextMessage()
-						content = extText.GetText()
-					case histMsg.Message.GetImageMessage() != nil:
-						img := histMsg.Message.GetImageMessage()
-						mediaType = "image"
-						content = img.GetCaption()
+					if media := extracted.Media; media != nil {
+						mediaType = media.Type
 						// Don't use caption as filename - it can be very long text
-						url = img.GetURL()
-						directPath = img.GetDirectPath()
-						mimeType = img.GetMimetype()
-						mediaKey = img.GetMediaKey()
-						fileSHA256 = img.GetFileSHA256()
-						fileEncSHA256 = img.GetFileEncSHA256()
-						fileLength = img.GetFileLength()
-					case histMsg.Message.GetVideoMessage() != nil:
-						video := histMsg.Message.GetVideoMessage()
-						mediaType = "video"
-						content = video.GetCaption()
-						// Don't use caption as filename - it can be very long text
-						url = video.GetURL()
-						directPath = video.GetDirectPath()
-						mimeType = video.GetMimetype()
-						mediaKey = video.GetMediaKey()
-						fileSHA256 = video.GetFileSHA256()
-						fileEncSHA256 = video.GetFileEncSHA256()
-						fileLength = video.GetFileLength()
-					case histMsg.Message.GetAudioMessage() != nil:
-						audio := histMsg.Message.GetAudioMessage()
-						mediaType = "audio"
-						content = "[Audio]"
-						url = audio.GetURL()
-						directPath = audio.GetDirectPath()
-						mimeType = audio.GetMimetype()
-						mediaKey = audio.GetMediaKey()
-						fileSHA256 = audio.GetFileSHA256()
-						fileEncSHA256 = audio.GetFileEncSHA256()
-						fileLength = audio.GetFileLength()
-					case histMsg.Message.GetDocumentMessage() != nil:
-						doc := histMsg.Message.GetDocumentMessage()
-						mediaType = "document"
-						content = doc.GetCaption()
-						filename = doc.GetFileName()
-						url = doc.GetURL()
-						directPath = doc.GetDirectPath()
-						mimeType = doc.GetMimetype()
-						mediaKey = doc.GetMediaKey()
-						fileSHA256 = doc.GetFileSHA256()
-						fileEncSHA256 = doc.GetFileEncSHA256()
-						fileLength = doc.GetFileLength()
+						filename = media.Filename
+						url = media.URL
+						directPath = media.DirectPath
+						mimeType = media.MimeType
+						mediaKey = media.MediaKey
+						fileSHA256 = media.FileSHA256
+						fileEncSHA256 = media.FileEncSHA256
+						fileLength = media.FileLength
 					}
 
 					// Store chat
@@ -781,35 +997,107 @@ func (a *App) Sync(ctx context.Context) string {
 						mimeType,
 						mediaKey, fileSHA256, fileEncSHA256, fileLength,
 					)
+					a.store.StoreRawMessage(msgID, chatJID, client.MarshalRaw(histMsg.Message), extracted.ReplyToID)
+					if extracted.Commerce != nil {
+						a.storeCommerce(msgID, chatJID, extracted.Commerce)
+					}
+					if extracted.Interactive != nil {
+						a.store.StoreInteractive(msgID, chatJID, extracted.Interactive.Kind, extracted.Interactive.SelectedID)
+					}
+					if !isFromMe {
+						names.Record(chatJID, sender, histMsg.GetPushName())
+					}
 
 					if directPath != "" && len(mediaKey) > 0 {
 						worker.Enqueue(mediaJob{messageID: msgID, chatJID: chatJID})
 					}
 
 					messageCount++
+					convMessages++
 				}
+				// Names are resolved once the chat row exists, since
+				// enrichment only renames stored chats.
+				if unnamed && convMessages > 0 {
+					enricher.Enqueue(chatJID)
+				}
+
+				history.Record(time.Now(), 1, convMessages)
+				fmt.Fprintf(output.Stderr, "\r%s", history.Line(time.Now()))
+			}
+
+		case *events.Receipt:
+			a.applyReceipt(v)
+
+		case *events.GroupInfo:
+			a.store.RecordParticipantEvents(groupInfoParticipantEvents(v))
+
+		case *events.DeleteChat:
+			// Messages are kept; the chat is only hidden from chats list.
+			a.store.MarkChatDeleted(v.JID.String(), v.Timestamp)
+
+		case *events.JoinedGroup:
+			a.store.RecordParticipantEvents(joinedGroupParticipantEvents(v, time.Now()))
+			for _, p := range v.Participants {
+				names.Record(v.JID.String(), p.JID.String(), p.DisplayName)
 			}
-			fmt.Fprintf(os.Stderr, "\r💬 Synced %d messages...", messageCount)
 
 		case *events.Connected:
-			fmt.Fprintln(os.Stderr, "\n✓ Connected to WhatsApp")
-			fmt.Fprintln(os.Stderr, "🔄 Listening for messages... (Press Ctrl+C to stop)")
+			fmt.Fprintln(output.Stderr, "\n✓ Connected to WhatsApp")
+			fmt.Fprintln(output.Stderr, "🔄 Listening for messages... (Press Ctrl+C to stop)")
 
 		case *events.Disconnected:
-			fmt.Fprintln(os.Stderr, "\n⚠ Disconnected from WhatsApp")
+			fmt.Fprintln(output.Stderr, "\n⚠ Disconnected from WhatsApp")
+
+		case *events.LoggedOut, *events.StreamReplaced, *events.ClientOutdated:
+			select {
+			case fatal <- fatalSyncError(v):
+				stop()
+			default:
+			}
 		}
 	}
 
+	// Commands run with --consistent read the state file to wait until
+	// events received before they started have been stored.
+	syncState := newSyncStateWriter(a.storeDir)
+	syncState.Start(ctx)
+	defer func() {
+		stop()
+		syncState.Wait()
+	}()
+
 	// Start syncing
-	fmt.Fprintln(os.Stderr, "🚀 Starting WhatsApp sync...")
-	if err := a.client.StartSync(ctx, eventHandler); err != nil {
+	fmt.Fprintln(output.Stderr, "🚀 Starting WhatsApp sync...")
+	if err := a.client.StartSync(ctx, func(evt interface{}) {
+		syncState.Begin()
+		defer syncState.Done()
+		a.events.Record(evt)
+		a.safely("sync event handler", func() { eventHandler(evt) })
+	}); err != nil {
 		return output.Error(err)
 	}
 
-	// Wait for context cancellation (Ctrl+C)
+	if opts.Daemon {
+		a.startMaintenance(ctx, maintenanceInterval)
+	}
+	if monitor != nil {
+		monitor.Start(ctx)
+	}
+	if groups != nil {
+		groups.Start(ctx)
+	}
+
+	// Wait for context cancellation (Ctrl+C) or a fatal connection event
 	<-ctx.Done()
 
-	fmt.Fprintf(os.Stderr, "\n\n✓ Sync completed. Total messages synced: %d\n", messageCount)
+	select {
+	case err := <-fatal:
+		path := a.reportCrash("sync", nil, err, nil)
+		return output.Error(fmt.Errorf("sync stopped: %v (crash report: %s)", err, path))
+	default:
+	}
+
+	fmt.Fprintf(output.Stderr, "\n\n✓ Sync completed. Total messages synced: %d\n", messageCount)
 
 	return output.Success(map[string]interface{}{
 		"synced":         true,
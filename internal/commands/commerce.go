@@ -0,0 +1,26 @@
+package commands
+
+import (
+	"github.com/vicentereig/whatsapp-cli/internal/client"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+func toCommerce(c *client.CommerceInfo) store.Commerce {
+	return store.Commerce{
+		Type:       c.Type,
+		Amount1000: c.Amount1000,
+		Currency:   c.Currency,
+		ItemCount:  c.ItemCount,
+		Status:     c.Status,
+	}
+}
+
+// storeCommerce records the typed fields of a business or payment message
+// and, for payments and declined or cancelled requests, moves the payment
+// request they answer to its new status.
+func (a *App) storeCommerce(id, chatJID string, c *client.CommerceInfo) {
+	a.store.StoreCommerce(id, chatJID, toCommerce(c))
+	if c.RequestID != "" && c.RequestStatus != "" {
+		a.store.UpdateCommerceStatus(c.RequestID, chatJID, c.RequestStatus)
+	}
+}
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vicentereig/whatsapp-cli/internal/client"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// TestStoreCommerce_SettlesPaymentRequest verifies a declined request is
+// stored as its own entry and moves the original request to "declined".
+func TestStoreCommerce_SettlesPaymentRequest(t *testing.T) {
+	var stored store.Commerce
+	statuses := map[string]string{}
+	mockStore := &MockMessageStore{
+		StoreCommerceFunc: func(id, chatJID string, c store.Commerce) error {
+			stored = c
+			return nil
+		},
+		UpdateCommerceStatusFunc: func(id, chatJID, status string) error {
+			statuses[id] = status
+			return nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+
+	app.storeCommerce("DECLINE1", "friend@s.whatsapp.net", &client.CommerceInfo{
+		Type:          client.CommercePaymentRequest,
+		Status:        client.PaymentDeclined,
+		RequestID:     "REQ1",
+		RequestStatus: client.PaymentDeclined,
+	})
+	assert.Equal(t, client.PaymentDeclined, stored.Status)
+	assert.Equal(t, map[string]string{"REQ1": client.PaymentDeclined}, statuses)
+
+	app.storeCommerce("ORDER1", "shop@s.whatsapp.net", &client.CommerceInfo{Type: client.CommerceOrder})
+	assert.Len(t, statuses, 1, "orders don't settle anything")
+}
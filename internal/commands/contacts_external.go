@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/addressbook"
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+)
+
+// ExternalSyncTimeout bounds a single address book pull, so an unresponsive
+// server can't stall a periodic sync.
+const ExternalSyncTimeout = 5 * time.Minute
+
+// ExternalContactsOptions configures "contacts sync-external". Empty
+// connection fields fall back to the carddav section of config.json.
+type ExternalContactsOptions struct {
+	CardDAV config.CardDAVConfig
+	// Interval repeats the sync until ctx is cancelled; zero syncs once.
+	Interval time.Duration
+}
+
+type externalSyncResult struct {
+	Fetched int `json:"fetched"`
+	Chats   int `json:"chats"`
+	Matched int `json:"matched"`
+	Updated int `json:"updated"`
+}
+
+// SyncExternalContacts pulls an external address book and renames one-to-one
+// chats whose phone number matches an entry.
+func (a *App) SyncExternalContacts(ctx context.Context, opts ExternalContactsOptions) string {
+	dav := opts.CardDAV
+	if a.cfg != nil {
+		fallback := a.cfg.CardDAV
+		if dav.URL == "" {
+			dav.URL = fallback.URL
+		}
+		if dav.Username == "" {
+			dav.Username = fallback.Username
+		}
+		if dav.Password == "" {
+			dav.Password = fallback.Password
+		}
+		if dav.Token == "" {
+			dav.Token = fallback.Token
+		}
+	}
+	if !dav.Enabled() {
+		return output.Error(fmt.Errorf("--carddav-url is required (or set carddav.url in %s)", config.FileName))
+	}
+	dc := cardDAVClient(dav)
+
+	runs := 0
+	var last externalSyncResult
+	for {
+		res, err := a.syncExternalOnce(ctx, dc)
+		switch {
+		case err == nil:
+			runs++
+			last = res
+			fmt.Fprintf(output.Stderr, "📇 Fetched %d contacts, matched %d chats, renamed %d\n", res.Fetched, res.Matched, res.Updated)
+		case opts.Interval <= 0:
+			return output.Error(err)
+		case ctx.Err() == nil:
+			// Periodic mode keeps going; the next run may succeed.
+			fmt.Fprintf(output.Stderr, "⚠️  External contact sync failed: %v\n", err)
+		}
+
+		if opts.Interval <= 0 || !waitOrDone(ctx, opts.Interval) {
+			break
+		}
+	}
+
+	return output.Success(map[string]interface{}{
+		"runs":    runs,
+		"fetched": last.Fetched,
+		"chats":   last.Chats,
+		"matched": last.Matched,
+		"updated": last.Updated,
+	})
+}
+
+func (a *App) syncExternalOnce(ctx context.Context, dc *addressbook.CardDAVClient) (externalSyncResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, ExternalSyncTimeout)
+	defer cancel()
+	entries, err := dc.FetchEntries(ctx)
+	if err != nil {
+		return externalSyncResult{}, err
+	}
+	idx := addressbook.NewIndex(entries)
+
+	jids, err := a.store.ListDirectChatJIDs()
+	if err != nil {
+		return externalSyncResult{}, fmt.Errorf("listing chats: %w", err)
+	}
+
+	res := externalSyncResult{Fetched: len(entries), Chats: len(jids)}
+	for _, jid := range jids {
+		phone, _, _ := strings.Cut(jid, "@")
+		name := idx.Lookup(phone)
+		if name == "" {
+			continue
+		}
+		res.Matched++
+		changed, err := a.store.SetExternalChatName(jid, name)
+		if err != nil {
+			return res, fmt.Errorf("renaming %s: %w", jid, err)
+		}
+		if changed {
+			res.Updated++
+		}
+	}
+	return res, nil
+}
+
+// waitOrDone sleeps for d, returning false early if ctx is cancelled.
+func waitOrDone(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+)
+
+const externalContactsBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:multistatus xmlns:d="DAV:" xmlns:card="urn:ietf:params:xml:ns:carddav">
+  <d:response>
+    <d:propstat>
+      <d:prop><card:address-data>BEGIN:VCARD
+FN:Alice Smith
+TEL:+1 555 123 4567
+END:VCARD
+</card:address-data></d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`
+
+// TestSyncExternalContacts_RenamesMatchingChats verifies chats are renamed
+// only when an address book entry matches their phone number.
+func TestSyncExternalContacts_RenamesMatchingChats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMultiStatus)
+		io.WriteString(w, externalContactsBody)
+	}))
+	defer srv.Close()
+
+	renamed := map[string]string{}
+	mockStore := &MockMessageStore{
+		ListDirectChatJIDsFunc: func() ([]string, error) {
+			return []string{"15551234567@s.whatsapp.net", "34600000000@s.whatsapp.net"}, nil
+		},
+		SetExternalChatNameFunc: func(jid, name string) (bool, error) {
+			renamed[jid] = name
+			return true, nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, "/tmp", "test")
+
+	result := app.SyncExternalContacts(context.Background(), ExternalContactsOptions{
+		CardDAV: config.CardDAVConfig{URL: srv.URL},
+	})
+
+	resp := parseResponse(t, result)
+	require.True(t, resp.Success)
+	require.Equal(t, map[string]string{"15551234567@s.whatsapp.net": "Alice Smith"}, renamed)
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+	require.EqualValues(t, 1, data["fetched"])
+	require.EqualValues(t, 2, data["chats"])
+	require.EqualValues(t, 1, data["updated"])
+}
+
+func TestSyncExternalContacts_RequiresURL(t *testing.T) {
+	app := NewAppWithDeps(&MockWAClient{}, &MockMessageStore{}, "/tmp", "test")
+
+	resp := parseResponse(t, app.SyncExternalContacts(context.Background(), ExternalContactsOptions{}))
+	require.False(t, resp.Success)
+	require.Contains(t, *resp.Error, "--carddav-url")
+}
@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// crashEventHistory is how many recent sync events a crash report includes.
+const crashEventHistory = 50
+
+// eventSummary is a compact, privacy-conscious record of a sync event:
+// identifiers and counts, never message content.
+type eventSummary struct {
+	At     time.Time `json:"at"`
+	Type   string    `json:"type"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// eventRecorder keeps the last N sync events in a ring buffer.
+type eventRecorder struct {
+	mu    sync.Mutex
+	ring  []eventSummary
+	next  int
+	total int
+}
+
+func newEventRecorder(size int) *eventRecorder {
+	return &eventRecorder{ring: make([]eventSummary, size)}
+}
+
+func (r *eventRecorder) Record(evt interface{}) {
+	if r == nil || len(r.ring) == 0 {
+		return
+	}
+	summary := summarizeEvent(evt)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ring[r.next] = summary
+	r.next = (r.next + 1) % len(r.ring)
+	r.total++
+}
+
+// Snapshot returns the recorded events, oldest first.
+func (r *eventRecorder) Snapshot() []eventSummary {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.total < len(r.ring) {
+		return append([]eventSummary(nil), r.ring[:r.total]...)
+	}
+	out := make([]eventSummary, 0, len(r.ring))
+	out = append(out, r.ring[r.next:]...)
+	return append(out, r.ring[:r.next]...)
+}
+
+func summarizeEvent(evt interface{}) eventSummary {
+	s := eventSummary{At: time.Now().UTC(), Type: fmt.Sprintf("%T", evt)}
+	switch v := evt.(type) {
+	case *events.Message:
+		s.Detail = fmt.Sprintf("chat=%s id=%s", v.Info.Chat, v.Info.ID)
+	case *events.HistorySync:
+		if v.Data != nil {
+			s.Detail = fmt.Sprintf("type=%s chunk=%d progress=%d%% conversations=%d",
+				v.Data.GetSyncType(), v.Data.GetChunkOrder(), v.Data.GetProgress(), len(v.Data.GetConversations()))
+		}
+	case *events.Receipt:
+		s.Detail = fmt.Sprintf("chat=%s type=%q ids=%d", v.Chat, v.Type, len(v.MessageIDs))
+	}
+	return s
+}
+
+// CrashReport is written to the store directory when sync panics or fails.
+type CrashReport struct {
+	Time       time.Time      `json:"time"`
+	Where      string         `json:"where"`
+	Panic      string         `json:"panic,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Stack      string         `json:"stack,omitempty"`
+	Versions   crashVersions  `json:"versions"`
+	LastEvents []eventSummary `json:"last_events"`
+	StoreStats *store.Stats   `json:"store_stats,omitempty"`
+	StatsError string         `json:"store_stats_error,omitempty"`
+}
+
+type crashVersions struct {
+	CLI       string `json:"cli"`
+	Go        string `json:"go"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	Whatsmeow string `json:"whatsmeow,omitempty"`
+}
+
+func buildVersions(cliVersion string) crashVersions {
+	v := crashVersions{CLI: cliVersion, Go: runtime.Version(), OS: runtime.GOOS, Arch: runtime.GOARCH}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == "go.mau.fi/whatsmeow" {
+				v.Whatsmeow = dep.Version
+			}
+		}
+	}
+	return v
+}
+
+// writeCrashReport saves a report as crash-<timestamp>.json in the store
+// directory and returns its path.
+func (a *App) writeCrashReport(where string, panicValue interface{}, failure error, stack []byte) (string, error) {
+	report := CrashReport{
+		Time:       time.Now().UTC(),
+		Where:      where,
+		Stack:      string(stack),
+		Versions:   buildVersions(a.version),
+		LastEvents: a.events.Snapshot(),
+	}
+	if panicValue != nil {
+		report.Panic = fmt.Sprint(panicValue)
+	}
+	if failure != nil {
+		report.Error = failure.Error()
+	}
+	if stats, err := a.store.Stats(); err != nil {
+		report.StatsError = err.Error()
+	} else {
+		report.StoreStats = &stats
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(a.storeDir, fmt.Sprintf("crash-%s.json", report.Time.Format("20060102T150405.000Z")))
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// reportCrash writes a crash report and tells the user where it is. The
+// notice follows --quiet and --no-emoji like other stderr output; the report
+// itself is written either way.
+func (a *App) reportCrash(where string, panicValue interface{}, failure error, stack []byte) string {
+	path, err := a.writeCrashReport(where, panicValue, failure, stack)
+	if err != nil {
+		fmt.Fprintf(output.Stderr, "\n💥 %s failed and the crash report could not be written: %v\n", where, err)
+		return ""
+	}
+	fmt.Fprintf(output.Stderr, "\n💥 %s failed; crash report written to %s\n", where, path)
+	return path
+}
+
+// fatalSyncError describes connection events after which sync cannot
+// continue without user action.
+func fatalSyncError(evt interface{}) error {
+	switch v := evt.(type) {
+	case *events.LoggedOut:
+		return fmt.Errorf("logged out by WhatsApp (reason %d); run auth again", v.Reason)
+	case *events.StreamReplaced:
+		return fmt.Errorf("another client connected with this session")
+	case *events.ClientOutdated:
+		return fmt.Errorf("WhatsApp rejected this client version as outdated; upgrade whatsapp-cli")
+	}
+	return fmt.Errorf("fatal event %T", evt)
+}
+
+// safely runs fn and turns a panic into a crash report, so one bad event
+// or media file doesn't take down a long-running sync. It reports whether
+// fn panicked.
+func (a *App) safely(where string, fn func()) (crashed bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			a.reportCrash(where, r, nil, debug.Stack())
+			crashed = true
+		}
+	}()
+	fn()
+	return false
+}
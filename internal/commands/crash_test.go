@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// TestEventRecorder_KeepsMostRecentInOrder verifies the ring buffer drops the
+// oldest events and returns the rest oldest first.
+func TestEventRecorder_KeepsMostRecentInOrder(t *testing.T) {
+	r := newEventRecorder(3)
+	r.Record(&events.Connected{})
+	r.Record(&events.Disconnected{})
+	r.Record(&events.OfflineSyncCompleted{})
+	r.Record(&events.KeepAliveTimeout{})
+
+	var types []string
+	for _, e := range r.Snapshot() {
+		types = append(types, e.Type)
+	}
+	require.Equal(t, []string{"*events.Disconnected", "*events.OfflineSyncCompleted", "*events.KeepAliveTimeout"}, types)
+}
+
+// TestSafely_WritesCrashReport verifies a panic is recovered and reported
+// with the panic value, recent events and store stats.
+func TestSafely_WritesCrashReport(t *testing.T) {
+	dir := t.TempDir()
+	mockStore := &MockMessageStore{
+		StatsFunc: func() (store.Stats, error) {
+			return store.Stats{Messages: 42}, nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, dir, "test")
+	app.events = newEventRecorder(crashEventHistory)
+	app.events.Record(&events.Connected{})
+
+	crashed := app.safely("test handler", func() { panic("boom") })
+	require.True(t, crashed)
+
+	matches, err := filepath.Glob(filepath.Join(dir, "crash-*.json"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+
+	data, err := os.ReadFile(matches[0])
+	require.NoError(t, err)
+	var report CrashReport
+	require.NoError(t, json.Unmarshal(data, &report))
+	require.Equal(t, "test handler", report.Where)
+	require.Equal(t, "boom", report.Panic)
+	require.NotEmpty(t, report.Stack)
+	require.Equal(t, "test", report.Versions.CLI)
+	require.Len(t, report.LastEvents, 1)
+	require.NotNil(t, report.StoreStats)
+	require.EqualValues(t, 42, report.StoreStats.Messages)
+}
+
+// TestReportCrash_NoticeFollowsStderrSettings verifies the crash notice goes
+// through output.Stderr, so --quiet and --no-emoji apply to it.
+func TestReportCrash_NoticeFollowsStderrSettings(t *testing.T) {
+	var buf bytes.Buffer
+	orig := output.Stderr
+	output.Stderr = &buf
+	t.Cleanup(func() { output.Stderr = orig })
+
+	app := NewAppWithDeps(&MockWAClient{}, &MockMessageStore{}, t.TempDir(), "test")
+	path := app.reportCrash("sync", "boom", nil, nil)
+	require.NotEmpty(t, path)
+	require.Contains(t, buf.String(), "crash report written to "+path)
+}
+
+// TestSync_LoggedOutStopsWithCrashReport verifies a fatal connection event
+// ends sync with an error pointing at the crash report.
+func TestSync_LoggedOutStopsWithCrashReport(t *testing.T) {
+	dir := t.TempDir()
+	mockClient := &MockWAClient{
+		StartSyncFunc: func(ctx context.Context, handler func(interface{})) error {
+			handler(&events.LoggedOut{})
+			return nil
+		},
+	}
+	app := NewAppWithDeps(mockClient, &MockMessageStore{}, dir, "test")
+
+	resp := parseResponse(t, app.Sync(context.Background(), SyncOptions{}))
+	require.False(t, resp.Success)
+	require.NotNil(t, resp.Error)
+	require.Contains(t, *resp.Error, "logged out")
+	require.Contains(t, *resp.Error, "crash report")
+
+	matches, err := filepath.Glob(filepath.Join(dir, "crash-*.json"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+}
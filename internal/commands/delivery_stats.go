@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// LatencySummary aggregates receipt latencies in milliseconds. Percentiles
+// use the nearest-rank method.
+type LatencySummary struct {
+	Count  int   `json:"count"`
+	MinMs  int64 `json:"min_ms"`
+	MeanMs int64 `json:"mean_ms"`
+	P50Ms  int64 `json:"p50_ms"`
+	P90Ms  int64 `json:"p90_ms"`
+	P95Ms  int64 `json:"p95_ms"`
+	MaxMs  int64 `json:"max_ms"`
+}
+
+// DeliveryStats summarizes how quickly sent messages were delivered and
+// read.
+type DeliveryStats struct {
+	ChatJID         string          `json:"chat_jid,omitempty"`
+	Sent            int             `json:"sent"`
+	Delivered       int             `json:"delivered"`
+	Read            int             `json:"read"`
+	Undelivered     int             `json:"undelivered"`
+	DeliveryLatency *LatencySummary `json:"delivery_latency"`
+	ReadLatency     *LatencySummary `json:"read_latency"`
+}
+
+// DeliveryReport is the result of stats delivery.
+type DeliveryReport struct {
+	Since *time.Time `json:"since,omitempty"`
+	DeliveryStats
+	Chats []DeliveryStats `json:"chats,omitempty"`
+}
+
+// DeliveryStatsOptions filters stats delivery.
+type DeliveryStatsOptions struct {
+	ChatJID string
+	// Since limits the report to messages sent within this window ("7d").
+	Since string
+	// ByChat adds a breakdown per chat.
+	ByChat bool
+}
+
+// DeliveryStats reports delivery and read latency for CLI-initiated sends,
+// based on the outbound log kept up to date by receipts during sync.
+func (a *App) DeliveryStats(opts DeliveryStatsOptions) string {
+	report := DeliveryReport{}
+	var since time.Time
+	if opts.Since != "" {
+		d, err := parseKeepDuration(opts.Since)
+		if err != nil {
+			return output.Error(err)
+		}
+		since = time.Now().UTC().Add(-d)
+		report.Since = &since
+	}
+	if opts.ChatJID != "" {
+		if err := a.checkChatAllowed(opts.ChatJID); err != nil {
+			return output.Error(err)
+		}
+	}
+
+	timings, err := a.store.ListDeliveryTimings(opts.ChatJID, since)
+	if err != nil {
+		return output.Error(err)
+	}
+
+	report.DeliveryStats = summarizeDelivery(timings)
+	report.ChatJID = opts.ChatJID
+	if opts.ByChat {
+		byChat := map[string][]store.DeliveryTiming{}
+		for _, t := range timings {
+			byChat[t.ChatJID] = append(byChat[t.ChatJID], t)
+		}
+		for jid, chatTimings := range byChat {
+			stats := summarizeDelivery(chatTimings)
+			stats.ChatJID = jid
+			report.Chats = append(report.Chats, stats)
+		}
+		sort.Slice(report.Chats, func(i, j int) bool {
+			if report.Chats[i].Sent != report.Chats[j].Sent {
+				return report.Chats[i].Sent > report.Chats[j].Sent
+			}
+			return report.Chats[i].ChatJID < report.Chats[j].ChatJID
+		})
+	}
+	return output.Success(report)
+}
+
+func summarizeDelivery(timings []store.DeliveryTiming) DeliveryStats {
+	stats := DeliveryStats{Sent: len(timings)}
+	var delivery, read []time.Duration
+	for _, t := range timings {
+		if d, ok := t.DeliveryLatency(); ok {
+			delivery = append(delivery, d)
+		} else {
+			stats.Undelivered++
+		}
+		if d, ok := t.ReadLatency(); ok {
+			read = append(read, d)
+		}
+	}
+	stats.Delivered = len(delivery)
+	stats.Read = len(read)
+	stats.DeliveryLatency = summarizeLatencies(delivery)
+	stats.ReadLatency = summarizeLatencies(read)
+	return stats
+}
+
+// summarizeLatencies returns nil when there is nothing to summarize, so
+// the JSON shows null rather than misleading zeros.
+func summarizeLatencies(latencies []time.Duration) *LatencySummary {
+	if len(latencies) == 0 {
+		return nil
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	percentile := func(p float64) int64 {
+		rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+		return sorted[max(rank, 0)].Milliseconds()
+	}
+	return &LatencySummary{
+		Count:  len(sorted),
+		MinMs:  sorted[0].Milliseconds(),
+		MeanMs: (total / time.Duration(len(sorted))).Milliseconds(),
+		P50Ms:  percentile(0.50),
+		P90Ms:  percentile(0.90),
+		P95Ms:  percentile(0.95),
+		MaxMs:  sorted[len(sorted)-1].Milliseconds(),
+	}
+}
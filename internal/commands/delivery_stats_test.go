@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// TestSummarizeLatencies_NearestRankPercentiles verifies the aggregate
+// figures over a known distribution.
+func TestSummarizeLatencies_NearestRankPercentiles(t *testing.T) {
+	var latencies []time.Duration
+	for i := 10; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Second)
+	}
+
+	summary := summarizeLatencies(latencies)
+	require.Equal(t, &LatencySummary{
+		Count:  10,
+		MinMs:  1000,
+		MeanMs: 5500,
+		P50Ms:  5000,
+		P90Ms:  9000,
+		P95Ms:  10000,
+		MaxMs:  10000,
+	}, summary)
+	require.Nil(t, summarizeLatencies(nil))
+}
+
+// TestDeliveryStats_CountsAndBreaksDownByChat verifies undelivered
+// messages are counted but excluded from latency, and the per-chat
+// breakdown is sorted by volume.
+func TestDeliveryStats_CountsAndBreaksDownByChat(t *testing.T) {
+	sent := time.Now().Add(-time.Hour)
+	delivered := sent.Add(3 * time.Second)
+	read := sent.Add(time.Minute)
+	var gotSince time.Time
+	mockStore := &MockMessageStore{
+		ListDeliveryTimingsFunc: func(chatJID string, since time.Time) ([]store.DeliveryTiming, error) {
+			gotSince = since
+			return []store.DeliveryTiming{
+				{ChatJID: "a@s.whatsapp.net", SentAt: sent, DeliveredAt: &delivered, ReadAt: &read},
+				{ChatJID: "a@s.whatsapp.net", SentAt: sent},
+				{ChatJID: "b@s.whatsapp.net", SentAt: sent, DeliveredAt: &delivered},
+			}, nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+
+	resp := parseResponse(t, app.DeliveryStats(DeliveryStatsOptions{Since: "7d", ByChat: true}))
+	require.True(t, resp.Success)
+	require.WithinDuration(t, time.Now().Add(-7*24*time.Hour), gotSince, time.Minute)
+
+	var report DeliveryReport
+	require.NoError(t, json.Unmarshal(resp.Data, &report))
+	require.Equal(t, 3, report.Sent)
+	require.Equal(t, 2, report.Delivered)
+	require.Equal(t, 1, report.Read)
+	require.Equal(t, 1, report.Undelivered)
+	require.EqualValues(t, 3000, report.DeliveryLatency.P50Ms)
+	require.EqualValues(t, 60000, report.ReadLatency.MaxMs)
+	require.Len(t, report.Chats, 2)
+	require.Equal(t, "a@s.whatsapp.net", report.Chats[0].ChatJID)
+	require.Nil(t, report.Chats[1].ReadLatency)
+}
@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+)
+
+// enrichQueueSize bounds the chats waiting for background name resolution.
+// Chats that do not fit stay unnamed until the next `enrich` run.
+const enrichQueueSize = 1024
+
+// enrichWorker resolves chat names off the event handler goroutine, so large
+// history syncs only pay for raw inserts while the network-bound lookups
+// (group info queries) happen in the background.
+type enrichWorker struct {
+	app   *App
+	queue chan string
+
+	mu      sync.Mutex
+	pending map[string]struct{}
+	named   int
+	dropped int
+
+	wg sync.WaitGroup
+}
+
+func newEnrichWorker(app *App) *enrichWorker {
+	return &enrichWorker{
+		app:     app,
+		queue:   make(chan string, enrichQueueSize),
+		pending: make(map[string]struct{}),
+	}
+}
+
+func (w *enrichWorker) Start(ctx context.Context) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case chatJID := <-w.queue:
+				w.app.safely("name enrichment worker", func() { w.enrich(ctx, chatJID) })
+			}
+		}
+	}()
+}
+
+// Enqueue schedules a chat for name resolution. It never blocks: duplicate
+// chats are coalesced and overflow is counted and left for `enrich`.
+func (w *enrichWorker) Enqueue(chatJID string) {
+	if w == nil || chatJID == "" {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, queued := w.pending[chatJID]; queued {
+		return
+	}
+	select {
+	case w.queue <- chatJID:
+		w.pending[chatJID] = struct{}{}
+	default:
+		w.dropped++
+	}
+}
+
+func (w *enrichWorker) enrich(ctx context.Context, chatJID string) {
+	if w.app.enrichChatName(ctx, chatJID) {
+		w.mu.Lock()
+		w.named++
+		w.mu.Unlock()
+	}
+	w.mu.Lock()
+	delete(w.pending, chatJID)
+	w.mu.Unlock()
+}
+
+func (w *enrichWorker) Wait() {
+	w.wg.Wait()
+}
+
+func (w *enrichWorker) PrintSummary() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	named, dropped := w.named, w.dropped
+	w.mu.Unlock()
+	if named > 0 {
+		fmt.Fprintf(output.Stderr, "🏷️  Resolved names for %d chats in the background\n", named)
+	}
+	if dropped > 0 {
+		fmt.Fprintf(output.Stderr, "⚠️  %d chats still need names; run `whatsapp-cli enrich`\n", dropped)
+	}
+}
+
+// enrichChatName resolves and stores a friendly name, reporting whether the
+// chat was renamed.
+func (a *App) enrichChatName(ctx context.Context, chatJID string) bool {
+	name := a.client.ResolveChatName(ctx, chatJID, nil)
+	if name == "" || name == chatJID {
+		return false
+	}
+	changed, err := a.store.UpdateChatName(chatJID, name)
+	return err == nil && changed
+}
+
+// EnrichOptions controls the `enrich` command.
+type EnrichOptions struct {
+	// Media also downloads media that was synced but never fetched.
+	Media bool
+	// MediaLimit caps how many media files are fetched in one run.
+	MediaLimit int
+}
+
+// Enrich reprocesses work deferred during sync: it resolves names for chats
+// still named by JID and, optionally, downloads pending media.
+func (a *App) Enrich(ctx context.Context, opts EnrichOptions) string {
+	if err := a.client.Connect(ctx); err != nil {
+		return output.Error(err)
+	}
+
+	jids, err := a.store.ListUnnamedChatJIDs()
+	if err != nil {
+		return output.Error(err)
+	}
+	named := 0
+	for i, jid := range jids {
+		if ctx.Err() != nil {
+			break
+		}
+		if a.enrichChatName(ctx, jid) {
+			named++
+		}
+		fmt.Fprintf(output.Stderr, "\r🏷️  Checked %d/%d unnamed chats...", i+1, len(jids))
+	}
+	if len(jids) > 0 {
+		fmt.Fprintln(output.Stderr)
+	}
+
+	downloaded, skipped, failed := 0, 0, 0
+	if opts.Media {
+		limit := opts.MediaLimit
+		if limit <= 0 {
+			limit = 100
+		}
+		refs, err := a.store.ListPendingMedia(limit)
+		if err != nil {
+			return output.Error(err)
+		}
+		for _, ref := range refs {
+			if ctx.Err() != nil {
+				break
+			}
+			if err := a.processMediaJob(ctx, mediaJob{messageID: ref.ID, chatJID: ref.ChatJID}); err != nil {
+				if errors.Is(err, errMediaSpace) || errors.Is(err, errMediaPolicyBlocked) {
+					skipped++
+				} else {
+					failed++
+				}
+				continue
+			}
+			downloaded++
+			fmt.Fprintf(output.Stderr, "\r📥 Downloaded %d/%d media files...", downloaded, len(refs))
+		}
+		if len(refs) > 0 {
+			fmt.Fprintln(output.Stderr)
+		}
+	}
+
+	return output.Success(map[string]interface{}{
+		"chats_checked":    len(jids),
+		"chats_named":      named,
+		"media_downloaded": downloaded,
+		"media_skipped":    skipped,
+		"media_failed":     failed,
+	})
+}
@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestSync_HistoryIngestDefersNameResolution verifies history sync stores
+// unnamed chats under their JID instead of blocking on name lookups.
+func TestSync_HistoryIngestDefersNameResolution(t *testing.T) {
+	chatJID := "120363000000000000@g.us"
+	var mu sync.Mutex
+	storedNames := map[string]string{}
+
+	mockStore := &MockMessageStore{
+		StoreChatFunc: func(jid, name string, lastMessageTime time.Time) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, seen := storedNames[jid]; !seen {
+				storedNames[jid] = name
+			}
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mockClient := &MockWAClient{
+		StartSyncFunc: func(ctx context.Context, handler func(interface{})) error {
+			handler(&events.HistorySync{Data: &waHistorySync.HistorySync{
+				Conversations: []*waHistorySync.Conversation{{
+					ID: proto.String(chatJID),
+					Messages: []*waHistorySync.HistorySyncMsg{{
+						Message: &waWeb.WebMessageInfo{
+							Key:              &waCommon.MessageKey{ID: proto.String("m1"), RemoteJID: proto.String(chatJID)},
+							Message:          &waE2E.Message{Conversation: proto.String("hello")},
+							MessageTimestamp: proto.Uint64(1700000000),
+						},
+					}},
+				}},
+			}})
+			cancel()
+			return nil
+		},
+	}
+
+	app := NewAppWithDeps(mockClient, mockStore, t.TempDir(), "test")
+	resp := parseResponse(t, app.Sync(ctx, SyncOptions{}))
+	require.True(t, resp.Success)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, chatJID, storedNames[chatJID], "ingest should store the raw JID as the name")
+}
+
+// TestSync_HistoryEnrichmentNamesNewChat verifies a chat first seen in
+// history sync gets its resolved name, which enrichment can only write once
+// the chat row exists.
+func TestSync_HistoryEnrichmentNamesNewChat(t *testing.T) {
+	chatJID := "120363000000000000@g.us"
+	var mu sync.Mutex
+	rows := map[string]string{}
+	renamed := make(chan struct{}, 1)
+	resolved := make(chan struct{}, 1)
+
+	mockStore := &MockMessageStore{
+		StoreChatFunc: func(jid, name string, lastMessageTime time.Time) error {
+			// A slow insert gives an early lookup the chance to win.
+			select {
+			case <-resolved:
+			case <-time.After(100 * time.Millisecond):
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if _, seen := rows[jid]; !seen {
+				rows[jid] = name
+			}
+			return nil
+		},
+		UpdateChatNameFunc: func(jid, name string) (bool, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, seen := rows[jid]; !seen {
+				return false, nil
+			}
+			rows[jid] = name
+			renamed <- struct{}{}
+			return true, nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mockClient := &MockWAClient{
+		ResolveChatNameFunc: func(ctx context.Context, jid string, evt interface{}) string {
+			select {
+			case resolved <- struct{}{}:
+			default:
+			}
+			return "Team"
+		},
+		StartSyncFunc: func(ctx context.Context, handler func(interface{})) error {
+			handler(&events.HistorySync{Data: &waHistorySync.HistorySync{
+				Conversations: []*waHistorySync.Conversation{{
+					ID: proto.String(chatJID),
+					Messages: []*waHistorySync.HistorySyncMsg{{
+						Message: &waWeb.WebMessageInfo{
+							Key:              &waCommon.MessageKey{ID: proto.String("m1"), RemoteJID: proto.String(chatJID)},
+							Message:          &waE2E.Message{Conversation: proto.String("hello")},
+							MessageTimestamp: proto.Uint64(1700000000),
+						},
+					}},
+				}},
+			}})
+			select {
+			case <-renamed:
+			case <-time.After(2 * time.Second):
+			}
+			cancel()
+			return nil
+		},
+	}
+
+	app := NewAppWithDeps(mockClient, mockStore, t.TempDir(), "test")
+	resp := parseResponse(t, app.Sync(ctx, SyncOptions{}))
+	require.True(t, resp.Success)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, "Team", rows[chatJID])
+}
+
+// TestEnrich_NamesUnnamedChats verifies the enrich command resolves and
+// persists names only when a real name is found.
+func TestEnrich_NamesUnnamedChats(t *testing.T) {
+	renamed := map[string]string{}
+	mockStore := &MockMessageStore{
+		ListUnnamedChatJIDsFunc: func() ([]string, error) {
+			return []string{"a@s.whatsapp.net", "b@g.us"}, nil
+		},
+		UpdateChatNameFunc: func(jid, name string) (bool, error) {
+			renamed[jid] = name
+			return true, nil
+		},
+	}
+	mockClient := &MockWAClient{
+		ResolveChatNameFunc: func(ctx context.Context, jid string, evt interface{}) string {
+			if jid == "b@g.us" {
+				return "Book Club"
+			}
+			return jid
+		},
+	}
+	app := NewAppWithDeps(mockClient, mockStore, t.TempDir(), "test")
+
+	resp := parseResponse(t, app.Enrich(context.Background(), EnrichOptions{}))
+	require.True(t, resp.Success)
+	require.Equal(t, map[string]string{"b@g.us": "Book Club"}, renamed)
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+	require.EqualValues(t, 2, data["chats_checked"])
+	require.EqualValues(t, 1, data["chats_named"])
+}
@@ -0,0 +1,236 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// matrixPuppetPrefix matches mautrix-whatsapp's default username template
+// (whatsapp_{{.}}), so imported senders line up with the bridge's puppets.
+const matrixPuppetPrefix = "whatsapp_"
+
+// matrixMsgTypes maps media types to Matrix message types.
+var matrixMsgTypes = map[string]string{
+	"image":    "m.image",
+	"video":    "m.video",
+	"audio":    "m.audio",
+	"document": "m.file",
+	"sticker":  "m.image",
+}
+
+// MatrixExportOptions controls the `export matrix` command.
+type MatrixExportOptions struct {
+	// ChatJID limits the export to one chat; empty exports every chat
+	// into the OutputPath directory.
+	ChatJID string
+	// UserID is the Matrix ID your own messages are attributed to.
+	UserID string
+	// Server is the homeserver of the bridge puppets; defaults to UserID's.
+	Server     string
+	OutputPath string
+}
+
+// matrixRoom is a chat as a Matrix room import: the room, its members and
+// its m.room.message events, oldest first.
+type matrixRoom struct {
+	Room    matrixRoomInfo `json:"room"`
+	Members []matrixMember `json:"members"`
+	Events  []matrixEvent  `json:"events"`
+}
+
+type matrixRoomInfo struct {
+	Name        string `json:"name"`
+	Topic       string `json:"topic"`
+	IsDirect    bool   `json:"is_direct"`
+	WhatsAppJID string `json:"whatsapp_jid"`
+}
+
+type matrixMember struct {
+	UserID      string `json:"user_id"`
+	DisplayName string `json:"displayname,omitempty"`
+}
+
+type matrixEvent struct {
+	Type           string                 `json:"type"`
+	EventID        string                 `json:"event_id"`
+	Sender         string                 `json:"sender"`
+	OriginServerTS int64                  `json:"origin_server_ts"`
+	Content        map[string]interface{} `json:"content"`
+	// LocalPath is the downloaded media file. It is not part of the Matrix
+	// event: importers upload it and set content.url to the mxc:// URI.
+	LocalPath string `json:"local_path,omitempty"`
+}
+
+// ExportMatrix writes chats as Matrix room imports for moving an archive
+// into a bridged Matrix setup.
+func (a *App) ExportMatrix(opts MatrixExportOptions) string {
+	if !strings.HasPrefix(opts.UserID, "@") || !strings.Contains(opts.UserID, ":") {
+		return output.Error(fmt.Errorf("--user must be a Matrix ID such as @me:example.org"))
+	}
+	if opts.Server == "" {
+		opts.Server = opts.UserID[strings.Index(opts.UserID, ":")+1:]
+	}
+
+	if opts.ChatJID != "" {
+		if err := a.checkChatAllowed(opts.ChatJID); err != nil {
+			return output.Error(err)
+		}
+		room, err := a.matrixRoom(opts.ChatJID, opts)
+		if err != nil {
+			return output.Error(err)
+		}
+		if opts.OutputPath == "" {
+			return output.Success(room)
+		}
+		if err := writeJSONFile(opts.OutputPath, room); err != nil {
+			return output.Error(err)
+		}
+		return output.Success(map[string]interface{}{
+			"rooms":  1,
+			"events": len(room.Events),
+			"path":   opts.OutputPath,
+		})
+	}
+
+	if opts.OutputPath == "" {
+		return output.Error(fmt.Errorf("exporting every chat requires --output DIR"))
+	}
+	if err := os.MkdirAll(opts.OutputPath, 0755); err != nil {
+		return output.Error(err)
+	}
+	// SQLite treats a negative LIMIT as no limit.
+	chats, err := a.store.ListChats(store.ListChatsParams{Limit: -1, IncludeDeleted: true})
+	if err != nil {
+		return output.Error(err)
+	}
+	rooms, events := 0, 0
+	for _, chat := range chats {
+		room, err := a.matrixRoom(chat.JID, opts)
+		if err != nil {
+			return output.Error(fmt.Errorf("exporting %s: %w", chat.JID, err))
+		}
+		if len(room.Events) == 0 {
+			continue
+		}
+		if err := writeJSONFile(filepath.Join(opts.OutputPath, chat.JID+".json"), room); err != nil {
+			return output.Error(err)
+		}
+		rooms++
+		events += len(room.Events)
+		fmt.Fprintf(output.Stderr, "\r📦 Exported %d rooms, %d events...", rooms, events)
+	}
+	if rooms > 0 {
+		fmt.Fprintln(output.Stderr)
+	}
+	return output.Success(map[string]interface{}{
+		"rooms":  rooms,
+		"events": events,
+		"path":   opts.OutputPath,
+	})
+}
+
+func (a *App) matrixRoom(chatJID string, opts MatrixExportOptions) (matrixRoom, error) {
+	history, err := a.store.ListChatHistory(chatJID)
+	if err != nil {
+		return matrixRoom{}, err
+	}
+	chatName, _ := a.store.GetChatName(chatJID)
+	if chatName == "" {
+		chatName = chatJID
+	}
+	isDirect := !strings.HasSuffix(chatJID, "@g.us")
+
+	room := matrixRoom{
+		Room: matrixRoomInfo{
+			Name:        chatName,
+			Topic:       "Imported from WhatsApp chat " + chatJID,
+			IsDirect:    isDirect,
+			WhatsAppJID: chatJID,
+		},
+		Members: []matrixMember{{UserID: opts.UserID}},
+		Events:  make([]matrixEvent, 0, len(history)),
+	}
+
+	seen := map[string]bool{opts.UserID: true}
+	for _, m := range history {
+		sender := opts.UserID
+		if !m.IsFromMe {
+			sender = matrixPuppet(m.Sender, opts.Server)
+			if !seen[sender] {
+				seen[sender] = true
+				name := m.SenderName
+				if isDirect {
+					name = chatName
+				}
+				room.Members = append(room.Members, matrixMember{UserID: sender, DisplayName: name})
+			}
+		}
+		room.Events = append(room.Events, matrixMessageEvent(m, sender, opts.Server))
+	}
+	return room, nil
+}
+
+// matrixPuppet returns the bridge puppet for a WhatsApp sender, stored
+// either as a bare user or as a JID.
+func matrixPuppet(sender, server string) string {
+	user := sender
+	if i := strings.IndexAny(user, "@:"); i >= 0 {
+		user = user[:i]
+	}
+	return "@" + matrixPuppetPrefix + strings.ToLower(user) + ":" + server
+}
+
+func matrixEventID(messageID, server string) string {
+	return "$" + messageID + ":" + server
+}
+
+func matrixMessageEvent(m store.HistoryMessage, sender, server string) matrixEvent {
+	content := map[string]interface{}{"msgtype": "m.text", "body": m.Content}
+	if msgType, ok := matrixMsgTypes[m.MediaType]; ok {
+		content["msgtype"] = msgType
+		// As in the Matrix spec, body is the caption when there is one
+		// and the file name otherwise. "[Audio]"-style placeholders are
+		// not captions.
+		body := m.Content
+		if body == "" || (strings.HasPrefix(body, "[") && strings.HasSuffix(body, "]")) {
+			body = m.Filename
+		}
+		if body == "" {
+			body = m.MediaType
+		}
+		content["body"] = body
+		if m.Filename != "" {
+			content["filename"] = m.Filename
+		}
+		if m.MimeType != "" {
+			content["info"] = map[string]interface{}{"mimetype": m.MimeType}
+		}
+	}
+	if m.ReplyToID != "" {
+		content["m.relates_to"] = map[string]interface{}{
+			"m.in_reply_to": map[string]string{"event_id": matrixEventID(m.ReplyToID, server)},
+		}
+	}
+	return matrixEvent{
+		Type:           "m.room.message",
+		EventID:        matrixEventID(m.ID, server),
+		Sender:         sender,
+		OriginServerTS: m.Timestamp.UnixMilli(),
+		Content:        content,
+		LocalPath:      m.LocalPath,
+	}
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
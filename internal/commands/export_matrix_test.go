@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+func matrixTestStore() *MockMessageStore {
+	at := time.UnixMilli(1700000000000)
+	histories := map[string][]store.HistoryMessage{
+		"123@g.us": {
+			{ID: "M1", Sender: "34611111111", SenderName: "Ana", Content: "dinner?", Timestamp: at},
+			{ID: "M2", Sender: "34699999999", Content: "yes", Timestamp: at.Add(time.Minute), IsFromMe: true, ReplyToID: "M1"},
+			{ID: "M3", Sender: "34611111111@s.whatsapp.net", Content: "menu", Timestamp: at.Add(2 * time.Minute),
+				MediaType: "image", Filename: "menu.jpg", MimeType: "image/jpeg", LocalPath: "/store/media/menu.jpg"},
+		},
+		"34622222222@s.whatsapp.net": {
+			{ID: "D1", Sender: "34622222222", Content: "[Audio]", Timestamp: at, MediaType: "audio"},
+		},
+	}
+	return &MockMessageStore{
+		ListChatHistoryFunc: func(chatJID string) ([]store.HistoryMessage, error) {
+			return histories[chatJID], nil
+		},
+		GetChatNameFunc: func(jid string) (string, error) {
+			return map[string]string{"123@g.us": "Family", "34622222222@s.whatsapp.net": "Bob"}[jid], nil
+		},
+		ListChatsFunc: func(params store.ListChatsParams) ([]store.Chat, error) {
+			return []store.Chat{{JID: "123@g.us"}, {JID: "34622222222@s.whatsapp.net"}, {JID: "empty@s.whatsapp.net"}}, nil
+		},
+	}
+}
+
+// TestExportMatrix_ConvertsChatToRoom verifies senders become bridge
+// puppets, replies become m.in_reply_to relations and media keeps its
+// local file.
+func TestExportMatrix_ConvertsChatToRoom(t *testing.T) {
+	app := NewAppWithDeps(&MockWAClient{}, matrixTestStore(), t.TempDir(), "test")
+
+	resp := parseResponse(t, app.ExportMatrix(MatrixExportOptions{ChatJID: "123@g.us", UserID: "@me:example.org"}))
+	require.True(t, resp.Success)
+
+	var room matrixRoom
+	require.NoError(t, json.Unmarshal(resp.Data, &room))
+	require.Equal(t, matrixRoomInfo{Name: "Family", Topic: "Imported from WhatsApp chat 123@g.us", WhatsAppJID: "123@g.us"}, room.Room)
+	require.Equal(t, []matrixMember{
+		{UserID: "@me:example.org"},
+		{UserID: "@whatsapp_34611111111:example.org", DisplayName: "Ana"},
+	}, room.Members)
+
+	require.Len(t, room.Events, 3)
+	require.Equal(t, "$M1:example.org", room.Events[0].EventID)
+	require.Equal(t, "@whatsapp_34611111111:example.org", room.Events[0].Sender)
+	require.EqualValues(t, 1700000000000, room.Events[0].OriginServerTS)
+
+	require.Equal(t, "@me:example.org", room.Events[1].Sender)
+	require.Equal(t, map[string]interface{}{"event_id": "$M1:example.org"},
+		room.Events[1].Content["m.relates_to"].(map[string]interface{})["m.in_reply_to"])
+
+	media := room.Events[2]
+	require.Equal(t, "@whatsapp_34611111111:example.org", media.Sender)
+	require.Equal(t, "m.image", media.Content["msgtype"])
+	require.Equal(t, "menu", media.Content["body"])
+	require.Equal(t, "menu.jpg", media.Content["filename"])
+	require.Equal(t, "/store/media/menu.jpg", media.LocalPath)
+}
+
+// TestExportMatrix_WritesEveryChat verifies exporting without --chat
+// writes one room per chat with messages into the output directory.
+func TestExportMatrix_WritesEveryChat(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "rooms")
+	app := NewAppWithDeps(&MockWAClient{}, matrixTestStore(), t.TempDir(), "test")
+
+	resp := parseResponse(t, app.ExportMatrix(MatrixExportOptions{UserID: "@me:example.org", Server: "bridge.example.org", OutputPath: dir}))
+	require.True(t, resp.Success)
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+	require.EqualValues(t, 2, data["rooms"])
+	require.EqualValues(t, 4, data["events"])
+	require.NoFileExists(t, filepath.Join(dir, "empty@s.whatsapp.net.json"))
+
+	raw, err := os.ReadFile(filepath.Join(dir, "34622222222@s.whatsapp.net.json"))
+	require.NoError(t, err)
+	var room matrixRoom
+	require.NoError(t, json.Unmarshal(raw, &room))
+	require.True(t, room.Room.IsDirect)
+	require.Equal(t, matrixMember{UserID: "@whatsapp_34622222222:bridge.example.org", DisplayName: "Bob"}, room.Members[1])
+	require.Equal(t, "m.audio", room.Events[0].Content["msgtype"])
+	require.Equal(t, "audio", room.Events[0].Content["body"])
+}
+
+// TestExportMatrix_ValidatesOptions verifies --user must be a Matrix ID and
+// exporting every chat needs an output directory.
+func TestExportMatrix_ValidatesOptions(t *testing.T) {
+	app := NewAppWithDeps(&MockWAClient{}, matrixTestStore(), t.TempDir(), "test")
+
+	resp := parseResponse(t, app.ExportMatrix(MatrixExportOptions{UserID: "me", ChatJID: "123@g.us"}))
+	require.False(t, resp.Success)
+	require.Contains(t, *resp.Error, "Matrix ID")
+
+	resp = parseResponse(t, app.ExportMatrix(MatrixExportOptions{UserID: "@me:example.org"}))
+	require.False(t, resp.Success)
+	require.Contains(t, *resp.Error, "--output")
+}
@@ -0,0 +1,432 @@
+package commands
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/client"
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+	"github.com/vicentereig/whatsapp-cli/internal/types"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// fixtureHistory is how far back generated messages go from --end.
+const fixtureHistory = 90 * 24 * time.Hour
+
+// fixtureOwnUser is the account the generated store belongs to. Fixture
+// phone numbers all use the unassigned +1 555 prefix.
+const fixtureOwnUser = "15555550100"
+
+// FixtureOptions controls `fixtures generate`.
+type FixtureOptions struct {
+	Chats    int
+	Messages int
+	Seed     int64
+	// End is the time of the newest possible message. Fixed seeds only give
+	// identical stores for the same End.
+	End time.Time
+}
+
+// FixturesResult is returned by `fixtures generate`.
+type FixturesResult struct {
+	Store     string    `json:"store"`
+	Seed      int64     `json:"seed"`
+	End       time.Time `json:"end"`
+	Chats     int       `json:"chats"`
+	Groups    int       `json:"groups"`
+	Messages  int       `json:"messages"`
+	Replies   int       `json:"replies"`
+	Reactions int       `json:"reactions"`
+	Media     int       `json:"media"`
+	Locations int       `json:"locations"`
+}
+
+// fixturePerson is a generated contact.
+type fixturePerson struct {
+	user string
+	name string
+}
+
+func (p fixturePerson) jid() string { return p.user + "@s.whatsapp.net" }
+
+// fixtureChat is a generated chat and the messages written to it so far,
+// which later replies and reactions point at.
+type fixtureChat struct {
+	jid     string
+	name    string
+	topic   string
+	members []fixturePerson
+	weight  float64
+	sent    []fixtureMessage
+}
+
+type fixtureMessage struct {
+	id     string
+	sender string
+	fromMe bool
+}
+
+// fixtureGenerator writes synthetic chats through the same extraction and
+// storage path history sync uses, so the rows look like real ones.
+type fixtureGenerator struct {
+	app    *App
+	rng    *rand.Rand
+	result FixturesResult
+	users  map[string]bool
+}
+
+// GenerateFixtures fills a new store in storeDir with synthetic chats and
+// messages. The same seed and end time always produce the same store. It
+// refuses to touch a directory that already holds a store, so it can never
+// mix fake messages into a real account.
+func GenerateFixtures(storeDir string, opts FixtureOptions) string {
+	if opts.Chats < 1 {
+		return output.Error(fmt.Errorf("--chats must be at least 1"))
+	}
+	if opts.Messages < 0 {
+		return output.Error(fmt.Errorf("--messages must not be negative"))
+	}
+	if config.HasStore(storeDir) {
+		return output.Error(fmt.Errorf("%s already contains a store; fixtures are only written to a new store directory", storeDir))
+	}
+	if opts.End.IsZero() {
+		opts.End = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+	if err := os.MkdirAll(storeDir, 0700); err != nil {
+		return output.Error(err)
+	}
+	st, err := store.NewMessageStore(filepath.Join(storeDir, "messages.db"))
+	if err != nil {
+		return output.Error(err)
+	}
+	defer st.Close()
+
+	g := &fixtureGenerator{
+		app:    &App{store: st, storeDir: storeDir},
+		rng:    rand.New(rand.NewSource(opts.Seed)),
+		result: FixturesResult{Store: storeDir, Seed: opts.Seed, End: opts.End.UTC()},
+		users:  map[string]bool{fixtureOwnUser: true},
+	}
+	if err := g.generate(opts); err != nil {
+		return output.Error(err)
+	}
+	return output.Success(g.result)
+}
+
+func (g *fixtureGenerator) generate(opts FixtureOptions) error {
+	start := opts.End.Add(-fixtureHistory)
+	chats := g.chats(opts.Chats)
+	for _, c := range chats {
+		if err := g.app.store.StoreChat(c.jid, c.name, start); err != nil {
+			return err
+		}
+		if !strings.HasSuffix(c.jid, "@g.us") {
+			continue
+		}
+		var joined []store.ParticipantEvent
+		for _, p := range c.members {
+			joined = append(joined, store.ParticipantEvent{ChatJID: c.jid, ParticipantJID: p.jid(), Action: store.ParticipantAdd, Timestamp: start})
+			if err := g.app.store.StoreParticipantName(c.jid, p.jid(), p.name, store.ParticipantNameContact); err != nil {
+				return err
+			}
+		}
+		if err := g.app.store.RecordParticipantEvents(joined); err != nil {
+			return err
+		}
+	}
+
+	// Messages are spread over the whole history, oldest first, so replies
+	// and reactions only ever point backwards in time.
+	times := make([]int64, opts.Messages)
+	for i := range times {
+		times[i] = start.Unix() + g.rng.Int63n(int64(fixtureHistory/time.Second))
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	for _, sec := range times {
+		if err := g.message(g.pickChat(chats), time.Unix(sec, 0).UTC()); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range chats {
+		if !strings.HasSuffix(c.jid, "@g.us") {
+			continue
+		}
+		meta := store.GroupMetadata{ChatJID: c.jid, Name: c.name, Topic: c.topic}
+		meta.Members = append(meta.Members, store.GroupMember{JID: fixtureOwnUser + "@s.whatsapp.net", Role: types.RoleSuperAdmin})
+		for i, p := range c.members {
+			role := types.RoleMember
+			if i == 0 {
+				role = types.RoleAdmin
+			}
+			meta.Members = append(meta.Members, store.GroupMember{JID: p.jid(), Role: role})
+		}
+		if err := g.app.store.StoreGroupMetadata(meta, opts.End); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chats creates n chats, roughly a third of them groups. Weights are
+// skewed so a few chats carry most of the traffic, as in a real account.
+func (g *fixtureGenerator) chats(n int) []*fixtureChat {
+	groups := (n + 1) / 3
+	g.result.Chats = n
+	g.result.Groups = groups
+
+	chats := make([]*fixtureChat, 0, n)
+	for i := 0; i < n; i++ {
+		c := &fixtureChat{weight: g.rng.ExpFloat64() + 0.05}
+		if i < groups {
+			c.jid = fmt.Sprintf("120363%012d@g.us", g.rng.Int63n(1e12))
+			c.name = fixtureGroupNames[i%len(fixtureGroupNames)]
+			if i >= len(fixtureGroupNames) {
+				c.name = fmt.Sprintf("%s %d", c.name, i/len(fixtureGroupNames)+1)
+			}
+			c.topic = fixtureTopics[g.rng.Intn(len(fixtureTopics))]
+			size := 3 + g.rng.Intn(8)
+			for j := 0; j < size; j++ {
+				c.members = append(c.members, g.person())
+			}
+		} else {
+			p := g.person()
+			c.jid, c.name, c.members = p.jid(), p.name, []fixturePerson{p}
+		}
+		chats = append(chats, c)
+	}
+	return chats
+}
+
+// person returns a new contact with a unique fictional phone number.
+func (g *fixtureGenerator) person() fixturePerson {
+	for {
+		user := fmt.Sprintf("1555%07d", g.rng.Intn(1e7))
+		if g.users[user] {
+			continue
+		}
+		g.users[user] = true
+		name := fixtureFirstNames[g.rng.Intn(len(fixtureFirstNames))] + " " + fixtureLastNames[g.rng.Intn(len(fixtureLastNames))]
+		return fixturePerson{user: user, name: name}
+	}
+}
+
+func (g *fixtureGenerator) pickChat(chats []*fixtureChat) *fixtureChat {
+	var total float64
+	for _, c := range chats {
+		total += c.weight
+	}
+	r := g.rng.Float64() * total
+	for _, c := range chats {
+		if r < c.weight {
+			return c
+		}
+		r -= c.weight
+	}
+	return chats[len(chats)-1]
+}
+
+// message writes one message of a random kind to c.
+func (g *fixtureGenerator) message(c *fixtureChat, at time.Time) error {
+	id := fmt.Sprintf("3EB0%016X", g.rng.Uint64())
+	fromMe := g.rng.Float64() < 0.3
+	sender := fixtureOwnUser
+	if !fromMe {
+		sender = c.members[g.rng.Intn(len(c.members))].user
+	}
+
+	msg := g.content(c)
+	extracted := client.ExtractContent(msg)
+	if extracted.Location != nil {
+		g.app.recordLocation(id, c.jid, sender, at, extracted.Location, false)
+		g.result.Locations++
+	}
+	var mediaType, filename, mimeType string
+	var fileSHA256 []byte
+	var fileLength uint64
+	if media := extracted.Media; media != nil {
+		mediaType, filename, mimeType = media.Type, media.Filename, media.MimeType
+		fileSHA256, fileLength = media.FileSHA256, media.FileLength
+		g.result.Media++
+	}
+	switch {
+	case extracted.ReplyToID != "":
+		g.result.Replies++
+	case msg.GetReactionMessage() != nil:
+		g.result.Reactions++
+	}
+
+	if err := g.app.store.StoreChat(c.jid, c.name, at); err != nil {
+		return err
+	}
+	// Media has metadata only: no URL or key, so nothing tries to download it.
+	if err := g.app.store.StoreMessage(id, c.jid, sender, extracted.Content, at, fromMe,
+		mediaType, filename, "", "", mimeType, nil, fileSHA256, nil, fileLength); err != nil {
+		return err
+	}
+	if err := g.app.store.StoreRawMessage(id, c.jid, client.MarshalRaw(msg), extracted.ReplyToID); err != nil {
+		return err
+	}
+	g.result.Messages++
+	if msg.GetReactionMessage() == nil {
+		c.sent = append(c.sent, fixtureMessage{id: id, sender: sender, fromMe: fromMe})
+	}
+	return nil
+}
+
+// content builds the proto of a random message. Replies and reactions
+// need an earlier message in the chat and fall back to plain text.
+func (g *fixtureGenerator) content(c *fixtureChat) *waProto.Message {
+	text := func() string { return fixtureTexts[g.rng.Intn(len(fixtureTexts))] }
+	roll := g.rng.Float64()
+	switch {
+	case roll < 0.12 && len(c.sent) > 0:
+		target := g.recent(c)
+		return &waProto.Message{ExtendedTextMessage: &waProto.ExtendedTextMessage{
+			Text: proto.String(text()),
+			ContextInfo: &waProto.ContextInfo{
+				StanzaID:    proto.String(target.id),
+				Participant: proto.String(target.sender + "@s.whatsapp.net"),
+			},
+		}}
+	case roll < 0.20 && len(c.sent) > 0:
+		target := g.recent(c)
+		return &waProto.Message{ReactionMessage: &waProto.ReactionMessage{
+			Key: &waProto.MessageKey{
+				RemoteJID:   proto.String(c.jid),
+				FromMe:      proto.Bool(target.fromMe),
+				ID:          proto.String(target.id),
+				Participant: proto.String(target.sender + "@s.whatsapp.net"),
+			},
+			Text: proto.String(fixtureReactions[g.rng.Intn(len(fixtureReactions))]),
+		}}
+	case roll < 0.27:
+		caption := ""
+		if g.rng.Intn(3) == 0 {
+			caption = text()
+		}
+		return &waProto.Message{ImageMessage: &waProto.ImageMessage{
+			Mimetype:   proto.String("image/jpeg"),
+			Caption:    proto.String(caption),
+			FileLength: proto.Uint64(uint64(40_000 + g.rng.Intn(3_000_000))),
+			FileSHA256: g.hash(),
+		}}
+	case roll < 0.29:
+		return &waProto.Message{VideoMessage: &waProto.VideoMessage{
+			Mimetype:   proto.String("video/mp4"),
+			Seconds:    proto.Uint32(uint32(3 + g.rng.Intn(120))),
+			FileLength: proto.Uint64(uint64(500_000 + g.rng.Intn(40_000_000))),
+			FileSHA256: g.hash(),
+		}}
+	case roll < 0.33:
+		return &waProto.Message{AudioMessage: &waProto.AudioMessage{
+			Mimetype:   proto.String("audio/ogg; codecs=opus"),
+			PTT:        proto.Bool(true),
+			Seconds:    proto.Uint32(uint32(2 + g.rng.Intn(90))),
+			FileLength: proto.Uint64(uint64(5_000 + g.rng.Intn(400_000))),
+			FileSHA256: g.hash(),
+		}}
+	case roll < 0.35:
+		doc := fixtureDocuments[g.rng.Intn(len(fixtureDocuments))]
+		return &waProto.Message{DocumentMessage: &waProto.DocumentMessage{
+			FileName:   proto.String(doc.name),
+			Mimetype:   proto.String(doc.mimeType),
+			FileLength: proto.Uint64(uint64(20_000 + g.rng.Intn(5_000_000))),
+			FileSHA256: g.hash(),
+		}}
+	case roll < 0.37:
+		return &waProto.Message{StickerMessage: &waProto.StickerMessage{
+			Mimetype:   proto.String("image/webp"),
+			FileLength: proto.Uint64(uint64(10_000 + g.rng.Intn(80_000))),
+			FileSHA256: g.hash(),
+		}}
+	case roll < 0.38:
+		place := fixturePlaces[g.rng.Intn(len(fixturePlaces))]
+		return &waProto.Message{LocationMessage: &waProto.LocationMessage{
+			DegreesLatitude:  proto.Float64(place.lat + (g.rng.Float64()-0.5)*0.01),
+			DegreesLongitude: proto.Float64(place.lon + (g.rng.Float64()-0.5)*0.01),
+			Name:             proto.String(place.name),
+		}}
+	}
+	return &waProto.Message{Conversation: proto.String(text())}
+}
+
+// recent picks one of the last few messages in c, as people mostly reply
+// to and react to what was just said.
+func (g *fixtureGenerator) recent(c *fixtureChat) fixtureMessage {
+	n := len(c.sent)
+	if n > 10 {
+		n = 10
+	}
+	return c.sent[len(c.sent)-1-g.rng.Intn(n)]
+}
+
+func (g *fixtureGenerator) hash() []byte {
+	b := make([]byte, 32)
+	g.rng.Read(b)
+	return b
+}
+
+var fixtureFirstNames = []string{
+	"Ana", "Ben", "Carla", "David", "Elena", "Farid", "Grace", "Hugo",
+	"Ines", "Jonas", "Kenji", "Laura", "Marta", "Nico", "Olivia", "Pablo",
+	"Quinn", "Rosa", "Sam", "Tomas", "Uma", "Victor", "Wen", "Yara",
+}
+
+var fixtureLastNames = []string{
+	"Alvarez", "Brown", "Costa", "Dubois", "Eriksen", "Fischer", "Garcia",
+	"Haddad", "Ito", "Jensen", "Kowalski", "Lopez", "Moreau", "Novak",
+	"Okafor", "Rossi",
+}
+
+var fixtureGroupNames = []string{
+	"Family", "Book club", "Climbing crew", "Flat 3B", "Project Atlas",
+	"Sunday football", "Parents of class 4", "Trip to Lisbon",
+	"Running club", "Board games", "Neighbours", "Alumni 2015",
+}
+
+var fixtureTopics = []string{
+	"", "Be nice 🙂", "Next meetup: first Saturday of the month",
+	"Bills and house stuff only", "Shared photos go to the album",
+}
+
+var fixtureTexts = []string{
+	"Hi!", "Good morning ☀️", "Are we still on for tonight?", "Running 10 minutes late, sorry",
+	"Sounds good", "👍", "Haha yes", "Can someone send the address?",
+	"I'll bring snacks", "Did you see the news?", "Thanks a lot!", "On my way",
+	"What time works for everyone?", "Let's do 7pm", "Happy birthday!! 🎉",
+	"Call me when you can", "Just landed", "Who's in for Saturday?",
+	"I can't make it this week", "That's hilarious 😂", "See you there",
+	"Can you pick up bread on the way?", "The meeting moved to Thursday",
+	"Here's the link I mentioned", "Good night", "Miss you all",
+	"Dinner at mine?", "Congrats!", "Ok", "Let me check and get back to you",
+}
+
+var fixtureReactions = []string{"👍", "❤️", "😂", "😮", "😢", "🙏"}
+
+var fixtureDocuments = []struct{ name, mimeType string }{
+	{"invoice-0423.pdf", "application/pdf"},
+	{"tickets.pdf", "application/pdf"},
+	{"budget.xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	{"notes.docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+	{"itinerary.pdf", "application/pdf"},
+}
+
+var fixturePlaces = []struct {
+	name     string
+	lat, lon float64
+}{
+	{"Retiro Park", 40.4153, -3.6845},
+	{"Praça do Comércio", 38.7075, -9.1364},
+	{"Tempelhofer Feld", 52.4730, 13.4036},
+	{"Central Park", 40.7826, -73.9656},
+	{"Shibuya Crossing", 35.6595, 139.7005},
+}
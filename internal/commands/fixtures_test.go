@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+var fixtureTestEnd = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+// generateTestFixtures generates a store and opens it for inspection.
+func generateTestFixtures(t *testing.T, opts FixtureOptions) (FixturesResult, *store.MessageStore) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "fixtures")
+	resp := parseResponse(t, GenerateFixtures(dir, opts))
+	require.True(t, resp.Success, "error: %v", resp.Error)
+	var result FixturesResult
+	require.NoError(t, json.Unmarshal(resp.Data, &result))
+
+	st, err := store.NewMessageStore(filepath.Join(dir, "messages.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { st.Close() })
+	return result, st
+}
+
+// TestGenerateFixtures_PopulatesStore verifies the generated store has the
+// requested chats and messages, including groups, media, replies and
+// reactions.
+func TestGenerateFixtures_PopulatesStore(t *testing.T) {
+	result, st := generateTestFixtures(t, FixtureOptions{Chats: 5, Messages: 1000, Seed: 42, End: fixtureTestEnd})
+	require.Equal(t, 5, result.Chats)
+	require.Equal(t, 2, result.Groups)
+	require.Equal(t, 1000, result.Messages)
+	require.Positive(t, result.Replies)
+	require.Positive(t, result.Reactions)
+	require.Positive(t, result.Media)
+
+	stats, err := st.Stats()
+	require.NoError(t, err)
+	require.EqualValues(t, 5, stats.Chats)
+	require.EqualValues(t, 1000, stats.Messages)
+	require.EqualValues(t, 1000, stats.RawArchived)
+
+	chats, err := st.ListChats(store.ListChatsParams{Limit: 10})
+	require.NoError(t, err)
+	var groups int
+	for _, c := range chats {
+		require.NotEmpty(t, c.Name)
+		if c.Group != nil {
+			groups++
+			require.Positive(t, c.Group.ParticipantCount)
+		}
+	}
+	require.Equal(t, 2, groups)
+
+	messages, err := st.ListMessages(store.ListMessagesParams{Limit: 1000})
+	require.NoError(t, err)
+	var replies int
+	for _, m := range messages {
+		require.False(t, m.Timestamp.After(fixtureTestEnd))
+		require.False(t, m.Timestamp.Before(fixtureTestEnd.Add(-fixtureHistory)))
+		if m.ReplyToID != "" {
+			replies++
+		}
+	}
+	require.Equal(t, result.Replies, replies)
+
+	image := "image"
+	media, err := st.ListMessages(store.ListMessagesParams{Type: &image, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, media, 1)
+}
+
+// TestGenerateFixtures_IsDeterministic verifies the same seed produces the
+// same messages and a different seed does not.
+func TestGenerateFixtures_IsDeterministic(t *testing.T) {
+	list := func(seed int64) []store.Message {
+		_, st := generateTestFixtures(t, FixtureOptions{Chats: 3, Messages: 200, Seed: seed, End: fixtureTestEnd})
+		messages, err := st.ListMessages(store.ListMessagesParams{Limit: 200})
+		require.NoError(t, err)
+		return messages
+	}
+	first := list(7)
+	require.Equal(t, first, list(7))
+	require.NotEqual(t, first, list(8))
+}
+
+// TestGenerateFixtures_RefusesExistingStore verifies fixtures are never
+// mixed into an existing store.
+func TestGenerateFixtures_RefusesExistingStore(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "whatsapp.db"), []byte("session"), 0600))
+
+	resp := parseResponse(t, GenerateFixtures(dir, FixtureOptions{Chats: 1, Messages: 10}))
+	require.False(t, resp.Success)
+	require.Contains(t, *resp.Error, "already contains a store")
+	require.NoFileExists(t, filepath.Join(dir, "messages.db"))
+
+	resp = parseResponse(t, GenerateFixtures(t.TempDir(), FixtureOptions{Chats: 0}))
+	require.False(t, resp.Success)
+}
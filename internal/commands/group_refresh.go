@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+const (
+	defaultGroupRefreshInterval = 24 * time.Hour
+	defaultGroupRefreshSpacing  = 30 * time.Second
+	// groupRefreshIdle is how long the refresher sleeps once every group
+	// is current before looking for due groups again.
+	groupRefreshIdle = 10 * time.Minute
+	// groupAvatarTimeout bounds a single group photo download.
+	groupAvatarTimeout = 30 * time.Second
+)
+
+// groupRefreshSettings is the validated form of config.GroupRefreshConfig.
+type groupRefreshSettings struct {
+	interval time.Duration
+	spacing  time.Duration
+}
+
+func resolveGroupRefreshSettings(cfg config.GroupRefreshConfig) (groupRefreshSettings, error) {
+	settings := groupRefreshSettings{
+		interval: defaultGroupRefreshInterval,
+		spacing:  defaultGroupRefreshSpacing,
+	}
+	if cfg.Interval != "" {
+		d, err := parseKeepDuration(cfg.Interval)
+		if err != nil {
+			return groupRefreshSettings{}, fmt.Errorf("group_refresh interval: %w", err)
+		}
+		settings.interval = d
+	}
+	if cfg.Spacing != "" {
+		d, err := parseKeepDuration(cfg.Spacing)
+		if err != nil {
+			return groupRefreshSettings{}, fmt.Errorf("group_refresh spacing: %w", err)
+		}
+		settings.spacing = d
+	}
+	return settings, nil
+}
+
+// groupRefresher walks known groups one at a time, least recently checked
+// first, and refreshes their name, members and photo. Lookups are spaced
+// out so metadata stays current without bursts of group queries or a
+// lookup per message.
+type groupRefresher struct {
+	app        *App
+	settings   groupRefreshSettings
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	refreshed int
+	failed    int
+
+	wg sync.WaitGroup
+}
+
+func newGroupRefresher(app *App, settings groupRefreshSettings) *groupRefresher {
+	return &groupRefresher{
+		app:        app,
+		settings:   settings,
+		httpClient: &http.Client{Timeout: groupAvatarTimeout},
+	}
+}
+
+func (r *groupRefresher) Start(ctx context.Context) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for {
+			pause := groupRefreshIdle
+			r.app.safely("group refresher", func() { pause = r.runOnce(ctx, time.Now()) })
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pause):
+			}
+		}
+	}()
+}
+
+// runOnce refreshes the group most in need of it and returns how long to
+// wait before the next one.
+func (r *groupRefresher) runOnce(ctx context.Context, now time.Time) time.Duration {
+	due, err := r.app.store.ListGroupsDueForRefresh(now.Add(-r.settings.interval), 1)
+	if err != nil {
+		fmt.Fprintf(output.Stderr, "\n⚠️  Listing groups to refresh failed: %v\n", err)
+		return groupRefreshIdle
+	}
+	if len(due) == 0 {
+		return groupRefreshIdle
+	}
+	if err := r.refresh(ctx, due[0], now); err != nil {
+		r.mu.Lock()
+		r.failed++
+		r.mu.Unlock()
+		r.app.store.RecordGroupRefreshError(due[0].ChatJID, err.Error(), now)
+	} else {
+		r.mu.Lock()
+		r.refreshed++
+		r.mu.Unlock()
+	}
+	return r.settings.spacing
+}
+
+func (r *groupRefresher) refresh(ctx context.Context, g store.GroupRefreshState, now time.Time) error {
+	meta, err := r.app.client.GetGroupMetadata(ctx, g.ChatJID, g.AvatarID)
+	if err != nil {
+		return err
+	}
+
+	record := store.GroupMetadata{
+		ChatJID:    g.ChatJID,
+		Name:       meta.Name,
+		Topic:      meta.Topic,
+		AvatarID:   meta.AvatarID,
+		AvatarPath: g.AvatarPath,
+	}
+	for _, p := range meta.Participants {
+		record.Members = append(record.Members, store.GroupMember{JID: p.JID, Role: p.Role})
+	}
+
+	switch {
+	case meta.AvatarID == "":
+		if g.AvatarPath != "" {
+			os.Remove(g.AvatarPath)
+		}
+		record.AvatarPath = ""
+	case meta.AvatarURL != "":
+		path, err := r.saveAvatar(ctx, g.ChatJID, meta.AvatarURL)
+		if err != nil {
+			// Keep the old photo; asking with its ID next time returns the
+			// new one again.
+			fmt.Fprintf(output.Stderr, "\n⚠️  Group photo download for %s failed: %v\n", g.ChatJID, err)
+			record.AvatarID = g.AvatarID
+		} else {
+			record.AvatarPath = path
+		}
+	}
+	return r.app.store.StoreGroupMetadata(record, now)
+}
+
+// saveAvatar downloads a group photo to avatars/<jid>.jpg in the store
+// directory, replacing the previous one.
+func (r *groupRefresher) saveAvatar(ctx context.Context, chatJID, url string) (string, error) {
+	dir := filepath.Join(r.app.storeDir, "avatars")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".avatar-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, filepath.Base(chatJID)+".jpg")
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (r *groupRefresher) Wait() {
+	if r == nil {
+		return
+	}
+	r.wg.Wait()
+}
+
+func (r *groupRefresher) PrintSummary() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	refreshed, failed := r.refreshed, r.failed
+	r.mu.Unlock()
+	if refreshed > 0 {
+		fmt.Fprintf(output.Stderr, "👥 Refreshed metadata for %d groups\n", refreshed)
+	}
+	if failed > 0 {
+		fmt.Fprintf(output.Stderr, "⚠️  %d group refreshes failed; they will be retried on the next pass\n", failed)
+	}
+}
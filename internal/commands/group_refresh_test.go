@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+	"github.com/vicentereig/whatsapp-cli/internal/types"
+)
+
+// TestResolveGroupRefreshSettings verifies the defaults and that bad
+// durations are rejected before sync starts.
+func TestResolveGroupRefreshSettings(t *testing.T) {
+	settings, err := resolveGroupRefreshSettings(config.GroupRefreshConfig{})
+	require.NoError(t, err)
+	require.Equal(t, groupRefreshSettings{interval: 24 * time.Hour, spacing: 30 * time.Second}, settings)
+
+	settings, err = resolveGroupRefreshSettings(config.GroupRefreshConfig{Interval: "2d", Spacing: "1m"})
+	require.NoError(t, err)
+	require.Equal(t, groupRefreshSettings{interval: 48 * time.Hour, spacing: time.Minute}, settings)
+
+	_, err = resolveGroupRefreshSettings(config.GroupRefreshConfig{Spacing: "soon"})
+	require.ErrorContains(t, err, "group_refresh spacing")
+}
+
+// TestGroupRefresher_StoresMetadataAndPhoto verifies a due group is fetched
+// with its known photo ID, and a new photo is downloaded to the store.
+func TestGroupRefresher_StoresMetadataAndPhoto(t *testing.T) {
+	photo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("jpeg"))
+	}))
+	defer photo.Close()
+
+	now := time.Now()
+	var before time.Time
+	var stored store.GroupMetadata
+	mockStore := &MockMessageStore{
+		ListGroupsDueForRefreshFunc: func(b time.Time, limit int) ([]store.GroupRefreshState, error) {
+			before = b
+			require.Equal(t, 1, limit)
+			return []store.GroupRefreshState{{ChatJID: "team@g.us", AvatarID: "old"}}, nil
+		},
+		StoreGroupMetadataFunc: func(m store.GroupMetadata, at time.Time) error {
+			stored = m
+			require.Equal(t, now, at)
+			return nil
+		},
+	}
+	mockClient := &MockWAClient{
+		GetGroupMetadataFunc: func(ctx context.Context, groupJID, knownAvatarID string) (types.GroupMetadata, error) {
+			require.Equal(t, "team@g.us", groupJID)
+			require.Equal(t, "old", knownAvatarID)
+			return types.GroupMetadata{
+				JID:          groupJID,
+				Name:         "Team",
+				Topic:        "Standups",
+				Participants: []types.GroupParticipant{{JID: "1@s.whatsapp.net", Role: types.RoleAdmin}},
+				AvatarID:     "new",
+				AvatarURL:    photo.URL,
+			}, nil
+		},
+	}
+	dir := t.TempDir()
+	app := NewAppWithDeps(mockClient, mockStore, dir, "test")
+	r := newGroupRefresher(app, groupRefreshSettings{interval: time.Hour, spacing: time.Minute})
+
+	require.Equal(t, time.Minute, r.runOnce(context.Background(), now))
+	require.Equal(t, now.Add(-time.Hour), before)
+
+	path := filepath.Join(dir, "avatars", "team@g.us.jpg")
+	require.Equal(t, store.GroupMetadata{
+		ChatJID:    "team@g.us",
+		Name:       "Team",
+		Topic:      "Standups",
+		Members:    []store.GroupMember{{JID: "1@s.whatsapp.net", Role: types.RoleAdmin}},
+		AvatarID:   "new",
+		AvatarPath: path,
+	}, stored)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "jpeg", string(data))
+}
+
+// TestGroupRefresher_KeepsOrDropsPhoto verifies an unchanged photo is not
+// downloaded again, a failed download keeps the old one, and a removed
+// photo is deleted.
+func TestGroupRefresher_KeepsOrDropsPhoto(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	}))
+	defer failing.Close()
+
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "team.jpg")
+	require.NoError(t, os.WriteFile(oldPath, []byte("old"), 0600))
+	due := store.GroupRefreshState{ChatJID: "team@g.us", AvatarID: "p1", AvatarPath: oldPath}
+
+	var meta types.GroupMetadata
+	var stored store.GroupMetadata
+	mockStore := &MockMessageStore{
+		ListGroupsDueForRefreshFunc: func(time.Time, int) ([]store.GroupRefreshState, error) {
+			return []store.GroupRefreshState{due}, nil
+		},
+		StoreGroupMetadataFunc: func(m store.GroupMetadata, at time.Time) error {
+			stored = m
+			return nil
+		},
+	}
+	mockClient := &MockWAClient{
+		GetGroupMetadataFunc: func(context.Context, string, string) (types.GroupMetadata, error) {
+			return meta, nil
+		},
+	}
+	app := NewAppWithDeps(mockClient, mockStore, dir, "test")
+	r := newGroupRefresher(app, groupRefreshSettings{interval: time.Hour, spacing: time.Minute})
+	ctx := context.Background()
+
+	meta = types.GroupMetadata{AvatarID: "p1"}
+	r.runOnce(ctx, time.Now())
+	require.Equal(t, "p1", stored.AvatarID)
+	require.Equal(t, oldPath, stored.AvatarPath)
+
+	meta = types.GroupMetadata{AvatarID: "p2", AvatarURL: failing.URL}
+	r.runOnce(ctx, time.Now())
+	require.Equal(t, "p1", stored.AvatarID, "a failed download should be retried with the old ID")
+	require.Equal(t, oldPath, stored.AvatarPath)
+
+	meta = types.GroupMetadata{}
+	r.runOnce(ctx, time.Now())
+	require.Empty(t, stored.AvatarID)
+	require.Empty(t, stored.AvatarPath)
+	require.NoFileExists(t, oldPath)
+}
+
+// TestGroupRefresher_RecordsFailuresAndIdles verifies a failed lookup is
+// recorded so the group waits its turn, and the refresher idles when no
+// group is due.
+func TestGroupRefresher_RecordsFailuresAndIdles(t *testing.T) {
+	var due []store.GroupRefreshState
+	var failedJID, reason string
+	mockStore := &MockMessageStore{
+		ListGroupsDueForRefreshFunc: func(time.Time, int) ([]store.GroupRefreshState, error) {
+			return due, nil
+		},
+		RecordGroupRefreshErrorFunc: func(chatJID, r string, at time.Time) error {
+			failedJID, reason = chatJID, r
+			return nil
+		},
+	}
+	mockClient := &MockWAClient{
+		GetGroupMetadataFunc: func(context.Context, string, string) (types.GroupMetadata, error) {
+			return types.GroupMetadata{}, errors.New("not a participant")
+		},
+	}
+	app := NewAppWithDeps(mockClient, mockStore, t.TempDir(), "test")
+	r := newGroupRefresher(app, groupRefreshSettings{interval: time.Hour, spacing: time.Minute})
+
+	require.Equal(t, groupRefreshIdle, r.runOnce(context.Background(), time.Now()))
+
+	due = []store.GroupRefreshState{{ChatJID: "left@g.us"}}
+	require.Equal(t, time.Minute, r.runOnce(context.Background(), time.Now()))
+	require.Equal(t, "left@g.us", failedJID)
+	require.Equal(t, "not a participant", reason)
+	require.Equal(t, 1, r.failed)
+}
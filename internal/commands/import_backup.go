@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/backup"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// importBatch is how many backup messages are inserted per transaction.
+const importBatch = 500
+
+// ImportBackupResult is returned by `import backup`.
+type ImportBackupResult struct {
+	Format     string `json:"format"`
+	Chats      int    `json:"chats"`
+	Messages   int    `json:"messages"`
+	Imported   int    `json:"imported"`
+	Duplicates int    `json:"duplicates"`
+	// Restricted counts messages in chats outside allowed_chats.
+	Restricted int `json:"restricted,omitempty"`
+}
+
+// ImportBackup merges the history in a phone backup (Android msgstore.db,
+// optionally crypt15-encrypted, or iOS ChatStorage.sqlite) into the store.
+// Messages already synced are kept as they are. Encrypted backups are
+// decrypted inside the store directory, and the copy is removed when the
+// import ends or is interrupted.
+func (a *App) ImportBackup(ctx context.Context, path, keyPath string) string {
+	var key []byte
+	if keyPath != "" {
+		var err error
+		if key, err = backup.LoadKey(keyPath); err != nil {
+			return output.Error(err)
+		}
+	}
+
+	reader, err := backup.Open(path, key, a.storeDir)
+	if err != nil {
+		return output.Error(err)
+	}
+	defer reader.Close()
+
+	chats, err := reader.Chats()
+	if err != nil {
+		return output.Error(fmt.Errorf("reading chats: %w", err))
+	}
+	names := make(map[string]string, len(chats))
+	for _, c := range chats {
+		names[c.JID] = c.Name
+	}
+
+	result := ImportBackupResult{Format: reader.Format()}
+	latest := map[string]time.Time{}
+	batch := make([]store.ImportedMessage, 0, importBatch)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		// Chats go in first so imported messages are never orphaned.
+		for _, m := range batch {
+			if _, seen := latest[m.ChatJID]; !seen {
+				if err := a.store.ImportChat(m.ChatJID, names[m.ChatJID], m.Timestamp); err != nil {
+					return err
+				}
+			}
+			if m.Timestamp.After(latest[m.ChatJID]) {
+				latest[m.ChatJID] = m.Timestamp
+			}
+		}
+		inserted, err := a.store.ImportMessages(batch)
+		if err != nil {
+			return err
+		}
+		result.Imported += inserted
+		batch = batch[:0]
+		fmt.Fprintf(output.Stderr, "\r📦 Imported %d of %d messages...", result.Imported, result.Messages)
+		return nil
+	}
+
+	err = reader.Messages(func(m backup.Message) error {
+		if err := ctx.Err(); err != nil {
+			return errors.New("interrupted; import again to finish")
+		}
+		if a.checkChatAllowed(m.ChatJID) != nil {
+			result.Restricted++
+			return nil
+		}
+		result.Messages++
+		batch = append(batch, store.ImportedMessage{
+			ID:        m.ID,
+			ChatJID:   m.ChatJID,
+			Sender:    m.Sender,
+			Content:   m.Content,
+			Timestamp: m.Timestamp,
+			IsFromMe:  m.IsFromMe,
+			MediaType: m.MediaType,
+		})
+		if len(batch) == importBatch {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if result.Messages > 0 {
+		fmt.Fprintln(output.Stderr)
+	}
+	if err != nil {
+		return output.Error(fmt.Errorf("importing messages: %w", err))
+	}
+
+	for jid, last := range latest {
+		if err := a.store.ImportChat(jid, names[jid], last); err != nil {
+			return output.Error(err)
+		}
+	}
+	result.Chats = len(latest)
+	result.Duplicates = result.Messages - result.Imported
+	return output.Success(result)
+}
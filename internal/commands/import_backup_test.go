@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+func writeAndroidBackup(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "msgstore.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`
+		CREATE TABLE jid (_id INTEGER PRIMARY KEY, raw_string TEXT);
+		CREATE TABLE chat (_id INTEGER PRIMARY KEY, jid_row_id INTEGER, subject TEXT);
+		CREATE TABLE message (_id INTEGER PRIMARY KEY, chat_row_id INTEGER, from_me INTEGER, key_id TEXT,
+			sender_jid_row_id INTEGER, timestamp INTEGER, message_type INTEGER, text_data TEXT);
+		INSERT INTO jid VALUES (1, '34611111111@s.whatsapp.net'), (2, '1203630001@g.us');
+		INSERT INTO chat VALUES (1, 1, NULL), (2, 2, 'Family');
+		INSERT INTO message VALUES
+			(1, 1, 0, 'A1', 0, 1500000000000, 0, 'hello'),
+			(2, 1, 1, 'A2', 0, 1500000060000, 0, 'hi'),
+			(3, 2, 0, 'G1', 1, 1500000120000, 0, 'dinner?');
+	`)
+	require.NoError(t, err)
+	return path
+}
+
+// TestImportBackup_MergesMessagesAndChats verifies backup messages are
+// imported with their chat names and duplicates are reported.
+func TestImportBackup_MergesMessagesAndChats(t *testing.T) {
+	var imported []store.ImportedMessage
+	chats := map[string]string{}
+	lastTimes := map[string]time.Time{}
+	mockStore := &MockMessageStore{
+		ImportChatFunc: func(jid, name string, last time.Time) error {
+			chats[jid] = name
+			lastTimes[jid] = last
+			return nil
+		},
+		ImportMessagesFunc: func(messages []store.ImportedMessage) (int, error) {
+			imported = append(imported, messages...)
+			return len(messages) - 1, nil // one was already synced
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+
+	resp := parseResponse(t, app.ImportBackup(context.Background(), writeAndroidBackup(t), ""))
+	require.True(t, resp.Success)
+
+	var result ImportBackupResult
+	require.NoError(t, json.Unmarshal(resp.Data, &result))
+	require.Equal(t, ImportBackupResult{Format: "android", Chats: 2, Messages: 3, Imported: 2, Duplicates: 1}, result)
+
+	require.Len(t, imported, 3)
+	require.Equal(t, "A1", imported[0].ID)
+	require.True(t, imported[1].IsFromMe)
+	require.Equal(t, "34611111111@s.whatsapp.net", imported[2].Sender)
+	require.Equal(t, map[string]string{"34611111111@s.whatsapp.net": "", "1203630001@g.us": "Family"}, chats)
+	require.Equal(t, time.UnixMilli(1500000060000), lastTimes["34611111111@s.whatsapp.net"])
+}
+
+// TestImportBackup_RespectsAllowedChats verifies chats outside
+// allowed_chats are not imported.
+func TestImportBackup_RespectsAllowedChats(t *testing.T) {
+	var imported []store.ImportedMessage
+	mockStore := &MockMessageStore{
+		ImportMessagesFunc: func(messages []store.ImportedMessage) (int, error) {
+			imported = append(imported, messages...)
+			return len(messages), nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+	app.cfg = &config.Config{AllowedChats: []string{"1203630001@g.us"}}
+
+	resp := parseResponse(t, app.ImportBackup(context.Background(), writeAndroidBackup(t), ""))
+	require.True(t, resp.Success)
+
+	var result ImportBackupResult
+	require.NoError(t, json.Unmarshal(resp.Data, &result))
+	require.Equal(t, 2, result.Restricted)
+	require.Len(t, imported, 1)
+	require.Equal(t, "1203630001@g.us", imported[0].ChatJID)
+}
+
+// TestImportBackup_RejectsEncryptedWithoutKey verifies a crypt15 file
+// without --key fails with a hint.
+func TestImportBackup_RejectsEncryptedWithoutKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "msgstore.db.crypt15")
+	require.NoError(t, os.WriteFile(path, []byte{0x10, 0x01, 0x08, 0x01}, 0600))
+	app := NewAppWithDeps(&MockWAClient{}, &MockMessageStore{}, t.TempDir(), "test")
+
+	resp := parseResponse(t, app.ImportBackup(context.Background(), path, ""))
+	require.False(t, resp.Success)
+	require.Contains(t, *resp.Error, "--key")
+}
+
+// TestImportBackup_StopsWhenInterrupted verifies a cancelled import fails
+// instead of reporting a partial result.
+func TestImportBackup_StopsWhenInterrupted(t *testing.T) {
+	app := NewAppWithDeps(&MockWAClient{}, &MockMessageStore{}, t.TempDir(), "test")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	resp := parseResponse(t, app.ImportBackup(ctx, writeAndroidBackup(t), ""))
+	require.False(t, resp.Success)
+	require.Contains(t, *resp.Error, "interrupted")
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/client"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/types"
+	"github.com/vicentereig/whatsapp-cli/internal/uri"
+)
+
+// WhatsApp's limits for interactive messages.
+const (
+	maxReplyButtons = 3
+	maxListRows     = 10
+)
+
+// loadInteractiveSpec reads and validates a `send interactive` spec file.
+func loadInteractiveSpec(path string) (types.InteractiveSpec, error) {
+	var spec types.InteractiveSpec
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return spec, fmt.Errorf("reading spec: %w", err)
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return spec, fmt.Errorf("parsing spec %s: %w", path, err)
+	}
+	return spec, validateInteractiveSpec(spec)
+}
+
+func validateInteractiveSpec(spec types.InteractiveSpec) error {
+	if spec.Body == "" {
+		return fmt.Errorf("spec: body is required")
+	}
+	ids := map[string]bool{}
+	checkOption := func(what, id, title string) error {
+		if id == "" || title == "" {
+			return fmt.Errorf("spec: every %s needs an id and a title", what)
+		}
+		if ids[id] {
+			return fmt.Errorf("spec: duplicate %s id %q", what, id)
+		}
+		ids[id] = true
+		return nil
+	}
+
+	switch spec.Type {
+	case types.InteractiveButtons:
+		if len(spec.Buttons) == 0 || len(spec.Buttons) > maxReplyButtons {
+			return fmt.Errorf("spec: a buttons message needs 1 to %d buttons, got %d", maxReplyButtons, len(spec.Buttons))
+		}
+		for _, b := range spec.Buttons {
+			if err := checkOption("button", b.ID, b.Title); err != nil {
+				return err
+			}
+		}
+	case types.InteractiveList:
+		if spec.ButtonText == "" {
+			return fmt.Errorf("spec: a list message needs button_text")
+		}
+		if len(spec.Sections) == 0 {
+			return fmt.Errorf("spec: a list message needs at least one section")
+		}
+		rows := 0
+		for _, section := range spec.Sections {
+			if len(section.Rows) == 0 {
+				return fmt.Errorf("spec: section %q has no rows", section.Title)
+			}
+			if len(spec.Sections) > 1 && section.Title == "" {
+				return fmt.Errorf("spec: sections need a title when there is more than one")
+			}
+			for _, r := range section.Rows {
+				if err := checkOption("row", r.ID, r.Title); err != nil {
+					return err
+				}
+			}
+			rows += len(section.Rows)
+		}
+		if rows > maxListRows {
+			return fmt.Errorf("spec: a list message can have at most %d rows, got %d", maxListRows, rows)
+		}
+	default:
+		return fmt.Errorf("spec: unknown type %q (use %s or %s)", spec.Type, types.InteractiveButtons, types.InteractiveList)
+	}
+	return nil
+}
+
+// SendInteractive sends a reply-button or list message described by the
+// JSON spec at specPath. Replies arrive during sync as button_reply and
+// list_reply messages carrying the selected ID.
+func (a *App) SendInteractive(ctx context.Context, recipient, specPath string, opts SendOptions) string {
+	spec, err := loadInteractiveSpec(specPath)
+	if err != nil {
+		return output.Error(err)
+	}
+	chatJID := recipientToJID(recipient)
+	if err := a.checkChatAllowed(chatJID); err != nil {
+		return output.Error(err)
+	}
+
+	content := client.ExtractContent(client.BuildInteractiveMessage(spec)).Content
+	logID := a.logOutboundQueued(chatJID, "interactive", content, specPath)
+	if err := a.client.Connect(ctx); err != nil {
+		a.logOutboundResult(logID, "", err)
+		return output.Error(err)
+	}
+	a.markReadBeforeSend(ctx, chatJID, opts)
+
+	msgID, err := a.client.SendInteractiveMessage(ctx, recipient, spec)
+	a.logOutboundResult(logID, msgID, err)
+	if err != nil {
+		return output.Error(err)
+	}
+
+	timestamp := time.Now()
+	chatName := a.client.ResolveChatName(ctx, chatJID, nil)
+	if chatName == "" {
+		chatName = recipient
+	}
+	if err := a.store.StoreChat(chatJID, chatName, timestamp); err != nil {
+		return output.Error(fmt.Errorf("storing chat: %w", err))
+	}
+	if err := a.store.StoreMessage(
+		msgID, chatJID, "me", content, timestamp, true,
+		"", "", "", "", "",
+		nil, nil, nil, 0,
+	); err != nil {
+		return output.Error(fmt.Errorf("storing message: %w", err))
+	}
+	if err := a.store.StoreInteractive(msgID, chatJID, spec.Type, ""); err != nil {
+		return output.Error(fmt.Errorf("storing message: %w", err))
+	}
+
+	return output.Success(map[string]interface{}{
+		"sent":      true,
+		"id":        msgID,
+		"uri":       uri.Message(chatJID, msgID),
+		"recipient": recipient,
+		"type":      spec.Type,
+		"content":   content,
+	})
+}
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/types"
+)
+
+func writeSpec(t *testing.T, spec string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "flow.json")
+	require.NoError(t, os.WriteFile(path, []byte(spec), 0644))
+	return path
+}
+
+// TestValidateInteractiveSpec covers WhatsApp's shape limits.
+func TestValidateInteractiveSpec(t *testing.T) {
+	button := func(id string) types.InteractiveButton { return types.InteractiveButton{ID: id, Title: id} }
+	rows := func(n int) []types.InteractiveRow {
+		out := make([]types.InteractiveRow, n)
+		for i := range out {
+			out[i] = types.InteractiveRow{ID: string(rune('a' + i)), Title: "row"}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name string
+		spec types.InteractiveSpec
+		ok   bool
+	}{
+		{"buttons", types.InteractiveSpec{Type: "buttons", Body: "b", Buttons: []types.InteractiveButton{button("y"), button("n")}}, true},
+		{"no body", types.InteractiveSpec{Type: "buttons", Buttons: []types.InteractiveButton{button("y")}}, false},
+		{"four buttons", types.InteractiveSpec{Type: "buttons", Body: "b", Buttons: []types.InteractiveButton{button("1"), button("2"), button("3"), button("4")}}, false},
+		{"duplicate ids", types.InteractiveSpec{Type: "buttons", Body: "b", Buttons: []types.InteractiveButton{button("y"), button("y")}}, false},
+		{"list", types.InteractiveSpec{Type: "list", Body: "b", ButtonText: "Open", Sections: []types.InteractiveSection{{Rows: rows(3)}}}, true},
+		{"list without button text", types.InteractiveSpec{Type: "list", Body: "b", Sections: []types.InteractiveSection{{Rows: rows(3)}}}, false},
+		{"too many rows", types.InteractiveSpec{Type: "list", Body: "b", ButtonText: "Open", Sections: []types.InteractiveSection{{Rows: rows(11)}}}, false},
+		{"unknown type", types.InteractiveSpec{Type: "carousel", Body: "b"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateInteractiveSpec(tt.spec)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+// TestSendInteractive_StoresTypedMessage verifies the spec is sent and the
+// message stored with its type, so replies can be matched to it.
+func TestSendInteractive_StoresTypedMessage(t *testing.T) {
+	path := writeSpec(t, `{
+		"type": "buttons",
+		"body": "Is 5pm OK?",
+		"buttons": [{"id": "yes", "title": "Yes"}, {"id": "no", "title": "No"}]
+	}`)
+
+	var sent types.InteractiveSpec
+	mockClient := &MockWAClient{
+		SendInteractiveMessageFunc: func(ctx context.Context, recipient string, spec types.InteractiveSpec) (string, error) {
+			sent = spec
+			return "BTN1", nil
+		},
+	}
+	var storedContent, storedKind string
+	mockStore := &MockMessageStore{
+		StoreMessageFunc: func(id, chatJID, sender, content string, timestamp time.Time, isFromMe bool,
+			mediaType, filename, url, directPath, mimeType string, mediaKey, fileSHA256, fileEncSHA256 []byte, fileLength uint64) error {
+			storedContent = content
+			return nil
+		},
+		StoreInteractiveFunc: func(id, chatJID, kind, selectedID string) error {
+			storedKind = kind
+			return nil
+		},
+	}
+	app := NewAppWithDeps(mockClient, mockStore, t.TempDir(), "test")
+
+	resp := parseResponse(t, app.SendInteractive(context.Background(), "1234567890", path, SendOptions{NoReadReceipt: true}))
+	require.True(t, resp.Success, resp.Error)
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+	assert.Equal(t, "BTN1", data["id"])
+	assert.Len(t, sent.Buttons, 2)
+	assert.Equal(t, "[Buttons] Is 5pm OK? (Yes / No)", storedContent)
+	assert.Equal(t, types.InteractiveButtons, storedKind)
+}
+
+// TestSendInteractive_InvalidSpecIsNotSent verifies validation happens
+// before connecting.
+func TestSendInteractive_InvalidSpecIsNotSent(t *testing.T) {
+	path := writeSpec(t, `{"type": "buttons", "body": "no buttons"}`)
+	mockClient := &MockWAClient{
+		ConnectFunc: func(ctx context.Context) error {
+			t.Fatal("should not connect")
+			return nil
+		},
+	}
+	app := NewAppWithDeps(mockClient, &MockMessageStore{}, t.TempDir(), "test")
+
+	resp := parseResponse(t, app.SendInteractive(context.Background(), "1234567890", path, SendOptions{}))
+	assert.False(t, resp.Success)
+}
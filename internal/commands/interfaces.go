@@ -24,14 +24,65 @@ import (
 // Defined here (at consumer) per Go best practice: "Accept interfaces, return concrete types"
 type MessageStore interface {
 	ListMessages(params store.ListMessagesParams) ([]store.Message, error)
+	GetMessageDetails(chatJID, id string) (store.MessageDetails, error)
+	ListMessagesAround(chatJID, id string, at time.Time, n int) (before, after []store.Message, err error)
 	SearchContacts(query string) ([]store.Contact, error)
 	ListChats(params store.ListChatsParams) ([]store.Chat, error)
 	StoreChat(jid, name string, lastMessageTime time.Time) error
+	GetChatName(jid string) (string, error)
+	UpdateChatName(jid, name string) (bool, error)
+	SetExternalChatName(jid, name string) (bool, error)
+	MarkChatDeleted(jid string, at time.Time) error
+	ListDirectChatJIDs() ([]string, error)
 	StoreMessage(id, chatJID, sender, content string, timestamp time.Time, isFromMe bool,
 		mediaType, filename, url, directPath, mimeType string,
 		mediaKey, fileSHA256, fileEncSHA256 []byte, fileLength uint64) error
 	GetMessageForDownload(id string, chatJID *string) (store.MessageDownloadInfo, error)
+	GetMediaForSync(id, chatJID string) (store.MessageDownloadInfo, error)
 	MarkMediaDownloaded(id, chatJID, localPath string, downloadedAt time.Time) error
+	SetChatRetention(chatJID string, keep time.Duration) error
+	ClearChatRetention(chatJID string) error
+	ListChatRetention() ([]store.ChatRetention, error)
+	SetChatMediaPolicy(chatJID string, types []string, maxSize int64) error
+	ClearChatMediaPolicy(chatJID string) error
+	GetChatMediaPolicy(chatJID string) (*store.ChatMediaPolicy, error)
+	ListChatMediaPolicies() ([]store.ChatMediaPolicy, error)
+	PruneExpiredMessages(now time.Time) (int64, error)
+	ListUnreadIncoming(chatJID string, limit int) ([]store.Message, error)
+	MarkMessagesRead(chatJID string, ids []string, at time.Time) error
+	ListUnnamedChatJIDs() ([]string, error)
+	ListPendingMedia(limit int) ([]store.MessageRef, error)
+	StoreRawMessage(id, chatJID string, raw []byte, replyToID string) error
+	ListRawMessages(afterRowID int64, limit int) ([]store.RawMessage, error)
+	UpdateExtractedContent(id, chatJID string, e store.ExtractedContent) (bool, error)
+	StoreCommerce(id, chatJID string, c store.Commerce) error
+	UpdateCommerceStatus(id, chatJID, status string) error
+	StoreInteractive(id, chatJID, kind, selectedID string) error
+	MediaUsage(root string) (int64, error)
+	ListEvictableMedia(root string, limit int) ([]store.StoredMedia, error)
+	MarkMediaEvicted(id, chatJID string, at time.Time) error
+	LogOutbound(chatJID, kind, content, attachment string, at time.Time) (int64, error)
+	MarkOutboundSent(logID int64, messageID string, at time.Time) error
+	MarkOutboundFailed(logID int64, reason string, at time.Time) error
+	UpdateOutboundStatus(messageIDs []string, status string, at time.Time) (int64, error)
+	ListOutbound(params store.ListOutboundParams) ([]store.OutboundMessage, error)
+	ListDeliveryTimings(chatJID string, since time.Time) ([]store.DeliveryTiming, error)
+	StoreLocation(p store.LocationPoint) error
+	FindLiveLocationTrack(chatJID, sender string, since time.Time) (string, int64, error)
+	LocationTrack(messageID string, chatJID *string) ([]store.LocationPoint, error)
+	ListLocations(chatJID string) ([]store.LocationPoint, error)
+	Stats() (store.Stats, error)
+	RecordParticipantEvents(events []store.ParticipantEvent) error
+	ListParticipantEvents(chatJID string, before time.Time) ([]store.ParticipantEvent, error)
+	StoreParticipantName(chatJID, participant, name, source string) error
+	ImportChat(jid, name string, lastMessageTime time.Time) error
+	ImportMessages(messages []store.ImportedMessage) (int, error)
+	ListChatHistory(chatJID string) ([]store.HistoryMessage, error)
+	ListChatActivity(baselineSince, recentSince time.Time) ([]store.ChatActivity, error)
+	LastMessageTime(chatJID string) (time.Time, error)
+	ListGroupsDueForRefresh(before time.Time, limit int) ([]store.GroupRefreshState, error)
+	StoreGroupMetadata(m store.GroupMetadata, at time.Time) error
+	RecordGroupRefreshError(chatJID, reason string, at time.Time) error
 	Close() error
 }
 
@@ -44,7 +95,10 @@ type WAClient interface {
 	Disconnect()
 	SendMessage(ctx context.Context, recipient, message string) (string, error)
 	SendImageMessage(ctx context.Context, recipient, imagePath, caption string) (string, error)
+	SendInteractiveMessage(ctx context.Context, recipient string, spec types.InteractiveSpec) (string, error)
+	MarkRead(ctx context.Context, chatJID, sender string, ids []string) error
 	ResolveChatName(ctx context.Context, jid string, evt interface{}) string
 	DownloadMediaToFile(ctx context.Context, req types.MediaDownloadRequest, targetPath string) (int64, error)
+	GetGroupMetadata(ctx context.Context, groupJID, knownAvatarID string) (types.GroupMetadata, error)
 	StartSync(ctx context.Context, eventHandler func(interface{})) error
 }
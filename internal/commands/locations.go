@@ -0,0 +1,312 @@
+package commands
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/client"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+	"github.com/vicentereig/whatsapp-cli/internal/uri"
+)
+
+// liveLocationMaxDuration is the longest live location share WhatsApp
+// offers; a point later than this can't continue an earlier share.
+const liveLocationMaxDuration = 8 * time.Hour
+
+// Location export formats.
+const (
+	LocationFormatJSON    = "json"
+	LocationFormatGeoJSON = "geojson"
+	LocationFormatKML     = "kml"
+)
+
+// recordLocation stores a shared position. With mergeUpdates it reports
+// whether the position was an update to a live location already being
+// tracked: updates arrive as new live location messages with a higher
+// sequence number, and are folded into the track of the message that
+// started the share instead of being stored as messages of their own.
+func (a *App) recordLocation(msgID, chatJID, sender string, at time.Time, loc *client.LocationInfo, mergeUpdates bool) (update bool) {
+	point := store.LocationPoint{
+		MessageID: msgID,
+		ChatJID:   chatJID,
+		Sender:    sender,
+		Live:      loc.Live,
+		Sequence:  loc.Sequence,
+		Latitude:  loc.Latitude,
+		Longitude: loc.Longitude,
+		AccuracyM: loc.AccuracyM,
+		SpeedMps:  loc.SpeedMps,
+		Heading:   loc.Heading,
+		Label:     loc.Label,
+		Timestamp: at,
+	}
+	if loc.Live && mergeUpdates {
+		trackID, lastSequence, err := a.store.FindLiveLocationTrack(chatJID, sender, at.Add(-liveLocationMaxDuration))
+		if err == nil && trackID != "" && trackID != msgID && loc.Sequence > lastSequence {
+			point.MessageID = trackID
+			update = true
+		}
+	}
+	if err := a.store.StoreLocation(point); err != nil {
+		fmt.Fprintf(output.Stderr, "\n⚠️  Could not store location: %v\n", err)
+	}
+	return update
+}
+
+// geoJSONFeature is a GeoJSON Feature with a Point or LineString geometry.
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// trackFeature renders points as a LineString (a Point for a single fix).
+// GeoJSON orders coordinates longitude first; per-point times, speeds and
+// accuracies go in parallel property arrays.
+func trackFeature(points []store.LocationPoint) geoJSONFeature {
+	first, last := points[0], points[len(points)-1]
+	coords := make([][]float64, len(points))
+	times := make([]time.Time, len(points))
+	speeds := make([]float32, len(points))
+	accuracies := make([]uint32, len(points))
+	for i, p := range points {
+		coords[i] = []float64{p.Longitude, p.Latitude}
+		times[i] = p.Timestamp
+		speeds[i] = p.SpeedMps
+		accuracies[i] = p.AccuracyM
+	}
+
+	feature := geoJSONFeature{
+		Type: "Feature",
+		Properties: map[string]interface{}{
+			"message_id": first.MessageID,
+			"uri":        uri.Message(first.ChatJID, first.MessageID),
+			"chat_jid":   first.ChatJID,
+			"sender":     first.Sender,
+			"live":       first.Live,
+			"start":      first.Timestamp,
+			"end":        last.Timestamp,
+			"points":     len(points),
+			"times":      times,
+			"speeds_mps": speeds,
+			"accuracy_m": accuracies,
+		},
+	}
+	if first.Label != "" {
+		feature.Properties["label"] = first.Label
+	}
+	if len(points) == 1 {
+		feature.Geometry = geoJSONGeometry{Type: "Point", Coordinates: coords[0]}
+	} else {
+		feature.Geometry = geoJSONGeometry{Type: "LineString", Coordinates: coords}
+	}
+	return feature
+}
+
+// LocationTrack returns the path of a live location (or the single point of
+// a pinned location) as JSON points or a GeoJSON Feature. With outputPath
+// the export is written to that file instead of being returned.
+func (a *App) LocationTrack(messageID string, chatJID *string, format, outputPath string) string {
+	if format == "" {
+		format = LocationFormatJSON
+	}
+	if format != LocationFormatJSON && format != LocationFormatGeoJSON {
+		return output.Error(fmt.Errorf("unknown format %q (use json or geojson)", format))
+	}
+
+	points, err := a.store.LocationTrack(messageID, chatJID)
+	if err != nil {
+		return output.Error(err)
+	}
+	if len(points) == 0 {
+		return output.Error(fmt.Errorf("no location recorded for message %s", messageID))
+	}
+
+	var export interface{} = points
+	if format == LocationFormatGeoJSON {
+		export = trackFeature(points)
+	}
+	if outputPath == "" {
+		return output.Success(export)
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return output.Error(err)
+	}
+	if err := os.WriteFile(outputPath, append(data, '\n'), 0644); err != nil {
+		return output.Error(err)
+	}
+	return output.Success(map[string]interface{}{
+		"message_id": messageID,
+		"uri":        uri.Message(points[0].ChatJID, messageID),
+		"format":     format,
+		"points":     len(points),
+		"path":       outputPath,
+	})
+}
+
+// splitTracks groups points ordered by track into one slice per message.
+func splitTracks(points []store.LocationPoint) [][]store.LocationPoint {
+	var tracks [][]store.LocationPoint
+	for i, p := range points {
+		if i == 0 || p.MessageID != points[i-1].MessageID {
+			tracks = append(tracks, nil)
+		}
+		tracks[len(tracks)-1] = append(tracks[len(tracks)-1], p)
+	}
+	return tracks
+}
+
+type geoJSONFeatureCollection struct {
+	Type       string                 `json:"type"`
+	Features   []geoJSONFeature       `json:"features"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// KML 2.2 document with one Placemark per location or live track.
+type kmlDocument struct {
+	XMLName   xml.Name       `xml:"kml"`
+	Namespace string         `xml:"xmlns,attr"`
+	Name      string         `xml:"Document>name"`
+	Placemark []kmlPlacemark `xml:"Document>Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name        string         `xml:"name"`
+	Description string         `xml:"description,omitempty"`
+	TimeSpan    *kmlTimeSpan   `xml:"TimeSpan,omitempty"`
+	Point       *kmlGeometry   `xml:"Point,omitempty"`
+	LineString  *kmlLineString `xml:"LineString,omitempty"`
+}
+
+type kmlTimeSpan struct {
+	Begin string `xml:"begin"`
+	End   string `xml:"end"`
+}
+
+type kmlGeometry struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlLineString struct {
+	Tessellate  int    `xml:"tessellate"`
+	Coordinates string `xml:"coordinates"`
+}
+
+// trackPlacemark renders a track as a KML Placemark: a Point for a single
+// fix, a LineString for a live track. KML coordinates are lon,lat[,alt].
+func trackPlacemark(points []store.LocationPoint) kmlPlacemark {
+	first, last := points[0], points[len(points)-1]
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = fmt.Sprintf("%g,%g", p.Longitude, p.Latitude)
+	}
+
+	name := first.Label
+	if name == "" {
+		kind := "Location"
+		if first.Live {
+			kind = "Live location"
+		}
+		name = kind
+		if first.Sender != "" {
+			name = fmt.Sprintf("%s from %s", kind, first.Sender)
+		}
+	}
+	placemark := kmlPlacemark{
+		Name:        name,
+		Description: fmt.Sprintf("%s, %d points", uri.Message(first.ChatJID, first.MessageID), len(points)),
+		TimeSpan: &kmlTimeSpan{
+			Begin: first.Timestamp.UTC().Format(time.RFC3339),
+			End:   last.Timestamp.UTC().Format(time.RFC3339),
+		},
+	}
+	if len(points) == 1 {
+		placemark.Point = &kmlGeometry{Coordinates: coords[0]}
+	} else {
+		placemark.LineString = &kmlLineString{Tessellate: 1, Coordinates: strings.Join(coords, " ")}
+	}
+	return placemark
+}
+
+// ExportLocations writes every static and live location shared in a chat
+// as a GeoJSON FeatureCollection or a KML document. GeoJSON is returned
+// inline without outputPath; KML always needs a file, since stdout carries
+// the JSON result.
+func (a *App) ExportLocations(chatJID, format, outputPath string) string {
+	if format == "" {
+		format = LocationFormatGeoJSON
+	}
+	if format != LocationFormatGeoJSON && format != LocationFormatKML {
+		return output.Error(fmt.Errorf("unknown format %q (use geojson or kml)", format))
+	}
+	if format == LocationFormatKML && outputPath == "" {
+		return output.Error(fmt.Errorf("kml export requires --output"))
+	}
+	if err := a.checkChatAllowed(chatJID); err != nil {
+		return output.Error(err)
+	}
+
+	points, err := a.store.ListLocations(chatJID)
+	if err != nil {
+		return output.Error(err)
+	}
+	if len(points) == 0 {
+		return output.Error(fmt.Errorf("no locations recorded for chat %s", chatJID))
+	}
+	tracks := splitTracks(points)
+
+	chatName, _ := a.store.GetChatName(chatJID)
+	var data []byte
+	switch format {
+	case LocationFormatKML:
+		doc := kmlDocument{Namespace: "http://www.opengis.net/kml/2.2", Name: chatLabel(chatJID, chatName)}
+		for _, track := range tracks {
+			doc.Placemark = append(doc.Placemark, trackPlacemark(track))
+		}
+		if data, err = xml.MarshalIndent(doc, "", "  "); err != nil {
+			return output.Error(err)
+		}
+		data = append([]byte(xml.Header), data...)
+	default:
+		collection := geoJSONFeatureCollection{
+			Type:       "FeatureCollection",
+			Features:   make([]geoJSONFeature, 0, len(tracks)),
+			Properties: map[string]interface{}{"chat_jid": chatJID},
+		}
+		if chatName != "" {
+			collection.Properties["chat_name"] = chatName
+		}
+		for _, track := range tracks {
+			collection.Features = append(collection.Features, trackFeature(track))
+		}
+		if outputPath == "" {
+			return output.Success(collection)
+		}
+		if data, err = json.MarshalIndent(collection, "", "  "); err != nil {
+			return output.Error(err)
+		}
+	}
+
+	if err := os.WriteFile(outputPath, append(data, '\n'), 0644); err != nil {
+		return output.Error(err)
+	}
+	return output.Success(map[string]interface{}{
+		"chat_jid": chatJID,
+		"format":   format,
+		"tracks":   len(tracks),
+		"points":   len(points),
+		"path":     outputPath,
+	})
+}
@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/client"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// TestRecordLocation_FoldsLiveUpdatesIntoTrack verifies a live update with
+// a higher sequence joins the sender's open track, while a restarted
+// sequence starts a new one.
+func TestRecordLocation_FoldsLiveUpdatesIntoTrack(t *testing.T) {
+	var stored []store.LocationPoint
+	mockStore := &MockMessageStore{
+		StoreLocationFunc: func(p store.LocationPoint) error {
+			stored = append(stored, p)
+			return nil
+		},
+		FindLiveLocationTrackFunc: func(chatJID, sender string, since time.Time) (string, int64, error) {
+			return "START", 2, nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+	now := time.Now()
+
+	update := app.recordLocation("UPDATE", "1@s.whatsapp.net", "1", now, &client.LocationInfo{Live: true, Sequence: 3}, true)
+	require.True(t, update)
+	require.Equal(t, "START", stored[0].MessageID)
+
+	update = app.recordLocation("RESTART", "1@s.whatsapp.net", "1", now, &client.LocationInfo{Live: true, Sequence: 1}, true)
+	require.False(t, update)
+	require.Equal(t, "RESTART", stored[1].MessageID)
+
+	update = app.recordLocation("HIST", "1@s.whatsapp.net", "1", now, &client.LocationInfo{Live: true, Sequence: 9}, false)
+	require.False(t, update, "history points are never merged")
+	require.Equal(t, "HIST", stored[2].MessageID)
+}
+
+// TestLocationTrack_GeoJSONLineString verifies the export uses longitude,
+// latitude order and writes raw GeoJSON when --output is given.
+func TestLocationTrack_GeoJSONLineString(t *testing.T) {
+	start := time.Unix(1700000000, 0).UTC()
+	mockStore := &MockMessageStore{
+		LocationTrackFunc: func(messageID string, chatJID *string) ([]store.LocationPoint, error) {
+			return []store.LocationPoint{
+				{MessageID: messageID, ChatJID: "1@s.whatsapp.net", Live: true, Latitude: 40.0, Longitude: -3.0, Timestamp: start},
+				{MessageID: messageID, ChatJID: "1@s.whatsapp.net", Live: true, Latitude: 40.1, Longitude: -3.1, Timestamp: start.Add(time.Minute)},
+			}, nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+
+	resp := parseResponse(t, app.LocationTrack("LIVE1", nil, LocationFormatGeoJSON, ""))
+	require.True(t, resp.Success)
+	var feature geoJSONFeature
+	require.NoError(t, json.Unmarshal(resp.Data, &feature))
+	require.Equal(t, "LineString", feature.Geometry.Type)
+	require.Equal(t, []interface{}{[]interface{}{-3.0, 40.0}, []interface{}{-3.1, 40.1}}, feature.Geometry.Coordinates)
+	require.EqualValues(t, 2, feature.Properties["points"])
+	require.Equal(t, "whatsapp-cli://chat/1@s.whatsapp.net/message/LIVE1", feature.Properties["uri"])
+
+	path := filepath.Join(t.TempDir(), "track.geojson")
+	resp = parseResponse(t, app.LocationTrack("LIVE1", nil, LocationFormatGeoJSON, path))
+	require.True(t, resp.Success)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &feature))
+	require.Equal(t, "Feature", feature.Type)
+}
+
+// TestLocationTrack_UnknownMessage verifies a clear error for messages
+// without recorded positions.
+func TestLocationTrack_UnknownMessage(t *testing.T) {
+	app := NewAppWithDeps(&MockWAClient{}, &MockMessageStore{}, t.TempDir(), "test")
+	resp := parseResponse(t, app.LocationTrack("NOPE", nil, LocationFormatGeoJSON, ""))
+	require.False(t, resp.Success)
+
+	resp = parseResponse(t, app.LocationTrack("NOPE", nil, "kml", ""))
+	require.False(t, resp.Success)
+}
+
+// TestExportLocations_GeoJSONCollection verifies each pin and live track in
+// a chat becomes one feature of a FeatureCollection.
+func TestExportLocations_GeoJSONCollection(t *testing.T) {
+	start := time.Unix(1700000000, 0).UTC()
+	mockStore := &MockMessageStore{
+		ListLocationsFunc: func(chatJID string) ([]store.LocationPoint, error) {
+			return []store.LocationPoint{
+				{MessageID: "LIVE1", ChatJID: chatJID, Live: true, Latitude: 40.0, Longitude: -3.0, Timestamp: start},
+				{MessageID: "LIVE1", ChatJID: chatJID, Live: true, Latitude: 40.1, Longitude: -3.1, Timestamp: start.Add(time.Minute)},
+				{MessageID: "PIN", ChatJID: chatJID, Latitude: 41.0, Longitude: 2.0, Label: "Camp", Timestamp: start.Add(time.Hour)},
+			}, nil
+		},
+		GetChatNameFunc: func(jid string) (string, error) { return "Trip", nil },
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+
+	resp := parseResponse(t, app.ExportLocations("123@g.us", LocationFormatGeoJSON, ""))
+	require.True(t, resp.Success)
+	var collection geoJSONFeatureCollection
+	require.NoError(t, json.Unmarshal(resp.Data, &collection))
+	require.Equal(t, "FeatureCollection", collection.Type)
+	require.Len(t, collection.Features, 2)
+	require.Equal(t, "LineString", collection.Features[0].Geometry.Type)
+	require.Equal(t, "Point", collection.Features[1].Geometry.Type)
+	require.Equal(t, "Camp", collection.Features[1].Properties["label"])
+	require.Equal(t, "Trip", collection.Properties["chat_name"])
+}
+
+// TestExportLocations_KML verifies the KML export is written to a file with
+// longitude-first coordinates, and refused without --output.
+func TestExportLocations_KML(t *testing.T) {
+	start := time.Unix(1700000000, 0).UTC()
+	mockStore := &MockMessageStore{
+		ListLocationsFunc: func(chatJID string) ([]store.LocationPoint, error) {
+			return []store.LocationPoint{
+				{MessageID: "LIVE1", ChatJID: chatJID, Sender: "1", Live: true, Latitude: 40, Longitude: -3, Timestamp: start},
+				{MessageID: "LIVE1", ChatJID: chatJID, Sender: "1", Live: true, Latitude: 40.5, Longitude: -3.5, Timestamp: start.Add(time.Minute)},
+			}, nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+
+	resp := parseResponse(t, app.ExportLocations("123@g.us", LocationFormatKML, ""))
+	require.False(t, resp.Success)
+
+	path := filepath.Join(t.TempDir(), "trip.kml")
+	resp = parseResponse(t, app.ExportLocations("123@g.us", LocationFormatKML, path))
+	require.True(t, resp.Success)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `<kml xmlns="http://www.opengis.net/kml/2.2">`)
+	require.Contains(t, string(data), "<coordinates>-3,40 -3.5,40.5</coordinates>")
+	require.Contains(t, string(data), "<name>Live location from 1</name>")
+	require.Contains(t, string(data), "<description>whatsapp-cli://chat/123@g.us/message/LIVE1, 2 points</description>")
+}
@@ -151,3 +151,29 @@ func TestDownloadMediaErrorsWhenMetadataMissing(t *testing.T) {
 	require.NotNil(t, res.Error)
 	assert.Contains(t, *res.Error, "no downloadable media")
 }
+
+// TestProcessMediaJob_IgnoresAllowedChats verifies sync downloads media for
+// chats outside allowed_chats, which only limits what is read back.
+func TestProcessMediaJob_IgnoresAllowedChats(t *testing.T) {
+	tmpDir := t.TempDir()
+	st, err := store.NewMessageStore(filepath.Join(tmpDir, "messages.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { st.Close() })
+	st.SetAllowedChats([]string{"team@g.us"})
+
+	chatJID := "1234@s.whatsapp.net"
+	require.NoError(t, st.StoreChat(chatJID, "John Doe", time.Now()))
+	require.NoError(t, st.StoreMessage("msg1", chatJID, "1234", "", time.Now(), false,
+		"image", "photo.jpg", "", "/media/direct/path", "image/jpeg", []byte{1}, nil, nil, 1024))
+
+	app := &App{store: st, version: "test", storeDir: tmpDir}
+	fake := &fakeDownloader{bytes: 1024}
+	app.mediaDownloader = func(ctx context.Context, info store.MessageDownloadInfo, targetPath string) (int64, error) {
+		stats, err := fake.download(ctx, info, targetPath)
+		return stats.bytes, err
+	}
+
+	require.NoError(t, app.processMediaJob(context.Background(), mediaJob{messageID: "msg1", chatJID: chatJID}))
+	require.True(t, fake.called, "expected downloader to be invoked")
+	assert.Equal(t, chatJID, fake.request.ChatJID)
+}
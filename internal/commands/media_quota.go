@@ -0,0 +1,298 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+)
+
+// Media space policies applied when a background download would exceed
+// the quota or the minimum free space.
+const (
+	MediaPolicySkip  = "skip"
+	MediaPolicyEvict = "evict"
+)
+
+// evictBatch is how many eviction candidates are loaded at a time.
+const evictBatch = 20
+
+// errMediaSpace is returned by background downloads skipped for space.
+var errMediaSpace = errors.New("media space limit reached")
+
+// MediaLimits bounds how much disk space background media downloads use.
+// Zero values mean unlimited.
+type MediaLimits struct {
+	// Quota caps the total size of media downloaded under STORE/media.
+	Quota int64
+	// MinFree is the free space that must remain on the store's filesystem.
+	MinFree int64
+	// Policy is MediaPolicySkip (default) or MediaPolicyEvict.
+	Policy string
+}
+
+func (l MediaLimits) enabled() bool {
+	return l.Quota > 0 || l.MinFree > 0
+}
+
+// parseByteSize accepts plain byte counts and binary-unit suffixes such as
+// "500MB", "20GB" or "1.5TB" (1GB = 1024³ bytes).
+func parseByteSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		return 0, nil
+	}
+
+	units := []struct {
+		suffix string
+		size   float64
+	}{
+		{"TB", 1 << 40},
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"T", 1 << 40},
+		{"G", 1 << 30},
+		{"M", 1 << 20},
+		{"K", 1 << 10},
+		{"B", 1},
+	}
+	multiplier := 1.0
+	number := value
+	for _, u := range units {
+		if strings.HasSuffix(value, u.suffix) {
+			multiplier = u.size
+			number = strings.TrimSpace(strings.TrimSuffix(value, u.suffix))
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 500MB, 20GB)", value)
+	}
+	return int64(n * multiplier), nil
+}
+
+func formatByteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGT"[exp])
+}
+
+func formatLimit(n int64) string {
+	if n <= 0 {
+		return "none"
+	}
+	return formatByteSize(n)
+}
+
+// resolveMediaLimits merges size flags over config.json settings. Empty
+// strings fall back to the configured value.
+func resolveMediaLimits(cfg *config.Config, quota, minFree, policy string) (MediaLimits, error) {
+	if cfg != nil {
+		if quota == "" {
+			quota = cfg.MediaQuota
+		}
+		if minFree == "" {
+			minFree = cfg.MediaMinFree
+		}
+		if policy == "" {
+			policy = cfg.MediaPolicy
+		}
+	}
+
+	var limits MediaLimits
+	var err error
+	if limits.Quota, err = parseByteSize(quota); err != nil {
+		return MediaLimits{}, fmt.Errorf("media quota: %w", err)
+	}
+	if limits.MinFree, err = parseByteSize(minFree); err != nil {
+		return MediaLimits{}, fmt.Errorf("media min free: %w", err)
+	}
+	switch policy {
+	case "", MediaPolicySkip:
+		limits.Policy = MediaPolicySkip
+	case MediaPolicyEvict:
+		limits.Policy = MediaPolicyEvict
+	default:
+		return MediaLimits{}, fmt.Errorf("unknown media policy %q (use skip or evict)", policy)
+	}
+	return limits, nil
+}
+
+// mediaGuard enforces MediaLimits before each background download. Checks
+// are serialized so concurrent workers don't evict the same files; the
+// download itself runs unlocked, so limits may be overshot by the files
+// in flight.
+type mediaGuard struct {
+	app       *App
+	limits    MediaLimits
+	root      string
+	freeSpace func(path string) (uint64, error)
+
+	mu           sync.Mutex
+	skipped      int
+	evicted      int
+	evictedBytes int64
+}
+
+func newMediaGuard(app *App, limits MediaLimits) *mediaGuard {
+	root := filepath.Join(app.storeDir, "media")
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &mediaGuard{
+		app:       app,
+		limits:    limits,
+		root:      root,
+		freeSpace: diskFree,
+	}
+}
+
+// Reserve makes room for a download of size bytes, evicting old media if
+// the policy allows. It returns errMediaSpace when the download must be
+// skipped.
+func (g *mediaGuard) Reserve(size int64) error {
+	if g == nil || !g.limits.enabled() {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for {
+		need, err := g.shortfall(size)
+		if err != nil {
+			return err
+		}
+		if need <= 0 {
+			return nil
+		}
+		if g.limits.Policy != MediaPolicyEvict {
+			g.skipped++
+			return errMediaSpace
+		}
+		// Don't empty the media directory for a download that wouldn't
+		// fit even then.
+		fits, err := g.fitsAfterEviction(size)
+		if err != nil {
+			return err
+		}
+		if !fits {
+			g.skipped++
+			return errMediaSpace
+		}
+		evicted, err := g.evict(need)
+		if err != nil {
+			return err
+		}
+		if evicted == 0 {
+			g.skipped++
+			return errMediaSpace
+		}
+	}
+}
+
+// shortfall returns how many bytes must be freed before size more bytes
+// fit within both limits.
+func (g *mediaGuard) shortfall(size int64) (int64, error) {
+	var need int64
+	if g.limits.Quota > 0 {
+		usage, err := g.app.store.MediaUsage(g.root)
+		if err != nil {
+			return 0, err
+		}
+		need = usage + size - g.limits.Quota
+	}
+	if g.limits.MinFree > 0 {
+		free, err := g.freeSpace(g.app.storeDir)
+		if err != nil {
+			return 0, fmt.Errorf("checking free space: %w", err)
+		}
+		if n := g.limits.MinFree + size - int64(free); n > need {
+			need = n
+		}
+	}
+	return need, nil
+}
+
+// fitsAfterEviction reports whether size more bytes would fit within both
+// limits if every downloaded media file were evicted.
+func (g *mediaGuard) fitsAfterEviction(size int64) (bool, error) {
+	if g.limits.Quota > 0 && size > g.limits.Quota {
+		return false, nil
+	}
+	if g.limits.MinFree <= 0 {
+		return true, nil
+	}
+	usage, err := g.app.store.MediaUsage(g.root)
+	if err != nil {
+		return false, err
+	}
+	free, err := g.freeSpace(g.app.storeDir)
+	if err != nil {
+		return false, fmt.Errorf("checking free space: %w", err)
+	}
+	return int64(free)+usage >= g.limits.MinFree+size, nil
+}
+
+// evict deletes the least recently downloaded files until need bytes are
+// freed or the batch runs out, returning how many files were removed.
+func (g *mediaGuard) evict(need int64) (int, error) {
+	candidates, err := g.app.store.ListEvictableMedia(g.root, evictBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	var freed int64
+	count := 0
+	for _, m := range candidates {
+		if freed >= need {
+			break
+		}
+		if err := os.Remove(m.LocalPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return count, fmt.Errorf("evicting %s: %w", m.LocalPath, err)
+		}
+		if err := g.app.store.MarkMediaEvicted(m.ID, m.ChatJID, time.Now().UTC()); err != nil {
+			return count, err
+		}
+		freed += m.Size
+		count++
+	}
+	if count > 0 {
+		g.evicted += count
+		g.evictedBytes += freed
+		fmt.Fprintf(output.Stderr, "\n🧹 Evicted %d media files (%s) to stay within media limits\n", count, formatByteSize(freed))
+	}
+	return count, nil
+}
+
+func (g *mediaGuard) PrintSummary() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	skipped, evicted, evictedBytes := g.skipped, g.evicted, g.evictedBytes
+	g.mu.Unlock()
+
+	if evicted > 0 {
+		fmt.Fprintf(output.Stderr, "🧹 Evicted %d media files (%s) in total\n", evicted, formatByteSize(evictedBytes))
+	}
+	if skipped > 0 {
+		fmt.Fprintf(output.Stderr, "⚠️  Skipped %d media downloads: media quota or free-space limit reached\n", skipped)
+	}
+}
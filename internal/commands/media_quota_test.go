@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "", want: 0},
+		{input: "512", want: 512},
+		{input: "20GB", want: 20 << 30},
+		{input: "1.5g", want: 3 << 29},
+		{input: "500 MB", want: 500 << 20},
+		{input: "lots", wantErr: true},
+		{input: "-1GB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseByteSize(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestResolveMediaLimits_FlagsOverrideConfig verifies empty flags fall back
+// to config.json while set flags win.
+func TestResolveMediaLimits_FlagsOverrideConfig(t *testing.T) {
+	cfg := &config.Config{MediaQuota: "1GB", MediaMinFree: "2GB", MediaPolicy: "evict"}
+
+	limits, err := resolveMediaLimits(cfg, "5GB", "", "")
+	require.NoError(t, err)
+	require.Equal(t, MediaLimits{Quota: 5 << 30, MinFree: 2 << 30, Policy: MediaPolicyEvict}, limits)
+
+	_, err = resolveMediaLimits(cfg, "", "", "delete-everything")
+	require.Error(t, err)
+}
+
+// TestMediaGuard_SkipPolicyRefusesOverQuota verifies the default policy
+// skips downloads instead of touching existing files.
+func TestMediaGuard_SkipPolicyRefusesOverQuota(t *testing.T) {
+	mockStore := &MockMessageStore{
+		MediaUsageFunc: func(root string) (int64, error) { return 90, nil },
+		ListEvictableMediaFunc: func(root string, limit int) ([]store.StoredMedia, error) {
+			t.Fatal("skip policy must not evict")
+			return nil, nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+	guard := newMediaGuard(app, MediaLimits{Quota: 100, Policy: MediaPolicySkip})
+
+	require.NoError(t, guard.Reserve(10))
+	require.ErrorIs(t, guard.Reserve(11), errMediaSpace)
+	require.Equal(t, 1, guard.skipped)
+}
+
+// TestMediaGuard_EvictPolicyRemovesOldestFiles verifies eviction deletes
+// just enough old files to fit the new download.
+func TestMediaGuard_EvictPolicyRemovesOldestFiles(t *testing.T) {
+	dir := t.TempDir()
+	oldest := filepath.Join(dir, "media", "a.jpg")
+	older := filepath.Join(dir, "media", "b.jpg")
+	newer := filepath.Join(dir, "media", "c.jpg")
+	require.NoError(t, os.MkdirAll(filepath.Dir(oldest), 0755))
+	for _, p := range []string{oldest, older, newer} {
+		require.NoError(t, os.WriteFile(p, []byte("x"), 0644))
+	}
+
+	usage := int64(90)
+	candidates := []store.StoredMedia{
+		{ID: "a", ChatJID: "c", LocalPath: oldest, Size: 30},
+		{ID: "b", ChatJID: "c", LocalPath: older, Size: 30},
+		{ID: "c", ChatJID: "c", LocalPath: newer, Size: 30},
+	}
+	var evicted []string
+	mockStore := &MockMessageStore{
+		MediaUsageFunc: func(root string) (int64, error) { return usage, nil },
+		ListEvictableMediaFunc: func(root string, limit int) ([]store.StoredMedia, error) {
+			return candidates[len(evicted):], nil
+		},
+		MarkMediaEvictedFunc: func(id, chatJID string, at time.Time) error {
+			evicted = append(evicted, id)
+			usage -= 30
+			return nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, dir, "test")
+	guard := newMediaGuard(app, MediaLimits{Quota: 100, Policy: MediaPolicyEvict})
+
+	require.NoError(t, guard.Reserve(40))
+	require.Equal(t, []string{"a"}, evicted)
+	require.NoFileExists(t, oldest)
+	require.FileExists(t, older)
+	require.Equal(t, int64(30), guard.evictedBytes)
+}
+
+// TestMediaGuard_MinFreeSpace verifies the free-space floor is enforced
+// independently of the quota.
+func TestMediaGuard_MinFreeSpace(t *testing.T) {
+	app := NewAppWithDeps(&MockWAClient{}, &MockMessageStore{}, t.TempDir(), "test")
+	guard := newMediaGuard(app, MediaLimits{MinFree: 1000, Policy: MediaPolicySkip})
+	guard.freeSpace = func(path string) (uint64, error) { return 1500, nil }
+
+	require.NoError(t, guard.Reserve(500))
+	require.ErrorIs(t, guard.Reserve(501), errMediaSpace)
+}
+
+// TestMediaGuard_EvictSkipsWhatCannotFit verifies downloads that wouldn't
+// fit even with all media evicted are skipped without evicting anything.
+func TestMediaGuard_EvictSkipsWhatCannotFit(t *testing.T) {
+	var evicted []string
+	mockStore := &MockMessageStore{
+		MediaUsageFunc: func(root string) (int64, error) { return 90, nil },
+		ListEvictableMediaFunc: func(root string, limit int) ([]store.StoredMedia, error) {
+			return []store.StoredMedia{{ID: "a", ChatJID: "c", LocalPath: filepath.Join(root, "a.jpg"), Size: 90}}, nil
+		},
+		MarkMediaEvictedFunc: func(id, chatJID string, at time.Time) error {
+			evicted = append(evicted, id)
+			return nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+
+	// Larger than the whole quota.
+	guard := newMediaGuard(app, MediaLimits{Quota: 100, Policy: MediaPolicyEvict})
+	require.ErrorIs(t, guard.Reserve(101), errMediaSpace)
+
+	// Free space plus all media still short of the floor.
+	guard = newMediaGuard(app, MediaLimits{MinFree: 1000, Policy: MediaPolicyEvict})
+	guard.freeSpace = func(path string) (uint64, error) { return 900, nil }
+	require.ErrorIs(t, guard.Reserve(20), errMediaSpace)
+
+	require.Empty(t, evicted)
+}
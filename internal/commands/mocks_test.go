@@ -14,9 +14,60 @@ type MockMessageStore struct {
 	SearchContactsFunc      func(query string) ([]store.Contact, error)
 	ListChatsFunc           func(params store.ListChatsParams) ([]store.Chat, error)
 	StoreChatFunc           func(jid, name string, lastMessageTime time.Time) error
+	UpdateChatNameFunc      func(jid, name string) (bool, error)
+	SetExternalChatNameFunc func(jid, name string) (bool, error)
+	MarkChatDeletedFunc     func(jid string, at time.Time) error
+	ListDirectChatJIDsFunc  func() ([]string, error)
 	StoreMessageFunc        func(id, chatJID, sender, content string, timestamp time.Time, isFromMe bool, mediaType, filename, url, directPath, mimeType string, mediaKey, fileSHA256, fileEncSHA256 []byte, fileLength uint64) error
 	GetMessageForDownloadFunc func(id string, chatJID *string) (store.MessageDownloadInfo, error)
+	GetMediaForSyncFunc     func(id, chatJID string) (store.MessageDownloadInfo, error)
 	MarkMediaDownloadedFunc func(id, chatJID, localPath string, downloadedAt time.Time) error
+	SetChatRetentionFunc    func(chatJID string, keep time.Duration) error
+	ClearChatRetentionFunc  func(chatJID string) error
+	ListChatRetentionFunc   func() ([]store.ChatRetention, error)
+	SetChatMediaPolicyFunc  func(chatJID string, types []string, maxSize int64) error
+	ClearChatMediaPolicyFunc func(chatJID string) error
+	GetChatMediaPolicyFunc  func(chatJID string) (*store.ChatMediaPolicy, error)
+	ListChatMediaPoliciesFunc func() ([]store.ChatMediaPolicy, error)
+	PruneExpiredMessagesFunc func(now time.Time) (int64, error)
+	ListUnreadIncomingFunc  func(chatJID string, limit int) ([]store.Message, error)
+	MarkMessagesReadFunc    func(chatJID string, ids []string, at time.Time) error
+	ListUnnamedChatJIDsFunc func() ([]string, error)
+	ListPendingMediaFunc    func(limit int) ([]store.MessageRef, error)
+	StoreRawMessageFunc     func(id, chatJID string, raw []byte, replyToID string) error
+	ListRawMessagesFunc     func(afterRowID int64, limit int) ([]store.RawMessage, error)
+	UpdateExtractedContentFunc func(id, chatJID string, e store.ExtractedContent) (bool, error)
+	MediaUsageFunc          func(root string) (int64, error)
+	ListEvictableMediaFunc  func(root string, limit int) ([]store.StoredMedia, error)
+	MarkMediaEvictedFunc    func(id, chatJID string, at time.Time) error
+	LogOutboundFunc         func(chatJID, kind, content, attachment string, at time.Time) (int64, error)
+	MarkOutboundSentFunc    func(logID int64, messageID string, at time.Time) error
+	MarkOutboundFailedFunc  func(logID int64, reason string, at time.Time) error
+	UpdateOutboundStatusFunc func(messageIDs []string, status string, at time.Time) (int64, error)
+	ListOutboundFunc        func(params store.ListOutboundParams) ([]store.OutboundMessage, error)
+	StatsFunc               func() (store.Stats, error)
+	GetChatNameFunc         func(jid string) (string, error)
+	RecordParticipantEventsFunc func(events []store.ParticipantEvent) error
+	ListParticipantEventsFunc   func(chatJID string, before time.Time) ([]store.ParticipantEvent, error)
+	ListChatActivityFunc        func(baselineSince, recentSince time.Time) ([]store.ChatActivity, error)
+	LastMessageTimeFunc         func(chatJID string) (time.Time, error)
+	ListGroupsDueForRefreshFunc func(before time.Time, limit int) ([]store.GroupRefreshState, error)
+	StoreGroupMetadataFunc      func(m store.GroupMetadata, at time.Time) error
+	RecordGroupRefreshErrorFunc func(chatJID, reason string, at time.Time) error
+	GetMessageDetailsFunc       func(chatJID, id string) (store.MessageDetails, error)
+	ListMessagesAroundFunc      func(chatJID, id string, at time.Time, n int) ([]store.Message, []store.Message, error)
+	ListDeliveryTimingsFunc     func(chatJID string, since time.Time) ([]store.DeliveryTiming, error)
+	StoreLocationFunc           func(p store.LocationPoint) error
+	FindLiveLocationTrackFunc   func(chatJID, sender string, since time.Time) (string, int64, error)
+	LocationTrackFunc           func(messageID string, chatJID *string) ([]store.LocationPoint, error)
+	ListLocationsFunc           func(chatJID string) ([]store.LocationPoint, error)
+	StoreCommerceFunc           func(id, chatJID string, c store.Commerce) error
+	UpdateCommerceStatusFunc    func(id, chatJID, status string) error
+	StoreInteractiveFunc        func(id, chatJID, kind, selectedID string) error
+	StoreParticipantNameFunc    func(chatJID, participant, name, source string) error
+	ImportChatFunc              func(jid, name string, lastMessageTime time.Time) error
+	ImportMessagesFunc          func(messages []store.ImportedMessage) (int, error)
+	ListChatHistoryFunc         func(chatJID string) ([]store.HistoryMessage, error)
 	CloseFunc               func() error
 }
 
@@ -48,6 +99,34 @@ func (m *MockMessageStore) StoreChat(jid, name string, lastMessageTime time.Time
 	return nil
 }
 
+func (m *MockMessageStore) UpdateChatName(jid, name string) (bool, error) {
+	if m.UpdateChatNameFunc != nil {
+		return m.UpdateChatNameFunc(jid, name)
+	}
+	return false, nil
+}
+
+func (m *MockMessageStore) SetExternalChatName(jid, name string) (bool, error) {
+	if m.SetExternalChatNameFunc != nil {
+		return m.SetExternalChatNameFunc(jid, name)
+	}
+	return false, nil
+}
+
+func (m *MockMessageStore) MarkChatDeleted(jid string, at time.Time) error {
+	if m.MarkChatDeletedFunc != nil {
+		return m.MarkChatDeletedFunc(jid, at)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) ListDirectChatJIDs() ([]string, error) {
+	if m.ListDirectChatJIDsFunc != nil {
+		return m.ListDirectChatJIDsFunc()
+	}
+	return nil, nil
+}
+
 func (m *MockMessageStore) StoreMessage(id, chatJID, sender, content string, timestamp time.Time, isFromMe bool, mediaType, filename, url, directPath, mimeType string, mediaKey, fileSHA256, fileEncSHA256 []byte, fileLength uint64) error {
 	if m.StoreMessageFunc != nil {
 		return m.StoreMessageFunc(id, chatJID, sender, content, timestamp, isFromMe, mediaType, filename, url, directPath, mimeType, mediaKey, fileSHA256, fileEncSHA256, fileLength)
@@ -62,6 +141,13 @@ func (m *MockMessageStore) GetMessageForDownload(id string, chatJID *string) (st
 	return store.MessageDownloadInfo{}, nil
 }
 
+func (m *MockMessageStore) GetMediaForSync(id, chatJID string) (store.MessageDownloadInfo, error) {
+	if m.GetMediaForSyncFunc != nil {
+		return m.GetMediaForSyncFunc(id, chatJID)
+	}
+	return store.MessageDownloadInfo{}, nil
+}
+
 func (m *MockMessageStore) MarkMediaDownloaded(id, chatJID, localPath string, downloadedAt time.Time) error {
 	if m.MarkMediaDownloadedFunc != nil {
 		return m.MarkMediaDownloadedFunc(id, chatJID, localPath, downloadedAt)
@@ -69,6 +155,328 @@ func (m *MockMessageStore) MarkMediaDownloaded(id, chatJID, localPath string, do
 	return nil
 }
 
+func (m *MockMessageStore) SetChatRetention(chatJID string, keep time.Duration) error {
+	if m.SetChatRetentionFunc != nil {
+		return m.SetChatRetentionFunc(chatJID, keep)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) ClearChatRetention(chatJID string) error {
+	if m.ClearChatRetentionFunc != nil {
+		return m.ClearChatRetentionFunc(chatJID)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) ListChatRetention() ([]store.ChatRetention, error) {
+	if m.ListChatRetentionFunc != nil {
+		return m.ListChatRetentionFunc()
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) PruneExpiredMessages(now time.Time) (int64, error) {
+	if m.PruneExpiredMessagesFunc != nil {
+		return m.PruneExpiredMessagesFunc(now)
+	}
+	return 0, nil
+}
+
+func (m *MockMessageStore) ListUnreadIncoming(chatJID string, limit int) ([]store.Message, error) {
+	if m.ListUnreadIncomingFunc != nil {
+		return m.ListUnreadIncomingFunc(chatJID, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) MarkMessagesRead(chatJID string, ids []string, at time.Time) error {
+	if m.MarkMessagesReadFunc != nil {
+		return m.MarkMessagesReadFunc(chatJID, ids, at)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) ListUnnamedChatJIDs() ([]string, error) {
+	if m.ListUnnamedChatJIDsFunc != nil {
+		return m.ListUnnamedChatJIDsFunc()
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) ListPendingMedia(limit int) ([]store.MessageRef, error) {
+	if m.ListPendingMediaFunc != nil {
+		return m.ListPendingMediaFunc(limit)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) StoreRawMessage(id, chatJID string, raw []byte, replyToID string) error {
+	if m.StoreRawMessageFunc != nil {
+		return m.StoreRawMessageFunc(id, chatJID, raw, replyToID)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) ListRawMessages(afterRowID int64, limit int) ([]store.RawMessage, error) {
+	if m.ListRawMessagesFunc != nil {
+		return m.ListRawMessagesFunc(afterRowID, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) UpdateExtractedContent(id, chatJID string, e store.ExtractedContent) (bool, error) {
+	if m.UpdateExtractedContentFunc != nil {
+		return m.UpdateExtractedContentFunc(id, chatJID, e)
+	}
+	return false, nil
+}
+
+func (m *MockMessageStore) MediaUsage(root string) (int64, error) {
+	if m.MediaUsageFunc != nil {
+		return m.MediaUsageFunc(root)
+	}
+	return 0, nil
+}
+
+func (m *MockMessageStore) ListEvictableMedia(root string, limit int) ([]store.StoredMedia, error) {
+	if m.ListEvictableMediaFunc != nil {
+		return m.ListEvictableMediaFunc(root, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) MarkMediaEvicted(id, chatJID string, at time.Time) error {
+	if m.MarkMediaEvictedFunc != nil {
+		return m.MarkMediaEvictedFunc(id, chatJID, at)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) LogOutbound(chatJID, kind, content, attachment string, at time.Time) (int64, error) {
+	if m.LogOutboundFunc != nil {
+		return m.LogOutboundFunc(chatJID, kind, content, attachment, at)
+	}
+	return 0, nil
+}
+
+func (m *MockMessageStore) MarkOutboundSent(logID int64, messageID string, at time.Time) error {
+	if m.MarkOutboundSentFunc != nil {
+		return m.MarkOutboundSentFunc(logID, messageID, at)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) MarkOutboundFailed(logID int64, reason string, at time.Time) error {
+	if m.MarkOutboundFailedFunc != nil {
+		return m.MarkOutboundFailedFunc(logID, reason, at)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) UpdateOutboundStatus(messageIDs []string, status string, at time.Time) (int64, error) {
+	if m.UpdateOutboundStatusFunc != nil {
+		return m.UpdateOutboundStatusFunc(messageIDs, status, at)
+	}
+	return 0, nil
+}
+
+func (m *MockMessageStore) ListOutbound(params store.ListOutboundParams) ([]store.OutboundMessage, error) {
+	if m.ListOutboundFunc != nil {
+		return m.ListOutboundFunc(params)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) Stats() (store.Stats, error) {
+	if m.StatsFunc != nil {
+		return m.StatsFunc()
+	}
+	return store.Stats{}, nil
+}
+
+func (m *MockMessageStore) GetChatName(jid string) (string, error) {
+	if m.GetChatNameFunc != nil {
+		return m.GetChatNameFunc(jid)
+	}
+	return "", nil
+}
+
+func (m *MockMessageStore) RecordParticipantEvents(events []store.ParticipantEvent) error {
+	if m.RecordParticipantEventsFunc != nil {
+		return m.RecordParticipantEventsFunc(events)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) ListParticipantEvents(chatJID string, before time.Time) ([]store.ParticipantEvent, error) {
+	if m.ListParticipantEventsFunc != nil {
+		return m.ListParticipantEventsFunc(chatJID, before)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) ListChatActivity(baselineSince, recentSince time.Time) ([]store.ChatActivity, error) {
+	if m.ListChatActivityFunc != nil {
+		return m.ListChatActivityFunc(baselineSince, recentSince)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) LastMessageTime(chatJID string) (time.Time, error) {
+	if m.LastMessageTimeFunc != nil {
+		return m.LastMessageTimeFunc(chatJID)
+	}
+	return time.Time{}, nil
+}
+
+func (m *MockMessageStore) ListDeliveryTimings(chatJID string, since time.Time) ([]store.DeliveryTiming, error) {
+	if m.ListDeliveryTimingsFunc != nil {
+		return m.ListDeliveryTimingsFunc(chatJID, since)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) StoreLocation(p store.LocationPoint) error {
+	if m.StoreLocationFunc != nil {
+		return m.StoreLocationFunc(p)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) FindLiveLocationTrack(chatJID, sender string, since time.Time) (string, int64, error) {
+	if m.FindLiveLocationTrackFunc != nil {
+		return m.FindLiveLocationTrackFunc(chatJID, sender, since)
+	}
+	return "", 0, nil
+}
+
+func (m *MockMessageStore) LocationTrack(messageID string, chatJID *string) ([]store.LocationPoint, error) {
+	if m.LocationTrackFunc != nil {
+		return m.LocationTrackFunc(messageID, chatJID)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) ListLocations(chatJID string) ([]store.LocationPoint, error) {
+	if m.ListLocationsFunc != nil {
+		return m.ListLocationsFunc(chatJID)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) StoreCommerce(id, chatJID string, c store.Commerce) error {
+	if m.StoreCommerceFunc != nil {
+		return m.StoreCommerceFunc(id, chatJID, c)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) UpdateCommerceStatus(id, chatJID, status string) error {
+	if m.UpdateCommerceStatusFunc != nil {
+		return m.UpdateCommerceStatusFunc(id, chatJID, status)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) StoreInteractive(id, chatJID, kind, selectedID string) error {
+	if m.StoreInteractiveFunc != nil {
+		return m.StoreInteractiveFunc(id, chatJID, kind, selectedID)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) SetChatMediaPolicy(chatJID string, types []string, maxSize int64) error {
+	if m.SetChatMediaPolicyFunc != nil {
+		return m.SetChatMediaPolicyFunc(chatJID, types, maxSize)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) ClearChatMediaPolicy(chatJID string) error {
+	if m.ClearChatMediaPolicyFunc != nil {
+		return m.ClearChatMediaPolicyFunc(chatJID)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) GetChatMediaPolicy(chatJID string) (*store.ChatMediaPolicy, error) {
+	if m.GetChatMediaPolicyFunc != nil {
+		return m.GetChatMediaPolicyFunc(chatJID)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) ListChatMediaPolicies() ([]store.ChatMediaPolicy, error) {
+	if m.ListChatMediaPoliciesFunc != nil {
+		return m.ListChatMediaPoliciesFunc()
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) StoreParticipantName(chatJID, participant, name, source string) error {
+	if m.StoreParticipantNameFunc != nil {
+		return m.StoreParticipantNameFunc(chatJID, participant, name, source)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) ImportChat(jid, name string, lastMessageTime time.Time) error {
+	if m.ImportChatFunc != nil {
+		return m.ImportChatFunc(jid, name, lastMessageTime)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) ImportMessages(messages []store.ImportedMessage) (int, error) {
+	if m.ImportMessagesFunc != nil {
+		return m.ImportMessagesFunc(messages)
+	}
+	return 0, nil
+}
+
+func (m *MockMessageStore) ListChatHistory(chatJID string) ([]store.HistoryMessage, error) {
+	if m.ListChatHistoryFunc != nil {
+		return m.ListChatHistoryFunc(chatJID)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) ListGroupsDueForRefresh(before time.Time, limit int) ([]store.GroupRefreshState, error) {
+	if m.ListGroupsDueForRefreshFunc != nil {
+		return m.ListGroupsDueForRefreshFunc(before, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageStore) StoreGroupMetadata(g store.GroupMetadata, at time.Time) error {
+	if m.StoreGroupMetadataFunc != nil {
+		return m.StoreGroupMetadataFunc(g, at)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) RecordGroupRefreshError(chatJID, reason string, at time.Time) error {
+	if m.RecordGroupRefreshErrorFunc != nil {
+		return m.RecordGroupRefreshErrorFunc(chatJID, reason, at)
+	}
+	return nil
+}
+
+func (m *MockMessageStore) GetMessageDetails(chatJID, id string) (store.MessageDetails, error) {
+	if m.GetMessageDetailsFunc != nil {
+		return m.GetMessageDetailsFunc(chatJID, id)
+	}
+	return store.MessageDetails{}, nil
+}
+
+func (m *MockMessageStore) ListMessagesAround(chatJID, id string, at time.Time, n int) ([]store.Message, []store.Message, error) {
+	if m.ListMessagesAroundFunc != nil {
+		return m.ListMessagesAroundFunc(chatJID, id, at, n)
+	}
+	return nil, nil, nil
+}
+
 func (m *MockMessageStore) Close() error {
 	if m.CloseFunc != nil {
 		return m.CloseFunc()
@@ -84,8 +492,11 @@ type MockWAClient struct {
 	DisconnectFunc          func()
 	SendMessageFunc         func(ctx context.Context, recipient, message string) (string, error)
 	SendImageMessageFunc    func(ctx context.Context, recipient, imagePath, caption string) (string, error)
+	SendInteractiveMessageFunc func(ctx context.Context, recipient string, spec types.InteractiveSpec) (string, error)
+	MarkReadFunc            func(ctx context.Context, chatJID, sender string, ids []string) error
 	ResolveChatNameFunc     func(ctx context.Context, jid string, evt interface{}) string
 	DownloadMediaToFileFunc func(ctx context.Context, req types.MediaDownloadRequest, targetPath string) (int64, error)
+	GetGroupMetadataFunc    func(ctx context.Context, groupJID, knownAvatarID string) (types.GroupMetadata, error)
 	StartSyncFunc           func(ctx context.Context, eventHandler func(interface{})) error
 }
 
@@ -130,6 +541,20 @@ func (m *MockWAClient) SendImageMessage(ctx context.Context, recipient, imagePat
 	return "mock-id", nil
 }
 
+func (m *MockWAClient) SendInteractiveMessage(ctx context.Context, recipient string, spec types.InteractiveSpec) (string, error) {
+	if m.SendInteractiveMessageFunc != nil {
+		return m.SendInteractiveMessageFunc(ctx, recipient, spec)
+	}
+	return "mock-id", nil
+}
+
+func (m *MockWAClient) MarkRead(ctx context.Context, chatJID, sender string, ids []string) error {
+	if m.MarkReadFunc != nil {
+		return m.MarkReadFunc(ctx, chatJID, sender, ids)
+	}
+	return nil
+}
+
 func (m *MockWAClient) ResolveChatName(ctx context.Context, jid string, evt interface{}) string {
 	if m.ResolveChatNameFunc != nil {
 		return m.ResolveChatNameFunc(ctx, jid, evt)
@@ -144,6 +569,13 @@ func (m *MockWAClient) DownloadMediaToFile(ctx context.Context, req types.MediaD
 	return 0, nil
 }
 
+func (m *MockWAClient) GetGroupMetadata(ctx context.Context, groupJID, knownAvatarID string) (types.GroupMetadata, error) {
+	if m.GetGroupMetadataFunc != nil {
+		return m.GetGroupMetadataFunc(ctx, groupJID, knownAvatarID)
+	}
+	return types.GroupMetadata{}, nil
+}
+
 func (m *MockWAClient) StartSync(ctx context.Context, eventHandler func(interface{})) error {
 	if m.StartSyncFunc != nil {
 		return m.StartSyncFunc(ctx, eventHandler)
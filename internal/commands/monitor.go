@@ -0,0 +1,229 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+)
+
+// Alert kinds raised by the anomaly monitor.
+const (
+	AlertSpike   = "spike"
+	AlertSilence = "silence"
+)
+
+const (
+	// baselineWindow is how much history the per-chat message rate is
+	// learned from.
+	baselineWindow = 7 * 24 * time.Hour
+	// spikeWindow is the recent period compared against the baseline.
+	spikeWindow = time.Hour
+	// silenceGapFactor times a chat's usual gap between messages is how
+	// long it may stay quiet before a silence alert.
+	silenceGapFactor = 4
+	// minLearnedSilence floors learned silence thresholds so busy chats
+	// don't alert over a lunch break.
+	minLearnedSilence = time.Hour
+)
+
+// monitorSettings is the validated form of config.MonitorConfig.
+type monitorSettings struct {
+	interval    time.Duration
+	spikeFactor float64
+	minSpike    int
+	critical    []criticalChat
+	sinks       []alertSink
+}
+
+type criticalChat struct {
+	jid string
+	// maxSilence is zero when the threshold should be learned.
+	maxSilence time.Duration
+}
+
+func resolveMonitorSettings(cfg config.MonitorConfig) (monitorSettings, error) {
+	settings := monitorSettings{
+		interval:    5 * time.Minute,
+		spikeFactor: cfg.SpikeFactor,
+		minSpike:    cfg.MinSpikeMessages,
+	}
+	if cfg.Interval != "" {
+		d, err := parseKeepDuration(cfg.Interval)
+		if err != nil {
+			return monitorSettings{}, fmt.Errorf("monitor interval: %w", err)
+		}
+		settings.interval = d
+	}
+	if settings.spikeFactor <= 0 {
+		settings.spikeFactor = 5
+	}
+	if settings.minSpike <= 0 {
+		settings.minSpike = 20
+	}
+	for _, c := range cfg.CriticalChats {
+		if c.JID == "" {
+			return monitorSettings{}, fmt.Errorf("monitor critical_chats entry is missing jid")
+		}
+		chat := criticalChat{jid: c.JID}
+		if c.MaxSilence != "" {
+			d, err := parseKeepDuration(c.MaxSilence)
+			if err != nil {
+				return monitorSettings{}, fmt.Errorf("monitor max_silence for %s: %w", c.JID, err)
+			}
+			chat.maxSilence = d
+		}
+		settings.critical = append(settings.critical, chat)
+	}
+
+	sinks, err := buildAlertSinks(cfg.Sinks)
+	if err != nil {
+		return monitorSettings{}, fmt.Errorf("monitor: %w", err)
+	}
+	settings.sinks = sinks
+	return settings, nil
+}
+
+// anomalyMonitor compares each chat's last hour against the rate learned
+// over the previous week, and watches critical chats for silence. An alert
+// fires once when its condition starts and again only after it clears.
+type anomalyMonitor struct {
+	app      *App
+	settings monitorSettings
+	// started bounds silence: quiet time before this sync began may just
+	// be time the CLI wasn't listening.
+	started time.Time
+	active  map[string]bool
+}
+
+func newAnomalyMonitor(app *App, settings monitorSettings, started time.Time) *anomalyMonitor {
+	return &anomalyMonitor{app: app, settings: settings, started: started, active: map[string]bool{}}
+}
+
+// Check returns the alerts that started firing since the previous check.
+func (m *anomalyMonitor) Check(now time.Time) ([]Alert, error) {
+	recentSince := now.Add(-spikeWindow)
+	activity, err := m.app.store.ListChatActivity(recentSince.Add(-baselineWindow), recentSince)
+	if err != nil {
+		return nil, err
+	}
+
+	firing := map[string]bool{}
+	var alerts []Alert
+	raise := func(alert Alert) {
+		key := alert.Kind + " " + alert.ChatJID
+		firing[key] = true
+		if !m.active[key] {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	rates := map[string]float64{}
+	names := map[string]string{}
+	for _, a := range activity {
+		rate := float64(a.Baseline) / baselineWindow.Hours()
+		rates[a.ChatJID] = rate
+		names[a.ChatJID] = a.ChatName
+
+		threshold := m.settings.spikeFactor * rate
+		if min := float64(m.settings.minSpike); threshold < min {
+			threshold = min
+		}
+		if float64(a.Recent) >= threshold {
+			raise(Alert{
+				Kind:      AlertSpike,
+				ChatJID:   a.ChatJID,
+				ChatName:  a.ChatName,
+				Message:   fmt.Sprintf("%s: %d messages in the last hour, usually %.1f/h", chatLabel(a.ChatJID, a.ChatName), a.Recent, rate),
+				Observed:  float64(a.Recent),
+				Threshold: threshold,
+				Time:      now,
+			})
+		}
+	}
+
+	for _, c := range m.settings.critical {
+		limit := c.maxSilence
+		if limit == 0 {
+			rate := rates[c.jid]
+			if rate == 0 {
+				// Nothing to learn from yet.
+				continue
+			}
+			limit = time.Duration(silenceGapFactor * float64(time.Hour) / rate)
+			if limit < minLearnedSilence {
+				limit = minLearnedSilence
+			}
+		}
+
+		last, err := m.app.store.LastMessageTime(c.jid)
+		if err != nil {
+			return nil, err
+		}
+		if last.Before(m.started) {
+			last = m.started
+		}
+		silent := now.Sub(last)
+		if silent <= limit {
+			continue
+		}
+
+		name, ok := names[c.jid]
+		if !ok {
+			name, _ = m.app.store.GetChatName(c.jid)
+		}
+		raise(Alert{
+			Kind:      AlertSilence,
+			ChatJID:   c.jid,
+			ChatName:  name,
+			Message:   fmt.Sprintf("%s has been silent for %s (threshold %s)", chatLabel(c.jid, name), silent.Round(time.Minute), limit.Round(time.Minute)),
+			Observed:  silent.Seconds(),
+			Threshold: limit.Seconds(),
+			Time:      now,
+		})
+	}
+
+	m.active = firing
+	return alerts, nil
+}
+
+// Start checks every interval until ctx is cancelled. Check and sink
+// failures are reported on stderr and never stop the sync loop.
+func (m *anomalyMonitor) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(m.settings.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				m.app.safely("anomaly monitor", func() { m.runOnce(ctx, now) })
+			}
+		}
+	}()
+}
+
+func (m *anomalyMonitor) runOnce(ctx context.Context, now time.Time) {
+	alerts, err := m.Check(now)
+	if err != nil {
+		fmt.Fprintf(output.Stderr, "\n⚠️  Anomaly check failed: %v\n", err)
+		return
+	}
+	for _, alert := range alerts {
+		for _, sink := range m.settings.sinks {
+			if err := sink.send(ctx, alert); err != nil {
+				fmt.Fprintf(output.Stderr, "\n⚠️  Alert sink %s failed: %v\n", sink.kind, err)
+			}
+		}
+	}
+}
+
+func chatLabel(jid, name string) string {
+	if name == "" || name == jid {
+		return jid
+	}
+	return fmt.Sprintf("%s (%s)", name, jid)
+}
@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// TestAnomalyMonitor_SpikeFiresOnce verifies a chat far above its learned
+// rate raises one alert, which re-arms only after the spike clears.
+func TestAnomalyMonitor_SpikeFiresOnce(t *testing.T) {
+	recent := int64(50)
+	mockStore := &MockMessageStore{
+		ListChatActivityFunc: func(baselineSince, recentSince time.Time) ([]store.ChatActivity, error) {
+			// 168 messages over the week: one an hour.
+			return []store.ChatActivity{{ChatJID: "ops@g.us", ChatName: "Ops", Baseline: 168, Recent: recent}}, nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+	settings, err := resolveMonitorSettings(config.MonitorConfig{Enabled: true})
+	require.NoError(t, err)
+	now := time.Now()
+	m := newAnomalyMonitor(app, settings, now.Add(-time.Hour))
+
+	alerts, err := m.Check(now)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	require.Equal(t, AlertSpike, alerts[0].Kind)
+	require.Equal(t, "ops@g.us", alerts[0].ChatJID)
+	require.EqualValues(t, 20, alerts[0].Threshold, "min_spike_messages floors the threshold")
+
+	alerts, err = m.Check(now.Add(5 * time.Minute))
+	require.NoError(t, err)
+	require.Empty(t, alerts, "an ongoing spike should not alert again")
+
+	recent = 2
+	alerts, err = m.Check(now.Add(10 * time.Minute))
+	require.NoError(t, err)
+	require.Empty(t, alerts)
+
+	recent = 50
+	alerts, err = m.Check(now.Add(15 * time.Minute))
+	require.NoError(t, err)
+	require.Len(t, alerts, 1, "a new spike after recovery should alert")
+}
+
+// TestAnomalyMonitor_SilenceUsesLearnedRate verifies critical chats alert
+// after several of their usual gaps, measured from when sync started at
+// the earliest.
+func TestAnomalyMonitor_SilenceUsesLearnedRate(t *testing.T) {
+	now := time.Now()
+	started := now.Add(-10 * time.Hour)
+	mockStore := &MockMessageStore{
+		ListChatActivityFunc: func(baselineSince, recentSince time.Time) ([]store.ChatActivity, error) {
+			// 84 messages over the week: one every two hours, so the
+			// learned threshold is eight hours.
+			return []store.ChatActivity{{ChatJID: "alerts@g.us", ChatName: "Alerts", Baseline: 84}}, nil
+		},
+		LastMessageTimeFunc: func(chatJID string) (time.Time, error) {
+			return now.Add(-30 * 24 * time.Hour), nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+	settings, err := resolveMonitorSettings(config.MonitorConfig{
+		CriticalChats: []config.CriticalChat{{JID: "alerts@g.us"}},
+	})
+	require.NoError(t, err)
+	m := newAnomalyMonitor(app, settings, started)
+
+	alerts, err := m.Check(started.Add(7 * time.Hour))
+	require.NoError(t, err)
+	require.Empty(t, alerts)
+
+	alerts, err = m.Check(now)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	require.Equal(t, AlertSilence, alerts[0].Kind)
+	require.Equal(t, (8 * time.Hour).Seconds(), alerts[0].Threshold)
+	require.Equal(t, (10 * time.Hour).Seconds(), alerts[0].Observed)
+}
+
+// TestAnomalyMonitor_SilenceUsesConfiguredThreshold verifies max_silence
+// overrides learning, even for chats without recent history.
+func TestAnomalyMonitor_SilenceUsesConfiguredThreshold(t *testing.T) {
+	now := time.Now()
+	mockStore := &MockMessageStore{
+		LastMessageTimeFunc: func(chatJID string) (time.Time, error) {
+			return now.Add(-3 * time.Hour), nil
+		},
+		GetChatNameFunc: func(jid string) (string, error) { return "Pager", nil },
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+	settings, err := resolveMonitorSettings(config.MonitorConfig{
+		CriticalChats: []config.CriticalChat{{JID: "pager@g.us", MaxSilence: "2h"}},
+	})
+	require.NoError(t, err)
+	m := newAnomalyMonitor(app, settings, now.Add(-24*time.Hour))
+
+	alerts, err := m.Check(now)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	require.Equal(t, "Pager", alerts[0].ChatName)
+}
+
+// TestResolveMonitorSettings_Validates verifies defaults and rejection of
+// malformed monitor settings.
+func TestResolveMonitorSettings_Validates(t *testing.T) {
+	settings, err := resolveMonitorSettings(config.MonitorConfig{})
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Minute, settings.interval)
+	require.Equal(t, 5.0, settings.spikeFactor)
+	require.Len(t, settings.sinks, 1, "stderr is the default sink")
+
+	_, err = resolveMonitorSettings(config.MonitorConfig{Interval: "often"})
+	require.Error(t, err)
+	_, err = resolveMonitorSettings(config.MonitorConfig{CriticalChats: []config.CriticalChat{{MaxSilence: "1h"}}})
+	require.Error(t, err)
+	_, err = resolveMonitorSettings(config.MonitorConfig{Sinks: []config.AlertSink{{Type: "pager"}}})
+	require.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vicentereig/whatsapp-cli/internal/addressbook"
+	"github.com/vicentereig/whatsapp-cli/internal/client"
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+	waTypes "go.mau.fi/whatsmeow/types"
+)
+
+// buildNameProviders assembles the chat name resolution chain in the order
+// configured by name_providers. Providers without the settings they need
+// (no CSV path, no CardDAV URL) are skipped.
+func buildNameProviders(cfg *config.Config, cli *client.WAClient, st *store.MessageStore) ([]client.NameProvider, error) {
+	var providers []client.NameProvider
+	for _, name := range cfg.NameProviders {
+		switch name {
+		case config.ProviderWhatsmeow:
+			// Groups refreshed by the sync daemon are named from the store,
+			// so only never-refreshed groups cost a network round trip.
+			providers = append(providers, refreshedGroupNameProvider(st), cli.WhatsmeowNameProvider())
+		case config.ProviderStore:
+			providers = append(providers, storeNameProvider(st))
+		case config.ProviderCSV:
+			if cfg.ContactsCSV != "" {
+				providers = append(providers, client.NewCSVNameProvider(cfg.ContactsCSV))
+			}
+		case config.ProviderCardDAV:
+			if cfg.CardDAV.Enabled() {
+				providers = append(providers, client.NewCardDAVNameProvider(cardDAVClient(cfg.CardDAV)))
+			}
+		default:
+			return nil, fmt.Errorf("unknown name provider %q in %s", name, config.FileName)
+		}
+	}
+	return providers, nil
+}
+
+// storeNameProvider resolves names already known to the local chats table.
+func storeNameProvider(st *store.MessageStore) client.NameProvider {
+	return client.NameProviderFunc(func(ctx context.Context, jid waTypes.JID) string {
+		name, err := st.GetChatName(jid.String())
+		if err != nil {
+			return ""
+		}
+		return name
+	})
+}
+
+// refreshedGroupNameProvider resolves group names kept up to date by the
+// sync daemon's group refresh.
+func refreshedGroupNameProvider(st *store.MessageStore) client.NameProvider {
+	return client.NameProviderFunc(func(ctx context.Context, jid waTypes.JID) string {
+		if jid.Server != waTypes.GroupServer {
+			return ""
+		}
+		name, err := st.GetRefreshedGroupName(jid.String())
+		if err != nil {
+			return ""
+		}
+		return name
+	})
+}
+
+func cardDAVClient(cfg config.CardDAVConfig) *addressbook.CardDAVClient {
+	return &addressbook.CardDAVClient{
+		URL:      cfg.URL,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		Token:    cfg.Token,
+	}
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+	"github.com/vicentereig/whatsapp-cli/internal/uri"
+)
+
+// OpenResult is returned by `open`.
+type OpenResult struct {
+	Message store.MessageDetails `json:"message"`
+	// ReplyTo is the message this one replies to, when it is stored.
+	ReplyTo *store.Message `json:"reply_to,omitempty"`
+	// Before and After are the neighbouring messages in the chat, oldest
+	// first, when context was requested.
+	Before []store.Message `json:"before,omitempty"`
+	After  []store.Message `json:"after,omitempty"`
+}
+
+// Open resolves a whatsapp-cli:// message URI to the stored message and,
+// with context > 0, that many messages on each side of it.
+func (a *App) Open(messageURI string, context int) string {
+	ref, err := uri.Parse(messageURI)
+	if err != nil {
+		return output.Error(err)
+	}
+	if context < 0 {
+		return output.Error(fmt.Errorf("--context must not be negative"))
+	}
+
+	details, err := a.store.GetMessageDetails(ref.ChatJID, ref.MessageID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return output.Error(fmt.Errorf("message %s not found in chat %s", ref.MessageID, ref.ChatJID))
+		}
+		return output.Error(err)
+	}
+	result := OpenResult{Message: details}
+
+	if details.ReplyToID != "" {
+		quoted, err := a.store.GetMessageDetails(ref.ChatJID, details.ReplyToID)
+		if err == nil {
+			result.ReplyTo = &quoted.Message
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return output.Error(err)
+		}
+	}
+
+	result.Before, result.After, err = a.store.ListMessagesAround(ref.ChatJID, ref.MessageID, details.Timestamp, context)
+	if err != nil {
+		return output.Error(err)
+	}
+	return output.Success(result)
+}
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// TestOpen_ResolvesURIWithReplyAndContext verifies a message URI opens the
+// message, the message it quotes and the requested context.
+func TestOpen_ResolvesURIWithReplyAndContext(t *testing.T) {
+	at := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	var contextSize int
+	mockStore := &MockMessageStore{
+		GetMessageDetailsFunc: func(chatJID, id string) (store.MessageDetails, error) {
+			require.Equal(t, "123@g.us", chatJID)
+			switch id {
+			case "M2":
+				return store.MessageDetails{Message: store.Message{ID: "M2", ChatJID: chatJID, Content: "yes", Timestamp: at, ReplyToID: "M1"}}, nil
+			case "M1":
+				return store.MessageDetails{Message: store.Message{ID: "M1", ChatJID: chatJID, Content: "lunch?"}}, nil
+			}
+			return store.MessageDetails{}, sql.ErrNoRows
+		},
+		ListMessagesAroundFunc: func(chatJID, id string, ts time.Time, n int) ([]store.Message, []store.Message, error) {
+			require.Equal(t, "M2", id)
+			require.Equal(t, at, ts)
+			contextSize = n
+			return []store.Message{{ID: "M1"}}, []store.Message{{ID: "M3"}}, nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+
+	resp := parseResponse(t, app.Open("whatsapp-cli://chat/123@g.us/message/M2", 5))
+	require.True(t, resp.Success)
+	var result OpenResult
+	require.NoError(t, json.Unmarshal(resp.Data, &result))
+	require.Equal(t, "yes", result.Message.Content)
+	require.NotNil(t, result.ReplyTo)
+	require.Equal(t, "lunch?", result.ReplyTo.Content)
+	require.Len(t, result.Before, 1)
+	require.Len(t, result.After, 1)
+	require.Equal(t, 5, contextSize)
+}
+
+// TestOpen_RejectsBadURIsAndMissingMessages verifies errors name what was
+// wrong rather than returning an empty message.
+func TestOpen_RejectsBadURIsAndMissingMessages(t *testing.T) {
+	mockStore := &MockMessageStore{
+		GetMessageDetailsFunc: func(chatJID, id string) (store.MessageDetails, error) {
+			return store.MessageDetails{}, sql.ErrNoRows
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+
+	resp := parseResponse(t, app.Open("https://example.com", 0))
+	require.False(t, resp.Success)
+	require.Contains(t, *resp.Error, "not a whatsapp-cli:// URI")
+
+	resp = parseResponse(t, app.Open("whatsapp-cli://chat/123@g.us/message/M9", 0))
+	require.False(t, resp.Success)
+	require.Contains(t, *resp.Error, "message M9 not found in chat 123@g.us")
+
+	resp = parseResponse(t, app.Open("whatsapp-cli://chat/123@g.us/message/M9", -1))
+	require.False(t, resp.Success)
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+	waTypes "go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// logOutboundQueued records a send before it is attempted. Logging never
+// blocks sending: on failure it warns and returns 0, which the other
+// helpers ignore.
+func (a *App) logOutboundQueued(chatJID, kind, content, attachment string) int64 {
+	logID, err := a.store.LogOutbound(chatJID, kind, content, attachment, time.Now().UTC())
+	if err != nil {
+		fmt.Fprintf(output.Stderr, "⚠️  Could not record send in outbound log: %v\n", err)
+		return 0
+	}
+	return logID
+}
+
+// logOutboundResult moves a logged send to sent or failed.
+func (a *App) logOutboundResult(logID int64, messageID string, sendErr error) {
+	if logID == 0 {
+		return
+	}
+	now := time.Now().UTC()
+	var err error
+	if sendErr != nil {
+		err = a.store.MarkOutboundFailed(logID, sendErr.Error(), now)
+	} else {
+		err = a.store.MarkOutboundSent(logID, messageID, now)
+	}
+	if err != nil {
+		fmt.Fprintf(output.Stderr, "⚠️  Could not update outbound log: %v\n", err)
+	}
+}
+
+// receiptStatus maps a WhatsApp receipt to an outbound state, or "" for
+// receipts that don't affect sent messages (our own devices reading, retries).
+func receiptStatus(t waTypes.ReceiptType) string {
+	switch t {
+	case waTypes.ReceiptTypeDelivered:
+		return store.OutboundDelivered
+	case waTypes.ReceiptTypeRead, waTypes.ReceiptTypePlayed:
+		return store.OutboundRead
+	case waTypes.ReceiptTypeServerError:
+		return store.OutboundFailed
+	}
+	return ""
+}
+
+// applyReceipt updates the outbound log from a receipt seen during sync.
+// Receipts are matched by message ID only: WhatsApp may address them from a
+// LID chat JID rather than the phone JID the message was sent to.
+func (a *App) applyReceipt(evt *events.Receipt) {
+	status := receiptStatus(evt.Type)
+	if status == "" || evt.IsFromMe {
+		return
+	}
+	at := evt.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if _, err := a.store.UpdateOutboundStatus(evt.MessageIDs, status, at.UTC()); err != nil {
+		fmt.Fprintf(output.Stderr, "\n⚠️  Could not apply receipt: %v\n", err)
+	}
+}
+
+// ListOutbound returns CLI-initiated sends, newest first.
+func (a *App) ListOutbound(status string, limit int) string {
+	switch status {
+	case "", store.OutboundQueued, store.OutboundSent, store.OutboundDelivered, store.OutboundRead, store.OutboundFailed:
+	default:
+		return output.Error(fmt.Errorf("unknown status %q (use queued, sent, delivered, read or failed)", status))
+	}
+	entries, err := a.store.ListOutbound(store.ListOutboundParams{Status: status, Limit: limit})
+	if err != nil {
+		return output.Error(err)
+	}
+	return output.Success(entries)
+}
@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+	waTypes "go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// TestSendMessage_RecordsOutboundLifecycle verifies sends are logged as
+// queued and then marked sent or failed with the error.
+func TestSendMessage_RecordsOutboundLifecycle(t *testing.T) {
+	tests := []struct {
+		name       string
+		sendErr    error
+		wantSent   string
+		wantFailed string
+	}{
+		{name: "success", wantSent: "MSG1"},
+		{name: "failure", sendErr: errors.New("server said no"), wantFailed: "server said no"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var loggedChat, sent, failed string
+			mockStore := &MockMessageStore{
+				LogOutboundFunc: func(chatJID, kind, content, attachment string, at time.Time) (int64, error) {
+					loggedChat = chatJID
+					return 7, nil
+				},
+				MarkOutboundSentFunc: func(logID int64, messageID string, at time.Time) error {
+					require.Equal(t, int64(7), logID)
+					sent = messageID
+					return nil
+				},
+				MarkOutboundFailedFunc: func(logID int64, reason string, at time.Time) error {
+					require.Equal(t, int64(7), logID)
+					failed = reason
+					return nil
+				},
+			}
+			mockClient := &MockWAClient{
+				SendMessageFunc: func(ctx context.Context, recipient, message string) (string, error) {
+					if tt.sendErr != nil {
+						return "", tt.sendErr
+					}
+					return "MSG1", nil
+				},
+			}
+
+			app := NewAppWithDeps(mockClient, mockStore, t.TempDir(), "test")
+			app.SendMessage(context.Background(), "1234", "hi", SendOptions{})
+
+			require.Equal(t, "1234@s.whatsapp.net", loggedChat)
+			require.Equal(t, tt.wantSent, sent)
+			require.Equal(t, tt.wantFailed, failed)
+		})
+	}
+}
+
+// TestApplyReceipt_MapsReceiptTypes verifies which receipts advance the
+// outbound log and which are ignored.
+func TestApplyReceipt_MapsReceiptTypes(t *testing.T) {
+	tests := []struct {
+		receiptType waTypes.ReceiptType
+		fromMe      bool
+		want        string
+	}{
+		{receiptType: waTypes.ReceiptTypeDelivered, want: store.OutboundDelivered},
+		{receiptType: waTypes.ReceiptTypeRead, want: store.OutboundRead},
+		{receiptType: waTypes.ReceiptTypePlayed, want: store.OutboundRead},
+		{receiptType: waTypes.ReceiptTypeServerError, want: store.OutboundFailed},
+		{receiptType: waTypes.ReceiptTypeReadSelf, want: ""},
+		{receiptType: waTypes.ReceiptTypeRead, fromMe: true, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.receiptType), func(t *testing.T) {
+			got := ""
+			mockStore := &MockMessageStore{
+				UpdateOutboundStatusFunc: func(messageIDs []string, status string, at time.Time) (int64, error) {
+					require.Equal(t, []string{"MSG1"}, messageIDs)
+					got = status
+					return 1, nil
+				},
+			}
+			app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+
+			evt := &events.Receipt{MessageIDs: []waTypes.MessageID{"MSG1"}, Type: tt.receiptType}
+			evt.IsFromMe = tt.fromMe
+			app.applyReceipt(evt)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestListOutbound_RejectsUnknownStatus verifies typos in --status are
+// reported instead of silently returning nothing.
+func TestListOutbound_RejectsUnknownStatus(t *testing.T) {
+	app := NewAppWithDeps(&MockWAClient{}, &MockMessageStore{}, t.TempDir(), "test")
+	resp := parseResponse(t, app.ListOutbound("lost", 10))
+	require.False(t, resp.Success)
+}
@@ -0,0 +1,507 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"path"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// pipelineDrainTimeout is how long sinks keep delivering queued messages
+// after sync is asked to stop.
+var pipelineDrainTimeout = 30 * time.Second
+
+// pipelineQueueLimit caps the messages waiting for sinks. Beyond it new
+// messages are stored instead, so a stalled sink can't exhaust memory.
+var pipelineQueueLimit = 10000
+
+// redactedNumber replaces phone, card and account numbers removed by the
+// redact_numbers transform.
+const redactedNumber = "[number]"
+
+// numberPattern matches runs of seven or more digits, optionally grouped
+// with spaces or dashes and prefixed with "+".
+var numberPattern = regexp.MustCompile(`\+?\d(?:[ -]?\d){6,}`)
+
+// PipelineEvent is the message JSON delivered to webhook and exec sinks.
+type PipelineEvent struct {
+	Pipeline   string    `json:"pipeline"`
+	ID         string    `json:"id"`
+	URI        string    `json:"uri"`
+	ChatJID    string    `json:"chat_jid"`
+	ChatName   string    `json:"chat_name,omitempty"`
+	Sender     string    `json:"sender"`
+	SenderName string    `json:"sender_name,omitempty"`
+	Content    string    `json:"content"`
+	Timestamp  time.Time `json:"timestamp"`
+	IsFromMe   bool      `json:"is_from_me"`
+	// Type is "text", "location" or the media type.
+	Type     string `json:"type"`
+	Filename string `json:"filename,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	// MediaPath is set by the download_media transform.
+	MediaPath string `json:"media_path,omitempty"`
+}
+
+// pipeline is a validated config.Pipeline.
+type pipeline struct {
+	name       string
+	match      config.PipelineFilter
+	transforms []string
+	// store reports whether the pipeline has a store sink.
+	store bool
+	sinks []pipelineSink
+}
+
+// pipelineSink delivers pipeline events to one destination.
+type pipelineSink struct {
+	kind string
+	send func(ctx context.Context, event PipelineEvent) error
+}
+
+// pipelineJob is one message and the pipelines it matched.
+type pipelineJob struct {
+	event     PipelineEvent
+	pipelines []*pipeline
+	// stored reports whether the message was saved, so a download can be
+	// recorded against it.
+	stored bool
+	// media is set for messages with downloadable media.
+	media *store.MessageDownloadInfo
+	// storeMessage saves the message to messages.db. Messages kept out of
+	// the store are saved after all when a sink fails or doesn't run.
+	storeMessage func()
+}
+
+// pipelineRunner evaluates pipelines for live messages and runs their
+// transforms and sinks on a single goroutine, so each sink sees messages
+// in the order they arrived.
+type pipelineRunner struct {
+	app       *App
+	pipelines []*pipeline
+	wake      chan struct{}
+	wg        sync.WaitGroup
+
+	mu sync.Mutex
+	// queue holds up to pipelineQueueLimit jobs; Enqueue never waits for
+	// room, so a slow sink never stalls the event handler.
+	queue   []pipelineJob
+	started bool
+	closed  bool
+	// fellBack counts messages stored because a sink failed or didn't run.
+	fellBack  int
+	delivered int
+	failed    int
+}
+
+// newPipelineRunner validates pipeline settings. It returns nil when no
+// pipelines are configured; a nil runner matches nothing.
+func newPipelineRunner(a *App, cfgs []config.Pipeline) (*pipelineRunner, error) {
+	if len(cfgs) == 0 {
+		return nil, nil
+	}
+	r := &pipelineRunner{app: a, wake: make(chan struct{}, 1)}
+	for i, c := range cfgs {
+		p, err := buildPipeline(c)
+		if err != nil {
+			name := c.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+			return nil, fmt.Errorf("pipeline %s: %w", name, err)
+		}
+		r.pipelines = append(r.pipelines, p)
+	}
+	return r, nil
+}
+
+func buildPipeline(c config.Pipeline) (*pipeline, error) {
+	if c.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if len(c.Sinks) == 0 {
+		return nil, fmt.Errorf("at least one sink is required")
+	}
+	for _, pattern := range c.Match.Chats {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid chat pattern %q", pattern)
+		}
+	}
+	for _, t := range c.Transforms {
+		if t != config.TransformDownloadMedia && t != config.TransformRedactNumbers {
+			return nil, fmt.Errorf("unknown transform %q (use download_media or redact_numbers)", t)
+		}
+	}
+
+	p := &pipeline{name: c.Name, match: c.Match, transforms: c.Transforms}
+	for _, s := range c.Sinks {
+		switch s.Type {
+		case config.SinkStore:
+			p.store = true
+		case config.SinkWebhook:
+			if s.URL == "" {
+				return nil, fmt.Errorf("webhook sink requires url")
+			}
+			httpClient := &http.Client{Timeout: alertSinkTimeout}
+			url := s.URL
+			p.sinks = append(p.sinks, pipelineSink{kind: s.Type, send: func(ctx context.Context, event PipelineEvent) error {
+				return postJSON(ctx, httpClient, url, event)
+			}})
+		case config.SinkExec:
+			if len(s.Command) == 0 {
+				return nil, fmt.Errorf("exec sink requires command")
+			}
+			command := s.Command
+			p.sinks = append(p.sinks, pipelineSink{kind: s.Type, send: func(ctx context.Context, event PipelineEvent) error {
+				return runWithJSON(ctx, command, event,
+					"WHATSAPP_PIPELINE="+event.Pipeline,
+					"WHATSAPP_CHAT="+event.ChatJID,
+					"WHATSAPP_MESSAGE_ID="+event.ID,
+					"WHATSAPP_MEDIA_PATH="+event.MediaPath,
+				)
+			}})
+		case config.SinkNotify:
+			if _, err := notifyCommand("", ""); err != nil {
+				return nil, err
+			}
+			p.sinks = append(p.sinks, pipelineSink{kind: s.Type, send: sendNotification})
+		default:
+			return nil, fmt.Errorf("unknown sink type %q (use store, webhook, exec or notify)", s.Type)
+		}
+	}
+	return p, nil
+}
+
+// matches reports whether event satisfies every filter field that is set.
+func (p *pipeline) matches(event PipelineEvent) bool {
+	f := p.match
+	if len(f.Chats) > 0 {
+		ok := false
+		for _, pattern := range f.Chats {
+			if matched, _ := path.Match(pattern, event.ChatJID); matched {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(f.Types) > 0 {
+		ok := false
+		for _, t := range f.Types {
+			if t == event.Type || (t == "media" && event.Type != "text" && event.Type != "location") {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if f.FromMe != nil && *f.FromMe != event.IsFromMe {
+		return false
+	}
+	if f.Contains != "" && !strings.Contains(strings.ToLower(event.Content), strings.ToLower(f.Contains)) {
+		return false
+	}
+	return true
+}
+
+func (p *pipeline) hasTransform(name string) bool {
+	for _, t := range p.transforms {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the pipelines event matches.
+func (r *pipelineRunner) Match(event PipelineEvent) []*pipeline {
+	if r == nil {
+		return nil
+	}
+	var matched []*pipeline
+	for _, p := range r.pipelines {
+		if p.matches(event) {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
+// shouldStore reports whether a message matching these pipelines is saved
+// to messages.db: unmatched messages always are, matched ones only when a
+// matching pipeline has a store sink.
+func shouldStore(matched []*pipeline) bool {
+	if len(matched) == 0 {
+		return true
+	}
+	for _, p := range matched {
+		if p.store {
+			return true
+		}
+	}
+	return false
+}
+
+// downloadsMedia reports whether a matching pipeline downloads the media
+// itself, in which case the background media worker must not.
+func downloadsMedia(matched []*pipeline) bool {
+	for _, p := range matched {
+		if p.hasTransform(config.TransformDownloadMedia) {
+			return true
+		}
+	}
+	return false
+}
+
+// Start runs the sinks until ctx is cancelled, then keeps delivering the
+// messages already queued for up to pipelineDrainTimeout.
+func (r *pipelineRunner) Start(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	runCtx, cancel := context.WithCancel(context.Background())
+	stopDraining := context.AfterFunc(ctx, func() { time.AfterFunc(pipelineDrainTimeout, cancel) })
+	r.mu.Lock()
+	r.started = true
+	r.mu.Unlock()
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer cancel()
+		defer stopDraining()
+		for {
+			job, ok := r.next()
+			if !ok {
+				if ctx.Err() != nil {
+					break
+				}
+				select {
+				case <-r.wake:
+				case <-ctx.Done():
+				}
+				continue
+			}
+			// Messages a sink failed on, or past the drain deadline, are
+			// stored instead.
+			if runCtx.Err() != nil || !r.run(runCtx, job) {
+				r.fallBack(job)
+			}
+		}
+
+		r.mu.Lock()
+		r.closed = true
+		rest := r.queue
+		r.queue = nil
+		r.mu.Unlock()
+		for _, job := range rest {
+			r.fallBack(job)
+		}
+	}()
+}
+
+// Enqueue hands a matched message to the sinks without blocking. Once the
+// runner has stopped, or while the queue is full, messages go straight to
+// fallBack.
+func (r *pipelineRunner) Enqueue(job pipelineJob) {
+	if r == nil || len(job.pipelines) == 0 {
+		return
+	}
+	r.mu.Lock()
+	if !r.started || r.closed || len(r.queue) >= pipelineQueueLimit {
+		r.mu.Unlock()
+		r.fallBack(job)
+		return
+	}
+	r.queue = append(r.queue, job)
+	r.mu.Unlock()
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *pipelineRunner) next() (pipelineJob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.queue) == 0 {
+		return pipelineJob{}, false
+	}
+	job := r.queue[0]
+	r.queue = r.queue[1:]
+	return job, true
+}
+
+// fallBack handles a message a sink failed on or that never reached its
+// sinks: one kept out of the store is stored after all, so it is never
+// lost.
+func (r *pipelineRunner) fallBack(job pipelineJob) {
+	if job.stored || job.storeMessage == nil {
+		return
+	}
+	job.storeMessage()
+	r.mu.Lock()
+	r.fellBack++
+	r.mu.Unlock()
+}
+
+// Wait blocks until the worker has drained the queue after the sync
+// context is cancelled.
+func (r *pipelineRunner) Wait() {
+	if r == nil {
+		return
+	}
+	r.wg.Wait()
+}
+
+// run delivers job to its pipelines' sinks, reporting whether every sink
+// succeeded.
+func (r *pipelineRunner) run(ctx context.Context, job pipelineJob) bool {
+	ok := true
+	// The media is downloaded at most once, however many pipelines ask.
+	var mediaPath string
+	var mediaErr error
+	downloaded := false
+
+	for _, p := range job.pipelines {
+		event := job.event
+		event.Pipeline = p.name
+		for _, t := range p.transforms {
+			switch t {
+			case config.TransformDownloadMedia:
+				if job.media == nil {
+					continue
+				}
+				if !downloaded {
+					mediaPath, mediaErr = r.app.downloadPipelineMedia(ctx, *job.media, job.stored)
+					downloaded = true
+					// Space-limit skips are reported by mediaGuard; chat
+					// media policy skips are intended.
+					if mediaErr != nil && !errors.Is(mediaErr, errMediaSpace) && !errors.Is(mediaErr, errMediaPolicyBlocked) {
+						fmt.Fprintf(output.Stderr, "\n⚠️  Pipeline %s: media download for %s failed: %v\n", p.name, event.ID, mediaErr)
+					}
+				}
+				event.MediaPath = mediaPath
+			case config.TransformRedactNumbers:
+				event.Content = numberPattern.ReplaceAllString(event.Content, redactedNumber)
+			}
+		}
+
+		for _, s := range p.sinks {
+			err := s.send(ctx, event)
+			r.mu.Lock()
+			if err != nil {
+				r.failed++
+			} else {
+				r.delivered++
+			}
+			r.mu.Unlock()
+			if err != nil {
+				ok = false
+				fmt.Fprintf(output.Stderr, "\n⚠️  Pipeline %s: %s sink failed for %s: %v\n", p.name, s.kind, event.ID, err)
+			}
+		}
+	}
+	return ok
+}
+
+func (r *pipelineRunner) PrintSummary() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.delivered > 0 || r.failed > 0 {
+		fmt.Fprintf(output.Stderr, "\n📮 Pipelines: %d deliveries, %d failed\n", r.delivered, r.failed)
+	}
+	if r.fellBack > 0 {
+		fmt.Fprintf(output.Stderr, "⚠️  Pipelines: %d messages not delivered to every sink were stored instead\n", r.fellBack)
+	}
+}
+
+// downloadPipelineMedia fetches media for the download_media transform to
+// its usual location under the store, recording it when the message was
+// stored. Like the media worker it replaces, it respects the chat's media
+// policy and the media limits.
+func (a *App) downloadPipelineMedia(ctx context.Context, info store.MessageDownloadInfo, stored bool) (string, error) {
+	if err := a.admitMedia(info); err != nil {
+		return "", err
+	}
+	if stored {
+		finalPath, _, _, err := a.downloadMediaAndPersist(ctx, info, "")
+		return finalPath, err
+	}
+	finalPath, _, err := a.downloadMediaFile(ctx, info, "")
+	return finalPath, err
+}
+
+// pipelineEventType classifies a message for PipelineFilter.Types.
+func pipelineEventType(mediaType string, isLocation bool) string {
+	switch {
+	case mediaType != "":
+		return mediaType
+	case isLocation:
+		return "location"
+	default:
+		return "text"
+	}
+}
+
+// notifyCommand returns the desktop notification command for this OS.
+var notifyCommand = func(title, body string) ([]string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		return []string{"osascript", "-e", script}, nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return []string{"notify-send", "--app-name=whatsapp-cli", title, body}, nil
+	default:
+		return nil, fmt.Errorf("notify sinks are not supported on %s", runtime.GOOS)
+	}
+}
+
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// sendNotification shows the message as a desktop notification titled
+// with the chat name.
+func sendNotification(ctx context.Context, event PipelineEvent) error {
+	title := event.ChatName
+	if title == "" {
+		title = event.ChatJID
+	}
+	body := event.Content
+	if body == "" {
+		body = "[" + event.Type + "]"
+	}
+	if from := event.SenderName; from != "" && !event.IsFromMe && strings.HasSuffix(event.ChatJID, "@g.us") {
+		body = from + ": " + body
+	}
+
+	command, err := notifyCommand(title, body)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, alertSinkTimeout)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, command[0], command[1:]...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %v: %s", command[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
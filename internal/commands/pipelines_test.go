@@ -0,0 +1,370 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestNewPipelineRunner_ValidatesConfig verifies broken pipelines are
+// rejected when sync starts rather than on the first message.
+func TestNewPipelineRunner_ValidatesConfig(t *testing.T) {
+	runner, err := newPipelineRunner(nil, nil)
+	require.NoError(t, err)
+	require.Nil(t, runner)
+
+	cases := map[string]config.Pipeline{
+		"name is required":  {Sinks: []config.PipelineSink{{Type: config.SinkStore}}},
+		"sink is required":  {Name: "p"},
+		"unknown sink":      {Name: "p", Sinks: []config.PipelineSink{{Type: "s3"}}},
+		"requires url":      {Name: "p", Sinks: []config.PipelineSink{{Type: config.SinkWebhook}}},
+		"requires command":  {Name: "p", Sinks: []config.PipelineSink{{Type: config.SinkExec}}},
+		"unknown transform": {Name: "p", Transforms: []string{"resize"}, Sinks: []config.PipelineSink{{Type: config.SinkStore}}},
+		"invalid chat":      {Name: "p", Match: config.PipelineFilter{Chats: []string{"["}}, Sinks: []config.PipelineSink{{Type: config.SinkStore}}},
+	}
+	for want, cfg := range cases {
+		_, err := newPipelineRunner(nil, []config.Pipeline{cfg})
+		require.Error(t, err, want)
+		require.Contains(t, err.Error(), want)
+	}
+}
+
+// TestPipelineRunner_MatchAndStore verifies filters combine and that
+// matched messages are stored only when a matching pipeline has a store
+// sink.
+func TestPipelineRunner_MatchAndStore(t *testing.T) {
+	received := false
+	runner, err := newPipelineRunner(nil, []config.Pipeline{
+		{
+			Name:  "family-images",
+			Match: config.PipelineFilter{Chats: []string{"123@g.us"}, Types: []string{"media"}},
+			Sinks: []config.PipelineSink{{Type: config.SinkWebhook, URL: "http://example.invalid"}},
+		},
+		{
+			Name:  "bob-texts",
+			Match: config.PipelineFilter{Chats: []string{"346*@s.whatsapp.net"}, Types: []string{"text"}, FromMe: &received, Contains: "invoice"},
+			Sinks: []config.PipelineSink{{Type: config.SinkStore}},
+		},
+	})
+	require.NoError(t, err)
+
+	image := runner.Match(PipelineEvent{ChatJID: "123@g.us", Type: "image"})
+	require.Len(t, image, 1)
+	require.Equal(t, "family-images", image[0].name)
+	require.False(t, shouldStore(image))
+
+	require.Empty(t, runner.Match(PipelineEvent{ChatJID: "123@g.us", Type: "text"}))
+	require.True(t, shouldStore(nil))
+
+	text := runner.Match(PipelineEvent{ChatJID: "34611111111@s.whatsapp.net", Type: "text", Content: "Your INVOICE is ready"})
+	require.Len(t, text, 1)
+	require.True(t, shouldStore(text))
+
+	require.Empty(t, runner.Match(PipelineEvent{ChatJID: "34611111111@s.whatsapp.net", Type: "text", Content: "invoice", IsFromMe: true}))
+}
+
+// TestPipelineRunner_TransformsBeforeSinks verifies transforms run before
+// webhook delivery and media is downloaded once for every pipeline.
+func TestPipelineRunner_TransformsBeforeSinks(t *testing.T) {
+	var got []PipelineEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event PipelineEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		got = append(got, event)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var marked []string
+	app := NewAppWithDeps(&MockWAClient{}, &MockMessageStore{
+		MarkMediaDownloadedFunc: func(id, chatJID, localPath string, downloadedAt time.Time) error {
+			marked = append(marked, localPath)
+			return nil
+		},
+	}, t.TempDir(), "test")
+	downloads := 0
+	app.mediaDownloader = func(ctx context.Context, info store.MessageDownloadInfo, targetPath string) (int64, error) {
+		downloads++
+		return 10, nil
+	}
+
+	webhook := []config.PipelineSink{{Type: config.SinkWebhook, URL: server.URL}}
+	runner, err := newPipelineRunner(app, []config.Pipeline{
+		{Name: "archive", Transforms: []string{config.TransformDownloadMedia}, Sinks: webhook},
+		{Name: "redacted", Transforms: []string{config.TransformRedactNumbers, config.TransformDownloadMedia}, Sinks: webhook},
+	})
+	require.NoError(t, err)
+
+	event := PipelineEvent{ID: "M1", ChatJID: "123@g.us", Content: "call +34 611 111 111", Type: "image"}
+	runner.run(context.Background(), pipelineJob{
+		event:     event,
+		pipelines: runner.Match(event),
+		stored:    true,
+		media:     &store.MessageDownloadInfo{ID: "M1", ChatJID: "123@g.us", MediaType: "image", Filename: "menu.jpg"},
+	})
+
+	require.Equal(t, 1, downloads)
+	require.Len(t, marked, 1)
+	require.Len(t, got, 2)
+	require.Equal(t, "archive", got[0].Pipeline)
+	require.Equal(t, "call +34 611 111 111", got[0].Content)
+	require.Equal(t, marked[0], got[0].MediaPath)
+	require.Equal(t, "redacted", got[1].Pipeline)
+	require.Equal(t, "call "+redactedNumber, got[1].Content)
+	require.Equal(t, marked[0], got[1].MediaPath)
+	require.Equal(t, 2, runner.delivered)
+}
+
+// TestPipelineRunner_DownloadMediaRespectsLimits verifies the
+// download_media transform skips media over the quota or blocked by the
+// chat's media policy, and still delivers the message without it.
+func TestPipelineRunner_DownloadMediaRespectsLimits(t *testing.T) {
+	delivered := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event PipelineEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		require.Empty(t, event.MediaPath)
+		delivered++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	mockStore := &MockMessageStore{
+		MediaUsageFunc: func(root string) (int64, error) { return 100, nil },
+		GetChatMediaPolicyFunc: func(chatJID string) (*store.ChatMediaPolicy, error) {
+			if chatJID == "quiet@g.us" {
+				return &store.ChatMediaPolicy{ChatJID: chatJID, Types: []string{"document"}}, nil
+			}
+			return nil, nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+	app.mediaGuard = newMediaGuard(app, MediaLimits{Quota: 100, Policy: MediaPolicySkip})
+	app.mediaDownloader = func(ctx context.Context, info store.MessageDownloadInfo, targetPath string) (int64, error) {
+		t.Fatal("pipeline downloaded media past the media limits")
+		return 0, nil
+	}
+	runner, err := newPipelineRunner(app, []config.Pipeline{
+		{Name: "archive", Transforms: []string{config.TransformDownloadMedia}, Sinks: []config.PipelineSink{{Type: config.SinkWebhook, URL: server.URL}}},
+	})
+	require.NoError(t, err)
+
+	for _, chatJID := range []string{"123@g.us", "quiet@g.us"} {
+		event := PipelineEvent{ID: "M1", ChatJID: chatJID, Type: "image"}
+		runner.run(context.Background(), pipelineJob{
+			event:     event,
+			pipelines: runner.Match(event),
+			stored:    true,
+			media:     &store.MessageDownloadInfo{ID: "M1", ChatJID: chatJID, MediaType: "image", FileLength: 10},
+		})
+	}
+	require.Equal(t, 2, delivered)
+	require.Equal(t, 1, app.mediaGuard.skipped)
+}
+
+// TestPipelineRunner_EnqueueNeverBlocks verifies a slow sink doesn't stall
+// the event handler, and messages queued when sync stops are still
+// delivered.
+func TestPipelineRunner_EnqueueNeverBlocks(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	delivered := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		mu.Lock()
+		delivered++
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	runner, err := newPipelineRunner(nil, []config.Pipeline{
+		{Name: "hook", Sinks: []config.PipelineSink{{Type: config.SinkWebhook, URL: server.URL}}},
+	})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	runner.Start(ctx)
+
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		event := PipelineEvent{ID: fmt.Sprintf("M%d", i)}
+		runner.Enqueue(pipelineJob{event: event, pipelines: runner.Match(event)})
+	}
+	require.Less(t, time.Since(start), time.Second)
+
+	cancel()
+	close(release)
+	runner.Wait()
+	require.Equal(t, 1000, delivered)
+	require.Equal(t, 0, runner.fellBack)
+}
+
+// TestPipelineRunner_StoresUndeliveredOnShutdown verifies messages kept out
+// of the store are stored after all when sinks can't run before the drain
+// deadline.
+func TestPipelineRunner_StoresUndeliveredOnShutdown(t *testing.T) {
+	original := pipelineDrainTimeout
+	pipelineDrainTimeout = 50 * time.Millisecond
+	defer func() { pipelineDrainTimeout = original }()
+
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	runner, err := newPipelineRunner(nil, []config.Pipeline{
+		{Name: "hook", Sinks: []config.PipelineSink{{Type: config.SinkWebhook, URL: server.URL}}},
+	})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	runner.Start(ctx)
+
+	var stored []string
+	for _, id := range []string{"M1", "M2", "M3"} {
+		event := PipelineEvent{ID: id}
+		runner.Enqueue(pipelineJob{
+			event:        event,
+			pipelines:    runner.Match(event),
+			stored:       id == "M3",
+			storeMessage: func() { stored = append(stored, id) },
+		})
+	}
+	cancel()
+	runner.Wait()
+	require.Equal(t, []string{"M1", "M2"}, stored)
+	require.Equal(t, 2, runner.fellBack)
+
+	// Messages arriving after the runner stopped are stored right away.
+	event := PipelineEvent{ID: "M4"}
+	runner.Enqueue(pipelineJob{event: event, pipelines: runner.Match(event), storeMessage: func() { stored = append(stored, "M4") }})
+	require.Equal(t, []string{"M1", "M2", "M4"}, stored)
+}
+
+// TestSync_FailingSinkStoresMessage verifies a message kept out of the
+// store is saved to messages.db when its only sink fails.
+func TestSync_FailingSinkStoresMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	st, err := store.NewMessageStore(filepath.Join(t.TempDir(), "messages.db"))
+	require.NoError(t, err)
+	defer st.Close()
+
+	chat := types.NewJID("1234", types.DefaultUserServer)
+	ctx, cancel := context.WithCancel(context.Background())
+	mockClient := &MockWAClient{
+		StartSyncFunc: func(ctx context.Context, handler func(interface{})) error {
+			handler(&events.Message{
+				Info: types.MessageInfo{
+					MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+					ID:            "M1",
+					Timestamp:     time.Unix(1700000000, 0),
+				},
+				Message: &waE2E.Message{Conversation: proto.String("hi")},
+			})
+			cancel()
+			return nil
+		},
+	}
+
+	app := NewAppWithDeps(mockClient, st, t.TempDir(), "test")
+	app.cfg = &config.Config{Pipelines: []config.Pipeline{
+		{Name: "hook", Sinks: []config.PipelineSink{{Type: config.SinkWebhook, URL: server.URL}}},
+	}}
+	resp := parseResponse(t, app.Sync(ctx, SyncOptions{}))
+	require.True(t, resp.Success)
+
+	messages, err := st.ListMessages(store.ListMessagesParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.Equal(t, "M1", messages[0].ID)
+}
+
+// TestPipelineRunner_FullQueueStoresInstead verifies messages arriving
+// while the queue is full are stored right away instead of queued.
+func TestPipelineRunner_FullQueueStoresInstead(t *testing.T) {
+	original := pipelineQueueLimit
+	pipelineQueueLimit = 1
+	defer func() { pipelineQueueLimit = original }()
+
+	done := make(chan struct{})
+	started := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-done
+	}))
+	defer server.Close()
+
+	runner, err := newPipelineRunner(nil, []config.Pipeline{
+		{Name: "hook", Sinks: []config.PipelineSink{{Type: config.SinkWebhook, URL: server.URL}}},
+	})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	runner.Start(ctx)
+
+	var mu sync.Mutex
+	var stored []string
+	enqueue := func(id string) {
+		event := PipelineEvent{ID: id}
+		runner.Enqueue(pipelineJob{event: event, pipelines: runner.Match(event), storeMessage: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			stored = append(stored, id)
+		}})
+	}
+	// M1 blocks the sink, M2 fills the queue and M3 overflows it.
+	enqueue("M1")
+	<-started
+	enqueue("M2")
+	enqueue("M3")
+
+	mu.Lock()
+	require.Equal(t, []string{"M3"}, stored)
+	mu.Unlock()
+	close(done)
+	cancel()
+	runner.Wait()
+}
+
+// TestSendNotification_TitlesWithChat verifies notifications are titled
+// with the chat and prefix group messages with the sender.
+func TestSendNotification_TitlesWithChat(t *testing.T) {
+	var title, body string
+	original := notifyCommand
+	notifyCommand = func(t, b string) ([]string, error) {
+		title, body = t, b
+		return []string{"true"}, nil
+	}
+	defer func() { notifyCommand = original }()
+
+	require.NoError(t, sendNotification(context.Background(), PipelineEvent{ChatJID: "123@g.us", ChatName: "Family", SenderName: "Ana", Type: "image"}))
+	require.Equal(t, "Family", title)
+	require.Equal(t, "Ana: [image]", body)
+
+	require.NoError(t, sendNotification(context.Background(), PipelineEvent{ChatJID: "346@s.whatsapp.net", SenderName: "Bob", Content: "hi", Type: "text"}))
+	require.Equal(t, "346@s.whatsapp.net", title)
+	require.Equal(t, "hi", body)
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+)
+
+// markReadBatch caps how many messages are acknowledged per mark-read pass.
+const markReadBatch = 500
+
+// SendOptions controls optional behavior of send commands.
+type SendOptions struct {
+	// NoReadReceipt skips marking the chat's incoming messages as read
+	// before sending, even when auto_mark_read is enabled.
+	NoReadReceipt bool
+}
+
+// MarkChatRead sends read receipts for every incoming message in the chat
+// that has not been marked yet.
+func (a *App) MarkChatRead(ctx context.Context, chatJID string) string {
+	if err := a.checkChatAllowed(chatJID); err != nil {
+		return output.Error(err)
+	}
+	if err := a.client.Connect(ctx); err != nil {
+		return output.Error(err)
+	}
+	marked, err := a.markChatRead(ctx, chatJID)
+	if err != nil {
+		return output.Error(err)
+	}
+	return output.Success(map[string]interface{}{
+		"chat_jid": chatJID,
+		"marked":   marked,
+	})
+}
+
+// SetAutoMarkRead persists the auto_mark_read setting.
+func (a *App) SetAutoMarkRead(enabled bool) string {
+	if err := config.Set(a.storeDir, "auto_mark_read", enabled); err != nil {
+		return output.Error(err)
+	}
+	if a.cfg != nil {
+		a.cfg.AutoMarkRead = enabled
+	}
+	return output.Success(map[string]interface{}{
+		"auto_mark_read": enabled,
+	})
+}
+
+func (a *App) autoMarkRead() bool {
+	return a.cfg != nil && a.cfg.AutoMarkRead
+}
+
+// markChatRead acknowledges unread incoming messages, one receipt per
+// sender as WhatsApp requires for groups.
+func (a *App) markChatRead(ctx context.Context, chatJID string) (int, error) {
+	unread, err := a.store.ListUnreadIncoming(chatJID, markReadBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	bySender := map[string][]string{}
+	var senders []string
+	for _, m := range unread {
+		if _, seen := bySender[m.Sender]; !seen {
+			senders = append(senders, m.Sender)
+		}
+		bySender[m.Sender] = append(bySender[m.Sender], m.ID)
+	}
+
+	marked := 0
+	for _, sender := range senders {
+		ids := bySender[sender]
+		if err := a.client.MarkRead(ctx, chatJID, sender, ids); err != nil {
+			return marked, fmt.Errorf("marking messages from %s as read: %w", sender, err)
+		}
+		if err := a.store.MarkMessagesRead(chatJID, ids, time.Now()); err != nil {
+			return marked, err
+		}
+		marked += len(ids)
+	}
+	return marked, nil
+}
+
+// markReadBeforeSend mirrors the phone's behavior of reading a chat when
+// replying to it. Failures never block the send.
+func (a *App) markReadBeforeSend(ctx context.Context, chatJID string, opts SendOptions) {
+	if opts.NoReadReceipt || !a.autoMarkRead() {
+		return
+	}
+	if _, err := a.markChatRead(ctx, chatJID); err != nil {
+		fmt.Fprintf(output.Stderr, "⚠️  Could not mark %s as read: %v\n", chatJID, err)
+	}
+}
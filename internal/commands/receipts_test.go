@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// TestSendMessage_ReadReceiptPolicy verifies that replying marks the chat as
+// read only when auto_mark_read is on and the send did not opt out.
+func TestSendMessage_ReadReceiptPolicy(t *testing.T) {
+	tests := []struct {
+		name         string
+		autoMarkRead bool
+		opts         SendOptions
+		wantMarked   bool
+	}{
+		{name: "auto off sends no receipts", autoMarkRead: false, wantMarked: false},
+		{name: "auto on marks chat read", autoMarkRead: true, wantMarked: true},
+		{name: "opt-out overrides auto", autoMarkRead: true, opts: SendOptions{NoReadReceipt: true}, wantMarked: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var receipts [][]string
+			mockClient := &MockWAClient{
+				MarkReadFunc: func(ctx context.Context, chatJID, sender string, ids []string) error {
+					receipts = append(receipts, ids)
+					return nil
+				},
+			}
+			mockStore := &MockMessageStore{
+				ListUnreadIncomingFunc: func(chatJID string, limit int) ([]store.Message, error) {
+					return []store.Message{
+						{ID: "a", ChatJID: chatJID, Sender: "1234"},
+						{ID: "b", ChatJID: chatJID, Sender: "1234"},
+					}, nil
+				},
+			}
+			app := NewAppWithDeps(mockClient, mockStore, t.TempDir(), "test")
+			app.cfg = &config.Config{AutoMarkRead: tt.autoMarkRead}
+
+			resp := parseResponse(t, app.SendMessage(context.Background(), "1234", "hi", tt.opts))
+			require.True(t, resp.Success)
+
+			if tt.wantMarked {
+				require.Equal(t, [][]string{{"a", "b"}}, receipts)
+			} else {
+				require.Empty(t, receipts)
+			}
+		})
+	}
+}
+
+// TestMarkChatRead_GroupsReceiptsBySender verifies one receipt is sent per
+// sender, as WhatsApp requires in groups.
+func TestMarkChatRead_GroupsReceiptsBySender(t *testing.T) {
+	senders := map[string][]string{}
+	var stored []string
+	mockClient := &MockWAClient{
+		MarkReadFunc: func(ctx context.Context, chatJID, sender string, ids []string) error {
+			senders[sender] = ids
+			return nil
+		},
+	}
+	mockStore := &MockMessageStore{
+		ListUnreadIncomingFunc: func(chatJID string, limit int) ([]store.Message, error) {
+			return []store.Message{
+				{ID: "1", Sender: "alice"},
+				{ID: "2", Sender: "bob"},
+				{ID: "3", Sender: "alice"},
+			}, nil
+		},
+		MarkMessagesReadFunc: func(chatJID string, ids []string, at time.Time) error {
+			stored = append(stored, ids...)
+			return nil
+		},
+	}
+	app := NewAppWithDeps(mockClient, mockStore, "/tmp", "test")
+
+	resp := parseResponse(t, app.MarkChatRead(context.Background(), "team@g.us"))
+	require.True(t, resp.Success)
+	require.Equal(t, map[string][]string{"alice": {"1", "3"}, "bob": {"2"}}, senders)
+	require.ElementsMatch(t, []string{"1", "2", "3"}, stored)
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vicentereig/whatsapp-cli/internal/client"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// reprocessBatch is how many archived messages are loaded per query.
+const reprocessBatch = 500
+
+// ReprocessStore re-runs the current extraction logic over every archived
+// raw proto, so fields older versions could not parse (captions, locations,
+// replies, polls) are filled in without re-syncing. It fails rather than
+// reporting partial counts when ctx ends before every message was seen.
+func (a *App) ReprocessStore(ctx context.Context) string {
+	scanned, updated, failed := 0, 0, 0
+	var after int64
+
+	for ctx.Err() == nil {
+		batch, err := a.store.ListRawMessages(after, reprocessBatch)
+		if err != nil {
+			return output.Error(err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for _, raw := range batch {
+			after = raw.RowID
+			scanned++
+
+			msg, err := client.UnmarshalRaw(raw.Raw)
+			if err != nil {
+				failed++
+				continue
+			}
+			content := client.ExtractContent(msg)
+			if content.Location != nil {
+				// Points are stored once, so reruns don't duplicate them.
+				a.recordLocation(raw.ID, raw.ChatJID, raw.Sender, raw.Timestamp, content.Location, false)
+			}
+			changed, err := a.store.UpdateExtractedContent(raw.ID, raw.ChatJID, toExtractedContent(content))
+			if err != nil {
+				failed++
+				continue
+			}
+			if changed {
+				updated++
+			}
+		}
+		fmt.Fprintf(output.Stderr, "\r🔄 Reprocessed %d messages (%d updated)...", scanned, updated)
+	}
+	if scanned > 0 {
+		fmt.Fprintln(output.Stderr)
+	}
+	if ctx.Err() != nil {
+		// Reprocessing is idempotent, so a rerun finishes the job.
+		return output.Error(fmt.Errorf("reprocessing interrupted after %d messages (%d updated); run it again to finish", scanned, updated))
+	}
+
+	return output.Success(map[string]interface{}{
+		"scanned": scanned,
+		"updated": updated,
+		"failed":  failed,
+	})
+}
+
+func toExtractedContent(c client.MessageContent) store.ExtractedContent {
+	out := store.ExtractedContent{
+		Content:   c.Content,
+		ReplyToID: c.ReplyToID,
+	}
+	if m := c.Media; m != nil {
+		out.MediaType = m.Type
+		out.Filename = m.Filename
+		out.URL = m.URL
+		out.DirectPath = m.DirectPath
+		out.MimeType = m.MimeType
+		out.MediaKey = m.MediaKey
+		out.FileSHA256 = m.FileSHA256
+		out.FileEncSHA256 = m.FileEncSHA256
+		out.FileLength = m.FileLength
+	}
+	if c.Commerce != nil {
+		commerce := toCommerce(c.Commerce)
+		out.Commerce = &commerce
+	}
+	if c.Interactive != nil {
+		out.InteractiveKind = c.Interactive.Kind
+		out.SelectedID = c.Interactive.SelectedID
+	}
+	return out
+}
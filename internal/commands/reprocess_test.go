@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/client"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestReprocessStore_ReExtractsArchivedProtos verifies wrapped messages that
+// were stored without content get it back from their raw proto.
+func TestReprocessStore_ReExtractsArchivedProtos(t *testing.T) {
+	location := client.MarshalRaw(&waE2E.Message{
+		EphemeralMessage: &waE2E.FutureProofMessage{Message: &waE2E.Message{
+			LocationMessage: &waE2E.LocationMessage{
+				DegreesLatitude:  proto.Float64(40.4168),
+				DegreesLongitude: proto.Float64(-3.7038),
+				Name:             proto.String("Puerta del Sol"),
+			},
+		}},
+	})
+
+	updates := map[string]store.ExtractedContent{}
+	mockStore := &MockMessageStore{
+		ListRawMessagesFunc: func(afterRowID int64, limit int) ([]store.RawMessage, error) {
+			if afterRowID > 0 {
+				return nil, nil
+			}
+			return []store.RawMessage{
+				{RowID: 1, ID: "loc", ChatJID: "1234@s.whatsapp.net", Raw: location},
+				{RowID: 2, ID: "bad", ChatJID: "1234@s.whatsapp.net", Raw: []byte{0xff, 0xff}},
+			}, nil
+		},
+		UpdateExtractedContentFunc: func(id, chatJID string, e store.ExtractedContent) (bool, error) {
+			updates[id] = e
+			return true, nil
+		},
+	}
+
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+	resp := parseResponse(t, app.ReprocessStore(context.Background()))
+	require.True(t, resp.Success)
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+	assert.EqualValues(t, 2, data["scanned"])
+	assert.EqualValues(t, 1, data["updated"])
+	assert.EqualValues(t, 1, data["failed"])
+	assert.Contains(t, updates["loc"].Content, "Puerta del Sol")
+}
+
+// TestReprocessStore_RecordsLocations verifies locations already in the
+// raw archive are added to the location history.
+func TestReprocessStore_RecordsLocations(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	raw := client.MarshalRaw(&waE2E.Message{
+		LocationMessage: &waE2E.LocationMessage{
+			DegreesLatitude:  proto.Float64(40.4168),
+			DegreesLongitude: proto.Float64(-3.7038),
+			Name:             proto.String("Puerta del Sol"),
+		},
+	})
+
+	var points []store.LocationPoint
+	mockStore := &MockMessageStore{
+		ListRawMessagesFunc: func(afterRowID int64, limit int) ([]store.RawMessage, error) {
+			if afterRowID > 0 {
+				return nil, nil
+			}
+			return []store.RawMessage{{RowID: 1, ID: "loc", ChatJID: "1234@s.whatsapp.net", Sender: "1234", Timestamp: at, Raw: raw}}, nil
+		},
+		StoreLocationFunc: func(p store.LocationPoint) error {
+			points = append(points, p)
+			return nil
+		},
+	}
+
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+	resp := parseResponse(t, app.ReprocessStore(context.Background()))
+	require.True(t, resp.Success)
+	require.Len(t, points, 1)
+	assert.Equal(t, "loc", points[0].MessageID)
+	assert.Equal(t, "1234", points[0].Sender)
+	assert.Equal(t, 40.4168, points[0].Latitude)
+	assert.Equal(t, "Puerta del Sol", points[0].Label)
+	assert.True(t, points[0].Timestamp.Equal(at))
+}
+
+// TestReprocessStore_ExtractsBusinessFields verifies order messages stored
+// as empty rows get their amount, currency and item count on reprocess.
+func TestReprocessStore_ExtractsBusinessFields(t *testing.T) {
+	order := client.MarshalRaw(&waE2E.Message{
+		OrderMessage: &waE2E.OrderMessage{
+			ItemCount:         proto.Int32(2),
+			TotalAmount1000:   proto.Int64(9990),
+			TotalCurrencyCode: proto.String("GBP"),
+		},
+	})
+
+	var got store.ExtractedContent
+	mockStore := &MockMessageStore{
+		ListRawMessagesFunc: func(afterRowID int64, limit int) ([]store.RawMessage, error) {
+			if afterRowID > 0 {
+				return nil, nil
+			}
+			return []store.RawMessage{{RowID: 1, ID: "order", ChatJID: "shop@s.whatsapp.net", Raw: order}}, nil
+		},
+		UpdateExtractedContentFunc: func(id, chatJID string, e store.ExtractedContent) (bool, error) {
+			got = e
+			return true, nil
+		},
+	}
+
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+	resp := parseResponse(t, app.ReprocessStore(context.Background()))
+	require.True(t, resp.Success)
+	require.NotNil(t, got.Commerce)
+	assert.Equal(t, store.Commerce{Type: "order", Amount1000: 9990, Currency: "GBP", ItemCount: 2}, *got.Commerce)
+	assert.Equal(t, "[Order] 2 items, 9.99 GBP", got.Content)
+}
+
+// TestReprocessStore_FailsWhenInterrupted verifies a cancelled run reports
+// an error instead of success with partial counts.
+func TestReprocessStore_FailsWhenInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	mockStore := &MockMessageStore{
+		ListRawMessagesFunc: func(afterRowID int64, limit int) ([]store.RawMessage, error) {
+			// Interrupted after the first batch, with more still to go.
+			cancel()
+			return []store.RawMessage{{RowID: afterRowID + 1, ID: "m", ChatJID: "1@s.whatsapp.net"}}, nil
+		},
+	}
+
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+	resp := parseResponse(t, app.ReprocessStore(ctx))
+	require.False(t, resp.Success)
+	require.Contains(t, *resp.Error, "interrupted after 1 messages")
+}
@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+)
+
+// maintenanceInterval is how often `sync --daemon` runs housekeeping tasks.
+const maintenanceInterval = time.Hour
+
+// parseKeepDuration accepts Go durations ("36h") plus day and week suffixes
+// ("30d", "2w"), which time.ParseDuration does not understand.
+func parseKeepDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("duration is required")
+	}
+
+	var d time.Duration
+	unit := value[len(value)-1]
+	switch unit {
+	case 'd', 'w':
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+		if unit == 'w' {
+			d *= 7
+		}
+	default:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q (use e.g. 30d, 2w, 12h)", value)
+		}
+		d = parsed
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive, got %q", value)
+	}
+	return d, nil
+}
+
+func (a *App) SetChatRetention(chatJID, keep string) string {
+	if err := a.checkChatAllowed(chatJID); err != nil {
+		return output.Error(err)
+	}
+	d, err := parseKeepDuration(keep)
+	if err != nil {
+		return output.Error(err)
+	}
+	if err := a.store.SetChatRetention(chatJID, d); err != nil {
+		return output.Error(err)
+	}
+	return output.Success(map[string]interface{}{
+		"chat_jid":     chatJID,
+		"keep":         keep,
+		"keep_seconds": int64(d / time.Second),
+	})
+}
+
+func (a *App) ClearChatRetention(chatJID string) string {
+	if err := a.checkChatAllowed(chatJID); err != nil {
+		return output.Error(err)
+	}
+	if err := a.store.ClearChatRetention(chatJID); err != nil {
+		return output.Error(err)
+	}
+	return output.Success(map[string]interface{}{
+		"chat_jid": chatJID,
+		"cleared":  true,
+	})
+}
+
+func (a *App) ListChatRetention() string {
+	policies, err := a.store.ListChatRetention()
+	if err != nil {
+		return output.Error(err)
+	}
+	return output.Success(policies)
+}
+
+// runMaintenance performs one pass of housekeeping. Failures are reported
+// on stderr and never stop the sync loop.
+func (a *App) runMaintenance(now time.Time) {
+	pruned, err := a.store.PruneExpiredMessages(now)
+	if err != nil {
+		fmt.Fprintf(output.Stderr, "\n⚠️  Retention pruning failed: %v\n", err)
+		return
+	}
+	if pruned > 0 {
+		fmt.Fprintf(output.Stderr, "\n🧹 Pruned %d messages past their chat retention\n", pruned)
+	}
+}
+
+// startMaintenance runs housekeeping immediately and then every interval
+// until ctx is cancelled.
+func (a *App) startMaintenance(ctx context.Context, interval time.Duration) {
+	go func() {
+		a.runMaintenance(time.Now())
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				a.runMaintenance(now)
+			}
+		}
+	}()
+}
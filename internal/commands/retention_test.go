@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeepDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "30d", want: 30 * 24 * time.Hour},
+		{input: "2w", want: 14 * 24 * time.Hour},
+		{input: "12h", want: 12 * time.Hour},
+		{input: "", wantErr: true},
+		{input: "0d", wantErr: true},
+		{input: "-1h", wantErr: true},
+		{input: "soon", wantErr: true},
+		{input: "xd", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseKeepDuration(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestSetChatRetention_StoresParsedDuration verifies the --keep value reaches
+// the store as a duration and is echoed back in seconds.
+func TestSetChatRetention_StoresParsedDuration(t *testing.T) {
+	var gotJID string
+	var gotKeep time.Duration
+	mockStore := &MockMessageStore{
+		SetChatRetentionFunc: func(chatJID string, keep time.Duration) error {
+			gotJID = chatJID
+			gotKeep = keep
+			return nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, "/tmp", "test")
+
+	resp := parseResponse(t, app.SetChatRetention("team@g.us", "30d"))
+	require.True(t, resp.Success)
+	require.Equal(t, "team@g.us", gotJID)
+	require.Equal(t, 30*24*time.Hour, gotKeep)
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Data, &data))
+	require.EqualValues(t, 30*24*3600, data["keep_seconds"])
+}
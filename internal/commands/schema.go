@@ -0,0 +1,27 @@
+package commands
+
+import (
+	"encoding/json"
+
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/schema"
+)
+
+// PrintSchema returns the JSON Schema of a command's output, such as
+// "messages.list". Like MigrateStore it needs no App, so it works before
+// a store exists.
+func PrintSchema(command string) string {
+	doc, err := schema.For(command)
+	if err != nil {
+		return output.Error(err)
+	}
+	return output.Success(json.RawMessage(doc))
+}
+
+// ListSchemas returns the schema version and the commands that have one.
+func ListSchemas() string {
+	return output.Success(map[string]interface{}{
+		"version":  schema.Version,
+		"commands": schema.Commands,
+	})
+}
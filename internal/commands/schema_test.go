@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/schema"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// schemaTestStore returns fully populated rows, so optional fields are
+// checked against the schemas too.
+func schemaTestStore() *MockMessageStore {
+	at := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	amount, items := 12.5, 2
+	last, lastSender, fromMe := "hi", "34611111111", false
+	latency := int64(900)
+	message := store.Message{
+		ID: "M1", ChatJID: "123@g.us", ChatName: "Family", Sender: "34611111111", Content: "order",
+		Timestamp: at, MediaType: "image", ReplyToID: "M0", SenderName: "Ana", Type: "order",
+		Amount: &amount, Currency: "EUR", ItemCount: &items, Status: "pending", SelectedID: "opt1",
+		URI: "whatsapp-cli://chat/123@g.us/message/M1",
+	}
+	points := []store.LocationPoint{
+		{MessageID: "L1", ChatJID: "123@g.us", Sender: "34611111111", Live: true, Sequence: 1, Latitude: 40.4, Longitude: -3.7, AccuracyM: 5, SpeedMps: 1.5, Heading: 90, Timestamp: at},
+		{MessageID: "L1", ChatJID: "123@g.us", Sender: "34611111111", Live: true, Sequence: 2, Latitude: 40.5, Longitude: -3.6, Timestamp: at.Add(time.Minute)},
+	}
+	return &MockMessageStore{
+		ListMessagesFunc: func(store.ListMessagesParams) ([]store.Message, error) {
+			return []store.Message{message}, nil
+		},
+		SearchContactsFunc: func(string) ([]store.Contact, error) {
+			return []store.Contact{{PhoneNumber: "34611111111", Name: "Ana", JID: "34611111111@s.whatsapp.net"}}, nil
+		},
+		GetMessageDetailsFunc: func(chatJID, id string) (store.MessageDetails, error) {
+			return store.MessageDetails{Message: message, Filename: "menu.jpg", MimeType: "image/jpeg", FileLength: 2048,
+				LocalPath: "/media/menu.jpg", DownloadedAt: &at, ReadMarkedAt: &at}, nil
+		},
+		ListMessagesAroundFunc: func(chatJID, id string, at time.Time, n int) ([]store.Message, []store.Message, error) {
+			return []store.Message{message}, []store.Message{message}, nil
+		},
+		ListChatsFunc: func(store.ListChatsParams) ([]store.Chat, error) {
+			return []store.Chat{{JID: "123@g.us", Name: "Family", LastMessageTime: at, LastMessage: &last, LastSender: &lastSender, LastIsFromMe: &fromMe,
+				Group: &store.GroupInfo{Topic: "Weekend plans", ParticipantCount: 4, AvatarPath: "/avatars/123@g.us.jpg", RefreshedAt: at}}}, nil
+		},
+		ListChatRetentionFunc: func() ([]store.ChatRetention, error) {
+			return []store.ChatRetention{{ChatJID: "123@g.us", ChatName: "Family", KeepSeconds: 86400, UpdatedAt: at}}, nil
+		},
+		ListChatMediaPoliciesFunc: func() ([]store.ChatMediaPolicy, error) {
+			return []store.ChatMediaPolicy{{ChatJID: "123@g.us", ChatName: "Family", Types: []string{"image"}, MaxSize: 1024, UpdatedAt: at}}, nil
+		},
+		ListOutboundFunc: func(store.ListOutboundParams) ([]store.OutboundMessage, error) {
+			return []store.OutboundMessage{{ID: 1, MessageID: "S1", URI: "whatsapp-cli://chat/123@g.us/message/S1", ChatJID: "123@g.us", Kind: "text", Content: "hi", Status: "read",
+				CreatedAt: at, UpdatedAt: at, SentAt: &at, DeliveredAt: &at, ReadAt: &at, DeliveryLatencyMs: &latency, ReadLatencyMs: &latency}}, nil
+		},
+		ListDeliveryTimingsFunc: func(string, time.Time) ([]store.DeliveryTiming, error) {
+			return []store.DeliveryTiming{{ChatJID: "123@g.us", SentAt: at, DeliveredAt: ptrTime(at.Add(time.Second)), ReadAt: ptrTime(at.Add(time.Minute))}}, nil
+		},
+		ListUnreadIncomingFunc: func(string, int) ([]store.Message, error) {
+			return []store.Message{message}, nil
+		},
+		LocationTrackFunc: func(string, *string) ([]store.LocationPoint, error) {
+			return points, nil
+		},
+		ListLocationsFunc: func(string) ([]store.LocationPoint, error) {
+			return points, nil
+		},
+		GetChatNameFunc: func(string) (string, error) {
+			return "Family", nil
+		},
+		ListChatHistoryFunc: func(string) ([]store.HistoryMessage, error) {
+			return []store.HistoryMessage{{ID: "M1", Sender: "34611111111", SenderName: "Ana", Content: "menu", Timestamp: at,
+				MediaType: "image", Filename: "menu.jpg", MimeType: "image/jpeg", LocalPath: "/media/menu.jpg", ReplyToID: "M0"}}, nil
+		},
+		GetMessageForDownloadFunc: func(id string, chatJID *string) (store.MessageDownloadInfo, error) {
+			name := "Family"
+			return store.MessageDownloadInfo{ID: id, ChatJID: "123@g.us", ChatName: &name, MediaType: "image", MimeType: "image/jpeg",
+				DirectPath: "/v/t62", MediaKey: []byte{1}}, nil
+		},
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
+
+// TestCommandOutputs_MatchSchemas verifies every command's output matches
+// its published schema, so field changes can't ship unnoticed.
+func TestCommandOutputs_MatchSchemas(t *testing.T) {
+	ctx := context.Background()
+	app := NewAppWithDeps(&MockWAClient{}, schemaTestStore(), t.TempDir(), "test")
+	app.mediaDownloader = func(ctx context.Context, info store.MessageDownloadInfo, targetPath string) (int64, error) {
+		return 42, nil
+	}
+	outDir := t.TempDir()
+	chatJID := "123@g.us"
+
+	migrateFrom := filepath.Join(t.TempDir(), "store")
+	require.NoError(t, os.MkdirAll(migrateFrom, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(migrateFrom, "messages.db"), nil, 0600))
+
+	fakeSystemd(t, true)
+
+	outputs := []struct {
+		command string
+		result  string
+	}{
+		{"auth", app.Auth(ctx)},
+		{"messages.list", app.ListMessages(&chatJID, nil, nil, 20, 0)},
+		{"messages.list", NewAppWithDeps(&MockWAClient{}, &MockMessageStore{}, t.TempDir(), "test").ListMessages(nil, nil, nil, 20, 0)},
+		{"messages.search", app.ListMessages(nil, &chatJID, nil, 20, 0)},
+		{"messages.location-track", app.LocationTrack("L1", nil, LocationFormatJSON, "")},
+		{"messages.location-track", app.LocationTrack("L1", nil, LocationFormatGeoJSON, "")},
+		{"messages.location-track", app.LocationTrack("L1", nil, LocationFormatJSON, filepath.Join(outDir, "track.json"))},
+		{"contacts.search", app.SearchContacts("ana")},
+		{"chats.list", app.ListChats(nil, 20, 0, false)},
+		{"chats.retention.set", app.SetChatRetention(chatJID, "30d")},
+		{"chats.retention.clear", app.ClearChatRetention(chatJID)},
+		{"chats.retention.list", app.ListChatRetention()},
+		{"chats.media-policy.set", app.SetChatMediaPolicy(chatJID, "image,video", "5MB")},
+		{"chats.media-policy.clear", app.ClearChatMediaPolicy(chatJID)},
+		{"chats.media-policy.list", app.ListChatMediaPolicies()},
+		{"chats.mark-read", app.MarkChatRead(ctx, chatJID)},
+		{"chats.mark-read", app.SetAutoMarkRead(true)},
+		{"chats.snapshot", app.ChatSnapshot(chatJID, "2026-10-16")},
+		{"send", app.SendMessage(ctx, chatJID, "hello", SendOptions{})},
+		{"send", app.SendImage(ctx, chatJID, "/tmp/photo.jpg", "", SendOptions{})},
+		{"media.download", app.DownloadMedia(ctx, "M1", nil, filepath.Join(outDir, "menu.jpg"))},
+		{"open", app.Open("whatsapp-cli://chat/123@g.us/message/M1", 2)},
+		{"outbound.list", app.ListOutbound("", 50)},
+		{"stats.delivery", app.DeliveryStats(DeliveryStatsOptions{Since: "7d", ByChat: true})},
+		{"export.locations", app.ExportLocations(chatJID, LocationFormatGeoJSON, "")},
+		{"export.locations", app.ExportLocations(chatJID, LocationFormatKML, filepath.Join(outDir, "chat.kml"))},
+		{"export.matrix", app.ExportMatrix(MatrixExportOptions{ChatJID: chatJID, UserID: "@me:example.org"})},
+		{"export.matrix", app.ExportMatrix(MatrixExportOptions{UserID: "@me:example.org", OutputPath: filepath.Join(outDir, "rooms")})},
+		{"import.backup", app.ImportBackup(context.Background(), writeAndroidBackup(t), "")},
+		{"store.reprocess", app.ReprocessStore(ctx)},
+		{"store.migrate", MigrateStore(migrateFrom, filepath.Join(t.TempDir(), "moved"))},
+		{"service.install", InstallService("/data/store", "")},
+		{"service.status", ServiceStatus()},
+		{"service.uninstall", UninstallService()},
+		{"fixtures.generate", GenerateFixtures(filepath.Join(t.TempDir(), "fixtures"), FixtureOptions{Chats: 2, Messages: 20, Seed: 1})},
+		{"schema.print", PrintSchema("messages.list")},
+		{"schema.list", ListSchemas()},
+	}
+
+	for _, o := range outputs {
+		require.True(t, parseResponse(t, o.result).Success, "%s failed: %s", o.command, o.result)
+		require.NoError(t, schema.Validate(o.command, []byte(o.result)), "%s: %s", o.command, o.result)
+	}
+}
@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// participantLookupQueueSize bounds the participants waiting for a contact
+// name lookup. Those that do not fit are retried on their next message.
+const participantLookupQueueSize = 1024
+
+// participantLookup is a participant waiting for a contact name lookup.
+type participantLookup struct {
+	chatJID, participantJID string
+}
+
+// participantNames fills the group participant name cache during sync.
+// Push names are stored as messages arrive; each participant is looked up
+// through the name providers once per session in the background, since
+// providers such as CardDAV go over the network. A contact name found
+// there replaces the push name.
+type participantNames struct {
+	app   *App
+	queue chan participantLookup
+
+	mu sync.Mutex
+	// resolved maps chat|participant to whether a contact name was found.
+	resolved map[string]bool
+
+	wg sync.WaitGroup
+}
+
+func newParticipantNames(app *App) *participantNames {
+	return &participantNames{
+		app:      app,
+		queue:    make(chan participantLookup, participantLookupQueueSize),
+		resolved: make(map[string]bool),
+	}
+}
+
+func (p *participantNames) Start(ctx context.Context) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case l := <-p.queue:
+				p.app.safely("participant name lookup", func() { p.lookup(ctx, l) })
+			}
+		}
+	}()
+}
+
+func (p *participantNames) Wait() {
+	p.wg.Wait()
+}
+
+// Record caches the display name of participantJID in the group chatJID.
+// pushName is the name the participant chose for themselves, if known.
+// Chats other than groups are ignored. It never blocks on a lookup.
+func (p *participantNames) Record(chatJID, participantJID, pushName string) {
+	if !strings.HasSuffix(chatJID, "@g.us") || participantJID == "" {
+		return
+	}
+	key := chatJID + "|" + participantJID
+
+	p.mu.Lock()
+	hasContact, seen := p.resolved[key]
+	if !seen {
+		select {
+		case p.queue <- participantLookup{chatJID: chatJID, participantJID: participantJID}:
+			p.resolved[key] = false
+		default:
+		}
+	}
+	p.mu.Unlock()
+	if hasContact {
+		return
+	}
+
+	if pushName = strings.TrimSpace(pushName); pushName != "" && pushName != "-" {
+		p.app.store.StoreParticipantName(chatJID, participantJID, pushName, store.ParticipantNamePush)
+	}
+}
+
+func (p *participantNames) lookup(ctx context.Context, l participantLookup) {
+	name := p.app.client.ResolveChatName(ctx, l.participantJID, nil)
+	if name == "" || name == l.participantJID {
+		return
+	}
+	p.app.store.StoreParticipantName(l.chatJID, l.participantJID, name, store.ParticipantNameContact)
+	p.mu.Lock()
+	p.resolved[l.chatJID+"|"+l.participantJID] = true
+	p.mu.Unlock()
+}
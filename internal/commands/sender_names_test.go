@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+type storedParticipantName struct {
+	chatJID, participant, name, source string
+}
+
+// participantNamesRecorder captures what a participantNames stores and how
+// often it looks names up.
+type participantNamesRecorder struct {
+	mu      sync.Mutex
+	stored  []storedParticipantName
+	lookups int
+}
+
+func (r *participantNamesRecorder) snapshot() ([]storedParticipantName, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]storedParticipantName(nil), r.stored...), r.lookups
+}
+
+func newParticipantNamesForTest(t *testing.T, contacts map[string]string) (*participantNames, *participantNamesRecorder) {
+	t.Helper()
+	rec := &participantNamesRecorder{}
+	mockStore := &MockMessageStore{
+		StoreParticipantNameFunc: func(chatJID, participant, name, source string) error {
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			rec.stored = append(rec.stored, storedParticipantName{chatJID, participant, name, source})
+			return nil
+		},
+	}
+	mockClient := &MockWAClient{
+		ResolveChatNameFunc: func(ctx context.Context, jid string, evt interface{}) string {
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			rec.lookups++
+			if name, ok := contacts[jid]; ok {
+				return name
+			}
+			return jid
+		},
+	}
+	app := NewAppWithDeps(mockClient, mockStore, t.TempDir(), "test")
+	names := newParticipantNames(app)
+	ctx, cancel := context.WithCancel(context.Background())
+	names.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		names.Wait()
+	})
+	return names, rec
+}
+
+// TestParticipantNames_PrefersContactName verifies a participant's contact
+// name, looked up in the background, replaces the push name and is not
+// looked up again.
+func TestParticipantNames_PrefersContactName(t *testing.T) {
+	names, rec := newParticipantNamesForTest(t, map[string]string{"34611111111@s.whatsapp.net": "Ana García"})
+
+	names.Record("123@g.us", "34611111111@s.whatsapp.net", "ana 🌸")
+	require.Eventually(t, func() bool {
+		stored, _ := rec.snapshot()
+		return len(stored) == 2
+	}, time.Second, 5*time.Millisecond)
+	names.Record("123@g.us", "34611111111@s.whatsapp.net", "ana ✨")
+
+	stored, lookups := rec.snapshot()
+	require.Equal(t, 1, lookups)
+	require.Equal(t, []storedParticipantName{
+		{"123@g.us", "34611111111@s.whatsapp.net", "ana 🌸", store.ParticipantNamePush},
+		{"123@g.us", "34611111111@s.whatsapp.net", "Ana García", store.ParticipantNameContact},
+	}, stored)
+}
+
+// TestParticipantNames_FallsBackToPushName verifies unknown participants
+// are cached by push name, refreshed on later messages, and looked up once.
+func TestParticipantNames_FallsBackToPushName(t *testing.T) {
+	names, rec := newParticipantNamesForTest(t, nil)
+
+	names.Record("123@g.us", "34622222222@s.whatsapp.net", "Bob")
+	names.Record("123@g.us", "34622222222@s.whatsapp.net", "Bobby")
+	names.Record("123@g.us", "34622222222@s.whatsapp.net", "")
+	require.Eventually(t, func() bool {
+		_, lookups := rec.snapshot()
+		return lookups == 1
+	}, time.Second, 5*time.Millisecond)
+
+	stored, _ := rec.snapshot()
+	require.Equal(t, []storedParticipantName{
+		{"123@g.us", "34622222222@s.whatsapp.net", "Bob", store.ParticipantNamePush},
+		{"123@g.us", "34622222222@s.whatsapp.net", "Bobby", store.ParticipantNamePush},
+	}, stored)
+}
+
+// TestParticipantNames_IgnoresDirectChats verifies only group chats are
+// cached; a direct chat's sender is already named by the chat itself.
+func TestParticipantNames_IgnoresDirectChats(t *testing.T) {
+	names, rec := newParticipantNamesForTest(t, nil)
+
+	names.Record("34622222222@s.whatsapp.net", "34622222222@s.whatsapp.net", "Bob")
+
+	stored, lookups := rec.snapshot()
+	require.Zero(t, lookups)
+	require.Empty(t, stored)
+}
+
+// TestParticipantNames_RecordNeverWaitsForLookups verifies a slow name
+// provider doesn't hold up the event handler.
+func TestParticipantNames_RecordNeverWaitsForLookups(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	mockClient := &MockWAClient{
+		ResolveChatNameFunc: func(ctx context.Context, jid string, evt interface{}) string {
+			<-release
+			return ""
+		},
+	}
+	app := NewAppWithDeps(mockClient, &MockMessageStore{}, t.TempDir(), "test")
+	names := newParticipantNames(app)
+	ctx, cancel := context.WithCancel(context.Background())
+	names.Start(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		names.Record("123@g.us", "34611111111@s.whatsapp.net", "Ana")
+		names.Record("123@g.us", "34622222222@s.whatsapp.net", "Bob")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Record blocked on a name lookup")
+	}
+	cancel()
+}
@@ -0,0 +1,338 @@
+package commands
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+)
+
+// ServiceName names the systemd unit and the Windows service.
+const ServiceName = "whatsapp-cli"
+
+// launchdLabel names the launchd job; launchd expects reverse-DNS labels.
+const launchdLabel = "com.github.vicentereig.whatsapp-cli"
+
+// serviceRestartDelay is how long the service manager waits before
+// restarting a sync that stopped, so a logged-out session doesn't spin.
+const serviceRestartDelay = 30 * time.Second
+
+// ServiceState describes the sync service registered with the platform's
+// service manager.
+type ServiceState struct {
+	// Manager is systemd, launchd or windows.
+	Manager   string `json:"manager"`
+	Name      string `json:"name"`
+	Installed bool   `json:"installed"`
+	Running   bool   `json:"running"`
+	// Path is the unit or plist file; Windows services have none.
+	Path string `json:"path,omitempty"`
+	// Command is what the service runs, set by install.
+	Command []string `json:"command,omitempty"`
+	// Logs says where the daemon's output goes.
+	Logs string `json:"logs,omitempty"`
+}
+
+// serviceManager registers `sync --daemon` with a platform service manager.
+// Services run as the installing user. password is that user's Windows
+// password, which the Windows service manager needs to log on as them;
+// systemd and launchd run the service in the user's session and ignore it.
+type serviceManager interface {
+	Install(command []string, password string) error
+	Uninstall() error
+	Status() (ServiceState, error)
+}
+
+// newServiceManager returns the service manager for this OS.
+var newServiceManager = func() (serviceManager, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return newSystemdManager()
+	case "darwin":
+		return newLaunchdManager()
+	case "windows":
+		return newWindowsServiceManager()
+	default:
+		return nil, fmt.Errorf("services are not supported on %s", runtime.GOOS)
+	}
+}
+
+// runServiceCommand runs a service manager command such as systemctl.
+var runServiceCommand = func(name string, args ...string) ([]byte, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("%s %s: %v: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+// serviceCommand is the command line the service runs: this binary syncing
+// the given store. The path is kept as invoked rather than resolved, so a
+// package manager's symlink keeps working across upgrades.
+func serviceCommand(storeDir string) ([]string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locating whatsapp-cli: %w", err)
+	}
+	return []string{exe, "--store", storeDir, "sync", "--daemon"}, nil
+}
+
+// InstallService registers `sync --daemon` for storeDir as a service that
+// starts at login (at boot on Windows) and restarts when it stops.
+// Installing again replaces the existing service and restarts it.
+func InstallService(storeDir, password string) string {
+	manager, err := newServiceManager()
+	if err != nil {
+		return output.Error(err)
+	}
+	command, err := serviceCommand(storeDir)
+	if err != nil {
+		return output.Error(err)
+	}
+	if err := manager.Install(command, password); err != nil {
+		return output.Error(err)
+	}
+	state, err := manager.Status()
+	if err != nil {
+		return output.Error(err)
+	}
+	state.Command = command
+	return output.Success(state)
+}
+
+// UninstallService stops the sync service and removes it.
+func UninstallService() string {
+	manager, err := newServiceManager()
+	if err != nil {
+		return output.Error(err)
+	}
+	state, err := manager.Status()
+	if err != nil {
+		return output.Error(err)
+	}
+	if !state.Installed {
+		return output.Error(fmt.Errorf("the %s service is not installed", state.Manager))
+	}
+	if err := manager.Uninstall(); err != nil {
+		return output.Error(err)
+	}
+	state, err = manager.Status()
+	if err != nil {
+		return output.Error(err)
+	}
+	return output.Success(state)
+}
+
+// ServiceStatus reports whether the sync service is installed and running.
+func ServiceStatus() string {
+	manager, err := newServiceManager()
+	if err != nil {
+		return output.Error(err)
+	}
+	state, err := manager.Status()
+	if err != nil {
+		return output.Error(err)
+	}
+	return output.Success(state)
+}
+
+// systemdManager installs a systemd user unit, which needs no root and
+// runs as the user who owns the store.
+type systemdManager struct {
+	unitPath string
+}
+
+func newSystemdManager() (*systemdManager, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return &systemdManager{unitPath: filepath.Join(dir, "systemd", "user", ServiceName+".service")}, nil
+}
+
+func (m *systemdManager) unit() string { return ServiceName + ".service" }
+
+func (m *systemdManager) Install(command []string, _ string) error {
+	if err := os.MkdirAll(filepath.Dir(m.unitPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(m.unitPath, []byte(systemdUnit(command)), 0644); err != nil {
+		return err
+	}
+	for _, args := range [][]string{
+		{"--user", "daemon-reload"},
+		{"--user", "enable", m.unit()},
+		// restart rather than start, so reinstalling picks up a new command.
+		{"--user", "restart", m.unit()},
+	} {
+		if _, err := runServiceCommand("systemctl", args...); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(output.Stderr, "ℹ️  User services stop when you log out; run `loginctl enable-linger` to keep syncing")
+	return nil
+}
+
+func (m *systemdManager) Uninstall() error {
+	if _, err := runServiceCommand("systemctl", "--user", "disable", "--now", m.unit()); err != nil {
+		return err
+	}
+	if err := os.Remove(m.unitPath); err != nil {
+		return err
+	}
+	_, err := runServiceCommand("systemctl", "--user", "daemon-reload")
+	return err
+}
+
+func (m *systemdManager) Status() (ServiceState, error) {
+	state := ServiceState{Manager: "systemd", Name: m.unit(), Path: m.unitPath, Logs: "journalctl --user -u " + ServiceName}
+	if _, err := os.Stat(m.unitPath); err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return state, err
+	}
+	state.Installed = true
+	// is-active exits non-zero for anything but "active".
+	out, _ := runServiceCommand("systemctl", "--user", "is-active", m.unit())
+	state.Running = strings.TrimSpace(string(out)) == "active"
+	return state, nil
+}
+
+// systemdUnit renders the user unit running command.
+func systemdUnit(command []string) string {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = systemdQuote(arg)
+	}
+	return fmt.Sprintf(`[Unit]
+Description=WhatsApp CLI sync
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+ExecStart=%s
+Restart=always
+RestartSec=%d
+
+[Install]
+WantedBy=default.target
+`, strings.Join(quoted, " "), int(serviceRestartDelay/time.Second))
+}
+
+// systemdQuote quotes an ExecStart argument, escaping the specifiers and
+// variables systemd would otherwise expand.
+func systemdQuote(arg string) string {
+	arg = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$").Replace(arg)
+	return `"` + arg + `"`
+}
+
+// launchdManager installs a launchd agent for the logged-in user.
+type launchdManager struct {
+	plistPath string
+	logPath   string
+	domain    string
+}
+
+func newLaunchdManager() (*launchdManager, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return &launchdManager{
+		plistPath: filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist"),
+		logPath:   filepath.Join(home, "Library", "Logs", ServiceName+".log"),
+		domain:    fmt.Sprintf("gui/%d", os.Getuid()),
+	}, nil
+}
+
+func (m *launchdManager) Install(command []string, _ string) error {
+	plist, err := launchdPlist(command, m.logPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.plistPath), 0755); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.logPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(m.plistPath, plist, 0644); err != nil {
+		return err
+	}
+	// Unload a previous install first; bootstrap fails if the job is loaded.
+	runServiceCommand("launchctl", "bootout", m.domain+"/"+launchdLabel)
+	_, err = runServiceCommand("launchctl", "bootstrap", m.domain, m.plistPath)
+	return err
+}
+
+func (m *launchdManager) Uninstall() error {
+	// bootout fails when the job isn't loaded, which is fine here.
+	runServiceCommand("launchctl", "bootout", m.domain+"/"+launchdLabel)
+	return os.Remove(m.plistPath)
+}
+
+func (m *launchdManager) Status() (ServiceState, error) {
+	state := ServiceState{Manager: "launchd", Name: launchdLabel, Path: m.plistPath, Logs: m.logPath}
+	if _, err := os.Stat(m.plistPath); err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return state, err
+	}
+	state.Installed = true
+	if out, err := runServiceCommand("launchctl", "print", m.domain+"/"+launchdLabel); err == nil {
+		state.Running = strings.Contains(string(out), "state = running")
+	}
+	return state, nil
+}
+
+// launchdPlist renders the agent running command at login, restarting it
+// whenever it exits.
+func launchdPlist(command []string, logPath string) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+	str := func(key, value string) error {
+		b.WriteString("\t<key>" + key + "</key>\n\t<string>")
+		if err := xml.EscapeText(&b, []byte(value)); err != nil {
+			return err
+		}
+		b.WriteString("</string>\n")
+		return nil
+	}
+	if err := str("Label", launchdLabel); err != nil {
+		return nil, err
+	}
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range command {
+		b.WriteString("\t\t<string>")
+		if err := xml.EscapeText(&b, []byte(arg)); err != nil {
+			return nil, err
+		}
+		b.WriteString("</string>\n")
+	}
+	b.WriteString("\t</array>\n")
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	b.WriteString("\t<key>KeepAlive</key>\n\t<true/>\n")
+	fmt.Fprintf(&b, "\t<key>ThrottleInterval</key>\n\t<integer>%d</integer>\n", int(serviceRestartDelay/time.Second))
+	if err := str("StandardOutPath", logPath); err != nil {
+		return nil, err
+	}
+	if err := str("StandardErrorPath", logPath); err != nil {
+		return nil, err
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return []byte(b.String()), nil
+}
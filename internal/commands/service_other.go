@@ -0,0 +1,18 @@
+//go:build !windows
+
+package commands
+
+import (
+	"context"
+	"errors"
+)
+
+func newWindowsServiceManager() (serviceManager, error) {
+	return nil, errors.New("Windows services can only be installed on Windows")
+}
+
+// StartServiceHost connects to the Windows service manager when the process
+// was started as a service. Elsewhere there is nothing to connect to.
+func StartServiceHost(cancel context.CancelFunc) (finish func()) {
+	return nil
+}
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSystemd points the service commands at a systemd user unit in a temp
+// directory and records the systemctl calls instead of running them.
+func fakeSystemd(t *testing.T, active bool) (*systemdManager, *[]string) {
+	t.Helper()
+	manager := &systemdManager{unitPath: filepath.Join(t.TempDir(), "systemd", "user", "whatsapp-cli.service")}
+	var calls []string
+	origManager, origRun := newServiceManager, runServiceCommand
+	newServiceManager = func() (serviceManager, error) { return manager, nil }
+	runServiceCommand = func(name string, args ...string) ([]byte, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		if len(args) > 1 && args[1] == "is-active" && active {
+			return []byte("active\n"), nil
+		}
+		return []byte("inactive\n"), nil
+	}
+	t.Cleanup(func() { newServiceManager, runServiceCommand = origManager, origRun })
+	return manager, &calls
+}
+
+// TestInstallService_WritesAndStartsSystemdUnit verifies install writes a
+// user unit running sync --daemon on the store and starts it.
+func TestInstallService_WritesAndStartsSystemdUnit(t *testing.T) {
+	manager, calls := fakeSystemd(t, true)
+
+	resp := parseResponse(t, InstallService("/data/my store", ""))
+	require.True(t, resp.Success, "error: %v", resp.Error)
+	var state ServiceState
+	require.NoError(t, json.Unmarshal(resp.Data, &state))
+	require.Equal(t, "systemd", state.Manager)
+	require.True(t, state.Installed)
+	require.True(t, state.Running)
+	require.Equal(t, []string{"--store", "/data/my store", "sync", "--daemon"}, state.Command[1:])
+
+	unit, err := os.ReadFile(manager.unitPath)
+	require.NoError(t, err)
+	require.Contains(t, string(unit), `"--store" "/data/my store" "sync" "--daemon"`)
+	require.Contains(t, string(unit), "Restart=always\nRestartSec=30\n")
+	require.Contains(t, string(unit), "WantedBy=default.target")
+	require.Equal(t, []string{
+		"systemctl --user daemon-reload",
+		"systemctl --user enable whatsapp-cli.service",
+		"systemctl --user restart whatsapp-cli.service",
+		"systemctl --user is-active whatsapp-cli.service",
+	}, *calls)
+}
+
+// TestUninstallService_RemovesSystemdUnit verifies uninstall stops the
+// unit and removes its file, and fails when nothing is installed.
+func TestUninstallService_RemovesSystemdUnit(t *testing.T) {
+	manager, calls := fakeSystemd(t, false)
+
+	resp := parseResponse(t, UninstallService())
+	require.False(t, resp.Success)
+	require.Contains(t, *resp.Error, "not installed")
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(manager.unitPath), 0755))
+	require.NoError(t, os.WriteFile(manager.unitPath, []byte(systemdUnit([]string{"whatsapp-cli"})), 0644))
+	resp = parseResponse(t, ServiceStatus())
+	require.True(t, resp.Success)
+	var state ServiceState
+	require.NoError(t, json.Unmarshal(resp.Data, &state))
+	require.True(t, state.Installed)
+	require.False(t, state.Running)
+
+	*calls = nil
+	resp = parseResponse(t, UninstallService())
+	require.True(t, resp.Success, "error: %v", resp.Error)
+	require.NoError(t, json.Unmarshal(resp.Data, &state))
+	require.False(t, state.Installed)
+	require.NoFileExists(t, manager.unitPath)
+	require.Contains(t, *calls, "systemctl --user disable --now whatsapp-cli.service")
+}
+
+// TestSystemdQuote verifies arguments can't be split or expanded by systemd.
+func TestSystemdQuote(t *testing.T) {
+	require.Equal(t, `"/home/a b/100%%/$$HOME/\"x\""`, systemdQuote(`/home/a b/100%/$HOME/"x"`))
+}
+
+// TestLaunchdPlist verifies the agent runs the command at login, restarts
+// it and escapes paths.
+func TestLaunchdPlist(t *testing.T) {
+	plist, err := launchdPlist([]string{"/usr/local/bin/whatsapp-cli", "--store", "/Users/me/R&D", "sync", "--daemon"}, "/Users/me/Library/Logs/whatsapp-cli.log")
+	require.NoError(t, err)
+	s := string(plist)
+	require.Contains(t, s, "<string>com.github.vicentereig.whatsapp-cli</string>")
+	require.Contains(t, s, "<string>/Users/me/R&amp;D</string>")
+	require.Contains(t, s, "<key>KeepAlive</key>\n\t<true/>")
+	require.Contains(t, s, "<key>RunAtLoad</key>\n\t<true/>")
+	require.Contains(t, s, "<integer>30</integer>")
+	require.Contains(t, s, "<key>StandardErrorPath</key>\n\t<string>/Users/me/Library/Logs/whatsapp-cli.log</string>")
+}
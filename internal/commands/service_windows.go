@@ -0,0 +1,204 @@
+//go:build windows
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/user"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// windowsServiceManager installs a Windows service. The service manager
+// needs an Administrator prompt, and the service starts at boot. It runs as
+// the installing user rather than LocalSystem, so sync has no more access
+// than the user who owns the store.
+type windowsServiceManager struct{}
+
+func newWindowsServiceManager() (serviceManager, error) {
+	return windowsServiceManager{}, nil
+}
+
+func (windowsServiceManager) Install(command []string, password string) error {
+	if password == "" {
+		return errors.New("the service runs as your Windows account; pass --password or set WHATSAPP_CLI_SERVICE_PASSWORD")
+	}
+	account, err := user.Current()
+	if err != nil {
+		return err
+	}
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connecting to the service manager (run as Administrator): %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(ServiceName)
+	if err == nil {
+		// Reinstalling: point the existing service at the new command.
+		cfg, err := s.Config()
+		if err != nil {
+			s.Close()
+			return err
+		}
+		cfg.BinaryPathName = windowsCommandLine(command)
+		cfg.ServiceStartName = account.Username
+		cfg.Password = password
+		if err := s.UpdateConfig(cfg); err != nil {
+			s.Close()
+			return err
+		}
+		stopWindowsService(s)
+	} else {
+		s, err = m.CreateService(ServiceName, command[0], mgr.Config{
+			DisplayName:      "WhatsApp CLI sync",
+			Description:      "Keeps the whatsapp-cli message store in sync.",
+			StartType:        mgr.StartAutomatic,
+			DelayedAutoStart: true,
+			ServiceStartName: account.Username,
+			Password:         password,
+		}, command[1:]...)
+		if err != nil {
+			return err
+		}
+	}
+	defer s.Close()
+
+	restart := mgr.RecoveryAction{Type: mgr.ServiceRestart, Delay: serviceRestartDelay}
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{restart, restart, restart}, uint32((24 * time.Hour).Seconds())); err != nil {
+		return err
+	}
+	// Sync exits with an error code rather than crashing when it stops on
+	// its own; count that as a failure so it is restarted too.
+	if err := s.SetRecoveryActionsOnNonCrashFailures(true); err != nil {
+		return err
+	}
+	if err := s.Start(); err != nil {
+		if errors.Is(err, windows.ERROR_SERVICE_LOGON_FAILED) {
+			return fmt.Errorf("%s could not log on as a service: check the password, and grant the account \"Log on as a service\" in secpol.msc if it lacks it: %w", account.Username, err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (windowsServiceManager) Uninstall() error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connecting to the service manager (run as Administrator): %w", err)
+	}
+	defer m.Disconnect()
+	s, err := m.OpenService(ServiceName)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	stopWindowsService(s)
+	return s.Delete()
+}
+
+func (windowsServiceManager) Status() (ServiceState, error) {
+	state := ServiceState{Manager: "windows", Name: ServiceName}
+	m, err := mgr.Connect()
+	if err != nil {
+		return state, fmt.Errorf("connecting to the service manager: %w", err)
+	}
+	defer m.Disconnect()
+	s, err := m.OpenService(ServiceName)
+	if err != nil {
+		if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+			return state, nil
+		}
+		return state, err
+	}
+	defer s.Close()
+	state.Installed = true
+	status, err := s.Query()
+	if err != nil {
+		return state, err
+	}
+	state.Running = status.State == svc.Running
+	return state, nil
+}
+
+// stopWindowsService stops s and waits briefly for it to exit. Errors are
+// ignored: the service may not be running.
+func stopWindowsService(s *mgr.Service) {
+	status, err := s.Control(svc.Stop)
+	for deadline := time.Now().Add(serviceRestartDelay); err == nil && status.State != svc.Stopped && time.Now().Before(deadline); {
+		time.Sleep(300 * time.Millisecond)
+		status, err = s.Query()
+	}
+}
+
+// windowsCommandLine quotes command as CreateService does.
+func windowsCommandLine(command []string) string {
+	line := ""
+	for i, arg := range command {
+		if i > 0 {
+			line += " "
+		}
+		line += syscall.EscapeArg(arg)
+	}
+	return line
+}
+
+// serviceHost answers the Windows service manager while a command runs,
+// cancelling the command when the service is stopped.
+type serviceHost struct {
+	cancel    context.CancelFunc
+	requested atomic.Bool
+	finished  chan struct{}
+	exited    chan struct{}
+}
+
+// StartServiceHost connects to the Windows service manager when the process
+// was started as a service, and returns a function to call once the
+// command has finished. It returns nil otherwise.
+func StartServiceHost(cancel context.CancelFunc) (finish func()) {
+	if ok, err := svc.IsWindowsService(); err != nil || !ok {
+		return nil
+	}
+	h := &serviceHost{cancel: cancel, finished: make(chan struct{}), exited: make(chan struct{})}
+	go func() {
+		defer close(h.exited)
+		if err := svc.Run(ServiceName, h); err != nil {
+			cancel()
+		}
+	}()
+	return func() {
+		close(h.finished)
+		<-h.exited
+	}
+}
+
+func (h *serviceHost) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				h.requested.Store(true)
+				status <- svc.Status{State: svc.StopPending}
+				h.cancel()
+			}
+		case <-h.finished:
+			if h.requested.Load() {
+				return false, 0
+			}
+			// Stopped without being asked to: report a failure so the
+			// recovery actions restart it.
+			return true, 1
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	waTypes "go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// asOfLayouts are the formats accepted by --as-of, most specific first.
+var asOfLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+}
+
+// parseAsOf parses a snapshot cutoff. Timestamps without a zone are local
+// time. A bare date ("2023-12-31") means the end of that day, so the
+// snapshot includes everything that happened on it. The result is always in
+// local time, as stored timestamps are compared with it as text.
+func parseAsOf(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if day, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return day.AddDate(0, 0, 1), nil
+	}
+	for _, layout := range asOfLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t.In(time.Local), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --as-of %q (use e.g. 2023-12-31 or 2023-12-31T18:00:00Z)", value)
+}
+
+// SnapshotParticipant is a group member at the snapshot cutoff.
+type SnapshotParticipant struct {
+	JID     string    `json:"jid"`
+	IsAdmin bool      `json:"is_admin"`
+	Since   time.Time `json:"since"`
+}
+
+// ChatSnapshot is a chat as it existed at a point in time.
+type ChatSnapshot struct {
+	ChatJID           string                `json:"chat_jid"`
+	Name              string                `json:"name,omitempty"`
+	AsOf              time.Time             `json:"as_of"`
+	LastMessage       *store.Message        `json:"last_message"`
+	IsGroup           bool                  `json:"is_group"`
+	ParticipantsKnown bool                  `json:"participants_known,omitempty"`
+	Participants      []SnapshotParticipant `json:"participants,omitempty"`
+	ParticipantEvents int                   `json:"participant_events,omitempty"`
+}
+
+// ChatSnapshot returns the last message before asOf and, for groups, the
+// member list replayed from recorded membership changes. The name is the
+// current one; name history is not tracked.
+func (a *App) ChatSnapshot(chatJID, asOf string) string {
+	if err := a.checkChatAllowed(chatJID); err != nil {
+		return output.Error(err)
+	}
+	cutoff, err := parseAsOf(asOf)
+	if err != nil {
+		return output.Error(err)
+	}
+
+	snapshot := ChatSnapshot{
+		ChatJID: chatJID,
+		AsOf:    cutoff,
+		IsGroup: strings.HasSuffix(chatJID, "@g.us"),
+	}
+	if snapshot.Name, err = a.store.GetChatName(chatJID); err != nil {
+		return output.Error(err)
+	}
+
+	messages, err := a.store.ListMessages(store.ListMessagesParams{
+		ChatJID: &chatJID,
+		Before:  &cutoff,
+		Limit:   1,
+	})
+	if err != nil {
+		return output.Error(err)
+	}
+	if len(messages) > 0 {
+		snapshot.LastMessage = &messages[0]
+	}
+
+	if snapshot.IsGroup {
+		history, err := a.store.ListParticipantEvents(chatJID, cutoff)
+		if err != nil {
+			return output.Error(err)
+		}
+		snapshot.ParticipantEvents = len(history)
+		snapshot.ParticipantsKnown = len(history) > 0
+		snapshot.Participants = replayParticipants(history)
+	}
+
+	return output.Success(snapshot)
+}
+
+// replayParticipants applies membership changes in order and returns the
+// resulting members sorted by JID.
+func replayParticipants(history []store.ParticipantEvent) []SnapshotParticipant {
+	members := map[string]*SnapshotParticipant{}
+	join := func(e store.ParticipantEvent) *SnapshotParticipant {
+		m, ok := members[e.ParticipantJID]
+		if !ok {
+			m = &SnapshotParticipant{JID: e.ParticipantJID, Since: e.Timestamp}
+			members[e.ParticipantJID] = m
+		}
+		return m
+	}
+
+	for _, e := range history {
+		switch e.Action {
+		case store.ParticipantAdd:
+			join(e)
+		case store.ParticipantRemove:
+			delete(members, e.ParticipantJID)
+		case store.ParticipantPromote:
+			// Only members can be promoted, so a promotion implies membership.
+			join(e).IsAdmin = true
+		case store.ParticipantDemote:
+			if m, ok := members[e.ParticipantJID]; ok {
+				m.IsAdmin = false
+			}
+		}
+	}
+
+	out := make([]SnapshotParticipant, 0, len(members))
+	for _, m := range members {
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].JID < out[j].JID })
+	return out
+}
+
+// groupInfoParticipantEvents converts a live group change notification
+// into membership events.
+func groupInfoParticipantEvents(v *events.GroupInfo) []store.ParticipantEvent {
+	at := v.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	chatJID := v.JID.String()
+
+	var out []store.ParticipantEvent
+	add := func(action string, jids []string) {
+		for _, jid := range jids {
+			out = append(out, store.ParticipantEvent{ChatJID: chatJID, ParticipantJID: jid, Action: action, Timestamp: at})
+		}
+	}
+	add(store.ParticipantAdd, jidStrings(v.Join))
+	add(store.ParticipantRemove, jidStrings(v.Leave))
+	add(store.ParticipantPromote, jidStrings(v.Promote))
+	add(store.ParticipantDemote, jidStrings(v.Demote))
+	return out
+}
+
+func jidStrings(jids []waTypes.JID) []string {
+	out := make([]string, len(jids))
+	for i, jid := range jids {
+		out[i] = jid.String()
+	}
+	return out
+}
+
+// joinedGroupParticipantEvents records the member list received when this
+// account joins or creates a group.
+func joinedGroupParticipantEvents(v *events.JoinedGroup, now time.Time) []store.ParticipantEvent {
+	at := now
+	if v.Type == "new" && !v.GroupCreated.IsZero() {
+		at = v.GroupCreated
+	}
+	chatJID := v.JID.String()
+
+	var out []store.ParticipantEvent
+	for _, p := range v.Participants {
+		jid := p.JID.String()
+		out = append(out, store.ParticipantEvent{ChatJID: chatJID, ParticipantJID: jid, Action: store.ParticipantAdd, Timestamp: at})
+		if p.IsAdmin || p.IsSuperAdmin {
+			out = append(out, store.ParticipantEvent{ChatJID: chatJID, ParticipantJID: jid, Action: store.ParticipantPromote, Timestamp: at})
+		}
+	}
+	return out
+}
+
+// stubParticipantActions maps history sync system messages to membership
+// changes.
+var stubParticipantActions = map[waWeb.WebMessageInfo_StubType]string{
+	waWeb.WebMessageInfo_GROUP_PARTICIPANT_ADD:                           store.ParticipantAdd,
+	waWeb.WebMessageInfo_GROUP_PARTICIPANT_INVITE:                        store.ParticipantAdd,
+	waWeb.WebMessageInfo_GROUP_PARTICIPANT_ADD_REQUEST_JOIN:              store.ParticipantAdd,
+	waWeb.WebMessageInfo_GROUP_PARTICIPANT_ACCEPT:                        store.ParticipantAdd,
+	waWeb.WebMessageInfo_GROUP_PARTICIPANT_LINKED_GROUP_JOIN:             store.ParticipantAdd,
+	waWeb.WebMessageInfo_GROUP_PARTICIPANT_JOINED_GROUP_AND_PARENT_GROUP: store.ParticipantAdd,
+	waWeb.WebMessageInfo_GROUP_PARTICIPANT_REMOVE:                        store.ParticipantRemove,
+	waWeb.WebMessageInfo_GROUP_PARTICIPANT_LEAVE:                         store.ParticipantRemove,
+	waWeb.WebMessageInfo_GROUP_PARTICIPANT_PROMOTE:                       store.ParticipantPromote,
+	waWeb.WebMessageInfo_GROUP_PARTICIPANT_DEMOTE:                        store.ParticipantDemote,
+}
+
+// stubParticipantEvents extracts membership changes from a history sync
+// system message. The affected JIDs are the stub parameters; a leave with
+// no parameters refers to the message's own participant.
+func stubParticipantEvents(chatJID string, msg *waWeb.WebMessageInfo) []store.ParticipantEvent {
+	action, ok := stubParticipantActions[msg.GetMessageStubType()]
+	if !ok {
+		return nil
+	}
+	jids := msg.GetMessageStubParameters()
+	if len(jids) == 0 && msg.GetKey().GetParticipant() != "" {
+		jids = []string{msg.GetKey().GetParticipant()}
+	}
+	at := time.Unix(int64(msg.GetMessageTimestamp()), 0)
+
+	out := make([]store.ParticipantEvent, 0, len(jids))
+	for _, jid := range jids {
+		out = append(out, store.ParticipantEvent{ChatJID: chatJID, ParticipantJID: jid, Action: action, Timestamp: at})
+	}
+	return out
+}
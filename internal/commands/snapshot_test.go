@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestParseAsOf_DateMeansEndOfDay verifies a bare date includes the whole
+// day while full timestamps are used as given.
+func TestParseAsOf_DateMeansEndOfDay(t *testing.T) {
+	got, err := parseAsOf("2023-12-31")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local), got)
+
+	got, err = parseAsOf("2023-12-31T18:30:00Z")
+	require.NoError(t, err)
+	require.True(t, got.Equal(time.Date(2023, 12, 31, 18, 30, 0, 0, time.UTC)))
+
+	_, err = parseAsOf("last tuesday")
+	require.Error(t, err)
+}
+
+// TestParseAsOf_ZonedTimestampInLocalTime verifies a UTC cutoff is converted
+// to local time, so it compares correctly with locally stored timestamps.
+func TestParseAsOf_ZonedTimestampInLocalTime(t *testing.T) {
+	orig := time.Local
+	time.Local = time.FixedZone("UTC+2", 2*60*60)
+	t.Cleanup(func() { time.Local = orig })
+
+	got, err := parseAsOf("2023-12-31T18:30:00Z")
+	require.NoError(t, err)
+	require.Equal(t, time.Local, got.Location())
+	require.Equal(t, "2023-12-31 20:30:00+02:00", got.Format("2006-01-02 15:04:05-07:00"))
+}
+
+// TestReplayParticipants_AppliesChangesInOrder verifies joins, leaves and
+// admin changes are replayed into the member list at the cutoff.
+func TestReplayParticipants_AppliesChangesInOrder(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	history := []store.ParticipantEvent{
+		{ParticipantJID: "a@s.whatsapp.net", Action: store.ParticipantAdd, Timestamp: t0},
+		{ParticipantJID: "b@s.whatsapp.net", Action: store.ParticipantAdd, Timestamp: t0},
+		{ParticipantJID: "a@s.whatsapp.net", Action: store.ParticipantPromote, Timestamp: t0.Add(time.Hour)},
+		{ParticipantJID: "b@s.whatsapp.net", Action: store.ParticipantRemove, Timestamp: t0.Add(2 * time.Hour)},
+		{ParticipantJID: "c@s.whatsapp.net", Action: store.ParticipantAdd, Timestamp: t0.Add(3 * time.Hour)},
+	}
+
+	members := replayParticipants(history)
+	require.Equal(t, []SnapshotParticipant{
+		{JID: "a@s.whatsapp.net", IsAdmin: true, Since: t0},
+		{JID: "c@s.whatsapp.net", Since: t0.Add(3 * time.Hour)},
+	}, members)
+}
+
+// TestStubParticipantEvents_MapsHistorySystemMessages verifies history sync
+// membership stubs become participant events.
+func TestStubParticipantEvents_MapsHistorySystemMessages(t *testing.T) {
+	msg := &waWeb.WebMessageInfo{
+		Key:                   &waCommon.MessageKey{ID: proto.String("s1"), Participant: proto.String("admin@s.whatsapp.net")},
+		MessageTimestamp:      proto.Uint64(1700000000),
+		MessageStubType:       waWeb.WebMessageInfo_GROUP_PARTICIPANT_ADD.Enum(),
+		MessageStubParameters: []string{"a@s.whatsapp.net", "b@s.whatsapp.net"},
+	}
+	changes := stubParticipantEvents("team@g.us", msg)
+	require.Len(t, changes, 2)
+	require.Equal(t, store.ParticipantAdd, changes[0].Action)
+	require.Equal(t, "b@s.whatsapp.net", changes[1].ParticipantJID)
+
+	leave := &waWeb.WebMessageInfo{
+		Key:              &waCommon.MessageKey{ID: proto.String("s2"), Participant: proto.String("a@s.whatsapp.net")},
+		MessageTimestamp: proto.Uint64(1700000100),
+		MessageStubType:  waWeb.WebMessageInfo_GROUP_PARTICIPANT_LEAVE.Enum(),
+	}
+	changes = stubParticipantEvents("team@g.us", leave)
+	require.Len(t, changes, 1)
+	require.Equal(t, store.ParticipantRemove, changes[0].Action)
+	require.Equal(t, "a@s.whatsapp.net", changes[0].ParticipantJID)
+
+	require.Empty(t, stubParticipantEvents("team@g.us", &waWeb.WebMessageInfo{}))
+}
+
+// TestChatSnapshot_UsesCutoff verifies the snapshot queries messages and
+// membership changes strictly before the cutoff.
+func TestChatSnapshot_UsesCutoff(t *testing.T) {
+	chatJID := "team@g.us"
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	var gotBefore *time.Time
+	mockStore := &MockMessageStore{
+		GetChatNameFunc: func(jid string) (string, error) { return "Team", nil },
+		ListMessagesFunc: func(params store.ListMessagesParams) ([]store.Message, error) {
+			gotBefore = params.Before
+			return []store.Message{{ID: "m1", ChatJID: chatJID, Content: "happy new year"}}, nil
+		},
+		ListParticipantEventsFunc: func(jid string, before time.Time) ([]store.ParticipantEvent, error) {
+			require.Equal(t, cutoff, before)
+			return []store.ParticipantEvent{
+				{ChatJID: jid, ParticipantJID: "a@s.whatsapp.net", Action: store.ParticipantAdd, Timestamp: cutoff.Add(-time.Hour)},
+			}, nil
+		},
+	}
+	app := NewAppWithDeps(&MockWAClient{}, mockStore, t.TempDir(), "test")
+
+	resp := parseResponse(t, app.ChatSnapshot(chatJID, "2023-12-31"))
+	require.True(t, resp.Success)
+	require.NotNil(t, gotBefore)
+	require.Equal(t, cutoff, *gotBefore)
+
+	var snapshot ChatSnapshot
+	require.NoError(t, json.Unmarshal(resp.Data, &snapshot))
+	require.Equal(t, "Team", snapshot.Name)
+	require.True(t, snapshot.IsGroup)
+	require.True(t, snapshot.ParticipantsKnown)
+	require.NotNil(t, snapshot.LastMessage)
+	require.Equal(t, "m1", snapshot.LastMessage.ID)
+	require.Len(t, snapshot.Participants, 1)
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// MigrateStoreResult is returned by `store migrate`.
+type MigrateStoreResult struct {
+	From           string `json:"from"`
+	To             string `json:"to"`
+	Copied         bool   `json:"copied"`
+	MediaUpdated   int64  `json:"media_paths_updated"`
+	AvatarsUpdated int64  `json:"avatar_paths_updated"`
+}
+
+// MigrateStore moves a store directory (by default the legacy ./store) to
+// a new location and repoints downloaded media and group photos at it. It
+// runs without an App because opening the destination would create an
+// empty store there.
+func MigrateStore(from, to string) string {
+	absFrom, err := filepath.Abs(from)
+	if err != nil {
+		return output.Error(fmt.Errorf("invalid source path: %w", err))
+	}
+	absTo, err := filepath.Abs(to)
+	if err != nil {
+		return output.Error(fmt.Errorf("invalid destination path: %w", err))
+	}
+	if absFrom == absTo {
+		return output.Error(fmt.Errorf("store is already at %s", absTo))
+	}
+	if !config.HasStore(absFrom) {
+		return output.Error(fmt.Errorf("no store found at %s", absFrom))
+	}
+	if running, err := readSyncState(absFrom, time.Now()); err != nil {
+		return output.Error(err)
+	} else if running != nil {
+		return output.Error(fmt.Errorf("sync is running on %s (pid %d); stop it before migrating", absFrom, running.PID))
+	}
+	if config.HasStore(absTo) {
+		return output.Error(fmt.Errorf("%s already contains a store; move or remove it first", absTo))
+	}
+	if entries, err := os.ReadDir(absTo); err == nil {
+		if len(entries) > 0 {
+			return output.Error(fmt.Errorf("%s is not empty", absTo))
+		}
+		if err := os.Remove(absTo); err != nil {
+			return output.Error(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(absTo), 0700); err != nil {
+		return output.Error(err)
+	}
+
+	result := MigrateStoreResult{From: absFrom, To: absTo}
+	if err := os.Rename(absFrom, absTo); err != nil {
+		// Renames fail across filesystems; only then fall back to copy and
+		// delete. Any other failure leaves the store where it is.
+		if !isCrossDevice(err) {
+			return output.Error(err)
+		}
+		fmt.Fprintf(output.Stderr, "📦 Copying %s to %s...\n", absFrom, absTo)
+		if err := copyDir(absFrom, absTo); err != nil {
+			os.RemoveAll(absTo)
+			return output.Error(fmt.Errorf("copying store: %w", err))
+		}
+		if err := os.RemoveAll(absFrom); err != nil {
+			return output.Error(fmt.Errorf("store copied to %s but %s could not be removed: %w", absTo, absFrom, err))
+		}
+		result.Copied = true
+	}
+
+	st, err := store.NewMessageStore(filepath.Join(absTo, "messages.db"))
+	if err != nil {
+		return output.Error(fmt.Errorf("store moved to %s but could not be opened: %w", absTo, err))
+	}
+	defer st.Close()
+	result.MediaUpdated, err = st.RelocateMedia(filepath.Join(absFrom, "media"), filepath.Join(absTo, "media"))
+	if err != nil {
+		return output.Error(fmt.Errorf("store moved to %s but media paths were not updated: %w", absTo, err))
+	}
+	result.AvatarsUpdated, err = st.RelocateAvatars(filepath.Join(absFrom, "avatars"), filepath.Join(absTo, "avatars"))
+	if err != nil {
+		return output.Error(fmt.Errorf("store moved to %s but group photo paths were not updated: %w", absTo, err))
+	}
+	return output.Success(result)
+}
+
+// copyDir recursively copies src to dst, keeping file permissions.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFile(path, target, info.Mode().Perm())
+	})
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vicentereig/whatsapp-cli/internal/store"
+)
+
+// TestMigrateStore_MovesStoreAndMedia verifies the store directory is moved
+// and downloaded media and group photo paths follow it.
+func TestMigrateStore_MovesStoreAndMedia(t *testing.T) {
+	from := filepath.Join(t.TempDir(), "store")
+	to := filepath.Join(t.TempDir(), "data", "whatsapp-cli")
+	require.NoError(t, os.MkdirAll(filepath.Join(from, "media"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(from, "whatsapp.db"), []byte("session"), 0600))
+
+	st, err := store.NewMessageStore(filepath.Join(from, "messages.db"))
+	require.NoError(t, err)
+	now := time.Now()
+	chatJID := "1234@s.whatsapp.net"
+	require.NoError(t, st.StoreChat(chatJID, "John", now))
+	require.NoError(t, st.StoreMessage("m1", chatJID, "1234", "", now, false, "image", "", "", "/a", "image/jpeg", []byte{1}, nil, nil, 10))
+	require.NoError(t, st.MarkMediaDownloaded("m1", chatJID, filepath.Join(from, "media", "m1.jpg"), now))
+	groupJID := "120363000000000000@g.us"
+	require.NoError(t, st.StoreChat(groupJID, "Team", now))
+	require.NoError(t, st.StoreGroupMetadata(store.GroupMetadata{
+		ChatJID: groupJID, AvatarID: "a1", AvatarPath: filepath.Join(from, "avatars", "120363000000000000@g.us.jpg"),
+	}, now))
+	require.NoError(t, st.Close())
+
+	resp := parseResponse(t, MigrateStore(from, to))
+	require.True(t, resp.Success, "error: %v", resp.Error)
+
+	var result MigrateStoreResult
+	require.NoError(t, json.Unmarshal(resp.Data, &result))
+	require.Equal(t, to, result.To)
+	require.EqualValues(t, 1, result.MediaUpdated)
+	require.EqualValues(t, 1, result.AvatarsUpdated)
+
+	require.NoDirExists(t, from)
+	session, err := os.ReadFile(filepath.Join(to, "whatsapp.db"))
+	require.NoError(t, err)
+	require.Equal(t, "session", string(session))
+
+	st, err = store.NewMessageStore(filepath.Join(to, "messages.db"))
+	require.NoError(t, err)
+	defer st.Close()
+	info, err := st.GetMessageForDownload("m1", &chatJID)
+	require.NoError(t, err)
+	require.NotNil(t, info.LocalPath)
+	require.Equal(t, filepath.Join(to, "media", "m1.jpg"), *info.LocalPath)
+
+	groups, err := st.ListGroupsDueForRefresh(now.Add(time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Equal(t, filepath.Join(to, "avatars", "120363000000000000@g.us.jpg"), groups[0].AvatarPath)
+}
+
+// TestMigrateStore_RefusesToOverwrite verifies an existing store at the
+// destination is never replaced.
+func TestMigrateStore_RefusesToOverwrite(t *testing.T) {
+	from := t.TempDir()
+	to := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(from, "whatsapp.db"), nil, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(to, "messages.db"), nil, 0600))
+
+	resp := parseResponse(t, MigrateStore(from, to))
+	require.False(t, resp.Success)
+	require.Contains(t, *resp.Error, "already contains a store")
+	require.FileExists(t, filepath.Join(from, "whatsapp.db"))
+}
+
+// TestMigrateStore_RefusesWhileSyncing verifies a store is not moved out
+// from under a running sync.
+func TestMigrateStore_RefusesWhileSyncing(t *testing.T) {
+	from := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(from, "whatsapp.db"), nil, 0600))
+	writeSyncState(t, from, SyncState{PID: 4242, HeartbeatAt: time.Now()})
+
+	resp := parseResponse(t, MigrateStore(from, filepath.Join(t.TempDir(), "moved")))
+	require.False(t, resp.Success)
+	require.Contains(t, *resp.Error, "sync is running")
+	require.FileExists(t, filepath.Join(from, "whatsapp.db"))
+}
+
+// TestIsCrossDevice verifies only cross-filesystem renames fall back to
+// copy and delete; other rename errors must leave the store alone.
+func TestIsCrossDevice(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows reports cross-volume renames with its own error")
+	}
+	require.True(t, isCrossDevice(&os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.EXDEV}))
+	require.False(t, isCrossDevice(&os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.EACCES}))
+	require.False(t, isCrossDevice(&os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.ENOTEMPTY}))
+}
+
+// TestMigrateStore_RequiresSourceStore verifies a directory without a
+// store is rejected.
+func TestMigrateStore_RequiresSourceStore(t *testing.T) {
+	resp := parseResponse(t, MigrateStore(t.TempDir(), t.TempDir()))
+	require.False(t, resp.Success)
+	require.Contains(t, *resp.Error, "no store found")
+}
+
+// TestCopyDir verifies the cross-filesystem fallback copies nested files.
+func TestCopyDir(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "copy")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "media", "chat"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "media", "chat", "a.jpg"), []byte("jpeg"), 0600))
+
+	require.NoError(t, copyDir(src, dst))
+	data, err := os.ReadFile(filepath.Join(dst, "media", "chat", "a.jpg"))
+	require.NoError(t, err)
+	require.Equal(t, "jpeg", string(data))
+}
@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+)
+
+// throughputWindow is how far back the rolling throughput looks. Long
+// enough to smooth out bursty history chunks, short enough to reflect
+// slowdowns quickly.
+const throughputWindow = 30 * time.Second
+
+type progressSample struct {
+	at            time.Time
+	conversations int
+	messages      int
+}
+
+// syncProgress tracks history sync throughput and estimates completion from
+// the progress percentage WhatsApp attaches to each history chunk.
+type syncProgress struct {
+	started       time.Time
+	conversations int
+	messages      int
+	percent       uint32
+	samples       []progressSample
+}
+
+// SetPercent records the server-reported completion of the history sync.
+// The first call starts the clock.
+func (p *syncProgress) SetPercent(now time.Time, percent uint32) {
+	p.start(now)
+	if percent > 100 {
+		percent = 100
+	}
+	if percent > p.percent {
+		p.percent = percent
+	}
+}
+
+// Record adds processed work and keeps only samples inside the window.
+func (p *syncProgress) Record(now time.Time, conversations, messages int) {
+	p.start(now)
+	p.conversations += conversations
+	p.messages += messages
+	p.samples = append(p.samples, progressSample{at: now, conversations: p.conversations, messages: p.messages})
+
+	cutoff := now.Add(-throughputWindow)
+	drop := 0
+	for drop < len(p.samples)-1 && p.samples[drop].at.Before(cutoff) {
+		drop++
+	}
+	p.samples = p.samples[drop:]
+}
+
+func (p *syncProgress) start(now time.Time) {
+	if p.started.IsZero() {
+		p.started = now
+		p.samples = append(p.samples, progressSample{at: now})
+	}
+}
+
+// Rates returns conversations and messages per second over the rolling
+// window.
+func (p *syncProgress) Rates(now time.Time) (convPerSec, msgPerSec float64) {
+	if len(p.samples) == 0 {
+		return 0, 0
+	}
+	first := p.samples[0]
+	elapsed := now.Sub(first.at).Seconds()
+	if elapsed <= 0 {
+		return 0, 0
+	}
+	return float64(p.conversations-first.conversations) / elapsed,
+		float64(p.messages-first.messages) / elapsed
+}
+
+// ETA extrapolates the remaining time from elapsed time and the reported
+// percentage. ok is false until WhatsApp has reported any progress.
+func (p *syncProgress) ETA(now time.Time) (eta time.Duration, ok bool) {
+	if p.percent == 0 || p.started.IsZero() {
+		return 0, false
+	}
+	if p.percent >= 100 {
+		return 0, true
+	}
+	elapsed := now.Sub(p.started)
+	return time.Duration(float64(elapsed) * float64(100-p.percent) / float64(p.percent)), true
+}
+
+// Line renders the progress line printed to stderr during history sync.
+func (p *syncProgress) Line(now time.Time) string {
+	convRate, msgRate := p.Rates(now)
+	line := fmt.Sprintf("📜 History: %d conversations, %d messages (%.1f conv/s, %.0f msg/s)",
+		p.conversations, p.messages, convRate, msgRate)
+	if eta, ok := p.ETA(now); ok {
+		line += fmt.Sprintf(" · %d%% · ETA %s", p.percent, formatETA(eta))
+	}
+	return line
+}
+
+func formatETA(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "<1m"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Round(time.Minute).Minutes()))
+	default:
+		d = d.Round(time.Minute)
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
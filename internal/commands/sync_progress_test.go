@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestSyncProgress_RatesUseRollingWindow verifies old samples stop
+// influencing throughput once they fall out of the window.
+func TestSyncProgress_RatesUseRollingWindow(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := &syncProgress{}
+
+	// A fast burst at the beginning...
+	p.Record(start, 0, 0)
+	p.Record(start.Add(10*time.Second), 10, 10000)
+	conv, msg := p.Rates(start.Add(10 * time.Second))
+	require.InDelta(t, 1.0, conv, 0.001)
+	require.InDelta(t, 1000.0, msg, 0.001)
+
+	// ...followed by a slow stretch: the burst ages out of the window.
+	for i := 1; i <= 6; i++ {
+		p.Record(start.Add(10*time.Second+time.Duration(i)*10*time.Second), 1, 100)
+	}
+	now := start.Add(70 * time.Second)
+	conv, msg = p.Rates(now)
+	require.InDelta(t, 0.1, conv, 0.02)
+	require.InDelta(t, 10.0, msg, 2)
+}
+
+// TestSyncProgress_ETAFromPercent verifies the estimate extrapolates the
+// elapsed time by the reported percentage.
+func TestSyncProgress_ETAFromPercent(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := &syncProgress{}
+
+	_, ok := p.ETA(start)
+	require.False(t, ok, "no ETA before any progress is reported")
+
+	p.SetPercent(start, 0)
+	p.SetPercent(start.Add(10*time.Minute), 25)
+	eta, ok := p.ETA(start.Add(10 * time.Minute))
+	require.True(t, ok)
+	require.Equal(t, 30*time.Minute, eta)
+
+	// Percentages never go backwards, even if chunks arrive out of order.
+	p.SetPercent(start.Add(11*time.Minute), 20)
+	require.Equal(t, uint32(25), p.percent)
+}
+
+func TestFormatETA(t *testing.T) {
+	require.Equal(t, "<1m", formatETA(30*time.Second))
+	require.Equal(t, "12m", formatETA(12*time.Minute+10*time.Second))
+	require.Equal(t, "2h05m", formatETA(2*time.Hour+5*time.Minute))
+}
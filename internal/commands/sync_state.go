@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/output"
+)
+
+// SyncStateFile is written to the store directory by a running sync so
+// other commands can tell how far it has flushed events to messages.db.
+const SyncStateFile = "sync-state.json"
+
+const (
+	// syncStateInterval is how often a running sync refreshes the file.
+	syncStateInterval = 250 * time.Millisecond
+	// syncStateStale is how old a heartbeat may be before the sync that
+	// wrote it is presumed gone.
+	syncStateStale = 5 * time.Second
+	// ConsistentTimeout bounds how long --consistent waits for sync.
+	ConsistentTimeout = 30 * time.Second
+)
+
+// SyncState is the content of SyncStateFile.
+type SyncState struct {
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+	// HeartbeatAt is refreshed every syncStateInterval while sync runs.
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+	// FlushedAt is the latest time at which every event received so far
+	// had been handled; messages that arrived before it are queryable.
+	FlushedAt time.Time `json:"flushed_at"`
+}
+
+// syncStateWriter tracks in-flight events and publishes SyncState.
+type syncStateWriter struct {
+	path string
+	now  func() time.Time
+
+	mu      sync.Mutex
+	state   SyncState
+	running int
+
+	wg sync.WaitGroup
+}
+
+func newSyncStateWriter(storeDir string) *syncStateWriter {
+	return &syncStateWriter{path: filepath.Join(storeDir, SyncStateFile), now: time.Now}
+}
+
+// Begin marks an event as being handled.
+func (w *syncStateWriter) Begin() {
+	w.mu.Lock()
+	w.running++
+	w.mu.Unlock()
+}
+
+// Done marks an event as handled.
+func (w *syncStateWriter) Done() {
+	w.mu.Lock()
+	w.running--
+	if w.running == 0 {
+		w.state.FlushedAt = w.now()
+	}
+	w.mu.Unlock()
+}
+
+// Start publishes the state until ctx is cancelled, then removes the file
+// so later commands don't wait for a sync that has stopped.
+func (w *syncStateWriter) Start(ctx context.Context) {
+	w.mu.Lock()
+	w.state.PID = os.Getpid()
+	w.state.StartedAt = w.now()
+	w.mu.Unlock()
+
+	if err := w.write(); err != nil {
+		fmt.Fprintf(output.Stderr, "⚠️  Could not write %s, --consistent will not wait for this sync: %v\n", SyncStateFile, err)
+	}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(syncStateInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				os.Remove(w.path)
+				return
+			case <-ticker.C:
+				w.write()
+			}
+		}
+	}()
+}
+
+// Wait blocks until the file has been removed after ctx is cancelled.
+func (w *syncStateWriter) Wait() {
+	w.wg.Wait()
+}
+
+// write refreshes the heartbeat and, when no event is in flight, the flush
+// time, replacing the file atomically so readers never see a partial one.
+func (w *syncStateWriter) write() error {
+	w.mu.Lock()
+	now := w.now()
+	w.state.HeartbeatAt = now
+	if w.running == 0 {
+		w.state.FlushedAt = now
+	}
+	data, err := json.Marshal(w.state)
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp := w.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, w.path)
+}
+
+// readSyncState returns the state of the running sync, or nil when none is
+// running.
+func readSyncState(storeDir string, now time.Time) (*SyncState, error) {
+	data, err := os.ReadFile(filepath.Join(storeDir, SyncStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state SyncState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", SyncStateFile, err)
+	}
+	// A sync that crashed leaves its file behind with a frozen heartbeat.
+	if now.Sub(state.HeartbeatAt) > syncStateStale {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// WaitForSync blocks until a running sync has flushed every event it
+// received before since, so a query that follows a send or an incoming
+// message sees it. Without a running sync it returns at once: the store
+// is as current as it can be.
+func (a *App) WaitForSync(ctx context.Context, since time.Time, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(syncStateInterval / 5)
+	defer ticker.Stop()
+	for {
+		state, err := readSyncState(a.storeDir, time.Now())
+		if err != nil {
+			return err
+		}
+		if state == nil || !state.FlushedAt.Before(since) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("sync (pid %d) has not caught up after %s; it may still be processing history", state.PID, timeout)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeSyncState(t *testing.T, dir string, state SyncState) {
+	t.Helper()
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+	// Replace the file like the writer does, so a polling reader never
+	// sees it half written.
+	path := filepath.Join(dir, SyncStateFile)
+	require.NoError(t, os.WriteFile(path+".tmp", data, 0600))
+	require.NoError(t, os.Rename(path+".tmp", path))
+}
+
+// TestSyncStateWriter_HoldsFlushWhileBusy verifies the flush time only
+// advances when no event is being handled.
+func TestSyncStateWriter_HoldsFlushWhileBusy(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	w := newSyncStateWriter(dir)
+	w.now = func() time.Time { return clock }
+
+	require.NoError(t, w.write())
+	w.Begin()
+	clock = clock.Add(time.Second)
+	require.NoError(t, w.write())
+
+	state, err := readSyncState(dir, clock)
+	require.NoError(t, err)
+	require.Equal(t, clock, state.HeartbeatAt)
+	require.Equal(t, clock.Add(-time.Second), state.FlushedAt)
+
+	clock = clock.Add(time.Second)
+	w.Done()
+	require.NoError(t, w.write())
+	state, err = readSyncState(dir, clock)
+	require.NoError(t, err)
+	require.Equal(t, clock, state.FlushedAt)
+}
+
+// TestSyncStateWriter_RemovesFileOnStop verifies a stopped sync leaves no
+// state behind for --consistent to wait on.
+func TestSyncStateWriter_RemovesFileOnStop(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	w := newSyncStateWriter(dir)
+	w.Start(ctx)
+	require.FileExists(t, filepath.Join(dir, SyncStateFile))
+
+	cancel()
+	w.Wait()
+	require.NoFileExists(t, filepath.Join(dir, SyncStateFile))
+}
+
+// TestWaitForSync_WaitsUntilFlushed verifies --consistent returns at once
+// without a live sync, waits for a busy one, and gives up after the
+// timeout.
+func TestWaitForSync_WaitsUntilFlushed(t *testing.T) {
+	dir := t.TempDir()
+	app := NewAppWithDeps(&MockWAClient{}, &MockMessageStore{}, dir, "test")
+	ctx := context.Background()
+	start := time.Now()
+
+	// No sync running.
+	require.NoError(t, app.WaitForSync(ctx, start, time.Second))
+
+	// A crashed sync's file is ignored.
+	writeSyncState(t, dir, SyncState{PID: 1, HeartbeatAt: start.Add(-time.Minute), FlushedAt: start.Add(-time.Minute)})
+	require.NoError(t, app.WaitForSync(ctx, start, time.Second))
+
+	// A live sync still handling an event that arrived before start.
+	writeSyncState(t, dir, SyncState{PID: 1, HeartbeatAt: start, FlushedAt: start.Add(-time.Second)})
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		writeSyncState(t, dir, SyncState{PID: 1, HeartbeatAt: time.Now(), FlushedAt: time.Now()})
+	}()
+	require.NoError(t, app.WaitForSync(ctx, start, 5*time.Second))
+
+	writeSyncState(t, dir, SyncState{PID: 42, HeartbeatAt: time.Now(), FlushedAt: start.Add(-time.Second)})
+	err := app.WaitForSync(ctx, start, 100*time.Millisecond)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "pid 42")
+}
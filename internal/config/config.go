@@ -0,0 +1,232 @@
+// Package config loads optional user settings from config.json in the store
+// directory. A missing file is not an error: every field has a usable default,
+// so the CLI works out of the box and config only tunes behavior.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileName is the name of the config file inside the store directory.
+const FileName = "config.json"
+
+// Name provider identifiers accepted in NameProviders.
+const (
+	ProviderWhatsmeow = "whatsmeow"
+	ProviderStore     = "store"
+	ProviderCSV       = "csv"
+	ProviderCardDAV   = "carddav"
+)
+
+// DefaultNameProviders is the resolution order used when config.json does not
+// set name_providers. Providers that are not configured are skipped.
+var DefaultNameProviders = []string{ProviderWhatsmeow, ProviderStore, ProviderCSV, ProviderCardDAV}
+
+type Config struct {
+	// NameProviders is the order in which chat names are resolved.
+	// The JID itself is always the final fallback.
+	NameProviders []string `json:"name_providers,omitempty"`
+
+	// ContactsCSV points to a "phone,name" CSV file used by the csv provider.
+	ContactsCSV string `json:"contacts_csv,omitempty"`
+
+	CardDAV CardDAVConfig `json:"carddav,omitempty"`
+
+	// AutoMarkRead sends read receipts for incoming messages as they sync
+	// and before replying with send. Off by default for privacy.
+	AutoMarkRead bool `json:"auto_mark_read,omitempty"`
+
+	// MediaQuota caps the size of auto-downloaded media (e.g. "20GB").
+	MediaQuota string `json:"media_quota,omitempty"`
+	// MediaMinFree is the free disk space auto-downloads must leave (e.g. "5GB").
+	MediaMinFree string `json:"media_min_free,omitempty"`
+	// MediaPolicy is what happens when a limit is hit: "skip" (default)
+	// or "evict" the least recently downloaded media.
+	MediaPolicy string `json:"media_policy,omitempty"`
+
+	// AllowedChats limits what the CLI reads and sends to these chat JIDs,
+	// so a bot integration only sees the chats it's meant to. Empty allows
+	// every chat.
+	AllowedChats []string `json:"allowed_chats,omitempty"`
+
+	// Monitor configures message-rate anomaly alerts raised by sync --daemon.
+	Monitor MonitorConfig `json:"monitor,omitempty"`
+
+	// Pipelines route live messages received by sync through filters and
+	// transforms to sinks. Messages no pipeline matches are stored as usual.
+	Pipelines []Pipeline `json:"pipelines,omitempty"`
+
+	// GroupRefresh tunes the background refresh of group names, members
+	// and photos run by sync --daemon.
+	GroupRefresh GroupRefreshConfig `json:"group_refresh,omitempty"`
+}
+
+// GroupRefreshConfig paces the group metadata refresher. Durations accept
+// the same syntax as MonitorConfig.
+type GroupRefreshConfig struct {
+	// Disabled turns the refresher off.
+	Disabled bool `json:"disabled,omitempty"`
+	// Interval is how often each group is refreshed; defaults to 1d.
+	Interval string `json:"interval,omitempty"`
+	// Spacing is the pause between two lookups, so refreshing many groups
+	// never bursts requests at WhatsApp; defaults to 30s.
+	Spacing string `json:"spacing,omitempty"`
+}
+
+// Alert sink types accepted in MonitorConfig.Sinks.
+const (
+	SinkStderr  = "stderr"
+	SinkWebhook = "webhook"
+	SinkExec    = "exec"
+)
+
+// MonitorConfig describes the anomaly monitor. Durations accept Go syntax
+// plus day and week suffixes ("90m", "6h", "2d").
+type MonitorConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Interval between checks; defaults to 5m.
+	Interval string `json:"interval,omitempty"`
+	// SpikeFactor is how many times the learned hourly rate a chat must
+	// exceed in the last hour to count as a spike; defaults to 5.
+	SpikeFactor float64 `json:"spike_factor,omitempty"`
+	// MinSpikeMessages ignores spikes smaller than this many messages an
+	// hour, so quiet chats don't alert on a short burst; defaults to 20.
+	MinSpikeMessages int `json:"min_spike_messages,omitempty"`
+	// CriticalChats alert when they go silent.
+	CriticalChats []CriticalChat `json:"critical_chats,omitempty"`
+	// Sinks receive alerts; defaults to stderr only.
+	Sinks []AlertSink `json:"sinks,omitempty"`
+}
+
+// CriticalChat is a chat whose silence raises an alert.
+type CriticalChat struct {
+	JID string `json:"jid"`
+	// MaxSilence overrides the silence threshold learned from the chat's
+	// usual message rate (e.g. "2h").
+	MaxSilence string `json:"max_silence,omitempty"`
+}
+
+// AlertSink is a destination for monitor alerts.
+type AlertSink struct {
+	Type string `json:"type"`
+	// URL receives a JSON POST for webhook sinks.
+	URL string `json:"url,omitempty"`
+	// Command is run for exec sinks with the alert JSON on stdin.
+	Command []string `json:"command,omitempty"`
+}
+
+// Pipeline sink types accepted in addition to SinkWebhook and SinkExec.
+const (
+	SinkStore  = "store"
+	SinkNotify = "notify"
+)
+
+// Pipeline transforms, applied in order before a message reaches the
+// webhook, exec and notify sinks.
+const (
+	TransformDownloadMedia = "download_media"
+	TransformRedactNumbers = "redact_numbers"
+)
+
+// Pipeline sends the messages matching Match through Transforms to Sinks.
+type Pipeline struct {
+	Name       string         `json:"name"`
+	Match      PipelineFilter `json:"match,omitempty"`
+	Transforms []string       `json:"transforms,omitempty"`
+	Sinks      []PipelineSink `json:"sinks"`
+}
+
+// PipelineFilter selects messages. Empty fields match everything; a
+// message must satisfy every field that is set.
+type PipelineFilter struct {
+	// Chats are JIDs or glob patterns such as "*@g.us".
+	Chats []string `json:"chats,omitempty"`
+	// Types are "text", "location", a media type ("image", "video",
+	// "audio", "document", "sticker") or "media" for any of them.
+	Types []string `json:"types,omitempty"`
+	// FromMe restricts the pipeline to sent (true) or received (false)
+	// messages.
+	FromMe *bool `json:"from_me,omitempty"`
+	// Contains is a case-insensitive substring of the message text.
+	Contains string `json:"contains,omitempty"`
+}
+
+// PipelineSink is a destination for pipeline messages.
+type PipelineSink struct {
+	Type string `json:"type"`
+	// URL receives a JSON POST for webhook sinks.
+	URL string `json:"url,omitempty"`
+	// Command is run for exec sinks with the message JSON on stdin.
+	Command []string `json:"command,omitempty"`
+}
+
+// CardDAVConfig describes an external address book.
+type CardDAVConfig struct {
+	URL      string `json:"url,omitempty"`
+	Username string `json:"username,omitempty"`
+	// Password may be left empty and supplied via WHATSAPP_CLI_CARDDAV_PASSWORD.
+	Password string `json:"password,omitempty"`
+	// Token is sent as a Bearer token instead of basic auth (e.g. Google).
+	Token string `json:"token,omitempty"`
+}
+
+// Enabled reports whether enough settings are present to query the server.
+func (c CardDAVConfig) Enabled() bool {
+	return c.URL != ""
+}
+
+// Load reads config.json from storeDir, returning defaults if it does not exist.
+func Load(storeDir string) (*Config, error) {
+	cfg := &Config{}
+	path := filepath.Join(storeDir, FileName)
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+
+	if len(cfg.NameProviders) == 0 {
+		cfg.NameProviders = append([]string(nil), DefaultNameProviders...)
+	}
+	if cfg.CardDAV.Password == "" {
+		cfg.CardDAV.Password = os.Getenv("WHATSAPP_CLI_CARDDAV_PASSWORD")
+	}
+
+	return cfg, nil
+}
+
+// Set writes a single top-level key to config.json in storeDir, preserving
+// every other setting already in the file.
+func Set(storeDir, key string, value interface{}) error {
+	path := filepath.Join(storeDir, FileName)
+	settings := map[string]interface{}{}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+
+	settings[key] = value
+	out, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(out, '\n'), 0600); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
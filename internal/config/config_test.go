@@ -0,0 +1,55 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadReturnsDefaultsWhenFileMissing(t *testing.T) {
+	t.Setenv("WHATSAPP_CLI_CARDDAV_PASSWORD", "")
+
+	cfg, err := Load(t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, DefaultNameProviders, cfg.NameProviders)
+	assert.False(t, cfg.CardDAV.Enabled())
+}
+
+func TestLoadReadsFileAndPasswordFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(`{
+		"name_providers": ["carddav", "whatsmeow"],
+		"carddav": {"url": "https://dav.example.com/addressbooks/me/", "username": "me"}
+	}`), 0600))
+	t.Setenv("WHATSAPP_CLI_CARDDAV_PASSWORD", "s3cret")
+
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"carddav", "whatsmeow"}, cfg.NameProviders)
+	assert.True(t, cfg.CardDAV.Enabled())
+	assert.Equal(t, "me", cfg.CardDAV.Username)
+	assert.Equal(t, "s3cret", cfg.CardDAV.Password)
+}
+
+func TestLoadRejectsInvalidJSON(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(`{not json`), 0600))
+
+	_, err := Load(dir)
+	assert.Error(t, err)
+}
+
+func TestSetPreservesOtherKeys(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(`{"contacts_csv": "/tmp/c.csv"}`), 0600))
+
+	require.NoError(t, Set(dir, "auto_mark_read", true))
+
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+	assert.True(t, cfg.AutoMarkRead)
+	assert.Equal(t, "/tmp/c.csv", cfg.ContactsCSV)
+}
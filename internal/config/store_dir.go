@@ -0,0 +1,44 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// StoreDirEnv overrides the default store directory.
+const StoreDirEnv = "WHATSAPP_CLI_STORE"
+
+// LegacyStoreDir is where earlier versions kept the store: relative to
+// the working directory, so each directory the CLI ran in got its own session.
+const LegacyStoreDir = "store"
+
+// storeMarkers are files whose presence means a directory holds a store.
+var storeMarkers = []string{"whatsapp.db", "messages.db"}
+
+// DefaultStoreDir returns the store directory used when --store is not
+// given: $WHATSAPP_CLI_STORE, else $XDG_DATA_HOME/whatsapp-cli, else
+// ~/.local/share/whatsapp-cli.
+func DefaultStoreDir() (string, error) {
+	if dir := os.Getenv(StoreDirEnv); dir != "" {
+		return dir, nil
+	}
+	if dataHome := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dataHome) {
+		return filepath.Join(dataHome, "whatsapp-cli"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.New("cannot determine the home directory; set " + StoreDirEnv + " or pass --store")
+	}
+	return filepath.Join(home, ".local", "share", "whatsapp-cli"), nil
+}
+
+// HasStore reports whether dir contains a session or message database.
+func HasStore(dir string) bool {
+	for _, name := range storeMarkers {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultStoreDirPrefersEnvThenXDG(t *testing.T) {
+	t.Setenv(StoreDirEnv, "/srv/wa")
+	t.Setenv("XDG_DATA_HOME", "/data")
+	dir, err := DefaultStoreDir()
+	require.NoError(t, err)
+	assert.Equal(t, "/srv/wa", dir)
+
+	t.Setenv(StoreDirEnv, "")
+	dir, err = DefaultStoreDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/data", "whatsapp-cli"), dir)
+}
+
+func TestDefaultStoreDirFallsBackToHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv(StoreDirEnv, "")
+	t.Setenv("XDG_DATA_HOME", "relative/ignored")
+	t.Setenv("HOME", home)
+
+	dir, err := DefaultStoreDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".local", "share", "whatsapp-cli"), dir)
+}
+
+func TestHasStore(t *testing.T) {
+	dir := t.TempDir()
+	assert.False(t, HasStore(dir))
+	assert.False(t, HasStore(filepath.Join(dir, "missing")))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "whatsapp.db"), nil, 0600))
+	assert.True(t, HasStore(dir))
+}
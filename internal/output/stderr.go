@@ -0,0 +1,79 @@
+package output
+
+import (
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Stderr receives progress, status and warning lines. Commands write here
+// instead of os.Stderr so --quiet and --no-emoji apply everywhere; stdout is
+// reserved for the single JSON result.
+var Stderr io.Writer = os.Stderr
+
+// ConfigureStderr applies the global --quiet and --no-emoji flags.
+// Quiet discards everything written to Stderr; noEmoji strips pictographs
+// (and the spacing after them) for terminals and log collectors that
+// mangle them.
+func ConfigureStderr(quiet, noEmoji bool) {
+	switch {
+	case quiet:
+		Stderr = io.Discard
+	case noEmoji:
+		Stderr = &emojiStripper{w: os.Stderr}
+	default:
+		Stderr = os.Stderr
+	}
+}
+
+type emojiStripper struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (e *emojiStripper) Write(p []byte) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, err := io.WriteString(e.w, StripEmoji(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// StripEmoji removes emoji and symbol pictographs from s, together with the
+// spaces that separate them from the following text.
+func StripEmoji(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	skipSpaces := false
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		if isEmoji(r) {
+			skipSpaces = true
+			continue
+		}
+		if skipSpaces && r == ' ' {
+			continue
+		}
+		skipSpaces = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isEmoji(r rune) bool {
+	switch {
+	case r == 0x2139: // ℹ information source
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // misc symbols, dingbats (✓ ⚠ ✗)
+		return true
+	case r >= 0x1F000 && r <= 0x1FAFF: // pictographs, emoticons, transport
+		return true
+	case r == 0xFE0F || r == 0x200D: // variation selector, zero-width joiner
+		return true
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package output
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripEmoji(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "ℹ️  whatsapp-cli version: 1.3.1\n", want: "whatsapp-cli version: 1.3.1\n"},
+		{in: "\r💬 Synced 12 messages...", want: "\rSynced 12 messages..."},
+		{in: "\n✓ Connected to WhatsApp\n", want: "\nConnected to WhatsApp\n"},
+		{in: "⚠️  3 chats still need names", want: "3 chats still need names"},
+		{in: "plain text · 42%", want: "plain text · 42%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, StripEmoji(tt.in))
+		})
+	}
+}
+
+func TestConfigureStderr(t *testing.T) {
+	t.Cleanup(func() { Stderr = os.Stderr })
+
+	ConfigureStderr(true, true)
+	assert.Equal(t, io.Discard, Stderr, "quiet wins over no-emoji")
+
+	ConfigureStderr(false, true)
+	assert.IsType(t, &emojiStripper{}, Stderr)
+
+	ConfigureStderr(false, false)
+	assert.Equal(t, os.Stderr, Stderr)
+}
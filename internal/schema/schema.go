@@ -0,0 +1,149 @@
+// Package schema holds the versioned JSON schemas of every command's output.
+// Each schemas/<command>.json file describes the command's "data" field;
+// For wraps it in the {success, data, error} envelope and inlines the
+// shared definitions from schemas/defs.json so the result stands alone.
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Version is bumped whenever a field is removed, renamed or changes type.
+// Adding optional fields does not change it.
+const Version = 1
+
+// baseID prefixes the $id of every published schema.
+const baseID = "https://github.com/vicentereig/whatsapp-cli/schemas/v"
+
+//go:embed schemas/*.json
+var files embed.FS
+
+// Commands lists the commands with a schema, named by their words joined
+// with dots ("messages list" is "messages.list").
+var Commands = []string{
+	"version",
+	"auth",
+	"sync",
+	"enrich",
+	"messages.list",
+	"messages.search",
+	"messages.location-track",
+	"contacts.search",
+	"contacts.sync-external",
+	"chats.list",
+	"chats.retention.set",
+	"chats.retention.clear",
+	"chats.retention.list",
+	"chats.media-policy.set",
+	"chats.media-policy.clear",
+	"chats.media-policy.list",
+	"chats.mark-read",
+	"chats.snapshot",
+	"chat",
+	"send",
+	"send.interactive",
+	"media.download",
+	"open",
+	"outbound.list",
+	"stats.delivery",
+	"export.locations",
+	"export.matrix",
+	"import.backup",
+	"store.reprocess",
+	"store.migrate",
+	"service.install",
+	"service.uninstall",
+	"service.status",
+	"fixtures.generate",
+	"schema.print",
+	"schema.list",
+}
+
+// For returns the complete schema of a command's output.
+func For(command string) ([]byte, error) {
+	command = strings.Join(strings.Fields(command), ".")
+	if !known(command) {
+		return nil, fmt.Errorf("no schema for command %q (see `schema list`)", command)
+	}
+
+	var data map[string]interface{}
+	if err := readJSON(command+".json", &data); err != nil {
+		return nil, err
+	}
+	var defs map[string]interface{}
+	if err := readJSON("defs.json", &defs); err != nil {
+		return nil, err
+	}
+
+	doc := map[string]interface{}{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"$id":                  fmt.Sprintf("%s%d/%s.json", baseID, Version, command),
+		"title":                "whatsapp-cli " + strings.ReplaceAll(command, ".", " ") + " output",
+		"type":                 "object",
+		"required":             []string{"success", "data", "error"},
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"success": map[string]interface{}{"type": "boolean"},
+			// Failed commands carry the message in error and null data.
+			"data":  map[string]interface{}{"anyOf": []interface{}{data, map[string]interface{}{"type": "null"}}},
+			"error": map[string]interface{}{"type": []string{"string", "null"}},
+		},
+	}
+	if used := usedDefs(data, defs); len(used) > 0 {
+		doc["$defs"] = used
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func known(command string) bool {
+	for _, c := range Commands {
+		if c == command {
+			return true
+		}
+	}
+	return false
+}
+
+func readJSON(name string, v interface{}) error {
+	raw, err := files.ReadFile("schemas/" + name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("schemas/%s: %w", name, err)
+	}
+	return nil
+}
+
+// usedDefs returns the shared definitions s refers to, directly or through
+// other definitions.
+func usedDefs(s interface{}, defs map[string]interface{}) map[string]interface{} {
+	used := map[string]interface{}{}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := node["$ref"].(string); ok {
+				name := strings.TrimPrefix(ref, "#/$defs/")
+				if _, seen := used[name]; !seen {
+					if def, ok := defs[name]; ok {
+						used[name] = def
+						walk(def)
+					}
+				}
+			}
+			for _, child := range node {
+				walk(child)
+			}
+		case []interface{}:
+			for _, item := range node {
+				walk(item)
+			}
+		}
+	}
+	walk(s)
+	return used
+}
@@ -0,0 +1,76 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestCommands_MatchEmbeddedFiles verifies every listed command has a
+// schema file and every schema file belongs to a listed command.
+func TestCommands_MatchEmbeddedFiles(t *testing.T) {
+	entries, err := files.ReadDir("schemas")
+	require.NoError(t, err)
+
+	var names []string
+	for _, e := range entries {
+		if name := strings.TrimSuffix(e.Name(), ".json"); name != "defs" {
+			names = append(names, name)
+		}
+	}
+	require.ElementsMatch(t, Commands, names)
+}
+
+// TestFor_BuildsSelfContainedSchemas verifies each schema is wrapped in the
+// response envelope, versioned, and carries every definition it refers to.
+func TestFor_BuildsSelfContainedSchemas(t *testing.T) {
+	for _, command := range Commands {
+		raw, err := For(command)
+		require.NoError(t, err, command)
+
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(raw, &doc), command)
+		require.Equal(t, fmt.Sprintf("%s%d/%s.json", baseID, Version, command), doc["$id"])
+
+		// Every $ref resolves, and a failed run validates for every command.
+		require.NoError(t, Validate(command, []byte(`{"success":false,"data":null,"error":"boom"}`)), command)
+		for _, ref := range refs(doc) {
+			name := strings.TrimPrefix(ref, "#/$defs/")
+			require.Contains(t, doc["$defs"], name, "%s refers to %s", command, ref)
+		}
+	}
+}
+
+// TestFor_AcceptsCommandWords verifies commands can be named as typed on
+// the command line.
+func TestFor_AcceptsCommandWords(t *testing.T) {
+	dotted, err := For("chats.retention.set")
+	require.NoError(t, err)
+	spaced, err := For("chats retention set")
+	require.NoError(t, err)
+	require.Equal(t, dotted, spaced)
+
+	_, err = For("messages.delete")
+	require.Error(t, err)
+}
+
+func refs(v interface{}) []string {
+	var out []string
+	switch node := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := node["$ref"].(string); ok {
+			out = append(out, ref)
+		}
+		for _, child := range node {
+			out = append(out, refs(child)...)
+		}
+	case []interface{}:
+		for _, item := range node {
+			out = append(out, refs(item)...)
+		}
+	}
+	return out
+}
@@ -0,0 +1,232 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Validate checks a command's JSON output against its schema. It supports
+// the subset of JSON Schema the embedded schemas use: type, enum, const,
+// properties, required, additionalProperties, items, anyOf, oneOf, format
+// "date-time" and $ref to "#/$defs/...".
+func Validate(command string, output []byte) error {
+	raw, err := For(command)
+	if err != nil {
+		return err
+	}
+	var s map[string]interface{}
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(output))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	defs, _ := s["$defs"].(map[string]interface{})
+	return (&validator{defs: defs}).validate(s, value, "$")
+}
+
+type validator struct {
+	defs map[string]interface{}
+}
+
+func (v *validator) validate(s interface{}, value interface{}, path string) error {
+	schema, ok := s.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s: invalid schema", path)
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		def, err := v.resolve(ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return v.validate(def, value, path)
+	}
+
+	if options, ok := schema["anyOf"].([]interface{}); ok {
+		var errs []string
+		for _, option := range options {
+			err := v.validate(option, value, path)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return fmt.Errorf("%s: matches none of the allowed shapes (%s)", path, strings.Join(errs, "; "))
+	}
+	if options, ok := schema["oneOf"].([]interface{}); ok {
+		matched := 0
+		var errs []string
+		for _, option := range options {
+			if err := v.validate(option, value, path); err != nil {
+				errs = append(errs, err.Error())
+			} else {
+				matched++
+			}
+		}
+		if matched != 1 {
+			if matched == 0 {
+				return fmt.Errorf("%s: matches none of the allowed shapes (%s)", path, strings.Join(errs, "; "))
+			}
+			return fmt.Errorf("%s: matches %d shapes, want exactly one", path, matched)
+		}
+		return nil
+	}
+
+	if t, ok := schema["type"]; ok {
+		if err := checkType(t, value, path); err != nil {
+			return err
+		}
+	}
+	if c, ok := schema["const"]; ok && !sameValue(c, value) {
+		return fmt.Errorf("%s: must be %v", path, c)
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if sameValue(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+		}
+	}
+	if schema["format"] == "date-time" {
+		if str, ok := value.(string); ok {
+			if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+				return fmt.Errorf("%s: %q is not an RFC 3339 date-time", path, str)
+			}
+		}
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		return v.validateObject(schema, val, path)
+	case []interface{}:
+		if items, ok := schema["items"]; ok {
+			for i, item := range val {
+				if err := v.validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (v *validator) validateObject(schema map[string]interface{}, obj map[string]interface{}, path string) error {
+	props, _ := schema["properties"].(map[string]interface{})
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: missing required field %q", path, name)
+			}
+		}
+	}
+
+	// Fields are checked in a stable order so errors are reproducible.
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if prop, ok := props[k]; ok {
+			if err := v.validate(prop, obj[k], path+"."+k); err != nil {
+				return err
+			}
+			continue
+		}
+		switch extra := schema["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				return fmt.Errorf("%s: unexpected field %q", path, k)
+			}
+		case map[string]interface{}:
+			if err := v.validate(extra, obj[k], path+"."+k); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *validator) resolve(ref string) (interface{}, error) {
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	def, ok := v.defs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown $ref %q", ref)
+	}
+	return def, nil
+}
+
+func checkType(t interface{}, value interface{}, path string) error {
+	var types []string
+	switch tt := t.(type) {
+	case string:
+		types = []string{tt}
+	case []interface{}:
+		for _, x := range tt {
+			if s, ok := x.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+	actual := typeOf(value)
+	for _, want := range types {
+		if want == actual || (want == "number" && actual == "integer") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), actual)
+}
+
+// typeOf names a decoded value's JSON Schema type. Numbers are decoded as
+// json.Number so integers can be told apart.
+func typeOf(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if strings.ContainsAny(val.String(), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// sameValue compares a schema literal (decoded without UseNumber) with a
+// decoded output value.
+func sameValue(literal, value interface{}) bool {
+	if n, ok := value.(json.Number); ok {
+		f, err := n.Float64()
+		lf, isNum := literal.(float64)
+		return err == nil && isNum && f == lf
+	}
+	return literal == value
+}
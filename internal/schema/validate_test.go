@@ -0,0 +1,42 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidate_AcceptsConformingOutput verifies valid success and error
+// outputs pass.
+func TestValidate_AcceptsConformingOutput(t *testing.T) {
+	require.NoError(t, Validate("messages.list", []byte(`{"success":true,"data":[
+		{"id":"M1","chat_jid":"1@g.us","sender":"34611111111","content":"hi","timestamp":"2026-10-15T09:00:00.5+02:00","is_from_me":false,"amount":12.5,"item_count":2}
+	],"error":null}`)))
+	require.NoError(t, Validate("messages.list", []byte(`{"success":true,"data":null,"error":null}`)))
+	require.NoError(t, Validate("send", []byte(`{"success":false,"data":null,"error":"not connected"}`)))
+}
+
+// TestValidate_ReportsDrift verifies removed, retyped and undocumented
+// fields are reported with their path.
+func TestValidate_ReportsDrift(t *testing.T) {
+	cases := map[string]string{
+		`$.data[0]: missing required field "timestamp"`:                 `{"success":true,"data":[{"id":"M1","chat_jid":"1@g.us","sender":"s","content":"","is_from_me":true}],"error":null}`,
+		`$.data[0].is_from_me: expected boolean, got string`:            `{"success":true,"data":[{"id":"M1","chat_jid":"1@g.us","sender":"s","content":"","timestamp":"2026-10-15T09:00:00Z","is_from_me":"yes"}],"error":null}`,
+		`$.data[0]: unexpected field "chat"`:                            `{"success":true,"data":[{"id":"M1","chat_jid":"1@g.us","chat":"x","sender":"s","content":"","timestamp":"2026-10-15T09:00:00Z","is_from_me":true}],"error":null}`,
+		`$.data[0].timestamp: "yesterday" is not an RFC 3339 date-time`: `{"success":true,"data":[{"id":"M1","chat_jid":"1@g.us","sender":"s","content":"","timestamp":"yesterday","is_from_me":true}],"error":null}`,
+		`$.data[0].item_count: expected integer, got number`:            `{"success":true,"data":[{"id":"M1","chat_jid":"1@g.us","sender":"s","content":"","timestamp":"2026-10-15T09:00:00Z","is_from_me":true,"item_count":1.5}],"error":null}`,
+		`$: missing required field "error"`:                             `{"success":true,"data":null}`,
+	}
+	for want, output := range cases {
+		err := Validate("messages.list", []byte(output))
+		require.Error(t, err, want)
+		require.Contains(t, err.Error(), want)
+	}
+}
+
+// TestValidate_ChecksEnumsAndConsts verifies fixed values are enforced.
+func TestValidate_ChecksEnumsAndConsts(t *testing.T) {
+	require.NoError(t, Validate("chats.retention.clear", []byte(`{"success":true,"data":{"chat_jid":"1@g.us","cleared":true},"error":null}`)))
+	require.Error(t, Validate("chats.retention.clear", []byte(`{"success":true,"data":{"chat_jid":"1@g.us","cleared":false},"error":null}`)))
+	require.Error(t, Validate("import.backup", []byte(`{"success":true,"data":{"format":"blackberry","chats":0,"messages":0,"imported":0,"duplicates":0},"error":null}`)))
+}
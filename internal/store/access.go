@@ -0,0 +1,25 @@
+package store
+
+// SetAllowedChats limits the queries behind user-facing reads (messages,
+// chats, contacts, media lookups, locations, the outbound log, participant
+// history and per-chat retention and media policies) to the given chat
+// JIDs. An empty list lifts the restriction. The list applies to the whole
+// process, not to individual callers. Sync and its background work (media
+// downloads, group refresh, monitoring, retention pruning) are never
+// filtered, so changing the list needs no resync.
+func (s *MessageStore) SetAllowedChats(jids []string) {
+	s.allowedChats = append([]string(nil), jids...)
+}
+
+// restrictChats appends the allowlist condition on column to a query that
+// already has a WHERE clause.
+func (s *MessageStore) restrictChats(query string, args []interface{}, column string) (string, []interface{}) {
+	if len(s.allowedChats) == 0 {
+		return query, args
+	}
+	query += " AND " + column + " IN (" + placeholders(len(s.allowedChats)) + ")"
+	for _, jid := range s.allowedChats {
+		args = append(args, jid)
+	}
+	return query, args
+}
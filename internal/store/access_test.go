@@ -0,0 +1,66 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowedChatsRestrictsReads(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now()
+	allowed := "allowed@s.whatsapp.net"
+	hidden := "hidden@s.whatsapp.net"
+	for _, jid := range []string{allowed, hidden} {
+		require.NoError(t, store.StoreChat(jid, "Contact "+jid, now))
+		require.NoError(t, store.StoreMessage("m-"+jid, jid, jid, "hello", now, false, "", "", "", "", "", nil, nil, nil, 0))
+	}
+	store.SetAllowedChats([]string{allowed})
+
+	chats, err := store.ListChats(ListChatsParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	assert.Equal(t, allowed, chats[0].JID)
+
+	messages, err := store.ListMessages(ListMessagesParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, allowed, messages[0].ChatJID)
+
+	hiddenJID := hidden
+	messages, err = store.ListMessages(ListMessagesParams{ChatJID: &hiddenJID, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+
+	contacts, err := store.SearchContacts("Contact")
+	require.NoError(t, err)
+	require.Len(t, contacts, 1)
+	assert.Equal(t, allowed, contacts[0].JID)
+
+	_, err = store.GetMessageForDownload("m-"+hidden, nil)
+	assert.Error(t, err)
+	info, err := store.GetMediaForSync("m-"+hidden, hidden)
+	require.NoError(t, err, "sync media downloads ignore the allowlist")
+	assert.Equal(t, hidden, info.ChatJID)
+
+	require.NoError(t, store.SetChatRetention(hidden, time.Hour))
+	require.NoError(t, store.SetChatMediaPolicy(hidden, []string{"image"}, 0))
+	retention, err := store.ListChatRetention()
+	require.NoError(t, err)
+	assert.Empty(t, retention)
+	mediaPolicies, err := store.ListChatMediaPolicies()
+	require.NoError(t, err)
+	assert.Empty(t, mediaPolicies)
+
+	require.NoError(t, store.StoreChat("team@g.us", "Team", now))
+	due, err := store.ListGroupsDueForRefresh(now.Add(time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1, "sync-internal work ignores the allowlist")
+
+	store.SetAllowedChats(nil)
+	chats, err = store.ListChats(ListChatsParams{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, chats, 3)
+}
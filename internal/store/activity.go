@@ -0,0 +1,57 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ChatActivity counts a chat's messages in a baseline window and in the
+// recent window that follows it.
+type ChatActivity struct {
+	ChatJID  string
+	ChatName string
+	Baseline int64
+	Recent   int64
+}
+
+// ListChatActivity counts messages per chat from baselineSince up to
+// recentSince (Baseline) and from recentSince on (Recent). Chats without
+// messages since baselineSince are omitted.
+func (s *MessageStore) ListChatActivity(baselineSince, recentSince time.Time) ([]ChatActivity, error) {
+	rows, err := s.db.Query(`
+		SELECT m.chat_jid, COALESCE(c.name, ''),
+			SUM(CASE WHEN m.timestamp < ? THEN 1 ELSE 0 END),
+			SUM(CASE WHEN m.timestamp >= ? THEN 1 ELSE 0 END)
+		FROM messages m LEFT JOIN chats c ON c.jid = m.chat_jid
+		WHERE m.timestamp >= ?
+		GROUP BY m.chat_jid`, recentSince, recentSince, baselineSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ChatActivity
+	for rows.Next() {
+		var a ChatActivity
+		if err := rows.Scan(&a.ChatJID, &a.ChatName, &a.Baseline, &a.Recent); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// LastMessageTime returns when the newest message in a chat was sent, or
+// the zero time if the chat has no messages.
+func (s *MessageStore) LastMessageTime(chatJID string) (time.Time, error) {
+	var at time.Time
+	err := s.db.QueryRow(
+		`SELECT timestamp FROM messages WHERE chat_jid = ? ORDER BY timestamp DESC LIMIT 1`,
+		chatJID,
+	).Scan(&at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	return at, err
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListChatActivitySplitsBaselineAndRecent(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now()
+	chatJID := "ops@g.us"
+	require.NoError(t, store.StoreChat(chatJID, "Ops", now))
+	for i, age := range []time.Duration{30 * time.Minute, 2 * time.Hour, 3 * time.Hour, 30 * 24 * time.Hour} {
+		id := string(rune('a' + i))
+		require.NoError(t, store.StoreMessage(id, chatJID, "1", "x", now.Add(-age), false, "", "", "", "", "", nil, nil, nil, 0))
+	}
+
+	activity, err := store.ListChatActivity(now.Add(-7*24*time.Hour), now.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, activity, 1)
+	assert.Equal(t, "Ops", activity[0].ChatName)
+	assert.EqualValues(t, 2, activity[0].Baseline)
+	assert.EqualValues(t, 1, activity[0].Recent)
+}
+
+func TestLastMessageTime(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now().Truncate(time.Second)
+	chatJID := "ops@g.us"
+
+	at, err := store.LastMessageTime(chatJID)
+	require.NoError(t, err)
+	assert.True(t, at.IsZero())
+
+	require.NoError(t, store.StoreChat(chatJID, "Ops", now))
+	require.NoError(t, store.StoreMessage("old", chatJID, "1", "x", now.Add(-time.Hour), false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreMessage("new", chatJID, "1", "x", now, false, "", "", "", "", "", nil, nil, nil, 0))
+
+	at, err = store.LastMessageTime(chatJID)
+	require.NoError(t, err)
+	assert.True(t, now.Equal(at))
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// chatCounterTriggers keep chats.message_count and chats.first_message_time
+// in step with the messages table, so listing chats never has to COUNT
+// over millions of rows. Deletes only recompute the first message time
+// when the oldest message goes, which idx_messages_chat_time makes cheap.
+const chatCounterTriggers = `
+	CREATE INDEX IF NOT EXISTS idx_messages_chat_time ON messages(chat_jid, timestamp);
+
+	CREATE TRIGGER IF NOT EXISTS chats_count_insert AFTER INSERT ON messages
+	BEGIN
+		UPDATE chats SET
+			message_count = message_count + 1,
+			first_message_time = CASE
+				WHEN first_message_time IS NULL OR NEW.timestamp < first_message_time THEN NEW.timestamp
+				ELSE first_message_time
+			END
+		WHERE jid = NEW.chat_jid;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS chats_count_delete AFTER DELETE ON messages
+	BEGIN
+		UPDATE chats SET
+			message_count = MAX(message_count - 1, 0),
+			first_message_time = CASE
+				WHEN OLD.timestamp <= first_message_time
+				THEN (SELECT MIN(timestamp) FROM messages WHERE chat_jid = OLD.chat_jid)
+				ELSE first_message_time
+			END
+		WHERE jid = OLD.chat_jid;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS chats_count_retime AFTER UPDATE OF timestamp ON messages
+	WHEN NEW.timestamp IS NOT OLD.timestamp
+	BEGIN
+		UPDATE chats SET first_message_time = (SELECT MIN(timestamp) FROM messages WHERE chat_jid = NEW.chat_jid)
+		WHERE jid = NEW.chat_jid;
+	END;
+`
+
+// ensureChatCounters adds the counter columns to stores created by older
+// versions, fills them in once, and installs the triggers that maintain
+// them from then on.
+func ensureChatCounters(db *sql.DB) error {
+	hasCount, err := columnExists(db, "chats", "message_count")
+	if err != nil {
+		return err
+	}
+	if !hasCount {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		for _, stmt := range []string{
+			`ALTER TABLE chats ADD COLUMN message_count INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE chats ADD COLUMN first_message_time TIMESTAMP`,
+			`UPDATE chats SET
+				message_count = (SELECT COUNT(*) FROM messages WHERE chat_jid = chats.jid),
+				first_message_time = (SELECT MIN(timestamp) FROM messages WHERE chat_jid = chats.jid)`,
+		} {
+			if _, err := tx.Exec(stmt); err != nil {
+				return fmt.Errorf("adding chat message counts: %w", err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := db.Exec(chatCounterTriggers); err != nil {
+		return fmt.Errorf("failed to create chat counter triggers: %w", err)
+	}
+	return nil
+}
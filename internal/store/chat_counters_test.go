@@ -0,0 +1,91 @@
+package store
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func chatByJID(t *testing.T, s *MessageStore, jid string) Chat {
+	t.Helper()
+	chats, err := s.ListChats(ListChatsParams{Query: &jid, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	return chats[0]
+}
+
+func TestChatCountersFollowInsertsAndPrunes(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	jid := "1234@s.whatsapp.net"
+	require.NoError(t, store.StoreChat(jid, "John", now))
+
+	chat := chatByJID(t, store, jid)
+	assert.Equal(t, 0, chat.MessageCount)
+	assert.Nil(t, chat.FirstMessageTime)
+
+	require.NoError(t, store.StoreMessage("new", jid, "1", "new", now.Add(-time.Hour), false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreMessage("old", jid, "1", "old", now.Add(-72*time.Hour), false, "", "", "", "", "", nil, nil, nil, 0))
+	// Redelivered messages update in place and are not counted twice.
+	require.NoError(t, store.StoreMessage("new", jid, "1", "edited", now.Add(-time.Hour), false, "", "", "", "", "", nil, nil, nil, 0))
+	inserted, err := store.ImportMessages([]ImportedMessage{
+		{ID: "old", ChatJID: jid, Sender: "1", Content: "old", Timestamp: now.Add(-72 * time.Hour)},
+		{ID: "mid", ChatJID: jid, Sender: "1", Content: "mid", Timestamp: now.Add(-48 * time.Hour)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, inserted)
+
+	chat = chatByJID(t, store, jid)
+	assert.Equal(t, 3, chat.MessageCount)
+	require.NotNil(t, chat.FirstMessageTime)
+	assert.True(t, chat.FirstMessageTime.Equal(now.Add(-72*time.Hour)))
+
+	require.NoError(t, store.SetChatRetention(jid, 60*time.Hour))
+	pruned, err := store.PruneExpiredMessages(now)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, pruned)
+
+	chat = chatByJID(t, store, jid)
+	assert.Equal(t, 2, chat.MessageCount)
+	require.NotNil(t, chat.FirstMessageTime)
+	assert.True(t, chat.FirstMessageTime.Equal(now.Add(-48*time.Hour)))
+}
+
+func TestChatCountersBackfillOlderStores(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "messages.db")
+	db, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+	first := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	_, err = db.Exec(`
+		CREATE TABLE chats (jid TEXT PRIMARY KEY, name TEXT, last_message_time TIMESTAMP);
+		CREATE TABLE messages (id TEXT, chat_jid TEXT, sender TEXT, content TEXT, timestamp TIMESTAMP, is_from_me BOOLEAN,
+			media_type TEXT, filename TEXT, url TEXT, media_key BLOB, file_sha256 BLOB, file_enc_sha256 BLOB, file_length INTEGER,
+			PRIMARY KEY (id, chat_jid));
+		INSERT INTO chats VALUES ('a@s.whatsapp.net', 'A', ?), ('b@s.whatsapp.net', 'B', ?);
+	`, first, first)
+	require.NoError(t, err)
+	for i, ts := range []time.Time{first.Add(time.Hour), first, first.Add(2 * time.Hour)} {
+		_, err = db.Exec(`INSERT INTO messages (id, chat_jid, sender, content, timestamp, is_from_me) VALUES (?, 'a@s.whatsapp.net', '1', 'x', ?, 0)`,
+			string(rune('a'+i)), ts)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+
+	store, err := NewMessageStore(dbPath)
+	require.NoError(t, err)
+	defer store.Close()
+
+	a := chatByJID(t, store, "a@s.whatsapp.net")
+	assert.Equal(t, 3, a.MessageCount)
+	require.NotNil(t, a.FirstMessageTime)
+	assert.True(t, a.FirstMessageTime.Equal(first))
+	assert.Equal(t, 0, chatByJID(t, store, "b@s.whatsapp.net").MessageCount)
+
+	// Triggers take over after the backfill.
+	require.NoError(t, store.StoreMessage("d", "b@s.whatsapp.net", "1", "x", first, false, "", "", "", "", "", nil, nil, nil, 0))
+	assert.Equal(t, 1, chatByJID(t, store, "b@s.whatsapp.net").MessageCount)
+}
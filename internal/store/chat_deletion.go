@@ -0,0 +1,28 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ensureChatDeletedAt adds chats.deleted_at to stores created by older
+// versions.
+func ensureChatDeletedAt(db *sql.DB) error {
+	exists, err := columnExists(db, "chats", "deleted_at")
+	if err != nil || exists {
+		return err
+	}
+	if _, err := db.Exec(`ALTER TABLE chats ADD COLUMN deleted_at TIMESTAMP`); err != nil {
+		return fmt.Errorf("failed to add column deleted_at: %w", err)
+	}
+	return nil
+}
+
+// MarkChatDeleted soft-deletes a chat deleted on the phone: it disappears
+// from `chats list` but its messages are kept. A message newer than the
+// deletion brings the chat back, as it does in WhatsApp.
+func (s *MessageStore) MarkChatDeleted(jid string, at time.Time) error {
+	_, err := s.db.Exec(`UPDATE chats SET deleted_at = ? WHERE jid = ?`, at, jid)
+	return err
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkChatDeletedHidesChatUntilNewMessage(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	jid := "1234@s.whatsapp.net"
+	require.NoError(t, store.StoreChat(jid, "John", now.Add(-time.Hour)))
+	require.NoError(t, store.StoreChat("5678@s.whatsapp.net", "Jane", now))
+	require.NoError(t, store.StoreMessage("m1", jid, "1234", "hi", now.Add(-time.Hour), false, "", "", "", "", "", nil, nil, nil, 0))
+
+	require.NoError(t, store.MarkChatDeleted(jid, now))
+	chats, err := store.ListChats(ListChatsParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	assert.Equal(t, "5678@s.whatsapp.net", chats[0].JID)
+
+	chats, err = store.ListChats(ListChatsParams{Limit: 10, IncludeDeleted: true})
+	require.NoError(t, err)
+	require.Len(t, chats, 2)
+	assert.Nil(t, chatByJID(t, store, "5678@s.whatsapp.net").DeletedAt)
+	for _, c := range chats {
+		if c.JID == jid {
+			require.NotNil(t, c.DeletedAt)
+			assert.True(t, c.DeletedAt.Equal(now))
+			assert.Equal(t, 1, c.MessageCount, "messages are kept")
+		}
+	}
+
+	// History older than the deletion doesn't bring the chat back.
+	require.NoError(t, store.StoreChat(jid, "John", now.Add(-2*time.Hour)))
+	chats, err = store.ListChats(ListChatsParams{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, chats, 1)
+
+	require.NoError(t, store.StoreChat(jid, "John", now.Add(time.Minute)))
+	restored := chatByJID(t, store, jid)
+	assert.Nil(t, restored.DeletedAt)
+}
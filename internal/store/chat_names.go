@@ -0,0 +1,42 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// nameSourceExternal marks chat names imported from an external address
+// book. Names WhatsApp reports never replace them.
+const nameSourceExternal = "external"
+
+// ensureChatNameSource adds chats.name_source to stores created by older
+// versions.
+func ensureChatNameSource(db *sql.DB) error {
+	exists, err := columnExists(db, "chats", "name_source")
+	if err != nil || exists {
+		return err
+	}
+	if _, err := db.Exec(`ALTER TABLE chats ADD COLUMN name_source TEXT`); err != nil {
+		return fmt.Errorf("failed to add column name_source: %w", err)
+	}
+	return nil
+}
+
+// SetExternalChatName names a chat from an external address book, reporting
+// whether the name changed. Later syncs keep the name instead of replacing
+// it with the one WhatsApp reports.
+func (s *MessageStore) SetExternalChatName(jid, name string) (bool, error) {
+	var current sql.NullString
+	err := s.db.QueryRow(`SELECT name FROM chats WHERE jid = ?`, jid).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, err := s.db.Exec(`UPDATE chats SET name = ?, name_source = ? WHERE jid = ?`, name, nameSourceExternal, jid); err != nil {
+		return false, err
+	}
+	return !current.Valid || current.String != name, nil
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetExternalChatNameSurvivesSync(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	jid := "15551234567@s.whatsapp.net"
+	require.NoError(t, store.StoreChat(jid, "~alice", now))
+
+	changed, err := store.SetExternalChatName(jid, "Alice Smith")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = store.SetExternalChatName(jid, "Alice Smith")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// Names WhatsApp reports on later syncs don't replace it.
+	require.NoError(t, store.StoreChat(jid, "~alice", now.Add(time.Minute)))
+	name, err := store.GetChatName(jid)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Smith", name)
+	assert.True(t, chatByJID(t, store, jid).LastMessageTime.Equal(now.Add(time.Minute)))
+
+	changed, err = store.SetExternalChatName("unknown@s.whatsapp.net", "Bob")
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
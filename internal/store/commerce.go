@@ -0,0 +1,39 @@
+package store
+
+// Commerce holds the structured fields of a business or payment message.
+// Amount1000 is in thousandths of the currency unit; zero means no amount
+// was given.
+type Commerce struct {
+	Type       string
+	Amount1000 int64
+	Currency   string
+	ItemCount  int
+	Status     string
+}
+
+// StoreCommerce records the business fields of a message already saved
+// with StoreMessage.
+func (s *MessageStore) StoreCommerce(id, chatJID string, c Commerce) error {
+	_, err := s.db.Exec(
+		`UPDATE messages SET
+			message_type = ?,
+			amount_1000 = NULLIF(?, 0),
+			currency = NULLIF(?, ''),
+			item_count = NULLIF(?, 0),
+			status = NULLIF(?, '')
+		WHERE id = ? AND chat_jid = ?`,
+		c.Type, c.Amount1000, c.Currency, c.ItemCount, c.Status, id, chatJID,
+	)
+	return err
+}
+
+// UpdateCommerceStatus sets the status of a typed message, e.g. when a
+// payment request is paid, declined or cancelled. Unknown or untyped
+// messages are left alone.
+func (s *MessageStore) UpdateCommerceStatus(id, chatJID, status string) error {
+	_, err := s.db.Exec(
+		`UPDATE messages SET status = ? WHERE id = ? AND chat_jid = ? AND message_type IS NOT NULL`,
+		status, id, chatJID,
+	)
+	return err
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreCommerceAndListByType(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "shop@s.whatsapp.net"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(chatJID, "Shop", now))
+	require.NoError(t, store.StoreMessage("order1", chatJID, "shop", "[Order] Weekly box", now, false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreMessage("text1", chatJID, "shop", "thanks!", now.Add(time.Second), false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreCommerce("order1", chatJID, Commerce{Type: "order", Amount1000: 42500, Currency: "EUR", ItemCount: 3}))
+
+	orderType := "order"
+	messages, err := store.ListMessages(ListMessagesParams{Type: &orderType, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	m := messages[0]
+	assert.Equal(t, "order", m.Type)
+	require.NotNil(t, m.Amount)
+	assert.InDelta(t, 42.5, *m.Amount, 0.0001)
+	assert.Equal(t, "EUR", m.Currency)
+	require.NotNil(t, m.ItemCount)
+	assert.Equal(t, 3, *m.ItemCount)
+
+	messages, err = store.ListMessages(ListMessagesParams{ChatJID: &chatJID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Empty(t, messages[0].Type)
+	assert.Nil(t, messages[0].Amount, "plain messages have no amount")
+}
+
+func TestUpdateExtractedContentFillsCommerce(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "shop@s.whatsapp.net"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(chatJID, "Shop", now))
+	require.NoError(t, store.StoreMessage("p1", chatJID, "shop", "", now, false, "", "", "", "", "", nil, nil, nil, 0))
+
+	changed, err := store.UpdateExtractedContent("p1", chatJID, ExtractedContent{
+		Content:  "[Product] Espresso beans: 15.00 USD",
+		Commerce: &Commerce{Type: "product", Amount1000: 15000, Currency: "USD", ItemCount: 1},
+	})
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	productType := "product"
+	messages, err := store.ListMessages(ListMessagesParams{Type: &productType, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "USD", messages[0].Currency)
+
+	changed, err = store.UpdateExtractedContent("p1", chatJID, ExtractedContent{
+		Content:  "[Product] Espresso beans: 15.00 USD",
+		Commerce: &Commerce{Type: "product", Amount1000: 15000, Currency: "USD", ItemCount: 1},
+	})
+	require.NoError(t, err)
+	assert.False(t, changed, "typed messages are left alone")
+}
+
+func TestUpdateCommerceStatusOnlyTouchesTypedMessages(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "friend@s.whatsapp.net"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(chatJID, "Friend", now))
+	require.NoError(t, store.StoreMessage("req", chatJID, "friend", "[Payment request] 10.00 USD", now, false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreMessage("text", chatJID, "friend", "hi", now.Add(-time.Second), false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreCommerce("req", chatJID, Commerce{Type: "payment_request", Amount1000: 10000, Currency: "USD", Status: "requested"}))
+
+	require.NoError(t, store.UpdateCommerceStatus("req", chatJID, "paid"))
+	require.NoError(t, store.UpdateCommerceStatus("text", chatJID, "paid"))
+
+	messages, err := store.ListMessages(ListMessagesParams{ChatJID: &chatJID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "paid", messages[0].Status)
+	assert.Empty(t, messages[1].Status)
+}
+
+func TestUpdateExtractedContentKeepsPaymentAmount(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "friend@s.whatsapp.net"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(chatJID, "Friend", now))
+	require.NoError(t, store.StoreMessage("pay", chatJID, "me", "[Payment] 25.00 EUR — dinner", now, true, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreCommerce("pay", chatJID, Commerce{Type: "payment", Amount1000: 25000, Currency: "EUR", Status: "complete"}))
+
+	changed, err := store.UpdateExtractedContent("pay", chatJID, ExtractedContent{
+		Content:  "[Payment] dinner",
+		Commerce: &Commerce{Type: "payment"},
+	})
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	messages, err := store.ListMessages(ListMessagesParams{ChatJID: &chatJID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "[Payment] 25.00 EUR — dinner", messages[0].Content)
+	assert.Equal(t, "EUR", messages[0].Currency)
+}
@@ -0,0 +1,54 @@
+package store
+
+// MessageRef identifies a message by its composite key.
+type MessageRef struct {
+	ID      string `json:"id"`
+	ChatJID string `json:"chat_jid"`
+}
+
+// ListUnnamedChatJIDs returns chats that have no friendly name yet (empty
+// or equal to their JID), typically because name resolution was deferred.
+func (s *MessageStore) ListUnnamedChatJIDs() ([]string, error) {
+	rows, err := s.db.Query(`SELECT jid FROM chats WHERE name IS NULL OR name = '' OR name = jid ORDER BY last_message_time DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jids []string
+	for rows.Next() {
+		var jid string
+		if err := rows.Scan(&jid); err != nil {
+			return nil, err
+		}
+		jids = append(jids, jid)
+	}
+	return jids, rows.Err()
+}
+
+// ListPendingMedia returns messages with downloadable media that has not
+// been saved locally yet, newest first. Media evicted to honor a quota is
+// not pending.
+func (s *MessageStore) ListPendingMedia(limit int) ([]MessageRef, error) {
+	rows, err := s.db.Query(`
+		SELECT id, chat_jid FROM messages
+		WHERE direct_path IS NOT NULL AND direct_path != ''
+		  AND media_key IS NOT NULL AND length(media_key) > 0
+		  AND local_path IS NULL AND media_evicted_at IS NULL
+		ORDER BY timestamp DESC
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []MessageRef
+	for rows.Next() {
+		var r MessageRef
+		if err := rows.Scan(&r.ID, &r.ChatJID); err != nil {
+			return nil, err
+		}
+		refs = append(refs, r)
+	}
+	return refs, rows.Err()
+}
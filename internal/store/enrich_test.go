@@ -0,0 +1,36 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListUnnamedChatJIDs(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now()
+	require.NoError(t, store.StoreChat("named@s.whatsapp.net", "John Doe", now))
+	require.NoError(t, store.StoreChat("raw@s.whatsapp.net", "raw@s.whatsapp.net", now))
+	require.NoError(t, store.StoreChat("empty@g.us", "", now))
+
+	jids, err := store.ListUnnamedChatJIDs()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"raw@s.whatsapp.net", "empty@g.us"}, jids)
+}
+
+func TestListPendingMediaSkipsDownloadedAndTextMessages(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "1234@s.whatsapp.net"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(chatJID, "John Doe", now))
+	require.NoError(t, store.StoreMessage("text", chatJID, "1234", "hi", now, false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreMessage("pending", chatJID, "1234", "", now, false, "image", "", "", "/p", "image/jpeg", []byte{1}, nil, nil, 10))
+	require.NoError(t, store.StoreMessage("done", chatJID, "1234", "", now, false, "image", "", "", "/d", "image/jpeg", []byte{1}, nil, nil, 10))
+	require.NoError(t, store.MarkMediaDownloaded("done", chatJID, "/tmp/done.jpg", now))
+
+	refs, err := store.ListPendingMedia(10)
+	require.NoError(t, err)
+	assert.Equal(t, []MessageRef{{ID: "pending", ChatJID: chatJID}}, refs)
+}
@@ -0,0 +1,46 @@
+package store
+
+import "time"
+
+// HistoryMessage is a message with the media and reply details exporters
+// need.
+type HistoryMessage struct {
+	ID         string
+	Sender     string
+	SenderName string
+	Content    string
+	Timestamp  time.Time
+	IsFromMe   bool
+	MediaType  string
+	Filename   string
+	MimeType   string
+	LocalPath  string
+	ReplyToID  string
+}
+
+// ListChatHistory returns every message in a chat, oldest first.
+func (s *MessageStore) ListChatHistory(chatJID string) ([]HistoryMessage, error) {
+	query, args := s.restrictChats(`
+		SELECT m.id, COALESCE(m.sender, ''), COALESCE(pn.name, ''), COALESCE(m.content, ''), m.timestamp, m.is_from_me,
+		       COALESCE(m.media_type, ''), COALESCE(m.filename, ''), COALESCE(m.mime_type, ''),
+		       COALESCE(m.local_path, ''), COALESCE(m.reply_to_id, '')
+		FROM messages m
+		LEFT JOIN group_participant_names pn ON pn.chat_jid = m.chat_jid AND pn.participant = `+senderUserSQL+`
+		WHERE m.chat_jid = ?`, []interface{}{chatJID}, "m.chat_jid")
+	rows, err := s.db.Query(query+" ORDER BY m.timestamp, m.rowid", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []HistoryMessage
+	for rows.Next() {
+		var m HistoryMessage
+		if err := rows.Scan(&m.ID, &m.Sender, &m.SenderName, &m.Content, &m.Timestamp, &m.IsFromMe,
+			&m.MediaType, &m.Filename, &m.MimeType, &m.LocalPath, &m.ReplyToID); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
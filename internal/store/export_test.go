@@ -0,0 +1,32 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListChatHistoryOldestFirstWithMedia(t *testing.T) {
+	store := setupTestDB(t)
+	group := "123@g.us"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(group, "Family", now))
+	require.NoError(t, store.StoreChat("other@s.whatsapp.net", "Other", now))
+	require.NoError(t, store.StoreMessage("m2", group, "34611111111", "look", now, false, "image", "beach.jpg", "", "/d", "image/jpeg", []byte{1}, nil, nil, 10))
+	require.NoError(t, store.StoreMessage("m1", group, "34611111111", "hi", now.Add(-time.Minute), false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreMessage("x", "other@s.whatsapp.net", "other", "nope", now, false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.MarkMediaDownloaded("m2", group, "/store/media/beach.jpg", now))
+	require.NoError(t, store.StoreRawMessage("m2", group, nil, "m1"))
+	require.NoError(t, store.StoreParticipantName(group, "34611111111", "Ana", ParticipantNamePush))
+
+	history, err := store.ListChatHistory(group)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "m1", history[0].ID)
+	assert.Equal(t, HistoryMessage{
+		ID: "m2", Sender: "34611111111", SenderName: "Ana", Content: "look", Timestamp: history[1].Timestamp,
+		MediaType: "image", Filename: "beach.jpg", MimeType: "image/jpeg", LocalPath: "/store/media/beach.jpg", ReplyToID: "m1",
+	}, history[1])
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// GroupInfo is the group metadata last fetched by the sync daemon.
+type GroupInfo struct {
+	Topic            string    `json:"topic,omitempty"`
+	ParticipantCount int       `json:"participant_count"`
+	AvatarPath       string    `json:"avatar_path,omitempty"`
+	RefreshedAt      time.Time `json:"refreshed_at"`
+}
+
+// GroupMember is a current member of a group.
+type GroupMember struct {
+	JID  string
+	Role string
+}
+
+// GroupMetadata is a freshly fetched snapshot of a group.
+type GroupMetadata struct {
+	ChatJID string
+	// Name renames the chat when set.
+	Name       string
+	Topic      string
+	Members    []GroupMember
+	AvatarID   string
+	AvatarPath string
+}
+
+// GroupRefreshState is what the refresher needs to know about a group
+// before fetching it again.
+type GroupRefreshState struct {
+	ChatJID    string
+	AvatarID   string
+	AvatarPath string
+}
+
+// ListGroupsDueForRefresh returns groups never checked or last checked
+// before the given time, least recently checked first.
+func (s *MessageStore) ListGroupsDueForRefresh(before time.Time, limit int) ([]GroupRefreshState, error) {
+	rows, err := s.db.Query(`
+		SELECT c.jid, COALESCE(g.avatar_id, ''), COALESCE(g.avatar_path, '')
+		FROM chats c LEFT JOIN group_metadata g ON g.chat_jid = c.jid
+		WHERE c.jid LIKE '%@g.us' AND (g.checked_at IS NULL OR g.checked_at < ?)
+		ORDER BY g.checked_at IS NOT NULL, g.checked_at, c.last_message_time DESC LIMIT ?`,
+		before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []GroupRefreshState
+	for rows.Next() {
+		var g GroupRefreshState
+		if err := rows.Scan(&g.ChatJID, &g.AvatarID, &g.AvatarPath); err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+// StoreGroupMetadata records a refreshed group, replacing its member list
+// and renaming the chat when the group name is known.
+func (s *MessageStore) StoreGroupMetadata(m GroupMetadata, at time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO group_metadata (chat_jid, topic, participant_count, avatar_id, avatar_path, refreshed_at, checked_at, last_error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, NULL)
+		ON CONFLICT(chat_jid) DO UPDATE SET
+			topic = excluded.topic,
+			participant_count = excluded.participant_count,
+			avatar_id = excluded.avatar_id,
+			avatar_path = excluded.avatar_path,
+			refreshed_at = excluded.refreshed_at,
+			checked_at = excluded.checked_at,
+			last_error = NULL`,
+		m.ChatJID, m.Topic, len(m.Members), nullIfEmpty(m.AvatarID), nullIfEmpty(m.AvatarPath), at, at,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM group_members WHERE chat_jid = ?`, m.ChatJID); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO group_members (chat_jid, participant, role) VALUES (?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, member := range m.Members {
+		if _, err := stmt.Exec(m.ChatJID, member.JID, member.Role); err != nil {
+			return err
+		}
+	}
+
+	if m.Name != "" {
+		if _, err := tx.Exec(`UPDATE chats SET name = ? WHERE jid = ?`, m.Name, m.ChatJID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetRefreshedGroupName returns the name of a group as last refreshed by the
+// sync daemon, or "" when the group has never been refreshed.
+func (s *MessageStore) GetRefreshedGroupName(jid string) (string, error) {
+	var name sql.NullString
+	err := s.db.QueryRow(`
+		SELECT c.name FROM chats c JOIN group_metadata g ON g.chat_jid = c.jid
+		WHERE c.jid = ? AND g.refreshed_at IS NOT NULL`, jid).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !name.Valid || name.String == jid {
+		return "", nil
+	}
+	return name.String, nil
+}
+
+// RecordGroupRefreshError notes a failed refresh so the group waits for its
+// next turn instead of being retried straight away. Metadata from earlier
+// refreshes is kept.
+func (s *MessageStore) RecordGroupRefreshError(chatJID, reason string, at time.Time) error {
+	_, err := s.db.Exec(`
+		INSERT INTO group_metadata (chat_jid, checked_at, last_error) VALUES (?, ?, ?)
+		ON CONFLICT(chat_jid) DO UPDATE SET checked_at = excluded.checked_at, last_error = excluded.last_error`,
+		chatJID, at, reason)
+	return err
+}
+
+// RelocateAvatars rewrites the paths of group photos saved under oldRoot to
+// point under newRoot, after the store directory has been moved. It returns
+// how many groups were updated.
+func (s *MessageStore) RelocateAvatars(oldRoot, newRoot string) (int64, error) {
+	oldRoot = mediaPrefix(oldRoot)
+	newRoot = mediaPrefix(newRoot)
+	res, err := s.db.Exec(`
+		UPDATE group_metadata SET avatar_path = ? || substr(avatar_path, length(?) + 1)
+		WHERE avatar_path IS NOT NULL AND substr(avatar_path, 1, length(?)) = ?`,
+		newRoot, oldRoot, oldRoot, oldRoot,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListGroupsDueForRefresh(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, store.StoreChat("old@g.us", "Old", now.Add(-48*time.Hour)))
+	require.NoError(t, store.StoreChat("busy@g.us", "Busy", now))
+	require.NoError(t, store.StoreChat("fresh@g.us", "Fresh", now))
+	require.NoError(t, store.StoreChat("1234@s.whatsapp.net", "John", now))
+
+	require.NoError(t, store.StoreGroupMetadata(GroupMetadata{ChatJID: "fresh@g.us", AvatarID: "p1", AvatarPath: "/a/fresh.jpg"}, now))
+	require.NoError(t, store.RecordGroupRefreshError("old@g.us", "not a participant", now.Add(-48*time.Hour)))
+
+	due, err := store.ListGroupsDueForRefresh(now.Add(-24*time.Hour), 10)
+	require.NoError(t, err)
+	// Never-checked groups come first, then the least recently checked.
+	require.Equal(t, []GroupRefreshState{{ChatJID: "busy@g.us"}, {ChatJID: "old@g.us"}}, due)
+
+	due, err = store.ListGroupsDueForRefresh(now.Add(time.Second), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 3)
+	assert.Equal(t, GroupRefreshState{ChatJID: "fresh@g.us", AvatarID: "p1", AvatarPath: "/a/fresh.jpg"}, due[2])
+
+	due, err = store.ListGroupsDueForRefresh(now.Add(time.Second), 1)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+}
+
+func TestStoreGroupMetadataShowsInChats(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	jid := "team@g.us"
+	require.NoError(t, store.StoreChat(jid, jid, now))
+	assert.Nil(t, chatByJID(t, store, jid).Group)
+
+	require.NoError(t, store.StoreGroupMetadata(GroupMetadata{
+		ChatJID:    jid,
+		Name:       "Team",
+		Topic:      "Standups at 9",
+		Members:    []GroupMember{{JID: "1@s.whatsapp.net", Role: "superadmin"}, {JID: "2@s.whatsapp.net", Role: "member"}},
+		AvatarID:   "p1",
+		AvatarPath: "/avatars/team.jpg",
+	}, now))
+
+	chat := chatByJID(t, store, jid)
+	assert.Equal(t, "Team", chat.Name)
+	require.NotNil(t, chat.Group)
+	assert.Equal(t, "Standups at 9", chat.Group.Topic)
+	assert.Equal(t, 2, chat.Group.ParticipantCount)
+	assert.Equal(t, "/avatars/team.jpg", chat.Group.AvatarPath)
+	assert.True(t, chat.Group.RefreshedAt.Equal(now))
+
+	// A failed refresh keeps what we had.
+	require.NoError(t, store.RecordGroupRefreshError(jid, "timeout", now.Add(time.Hour)))
+	chat = chatByJID(t, store, jid)
+	require.NotNil(t, chat.Group)
+	assert.Equal(t, 2, chat.Group.ParticipantCount)
+	assert.True(t, chat.Group.RefreshedAt.Equal(now))
+
+	// Members are replaced, not merged; an empty name leaves the chat name.
+	require.NoError(t, store.StoreGroupMetadata(GroupMetadata{
+		ChatJID: jid,
+		Members: []GroupMember{{JID: "3@s.whatsapp.net", Role: "admin"}},
+	}, now.Add(2*time.Hour)))
+	chat = chatByJID(t, store, jid)
+	assert.Equal(t, "Team", chat.Name)
+	assert.Equal(t, 1, chat.Group.ParticipantCount)
+	assert.Empty(t, chat.Group.AvatarPath)
+}
+
+func TestGetRefreshedGroupName(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, store.StoreChat("team@g.us", "Team", now))
+	require.NoError(t, store.StoreChat("failed@g.us", "Failed", now))
+
+	name, err := store.GetRefreshedGroupName("team@g.us")
+	require.NoError(t, err)
+	assert.Empty(t, name, "never refreshed")
+
+	require.NoError(t, store.RecordGroupRefreshError("failed@g.us", "not a participant", now))
+	name, err = store.GetRefreshedGroupName("failed@g.us")
+	require.NoError(t, err)
+	assert.Empty(t, name, "checked but never refreshed")
+
+	require.NoError(t, store.StoreGroupMetadata(GroupMetadata{ChatJID: "team@g.us", Name: "Team Renamed"}, now))
+	name, err = store.GetRefreshedGroupName("team@g.us")
+	require.NoError(t, err)
+	assert.Equal(t, "Team Renamed", name)
+}
@@ -0,0 +1,65 @@
+package store
+
+import "time"
+
+// ImportedMessage is a message read from a phone backup.
+type ImportedMessage struct {
+	ID        string
+	ChatJID   string
+	Sender    string
+	Content   string
+	Timestamp time.Time
+	IsFromMe  bool
+	MediaType string
+}
+
+// ImportChat records a chat found in a backup. Unlike StoreChat it never
+// renames a chat that already has a real name, and it only moves
+// last_message_time forward.
+func (s *MessageStore) ImportChat(jid, name string, lastMessageTime time.Time) error {
+	if name == "" {
+		name = jid
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO chats (jid, name, last_message_time) VALUES (?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			name = CASE
+				WHEN chats.name IS NULL OR chats.name = '' OR chats.name = chats.jid THEN excluded.name
+				ELSE chats.name
+			END,
+			last_message_time = MAX(COALESCE(chats.last_message_time, excluded.last_message_time), excluded.last_message_time)`,
+		jid, name, lastMessageTime,
+	)
+	return err
+}
+
+// ImportMessages inserts backup messages that aren't stored yet, leaving
+// synced copies of the same message untouched, and returns how many were
+// new.
+func (s *MessageStore) ImportMessages(messages []ImportedMessage) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO messages
+		(id, chat_jid, sender, content, timestamp, is_from_me, media_type)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	inserted := 0
+	for _, m := range messages {
+		res, err := stmt.Exec(m.ID, m.ChatJID, m.Sender, m.Content, m.Timestamp, m.IsFromMe, m.MediaType)
+		if err != nil {
+			return 0, err
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			inserted += int(n)
+		}
+	}
+	return inserted, tx.Commit()
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportMessagesSkipsStoredMessages(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "1234@s.whatsapp.net"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(chatJID, "John", now))
+	require.NoError(t, store.StoreMessage("synced", chatJID, "1234", "synced text", now, false, "", "", "", "", "", nil, nil, nil, 0))
+
+	inserted, err := store.ImportMessages([]ImportedMessage{
+		{ID: "synced", ChatJID: chatJID, Sender: chatJID, Content: "backup text", Timestamp: now},
+		{ID: "old", ChatJID: chatJID, Sender: chatJID, Content: "from 2017", Timestamp: now.AddDate(-8, 0, 0), MediaType: "image"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, inserted)
+
+	messages, err := store.ListMessages(ListMessagesParams{ChatJID: &chatJID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "synced text", messages[0].Content, "synced copies win")
+	assert.Equal(t, "image", messages[1].MediaType)
+
+	inserted, err = store.ImportMessages([]ImportedMessage{{ID: "old", ChatJID: chatJID, Timestamp: now}})
+	require.NoError(t, err)
+	assert.Zero(t, inserted, "re-importing is a no-op")
+}
+
+func TestImportChatKeepsNamesAndLatestTime(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now()
+	require.NoError(t, store.StoreChat("a@s.whatsapp.net", "Alice", now))
+	require.NoError(t, store.StoreChat("g@g.us", "g@g.us", now))
+
+	require.NoError(t, store.ImportChat("a@s.whatsapp.net", "Alice (old)", now.AddDate(-1, 0, 0)))
+	require.NoError(t, store.ImportChat("g@g.us", "Family", now.AddDate(-1, 0, 0)))
+	require.NoError(t, store.ImportChat("new@s.whatsapp.net", "", now.AddDate(-2, 0, 0)))
+
+	chats, err := store.ListChats(ListChatsParams{Limit: 10})
+	require.NoError(t, err)
+	names := map[string]string{}
+	for _, c := range chats {
+		names[c.JID] = c.Name
+		if c.JID != "new@s.whatsapp.net" {
+			assert.WithinDuration(t, now, c.LastMessageTime, time.Second, "%s keeps its latest time", c.JID)
+		}
+	}
+	assert.Equal(t, map[string]string{"a@s.whatsapp.net": "Alice", "g@g.us": "Family", "new@s.whatsapp.net": "new@s.whatsapp.net"}, names)
+}
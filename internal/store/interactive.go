@@ -0,0 +1,12 @@
+package store
+
+// StoreInteractive records that a message already saved with StoreMessage
+// is a reply-button or list message (kind "buttons" or "list") or a reply
+// to one ("button_reply", "list_reply" with the selected button or row ID).
+func (s *MessageStore) StoreInteractive(id, chatJID, kind, selectedID string) error {
+	_, err := s.db.Exec(
+		`UPDATE messages SET message_type = ?, selected_id = NULLIF(?, '') WHERE id = ? AND chat_jid = ?`,
+		kind, selectedID, id, chatJID,
+	)
+	return err
+}
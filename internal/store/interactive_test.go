@@ -0,0 +1,26 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreInteractiveRecordsSelection(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "1234@s.whatsapp.net"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(chatJID, "John", now))
+	require.NoError(t, store.StoreMessage("reply1", chatJID, "1234", "[Button reply] Yes", now, false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreRawMessage("reply1", chatJID, nil, "BTN1"))
+	require.NoError(t, store.StoreInteractive("reply1", chatJID, "button_reply", "yes"))
+
+	kind := "button_reply"
+	messages, err := store.ListMessages(ListMessagesParams{Type: &kind, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "yes", messages[0].SelectedID)
+	assert.Equal(t, "BTN1", messages[0].ReplyToID)
+}
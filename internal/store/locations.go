@@ -0,0 +1,107 @@
+package store
+
+import (
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/uri"
+)
+
+// LocationPoint is one shared position. Live location updates share the
+// MessageID of the live location message that started the track.
+type LocationPoint struct {
+	MessageID string    `json:"message_id"`
+	ChatJID   string    `json:"chat_jid"`
+	Sender    string    `json:"sender"`
+	Live      bool      `json:"live"`
+	Sequence  int64     `json:"sequence"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	AccuracyM uint32    `json:"accuracy_m,omitempty"`
+	SpeedMps  float32   `json:"speed_mps,omitempty"`
+	Heading   uint32    `json:"heading,omitempty"`
+	Label     string    `json:"label,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// URI references the location message; `open` resolves it.
+	URI string `json:"uri"`
+}
+
+// StoreLocation records a position. Points already stored (replayed
+// history, redelivered updates) are ignored.
+func (s *MessageStore) StoreLocation(p LocationPoint) error {
+	_, err := s.db.Exec(
+		`INSERT OR IGNORE INTO locations
+		(message_id, chat_jid, sender, live, sequence, latitude, longitude, accuracy_m, speed_mps, heading, label, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?)`,
+		p.MessageID, p.ChatJID, p.Sender, p.Live, p.Sequence, p.Latitude, p.Longitude,
+		p.AccuracyM, p.SpeedMps, p.Heading, p.Label, p.Timestamp,
+	)
+	return err
+}
+
+// FindLiveLocationTrack returns the message ID and last sequence number of
+// the most recent live location track from sender in a chat with a point
+// at or after since. id is "" if there is none.
+func (s *MessageStore) FindLiveLocationTrack(chatJID, sender string, since time.Time) (id string, lastSequence int64, err error) {
+	rows, err := s.db.Query(`
+		SELECT message_id, sequence FROM locations
+		WHERE chat_jid = ? AND sender = ? AND live = 1 AND timestamp >= ?
+		ORDER BY timestamp DESC, sequence DESC LIMIT 1`, chatJID, sender, since)
+	if err != nil {
+		return "", 0, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&id, &lastSequence); err != nil {
+			return "", 0, err
+		}
+	}
+	return id, lastSequence, rows.Err()
+}
+
+// LocationTrack returns the points of one location message in order. A nil
+// chatJID matches the message ID in any chat.
+func (s *MessageStore) LocationTrack(messageID string, chatJID *string) ([]LocationPoint, error) {
+	query := `SELECT message_id, chat_jid, COALESCE(sender, ''), live, sequence, latitude, longitude,
+		COALESCE(accuracy_m, 0), COALESCE(speed_mps, 0), COALESCE(heading, 0), COALESCE(label, ''), timestamp
+		FROM locations WHERE message_id = ?`
+	args := []interface{}{messageID}
+	if chatJID != nil {
+		query += ` AND chat_jid = ?`
+		args = append(args, *chatJID)
+	}
+	query, args = s.restrictChats(query, args, "chat_jid")
+	return s.queryLocations(query+` ORDER BY timestamp, sequence`, args...)
+}
+
+// ListLocations returns every point shared in a chat, ordered by track
+// (the message that started it) and then by time.
+func (s *MessageStore) ListLocations(chatJID string) ([]LocationPoint, error) {
+	query := `SELECT message_id, chat_jid, COALESCE(sender, ''), live, sequence, latitude, longitude,
+		COALESCE(accuracy_m, 0), COALESCE(speed_mps, 0), COALESCE(heading, 0), COALESCE(label, ''), timestamp
+		FROM locations WHERE chat_jid = ?`
+	query, args := s.restrictChats(query, []interface{}{chatJID}, "chat_jid")
+	return s.queryLocations(query+`
+		ORDER BY (SELECT MIN(l.timestamp) FROM locations l WHERE l.message_id = locations.message_id AND l.chat_jid = locations.chat_jid),
+		message_id, timestamp, sequence`, args...)
+}
+
+func (s *MessageStore) queryLocations(query string, args ...interface{}) ([]LocationPoint, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []LocationPoint
+	for rows.Next() {
+		var p LocationPoint
+		if err := rows.Scan(&p.MessageID, &p.ChatJID, &p.Sender, &p.Live, &p.Sequence, &p.Latitude, &p.Longitude,
+			&p.AccuracyM, &p.SpeedMps, &p.Heading, &p.Label, &p.Timestamp); err != nil {
+			return nil, err
+		}
+		p.URI = uri.Message(p.ChatJID, p.MessageID)
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
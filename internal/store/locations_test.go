@@ -0,0 +1,89 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocationTrackOrdersPointsAndIgnoresDuplicates(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "1@s.whatsapp.net"
+	start := time.Unix(1700000000, 0)
+
+	for i, lat := range []float64{40.0, 40.1, 40.2} {
+		require.NoError(t, store.StoreLocation(LocationPoint{
+			MessageID: "LIVE1", ChatJID: chatJID, Sender: "1", Live: true,
+			Sequence: int64(i + 1), Latitude: lat, Longitude: -3.7,
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+	// A redelivered update is ignored.
+	require.NoError(t, store.StoreLocation(LocationPoint{
+		MessageID: "LIVE1", ChatJID: chatJID, Sender: "1", Live: true,
+		Sequence: 2, Latitude: 40.1, Longitude: -3.7, Timestamp: start.Add(time.Minute),
+	}))
+
+	points, err := store.LocationTrack("LIVE1", nil)
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+	assert.Equal(t, 40.0, points[0].Latitude)
+	assert.Equal(t, 40.2, points[2].Latitude)
+	assert.True(t, points[0].Live)
+	assert.Equal(t, "whatsapp-cli://chat/1@s.whatsapp.net/message/LIVE1", points[0].URI)
+
+	other := "2@s.whatsapp.net"
+	points, err = store.LocationTrack("LIVE1", &other)
+	require.NoError(t, err)
+	assert.Empty(t, points)
+}
+
+func TestFindLiveLocationTrack(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "1@s.whatsapp.net"
+	now := time.Now()
+	require.NoError(t, store.StoreLocation(LocationPoint{
+		MessageID: "OLD", ChatJID: chatJID, Sender: "1", Live: true, Sequence: 1, Timestamp: now.Add(-24 * time.Hour),
+	}))
+	require.NoError(t, store.StoreLocation(LocationPoint{
+		MessageID: "NEW", ChatJID: chatJID, Sender: "1", Live: true, Sequence: 4, Timestamp: now.Add(-time.Hour),
+	}))
+	require.NoError(t, store.StoreLocation(LocationPoint{
+		MessageID: "PIN", ChatJID: chatJID, Sender: "1", Timestamp: now,
+	}))
+
+	id, seq, err := store.FindLiveLocationTrack(chatJID, "1", now.Add(-8*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "NEW", id)
+	assert.EqualValues(t, 4, seq)
+
+	id, _, err = store.FindLiveLocationTrack(chatJID, "2", now.Add(-8*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, id)
+}
+
+func TestListLocationsGroupsTracksInOrder(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "1@s.whatsapp.net"
+	start := time.Unix(1700000000, 0)
+
+	points := []LocationPoint{
+		{MessageID: "PIN", ChatJID: chatJID, Sender: "2", Latitude: 41.0, Longitude: 2.0, Timestamp: start.Add(2 * time.Minute)},
+		{MessageID: "LIVE1", ChatJID: chatJID, Sender: "1", Live: true, Sequence: 1, Latitude: 40.0, Longitude: -3.7, Timestamp: start},
+		{MessageID: "LIVE1", ChatJID: chatJID, Sender: "1", Live: true, Sequence: 2, Latitude: 40.1, Longitude: -3.7, Timestamp: start.Add(5 * time.Minute)},
+		{MessageID: "ELSEWHERE", ChatJID: "2@s.whatsapp.net", Latitude: 1, Longitude: 1, Timestamp: start},
+	}
+	for _, p := range points {
+		require.NoError(t, store.StoreLocation(p))
+	}
+
+	got, err := store.ListLocations(chatJID)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	// The live track started first, so both of its points come before the pin.
+	assert.Equal(t, "LIVE1", got[0].MessageID)
+	assert.Equal(t, "LIVE1", got[1].MessageID)
+	assert.Equal(t, "PIN", got[2].MessageID)
+}
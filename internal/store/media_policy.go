@@ -0,0 +1,104 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ChatMediaPolicy is a per-chat override of which media sync downloads
+// automatically. Empty Types allows every type; MaxSize 0 means no size cap.
+type ChatMediaPolicy struct {
+	ChatJID   string    `json:"chat_jid"`
+	ChatName  string    `json:"chat_name,omitempty"`
+	Types     []string  `json:"types"`
+	MaxSize   int64     `json:"max_size"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Allows reports whether media of the given type and size may be
+// downloaded. A size of 0 (unknown) passes the size cap.
+func (p ChatMediaPolicy) Allows(mediaType string, size int64) bool {
+	if p.MaxSize > 0 && size > p.MaxSize {
+		return false
+	}
+	if len(p.Types) == 0 {
+		return true
+	}
+	for _, t := range p.Types {
+		if t == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MessageStore) SetChatMediaPolicy(chatJID string, types []string, maxSize int64) error {
+	_, err := s.db.Exec(
+		`INSERT INTO chat_media_policy (chat_jid, types, max_size, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(chat_jid) DO UPDATE SET
+			types = excluded.types,
+			max_size = excluded.max_size,
+			updated_at = excluded.updated_at`,
+		chatJID, strings.Join(types, ","), maxSize, time.Now().UTC(),
+	)
+	return err
+}
+
+func (s *MessageStore) ClearChatMediaPolicy(chatJID string) error {
+	_, err := s.db.Exec(`DELETE FROM chat_media_policy WHERE chat_jid = ?`, chatJID)
+	return err
+}
+
+// GetChatMediaPolicy returns a chat's override, or nil if it has none.
+func (s *MessageStore) GetChatMediaPolicy(chatJID string) (*ChatMediaPolicy, error) {
+	var p ChatMediaPolicy
+	var types string
+	err := s.db.QueryRow(
+		`SELECT chat_jid, types, max_size, updated_at FROM chat_media_policy WHERE chat_jid = ?`, chatJID,
+	).Scan(&p.ChatJID, &types, &p.MaxSize, &p.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.Types = splitTypes(types)
+	return &p, nil
+}
+
+func (s *MessageStore) ListChatMediaPolicies() ([]ChatMediaPolicy, error) {
+	query, args := s.restrictChats(`
+		SELECT p.chat_jid, COALESCE(c.name, ''), p.types, p.max_size, p.updated_at
+		FROM chat_media_policy p
+		LEFT JOIN chats c ON p.chat_jid = c.jid
+		WHERE 1=1`, nil, "p.chat_jid")
+	rows, err := s.db.Query(query+" ORDER BY p.chat_jid", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []ChatMediaPolicy
+	for rows.Next() {
+		var p ChatMediaPolicy
+		var types string
+		if err := rows.Scan(&p.ChatJID, &p.ChatName, &types, &p.MaxSize, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		p.Types = splitTypes(types)
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+func splitTypes(value string) []string {
+	types := []string{}
+	for _, t := range strings.Split(value, ",") {
+		if t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
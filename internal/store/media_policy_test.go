@@ -0,0 +1,46 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatMediaPolicyRoundTrip(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "family@g.us"
+
+	policy, err := store.GetChatMediaPolicy(chatJID)
+	require.NoError(t, err)
+	assert.Nil(t, policy, "chats have no policy by default")
+
+	require.NoError(t, store.SetChatMediaPolicy(chatJID, []string{"image", "document"}, 5<<20))
+	policy, err = store.GetChatMediaPolicy(chatJID)
+	require.NoError(t, err)
+	require.NotNil(t, policy)
+	assert.Equal(t, []string{"image", "document"}, policy.Types)
+	assert.EqualValues(t, 5<<20, policy.MaxSize)
+
+	require.NoError(t, store.SetChatMediaPolicy(chatJID, []string{}, 0))
+	policies, err := store.ListChatMediaPolicies()
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Empty(t, policies[0].Types)
+
+	require.NoError(t, store.ClearChatMediaPolicy(chatJID))
+	policies, err = store.ListChatMediaPolicies()
+	require.NoError(t, err)
+	assert.Empty(t, policies)
+}
+
+func TestChatMediaPolicyAllows(t *testing.T) {
+	p := ChatMediaPolicy{Types: []string{"image", "document"}, MaxSize: 1000}
+	assert.True(t, p.Allows("image", 500))
+	assert.True(t, p.Allows("document", 0), "unknown sizes pass the cap")
+	assert.False(t, p.Allows("image", 1001))
+	assert.False(t, p.Allows("video", 10))
+
+	assert.True(t, ChatMediaPolicy{}.Allows("video", 1<<30))
+	assert.False(t, ChatMediaPolicy{Types: []string{"none"}}.Allows("image", 1))
+}
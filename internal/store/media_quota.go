@@ -0,0 +1,89 @@
+package store
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StoredMedia is a downloaded media file tracked for quota accounting.
+type StoredMedia struct {
+	ID           string
+	ChatJID      string
+	LocalPath    string
+	Size         int64
+	DownloadedAt time.Time
+}
+
+// mediaPrefix returns root with a trailing separator, so matching paths
+// against it skips sibling directories such as media-export.
+func mediaPrefix(root string) string {
+	return strings.TrimRight(root, `/\`) + string(filepath.Separator)
+}
+
+// MediaUsage sums the size of downloaded media whose local path is under
+// root. Files saved elsewhere with `media download --output` don't count.
+func (s *MessageStore) MediaUsage(root string) (int64, error) {
+	var total int64
+	root = mediaPrefix(root)
+	err := s.db.QueryRow(`
+		SELECT COALESCE(SUM(file_length), 0) FROM messages
+		WHERE local_path IS NOT NULL AND substr(local_path, 1, length(?)) = ?`,
+		root, root,
+	).Scan(&total)
+	return total, err
+}
+
+// ListEvictableMedia returns downloaded media under root, least recently
+// downloaded first. `media download` refreshes downloaded_at, so files the
+// user asks for again move to the back of the line.
+func (s *MessageStore) ListEvictableMedia(root string, limit int) ([]StoredMedia, error) {
+	root = mediaPrefix(root)
+	rows, err := s.db.Query(`
+		SELECT id, chat_jid, local_path, COALESCE(file_length, 0), downloaded_at FROM messages
+		WHERE local_path IS NOT NULL AND substr(local_path, 1, length(?)) = ?
+		ORDER BY downloaded_at ASC
+		LIMIT ?`, root, root, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var media []StoredMedia
+	for rows.Next() {
+		var m StoredMedia
+		if err := rows.Scan(&m.ID, &m.ChatJID, &m.LocalPath, &m.Size, &m.DownloadedAt); err != nil {
+			return nil, err
+		}
+		media = append(media, m)
+	}
+	return media, rows.Err()
+}
+
+// MarkMediaEvicted forgets the local copy of a message's media so it is no
+// longer counted or offered for background download again.
+func (s *MessageStore) MarkMediaEvicted(id, chatJID string, at time.Time) error {
+	_, err := s.db.Exec(
+		`UPDATE messages SET local_path = NULL, downloaded_at = NULL, media_evicted_at = ?
+		 WHERE id = ? AND chat_jid = ?`,
+		at, id, chatJID,
+	)
+	return err
+}
+
+// RelocateMedia rewrites the local paths of media downloaded under oldRoot
+// to point under newRoot, after the store directory has been moved. It
+// returns how many messages were updated.
+func (s *MessageStore) RelocateMedia(oldRoot, newRoot string) (int64, error) {
+	oldRoot = mediaPrefix(oldRoot)
+	newRoot = mediaPrefix(newRoot)
+	res, err := s.db.Exec(`
+		UPDATE messages SET local_path = ? || substr(local_path, length(?) + 1)
+		WHERE local_path IS NOT NULL AND substr(local_path, 1, length(?)) = ?`,
+		newRoot, oldRoot, oldRoot, oldRoot,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
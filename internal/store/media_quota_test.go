@@ -0,0 +1,84 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaUsageOnlyCountsFilesUnderRoot(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "1234@s.whatsapp.net"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(chatJID, "John Doe", now))
+	require.NoError(t, store.StoreMessage("in", chatJID, "1234", "", now, false, "image", "", "", "/a", "image/jpeg", []byte{1}, nil, nil, 100))
+	require.NoError(t, store.StoreMessage("out", chatJID, "1234", "", now, false, "image", "", "", "/b", "image/jpeg", []byte{1}, nil, nil, 50))
+	require.NoError(t, store.MarkMediaDownloaded("in", chatJID, "/store/media/in.jpg", now))
+	require.NoError(t, store.MarkMediaDownloaded("out", chatJID, "/tmp/out.jpg", now))
+	require.NoError(t, store.StoreMessage("sibling", chatJID, "1234", "", now, false, "image", "", "", "/c", "image/jpeg", []byte{1}, nil, nil, 25))
+	require.NoError(t, store.MarkMediaDownloaded("sibling", chatJID, "/store/media-export/s.jpg", now))
+
+	usage, err := store.MediaUsage("/store/media")
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), usage)
+
+	media, err := store.ListEvictableMedia("/store/media/", 10)
+	require.NoError(t, err)
+	require.Len(t, media, 1)
+	assert.Equal(t, "in", media[0].ID)
+}
+
+func TestListEvictableMediaOldestFirstAndEviction(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "1234@s.whatsapp.net"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(chatJID, "John Doe", now))
+	require.NoError(t, store.StoreMessage("old", chatJID, "1234", "", now, false, "image", "", "", "/a", "image/jpeg", []byte{1}, nil, nil, 10))
+	require.NoError(t, store.StoreMessage("new", chatJID, "1234", "", now, false, "image", "", "", "/b", "image/jpeg", []byte{1}, nil, nil, 20))
+	require.NoError(t, store.MarkMediaDownloaded("new", chatJID, "/m/new.jpg", now))
+	require.NoError(t, store.MarkMediaDownloaded("old", chatJID, "/m/old.jpg", now.Add(-time.Hour)))
+
+	media, err := store.ListEvictableMedia("/m", 10)
+	require.NoError(t, err)
+	require.Len(t, media, 2)
+	assert.Equal(t, "old", media[0].ID)
+	assert.Equal(t, int64(10), media[0].Size)
+
+	require.NoError(t, store.MarkMediaEvicted("old", chatJID, now))
+	usage, err := store.MediaUsage("/m")
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), usage)
+
+	pending, err := store.ListPendingMedia(10)
+	require.NoError(t, err)
+	assert.Empty(t, pending, "evicted media should not be re-downloaded in the background")
+}
+
+func TestRelocateMediaRewritesPathsUnderRoot(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "1234@s.whatsapp.net"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(chatJID, "John Doe", now))
+	for _, id := range []string{"in", "sibling", "out"} {
+		require.NoError(t, store.StoreMessage(id, chatJID, "1234", "", now, false, "image", "", "", "/a", "image/jpeg", []byte{1}, nil, nil, 10))
+	}
+	require.NoError(t, store.MarkMediaDownloaded("in", chatJID, "/old/media/1234/in.jpg", now))
+	require.NoError(t, store.MarkMediaDownloaded("sibling", chatJID, "/old/media-backup/s.jpg", now))
+	require.NoError(t, store.MarkMediaDownloaded("out", chatJID, "/tmp/out.jpg", now))
+
+	updated, err := store.RelocateMedia("/old/media", "/new/media/")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+
+	media, err := store.ListEvictableMedia("/", 10)
+	require.NoError(t, err)
+	paths := map[string]string{}
+	for _, m := range media {
+		paths[m.ID] = m.LocalPath
+	}
+	assert.Equal(t, "/new/media/1234/in.jpg", paths["in"])
+	assert.Equal(t, "/old/media-backup/s.jpg", paths["sibling"])
+	assert.Equal(t, "/tmp/out.jpg", paths["out"])
+}
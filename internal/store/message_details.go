@@ -0,0 +1,71 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+)
+
+// MessageDetails is everything the store knows about one message.
+type MessageDetails struct {
+	Message
+	Filename     string     `json:"filename,omitempty"`
+	MimeType     string     `json:"mime_type,omitempty"`
+	FileLength   int64      `json:"file_length,omitempty"`
+	LocalPath    string     `json:"local_path,omitempty"`
+	DownloadedAt *time.Time `json:"downloaded_at,omitempty"`
+	ReadMarkedAt *time.Time `json:"read_marked_at,omitempty"`
+}
+
+// GetMessageDetails returns one message. It returns sql.ErrNoRows when the
+// message is not in the store or its chat is not allowed.
+func (s *MessageStore) GetMessageDetails(chatJID, id string) (MessageDetails, error) {
+	query, args := s.restrictChats(messageSelect+" AND m.chat_jid = ? AND m.id = ?",
+		[]interface{}{chatJID, id}, "m.chat_jid")
+	messages, err := s.queryMessages(query, args...)
+	if err != nil {
+		return MessageDetails{}, err
+	}
+	if len(messages) == 0 {
+		return MessageDetails{}, sql.ErrNoRows
+	}
+
+	d := MessageDetails{Message: messages[0]}
+	var fileLength sql.NullInt64
+	var downloadedAt, readMarkedAt sql.NullTime
+	err = s.db.QueryRow(`
+		SELECT COALESCE(filename, ''), COALESCE(mime_type, ''), file_length, COALESCE(local_path, ''), downloaded_at, read_marked_at
+		FROM messages WHERE chat_jid = ? AND id = ?`, chatJID, id,
+	).Scan(&d.Filename, &d.MimeType, &fileLength, &d.LocalPath, &downloadedAt, &readMarkedAt)
+	if err != nil {
+		return MessageDetails{}, err
+	}
+	d.FileLength = fileLength.Int64
+	d.DownloadedAt = nullTimePtr(downloadedAt)
+	d.ReadMarkedAt = nullTimePtr(readMarkedAt)
+	return d, nil
+}
+
+// ListMessagesAround returns up to n messages on each side of a message in
+// its chat, oldest first. Messages sharing its timestamp are ordered by ID.
+func (s *MessageStore) ListMessagesAround(chatJID, id string, at time.Time, n int) (before, after []Message, err error) {
+	if n <= 0 {
+		return nil, nil, nil
+	}
+	query, args := s.restrictChats(messageSelect+" AND m.chat_jid = ? AND (m.timestamp < ? OR (m.timestamp = ? AND m.id < ?))",
+		[]interface{}{chatJID, at, at, id}, "m.chat_jid")
+	before, err = s.queryMessages(query+" ORDER BY m.timestamp DESC, m.id DESC LIMIT ?", append(args, n)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, j := 0, len(before)-1; i < j; i, j = i+1, j-1 {
+		before[i], before[j] = before[j], before[i]
+	}
+
+	query, args = s.restrictChats(messageSelect+" AND m.chat_jid = ? AND (m.timestamp > ? OR (m.timestamp = ? AND m.id > ?))",
+		[]interface{}{chatJID, at, at, id}, "m.chat_jid")
+	after, err = s.queryMessages(query+" ORDER BY m.timestamp, m.id LIMIT ?", append(args, n)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
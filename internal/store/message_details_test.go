@@ -0,0 +1,75 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMessageDetails(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	jid := "1234@s.whatsapp.net"
+	require.NoError(t, store.StoreChat(jid, "John", now))
+	require.NoError(t, store.StoreMessage("M1", jid, "1234", "look", now, false, "image", "cat.jpg", "", "/d/p", "image/jpeg", []byte{1}, nil, nil, 2048))
+	require.NoError(t, store.MarkMediaDownloaded("M1", jid, "/media/cat.jpg", now))
+
+	d, err := store.GetMessageDetails(jid, "M1")
+	require.NoError(t, err)
+	assert.Equal(t, "look", d.Content)
+	assert.Equal(t, "John", d.ChatName)
+	assert.Equal(t, "whatsapp-cli://chat/1234@s.whatsapp.net/message/M1", d.URI)
+	assert.Equal(t, "cat.jpg", d.Filename)
+	assert.Equal(t, "image/jpeg", d.MimeType)
+	assert.EqualValues(t, 2048, d.FileLength)
+	assert.Equal(t, "/media/cat.jpg", d.LocalPath)
+	require.NotNil(t, d.DownloadedAt)
+	assert.Nil(t, d.ReadMarkedAt)
+
+	_, err = store.GetMessageDetails("other@s.whatsapp.net", "M1")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	store.SetAllowedChats([]string{"other@s.whatsapp.net"})
+	_, err = store.GetMessageDetails(jid, "M1")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListMessagesAround(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	jid := "1234@s.whatsapp.net"
+	require.NoError(t, store.StoreChat(jid, "John", now))
+	require.NoError(t, store.StoreChat("other@s.whatsapp.net", "Other", now))
+	for i, id := range []string{"A", "B", "C", "D", "E"} {
+		require.NoError(t, store.StoreMessage(id, jid, "1234", id, now.Add(time.Duration(i)*time.Minute), false, "", "", "", "", "", nil, nil, nil, 0))
+	}
+	// Shares C's timestamp, so it is ordered by ID.
+	require.NoError(t, store.StoreMessage("C2", jid, "1234", "C2", now.Add(2*time.Minute), false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreMessage("X", "other@s.whatsapp.net", "9", "X", now.Add(2*time.Minute), false, "", "", "", "", "", nil, nil, nil, 0))
+
+	ids := func(messages []Message) []string {
+		var out []string
+		for _, m := range messages {
+			out = append(out, m.ID)
+		}
+		return out
+	}
+
+	before, after, err := store.ListMessagesAround(jid, "C", now.Add(2*time.Minute), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A", "B"}, ids(before))
+	assert.Equal(t, []string{"C2", "D"}, ids(after))
+
+	before, after, err = store.ListMessagesAround(jid, "A", now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, before)
+	assert.Equal(t, []string{"B", "C", "C2", "D", "E"}, ids(after))
+
+	before, after, err = store.ListMessagesAround(jid, "C", now.Add(2*time.Minute), 0)
+	require.NoError(t, err)
+	assert.Nil(t, before)
+	assert.Nil(t, after)
+}
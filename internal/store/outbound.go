@@ -0,0 +1,263 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/vicentereig/whatsapp-cli/internal/uri"
+)
+
+// Outbound message states, in lifecycle order. Failed can follow queued
+// (the send call errored) or sent (the server rejected delivery).
+const (
+	OutboundQueued    = "queued"
+	OutboundSent      = "sent"
+	OutboundDelivered = "delivered"
+	OutboundRead      = "read"
+	OutboundFailed    = "failed"
+)
+
+// OutboundMessage is one CLI-initiated send.
+type OutboundMessage struct {
+	ID        int64  `json:"id"`
+	MessageID string `json:"message_id,omitempty"`
+	// URI is set once the message has been sent and has an ID.
+	URI         string     `json:"uri,omitempty"`
+	ChatJID     string     `json:"chat_jid"`
+	Kind        string     `json:"kind"`
+	Content     string     `json:"content"`
+	Attachment  string     `json:"attachment,omitempty"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+	// DeliveryLatencyMs and ReadLatencyMs measure from the server ack to
+	// the first delivery and read receipt.
+	DeliveryLatencyMs *int64 `json:"delivery_latency_ms,omitempty"`
+	ReadLatencyMs     *int64 `json:"read_latency_ms,omitempty"`
+}
+
+// DeliveryTiming holds the receipt timestamps of one sent message.
+type DeliveryTiming struct {
+	ChatJID     string
+	SentAt      time.Time
+	DeliveredAt *time.Time
+	ReadAt      *time.Time
+}
+
+// DeliveryLatency is the time from send to the first delivery receipt. A
+// read receipt implies delivery, so it stands in when no delivery receipt
+// was seen. ok is false while the message is undelivered.
+func (d DeliveryTiming) DeliveryLatency() (latency time.Duration, ok bool) {
+	if d.DeliveredAt != nil {
+		return clampLatency(d.DeliveredAt.Sub(d.SentAt)), true
+	}
+	return d.ReadLatency()
+}
+
+// ReadLatency is the time from send to the first read receipt.
+func (d DeliveryTiming) ReadLatency() (latency time.Duration, ok bool) {
+	if d.ReadAt == nil {
+		return 0, false
+	}
+	return clampLatency(d.ReadAt.Sub(d.SentAt)), true
+}
+
+// clampLatency hides small negative values caused by receipts carrying
+// server time while sent_at is local time.
+func clampLatency(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+type ListOutboundParams struct {
+	Status string
+	Limit  int
+}
+
+// LogOutbound records a send before it is attempted and returns its log ID.
+// attachment is the local file sent, if any, so failed sends can be retried.
+func (s *MessageStore) LogOutbound(chatJID, kind, content, attachment string, at time.Time) (int64, error) {
+	res, err := s.db.Exec(
+		`INSERT INTO outbound_log (chat_jid, kind, content, attachment, status, created_at, updated_at)
+		VALUES (?, ?, ?, NULLIF(?, ''), ?, ?, ?)`,
+		chatJID, kind, content, attachment, OutboundQueued, at, at,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// MarkOutboundSent records the server ack for a logged send.
+func (s *MessageStore) MarkOutboundSent(logID int64, messageID string, at time.Time) error {
+	_, err := s.db.Exec(
+		`UPDATE outbound_log SET message_id = ?, status = ?, sent_at = ?, updated_at = ?
+		WHERE id = ? AND status = ?`,
+		messageID, OutboundSent, at, at, logID, OutboundQueued,
+	)
+	return err
+}
+
+// MarkOutboundFailed records why a logged send did not go through.
+func (s *MessageStore) MarkOutboundFailed(logID int64, reason string, at time.Time) error {
+	_, err := s.db.Exec(
+		`UPDATE outbound_log SET status = ?, error = ?, updated_at = ? WHERE id = ?`,
+		OutboundFailed, reason, at, logID,
+	)
+	return err
+}
+
+// UpdateOutboundStatus applies a receipt to logged sends by WhatsApp
+// message ID. Delivered and read only move a message forward, so a late
+// delivery receipt never downgrades a read message; failed
+// applies to anything not yet read. Unknown IDs (messages not sent by the
+// CLI) are ignored. It returns the number of log entries changed.
+func (s *MessageStore) UpdateOutboundStatus(messageIDs []string, status string, at time.Time) (int64, error) {
+	if len(messageIDs) == 0 {
+		return 0, nil
+	}
+
+	var allowed []string
+	timestampColumn := ""
+	switch status {
+	case OutboundDelivered:
+		allowed = []string{OutboundQueued, OutboundSent}
+		timestampColumn = "delivered_at"
+	case OutboundRead:
+		allowed = []string{OutboundQueued, OutboundSent, OutboundDelivered}
+		timestampColumn = "read_at"
+	case OutboundFailed:
+		allowed = []string{OutboundQueued, OutboundSent, OutboundDelivered}
+	default:
+		return 0, nil
+	}
+
+	query := `UPDATE outbound_log SET status = ?, updated_at = ?`
+	args := []interface{}{status, at}
+	if timestampColumn != "" {
+		query += `, ` + timestampColumn + ` = COALESCE(` + timestampColumn + `, ?)`
+		args = append(args, at)
+	}
+	query += ` WHERE message_id IN (` + placeholders(len(messageIDs)) + `)`
+	for _, id := range messageIDs {
+		args = append(args, id)
+	}
+	query += ` AND status IN (` + placeholders(len(allowed)) + `)`
+	for _, st := range allowed {
+		args = append(args, st)
+	}
+
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ListOutbound returns logged sends, newest first, optionally filtered by
+// status.
+func (s *MessageStore) ListOutbound(params ListOutboundParams) ([]OutboundMessage, error) {
+	query := `SELECT id, COALESCE(message_id, ''), chat_jid, kind, COALESCE(content, ''), COALESCE(attachment, ''), status, COALESCE(error, ''),
+		created_at, updated_at, sent_at, delivered_at, read_at
+		FROM outbound_log WHERE 1=1`
+	var args []interface{}
+	if params.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, params.Status)
+	}
+	query, args = s.restrictChats(query, args, "chat_jid")
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, params.Limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []OutboundMessage
+	for rows.Next() {
+		var m OutboundMessage
+		var sentAt, deliveredAt, readAt sql.NullTime
+		if err := rows.Scan(&m.ID, &m.MessageID, &m.ChatJID, &m.Kind, &m.Content, &m.Attachment, &m.Status, &m.Error,
+			&m.CreatedAt, &m.UpdatedAt, &sentAt, &deliveredAt, &readAt); err != nil {
+			return nil, err
+		}
+		m.SentAt = nullTimePtr(sentAt)
+		m.DeliveredAt = nullTimePtr(deliveredAt)
+		m.ReadAt = nullTimePtr(readAt)
+		if m.MessageID != "" {
+			m.URI = uri.Message(m.ChatJID, m.MessageID)
+		}
+		if m.SentAt != nil {
+			timing := DeliveryTiming{SentAt: *m.SentAt, DeliveredAt: m.DeliveredAt, ReadAt: m.ReadAt}
+			if d, ok := timing.DeliveryLatency(); ok {
+				m.DeliveryLatencyMs = durationMs(d)
+			}
+			if d, ok := timing.ReadLatency(); ok {
+				m.ReadLatencyMs = durationMs(d)
+			}
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// ListDeliveryTimings returns receipt timestamps for messages sent since
+// the given time (zero for all), optionally limited to one chat.
+func (s *MessageStore) ListDeliveryTimings(chatJID string, since time.Time) ([]DeliveryTiming, error) {
+	query := `SELECT chat_jid, sent_at, delivered_at, read_at FROM outbound_log WHERE sent_at IS NOT NULL`
+	var args []interface{}
+	if chatJID != "" {
+		query += ` AND chat_jid = ?`
+		args = append(args, chatJID)
+	}
+	if !since.IsZero() {
+		query += ` AND sent_at >= ?`
+		args = append(args, since)
+	}
+	query, args = s.restrictChats(query, args, "chat_jid")
+
+	rows, err := s.db.Query(query+` ORDER BY sent_at`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []DeliveryTiming
+	for rows.Next() {
+		var d DeliveryTiming
+		var deliveredAt, readAt sql.NullTime
+		if err := rows.Scan(&d.ChatJID, &d.SentAt, &deliveredAt, &readAt); err != nil {
+			return nil, err
+		}
+		d.DeliveredAt = nullTimePtr(deliveredAt)
+		d.ReadAt = nullTimePtr(readAt)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func durationMs(d time.Duration) *int64 {
+	ms := d.Milliseconds()
+	return &ms
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
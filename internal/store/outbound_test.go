@@ -0,0 +1,109 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboundLifecycle(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now().UTC()
+
+	logID, err := store.LogOutbound("1234@s.whatsapp.net", "text", "hello", "", now)
+	require.NoError(t, err)
+	require.NoError(t, store.MarkOutboundSent(logID, "MSG1", now.Add(time.Second)))
+
+	n, err := store.UpdateOutboundStatus([]string{"MSG1"}, OutboundRead, now.Add(2*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	// A late delivery receipt must not downgrade a read message.
+	n, err = store.UpdateOutboundStatus([]string{"MSG1"}, OutboundDelivered, now.Add(3*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	entries, err := store.ListOutbound(ListOutboundParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, OutboundRead, entries[0].Status)
+	assert.Equal(t, "MSG1", entries[0].MessageID)
+	require.NotNil(t, entries[0].SentAt)
+	require.NotNil(t, entries[0].ReadAt)
+	assert.Nil(t, entries[0].DeliveredAt)
+}
+
+func TestListOutboundFiltersByStatus(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now().UTC()
+
+	okID, err := store.LogOutbound("1@s.whatsapp.net", "text", "ok", "", now)
+	require.NoError(t, err)
+	require.NoError(t, store.MarkOutboundSent(okID, "OK", now))
+
+	failedID, err := store.LogOutbound("2@s.whatsapp.net", "image", "look", "/tmp/a.jpg", now)
+	require.NoError(t, err)
+	require.NoError(t, store.MarkOutboundFailed(failedID, "not connected", now))
+
+	failed, err := store.ListOutbound(ListOutboundParams{Status: OutboundFailed, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, "2@s.whatsapp.net", failed[0].ChatJID)
+	assert.Equal(t, "not connected", failed[0].Error)
+
+	n, err := store.UpdateOutboundStatus([]string{"unknown"}, OutboundDelivered, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+}
+
+func TestListDeliveryTimingsAndLatencies(t *testing.T) {
+	store := setupTestDB(t)
+	sent := time.Now().UTC().Add(-time.Hour)
+
+	readID, err := store.LogOutbound("1@s.whatsapp.net", "text", "hi", "", sent)
+	require.NoError(t, err)
+	require.NoError(t, store.MarkOutboundSent(readID, "READ", sent))
+	_, err = store.UpdateOutboundStatus([]string{"READ"}, OutboundDelivered, sent.Add(2*time.Second))
+	require.NoError(t, err)
+	_, err = store.UpdateOutboundStatus([]string{"READ"}, OutboundRead, sent.Add(time.Minute))
+	require.NoError(t, err)
+
+	pendingID, err := store.LogOutbound("2@s.whatsapp.net", "text", "hello", "", sent)
+	require.NoError(t, err)
+	require.NoError(t, store.MarkOutboundSent(pendingID, "PENDING", sent))
+
+	_, err = store.LogOutbound("3@s.whatsapp.net", "text", "never sent", "", sent)
+	require.NoError(t, err)
+
+	timings, err := store.ListDeliveryTimings("", time.Time{})
+	require.NoError(t, err)
+	require.Len(t, timings, 2, "unsent messages have no timing")
+
+	timings, err = store.ListDeliveryTimings("1@s.whatsapp.net", sent.Add(-time.Minute))
+	require.NoError(t, err)
+	require.Len(t, timings, 1)
+	delivery, ok := timings[0].DeliveryLatency()
+	require.True(t, ok)
+	assert.Equal(t, 2*time.Second, delivery)
+
+	entries, err := store.ListOutbound(ListOutboundParams{Status: OutboundRead, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.NotNil(t, entries[0].ReadLatencyMs)
+	assert.EqualValues(t, 60000, *entries[0].ReadLatencyMs)
+}
+
+func TestDeliveryLatencyFallsBackToRead(t *testing.T) {
+	sent := time.Now()
+	read := sent.Add(30 * time.Second)
+	timing := DeliveryTiming{SentAt: sent, ReadAt: &read}
+
+	d, ok := timing.DeliveryLatency()
+	require.True(t, ok)
+	assert.Equal(t, 30*time.Second, d)
+
+	_, ok = DeliveryTiming{SentAt: sent}.DeliveryLatency()
+	assert.False(t, ok)
+}
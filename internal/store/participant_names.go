@@ -0,0 +1,52 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// Where a cached participant name came from. Contact names (address book,
+// CSV, CardDAV, known chats) take precedence over self-chosen push names.
+const (
+	ParticipantNameContact = "contact"
+	ParticipantNamePush    = "push"
+)
+
+// senderUserSQL normalizes messages.sender to the bare user part. Live
+// messages store the user ("34612345678") while history sync stores the
+// participant JID ("34612345678@s.whatsapp.net").
+const senderUserSQL = `CASE WHEN instr(m.sender, '@') > 0 THEN substr(m.sender, 1, instr(m.sender, '@') - 1) ELSE m.sender END`
+
+// participantUser strips the server (and device) from a participant JID so
+// it matches senderUserSQL.
+func participantUser(participant string) string {
+	if i := strings.IndexByte(participant, '@'); i >= 0 {
+		participant = participant[:i]
+	}
+	if i := strings.IndexByte(participant, ':'); i >= 0 {
+		participant = participant[:i]
+	}
+	return participant
+}
+
+// StoreParticipantName caches a group participant's display name. A push
+// name never replaces a contact name; empty names are ignored.
+func (s *MessageStore) StoreParticipantName(chatJID, participant, name, source string) error {
+	name = strings.TrimSpace(name)
+	participant = participantUser(participant)
+	if name == "" || participant == "" || name == participant {
+		return nil
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO group_participant_names (chat_jid, participant, name, source, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(chat_jid, participant) DO UPDATE SET
+			name = excluded.name,
+			source = excluded.source,
+			updated_at = excluded.updated_at
+		WHERE excluded.source = ? OR group_participant_names.source = ?`,
+		chatJID, participant, name, source, time.Now().UTC(),
+		ParticipantNameContact, ParticipantNamePush,
+	)
+	return err
+}
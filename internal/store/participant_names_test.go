@@ -0,0 +1,49 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListMessagesJoinsSenderName(t *testing.T) {
+	store := setupTestDB(t)
+	group := "123@g.us"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(group, "Family", now))
+	// Live messages store the bare user, history the participant JID.
+	require.NoError(t, store.StoreMessage("live", group, "34611111111", "hi", now, false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreMessage("hist", group, "34622222222@s.whatsapp.net", "hello", now.Add(-time.Minute), false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreMessage("anon", group, "34633333333", "hey", now.Add(-2*time.Minute), false, "", "", "", "", "", nil, nil, nil, 0))
+
+	require.NoError(t, store.StoreParticipantName(group, "34611111111@s.whatsapp.net", "Ana", ParticipantNamePush))
+	require.NoError(t, store.StoreParticipantName(group, "34622222222:3@s.whatsapp.net", "Bob", ParticipantNameContact))
+	require.NoError(t, store.StoreParticipantName("other@g.us", "34633333333", "Carol", ParticipantNamePush))
+
+	messages, err := store.ListMessages(ListMessagesParams{ChatJID: &group, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "Ana", messages[0].SenderName)
+	assert.Equal(t, "Bob", messages[1].SenderName)
+	assert.Empty(t, messages[2].SenderName, "names are cached per group")
+}
+
+func TestStoreParticipantNamePrefersContactNames(t *testing.T) {
+	store := setupTestDB(t)
+	group := "123@g.us"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(group, "Family", now))
+	require.NoError(t, store.StoreMessage("m1", group, "34611111111", "hi", now, false, "", "", "", "", "", nil, nil, nil, 0))
+
+	require.NoError(t, store.StoreParticipantName(group, "34611111111", "ana 🌸", ParticipantNamePush))
+	require.NoError(t, store.StoreParticipantName(group, "34611111111", "Ana García", ParticipantNameContact))
+	require.NoError(t, store.StoreParticipantName(group, "34611111111", "ana ✨", ParticipantNamePush))
+	require.NoError(t, store.StoreParticipantName(group, "34611111111", "", ParticipantNameContact))
+
+	messages, err := store.ListMessages(ListMessagesParams{ChatJID: &group, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "Ana García", messages[0].SenderName)
+}
@@ -0,0 +1,70 @@
+package store
+
+import "time"
+
+// Group membership changes recorded in group_participant_events.
+const (
+	ParticipantAdd     = "add"
+	ParticipantRemove  = "remove"
+	ParticipantPromote = "promote"
+	ParticipantDemote  = "demote"
+)
+
+// ParticipantEvent is a single membership change in a group chat.
+type ParticipantEvent struct {
+	ChatJID        string    `json:"chat_jid"`
+	ParticipantJID string    `json:"participant_jid"`
+	Action         string    `json:"action"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// RecordParticipantEvents stores membership changes. Events already on
+// record are ignored, so replayed history chunks don't duplicate them.
+func (s *MessageStore) RecordParticipantEvents(events []ParticipantEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO group_participant_events
+		(chat_jid, participant_jid, action, timestamp) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range events {
+		if _, err := stmt.Exec(e.ChatJID, e.ParticipantJID, e.Action, e.Timestamp); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListParticipantEvents returns a chat's membership changes before the
+// given time, oldest first.
+func (s *MessageStore) ListParticipantEvents(chatJID string, before time.Time) ([]ParticipantEvent, error) {
+	query, args := s.restrictChats(`
+		SELECT chat_jid, participant_jid, action, timestamp
+		FROM group_participant_events
+		WHERE chat_jid = ? AND timestamp < ?`, []interface{}{chatJID, before}, "chat_jid")
+	rows, err := s.db.Query(query+" ORDER BY timestamp, rowid", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ParticipantEvent
+	for rows.Next() {
+		var e ParticipantEvent
+		if err := rows.Scan(&e.ChatJID, &e.ParticipantJID, &e.Action, &e.Timestamp); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordParticipantEventsIgnoresDuplicates(t *testing.T) {
+	store := setupTestDB(t)
+	group := "123@g.us"
+	at := time.Unix(1700000000, 0)
+	events := []ParticipantEvent{
+		{ChatJID: group, ParticipantJID: "a@s.whatsapp.net", Action: ParticipantAdd, Timestamp: at},
+		{ChatJID: group, ParticipantJID: "b@s.whatsapp.net", Action: ParticipantAdd, Timestamp: at.Add(time.Hour)},
+	}
+	require.NoError(t, store.RecordParticipantEvents(events))
+	require.NoError(t, store.RecordParticipantEvents(events[:1]))
+
+	listed, err := store.ListParticipantEvents(group, at.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+	assert.Equal(t, "a@s.whatsapp.net", listed[0].ParticipantJID)
+	assert.Equal(t, "b@s.whatsapp.net", listed[1].ParticipantJID)
+}
+
+func TestListParticipantEventsStopsAtCutoff(t *testing.T) {
+	store := setupTestDB(t)
+	group := "123@g.us"
+	at := time.Unix(1700000000, 0)
+	require.NoError(t, store.RecordParticipantEvents([]ParticipantEvent{
+		{ChatJID: group, ParticipantJID: "a@s.whatsapp.net", Action: ParticipantAdd, Timestamp: at},
+		{ChatJID: group, ParticipantJID: "a@s.whatsapp.net", Action: ParticipantRemove, Timestamp: at.Add(2 * time.Hour)},
+		{ChatJID: "other@g.us", ParticipantJID: "a@s.whatsapp.net", Action: ParticipantAdd, Timestamp: at},
+	}))
+
+	listed, err := store.ListParticipantEvents(group, at.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, ParticipantAdd, listed[0].Action)
+}
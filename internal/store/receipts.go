@@ -0,0 +1,48 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// ListUnreadIncoming returns incoming messages in a chat that have not been
+// marked as read by the CLI, oldest first.
+func (s *MessageStore) ListUnreadIncoming(chatJID string, limit int) ([]Message, error) {
+	rows, err := s.db.Query(`
+		SELECT id, chat_jid, sender, content, timestamp, is_from_me, COALESCE(media_type, '')
+		FROM messages
+		WHERE chat_jid = ? AND is_from_me = 0 AND read_marked_at IS NULL
+		ORDER BY timestamp ASC
+		LIMIT ?`, chatJID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []Message
+	for rows.Next() {
+		var m Message
+		if err := rows.Scan(&m.ID, &m.ChatJID, &m.Sender, &m.Content, &m.Timestamp, &m.IsFromMe, &m.MediaType); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+// MarkMessagesRead records that read receipts were sent for ids.
+func (s *MessageStore) MarkMessagesRead(chatJID string, ids []string, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := []interface{}{at, chatJID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	_, err := s.db.Exec(
+		`UPDATE messages SET read_marked_at = ? WHERE chat_jid = ? AND id IN (`+placeholders+`)`,
+		args...,
+	)
+	return err
+}
@@ -0,0 +1,32 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListUnreadIncomingSkipsOwnAndMarkedMessages(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "1234@s.whatsapp.net"
+	now := time.Now()
+
+	require.NoError(t, store.StoreChat(chatJID, "John Doe", now))
+	require.NoError(t, store.StoreMessage("in1", chatJID, "1234", "first", now, false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreMessage("in2", chatJID, "1234", "second", now.Add(time.Second), false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreMessage("out1", chatJID, "me", "reply", now.Add(2*time.Second), true, "", "", "", "", "", nil, nil, nil, 0))
+
+	unread, err := store.ListUnreadIncoming(chatJID, 10)
+	require.NoError(t, err)
+	require.Len(t, unread, 2)
+	assert.Equal(t, "in1", unread[0].ID, "oldest first")
+
+	require.NoError(t, store.MarkMessagesRead(chatJID, []string{"in1"}, now))
+
+	unread, err = store.ListUnreadIncoming(chatJID, 10)
+	require.NoError(t, err)
+	require.Len(t, unread, 1)
+	assert.Equal(t, "in2", unread[0].ID)
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// RawMessage is an archived message proto awaiting re-extraction.
+type RawMessage struct {
+	RowID     int64
+	ID        string
+	ChatJID   string
+	Sender    string
+	Timestamp time.Time
+	Raw       []byte
+}
+
+// ExtractedContent holds the fields produced by re-running extraction on a
+// raw message.
+type ExtractedContent struct {
+	Content       string
+	ReplyToID     string
+	MediaType     string
+	Filename      string
+	URL           string
+	DirectPath    string
+	MimeType      string
+	MediaKey      []byte
+	FileSHA256    []byte
+	FileEncSHA256 []byte
+	FileLength    uint64
+	Commerce      *Commerce
+	// InteractiveKind and SelectedID are set for interactive messages and
+	// replies; see StoreInteractive.
+	InteractiveKind string
+	SelectedID      string
+}
+
+// StoreRawMessage archives the serialized proto and reply reference of a
+// message already saved with StoreMessage.
+func (s *MessageStore) StoreRawMessage(id, chatJID string, raw []byte, replyToID string) error {
+	_, err := s.db.Exec(
+		`UPDATE messages SET
+			raw_proto = CASE WHEN ? IS NOT NULL AND length(?) > 0 THEN ? ELSE raw_proto END,
+			reply_to_id = COALESCE(NULLIF(?, ''), reply_to_id)
+		WHERE id = ? AND chat_jid = ?`,
+		raw, raw, raw, replyToID, id, chatJID,
+	)
+	return err
+}
+
+// ListRawMessages pages through messages that have an archived proto, in
+// insertion order. Pass the last RowID seen as afterRowID to continue.
+func (s *MessageStore) ListRawMessages(afterRowID int64, limit int) ([]RawMessage, error) {
+	rows, err := s.db.Query(`
+		SELECT rowid, id, chat_jid, COALESCE(sender, ''), timestamp, raw_proto FROM messages
+		WHERE rowid > ? AND raw_proto IS NOT NULL AND length(raw_proto) > 0
+		ORDER BY rowid
+		LIMIT ?`, afterRowID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []RawMessage
+	for rows.Next() {
+		var m RawMessage
+		if err := rows.Scan(&m.RowID, &m.ID, &m.ChatJID, &m.Sender, &m.Timestamp, &m.Raw); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// UpdateExtractedContent applies re-extracted fields to a message, reporting
+// whether anything changed. Non-empty content and reply references replace
+// what is stored; media metadata is only filled in when the message had none,
+// so download state is never disturbed. Business and interactive fields are
+// likewise only filled in for messages without a type. Content carrying a
+// payment amount or currency is kept: history sync attaches those from a
+// payment record that the archived message doesn't include.
+func (s *MessageStore) UpdateExtractedContent(id, chatJID string, e ExtractedContent) (bool, error) {
+	var content, replyToID, mediaType, messageType, currency sql.NullString
+	var amount1000 sql.NullInt64
+	err := s.db.QueryRow(
+		`SELECT content, reply_to_id, media_type, message_type, amount_1000, currency FROM messages WHERE id = ? AND chat_jid = ?`,
+		id, chatJID,
+	).Scan(&content, &replyToID, &mediaType, &messageType, &amount1000, &currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	newContent := content.String
+	hasPayment := amount1000.Valid || currency.String != ""
+	if e.Content != "" && e.Content != content.String && !hasPayment {
+		newContent = e.Content
+		changed = true
+	}
+	newReplyTo := replyToID.String
+	if e.ReplyToID != "" && e.ReplyToID != replyToID.String {
+		newReplyTo = e.ReplyToID
+		changed = true
+	}
+	fillMedia := mediaType.String == "" && e.MediaType != ""
+	if fillMedia {
+		changed = true
+	}
+	fillCommerce := messageType.String == "" && e.Commerce != nil
+	if fillCommerce {
+		changed = true
+	}
+	fillInteractive := messageType.String == "" && e.InteractiveKind != ""
+	if fillInteractive {
+		changed = true
+	}
+	if !changed {
+		return false, nil
+	}
+	if fillCommerce {
+		if err := s.StoreCommerce(id, chatJID, *e.Commerce); err != nil {
+			return false, err
+		}
+	}
+	if fillInteractive {
+		if err := s.StoreInteractive(id, chatJID, e.InteractiveKind, e.SelectedID); err != nil {
+			return false, err
+		}
+	}
+
+	if !fillMedia {
+		_, err = s.db.Exec(
+			`UPDATE messages SET content = ?, reply_to_id = NULLIF(?, '') WHERE id = ? AND chat_jid = ?`,
+			newContent, newReplyTo, id, chatJID,
+		)
+		return err == nil, err
+	}
+
+	_, err = s.db.Exec(
+		`UPDATE messages SET
+			content = ?, reply_to_id = NULLIF(?, ''),
+			media_type = ?, filename = ?, url = ?, direct_path = ?, mime_type = ?,
+			media_key = ?, file_sha256 = ?, file_enc_sha256 = ?, file_length = ?
+		WHERE id = ? AND chat_jid = ?`,
+		newContent, newReplyTo,
+		e.MediaType, e.Filename, e.URL, e.DirectPath, e.MimeType,
+		e.MediaKey, e.FileSHA256, e.FileEncSHA256, int64(e.FileLength),
+		id, chatJID,
+	)
+	return err == nil, err
+}
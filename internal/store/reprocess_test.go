@@ -0,0 +1,97 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListRawMessagesPagesByRowID(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "1234@s.whatsapp.net"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(chatJID, "John Doe", now))
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, store.StoreMessage(id, chatJID, "1234", "", now, false, "", "", "", "", "", nil, nil, nil, 0))
+	}
+	require.NoError(t, store.StoreRawMessage("a", chatJID, []byte{1}, ""))
+	require.NoError(t, store.StoreRawMessage("c", chatJID, []byte{3}, "quoted"))
+
+	first, err := store.ListRawMessages(0, 1)
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	assert.Equal(t, "a", first[0].ID)
+	assert.Equal(t, "1234", first[0].Sender)
+	assert.True(t, first[0].Timestamp.Equal(now))
+	assert.Equal(t, []byte{1}, first[0].Raw)
+
+	rest, err := store.ListRawMessages(first[0].RowID, 10)
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+	assert.Equal(t, "c", rest[0].ID)
+}
+
+func TestUpdateExtractedContentFillsMissingFields(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "1234@s.whatsapp.net"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(chatJID, "John Doe", now))
+	require.NoError(t, store.StoreMessage("m1", chatJID, "1234", "", now, false, "", "", "", "", "", nil, nil, nil, 0))
+
+	changed, err := store.UpdateExtractedContent("m1", chatJID, ExtractedContent{
+		Content:    "look at this",
+		ReplyToID:  "parent",
+		MediaType:  "image",
+		DirectPath: "/v/t62",
+		MimeType:   "image/jpeg",
+		MediaKey:   []byte{1, 2},
+		FileLength: 42,
+	})
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	info, err := store.GetMessageForDownload("m1", &chatJID)
+	require.NoError(t, err)
+	assert.Equal(t, "image", info.MediaType)
+	assert.Equal(t, "/v/t62", info.DirectPath)
+	assert.Equal(t, "look at this", info.Content)
+
+	messages, err := store.ListMessages(ListMessagesParams{ChatJID: &chatJID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "parent", messages[0].ReplyToID)
+
+	changed, err = store.UpdateExtractedContent("m1", chatJID, ExtractedContent{Content: "look at this"})
+	require.NoError(t, err)
+	assert.False(t, changed, "identical extraction should be a no-op")
+}
+
+func TestUpdateExtractedContentKeepsExistingMedia(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "1234@s.whatsapp.net"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(chatJID, "John Doe", now))
+	require.NoError(t, store.StoreMessage("m1", chatJID, "1234", "", now, false, "image", "", "", "/orig", "image/png", []byte{9}, nil, nil, 5))
+
+	changed, err := store.UpdateExtractedContent("m1", chatJID, ExtractedContent{
+		Content:    "caption",
+		MediaType:  "image",
+		DirectPath: "/new",
+	})
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	info, err := store.GetMessageForDownload("m1", &chatJID)
+	require.NoError(t, err)
+	assert.Equal(t, "/orig", info.DirectPath)
+	assert.Equal(t, "caption", info.Content)
+}
+
+func TestUpdateExtractedContentUnknownMessage(t *testing.T) {
+	store := setupTestDB(t)
+	changed, err := store.UpdateExtractedContent("missing", "x@s.whatsapp.net", ExtractedContent{Content: "hi"})
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
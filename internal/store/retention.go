@@ -0,0 +1,88 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// ChatRetention is a per-chat override of how long messages are kept.
+// Chats without an override keep their full history.
+type ChatRetention struct {
+	ChatJID     string    `json:"chat_jid"`
+	ChatName    string    `json:"chat_name,omitempty"`
+	KeepSeconds int64     `json:"keep_seconds"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Keep returns the retention window as a duration.
+func (r ChatRetention) Keep() time.Duration {
+	return time.Duration(r.KeepSeconds) * time.Second
+}
+
+func (s *MessageStore) SetChatRetention(chatJID string, keep time.Duration) error {
+	if keep <= 0 {
+		return fmt.Errorf("retention must be positive, got %s", keep)
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO chat_retention (chat_jid, keep_seconds, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(chat_jid) DO UPDATE SET
+			keep_seconds = excluded.keep_seconds,
+			updated_at = excluded.updated_at`,
+		chatJID, int64(keep/time.Second), time.Now().UTC(),
+	)
+	return err
+}
+
+func (s *MessageStore) ClearChatRetention(chatJID string) error {
+	_, err := s.db.Exec(`DELETE FROM chat_retention WHERE chat_jid = ?`, chatJID)
+	return err
+}
+
+func (s *MessageStore) ListChatRetention() ([]ChatRetention, error) {
+	query, args := s.restrictChats(`
+		SELECT r.chat_jid, COALESCE(c.name, ''), r.keep_seconds, r.updated_at
+		FROM chat_retention r
+		LEFT JOIN chats c ON r.chat_jid = c.jid
+		WHERE 1=1`, nil, "r.chat_jid")
+	rows, err := s.db.Query(query+" ORDER BY r.chat_jid", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []ChatRetention
+	for rows.Next() {
+		var r ChatRetention
+		if err := rows.Scan(&r.ChatJID, &r.ChatName, &r.KeepSeconds, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		policies = append(policies, r)
+	}
+	return policies, rows.Err()
+}
+
+// PruneExpiredMessages deletes messages older than their chat's retention
+// window, relative to now. Chats without an override are left untouched.
+func (s *MessageStore) PruneExpiredMessages(now time.Time) (int64, error) {
+	policies, err := s.ListChatRetention()
+	if err != nil {
+		return 0, err
+	}
+
+	var pruned int64
+	for _, p := range policies {
+		res, err := s.db.Exec(
+			`DELETE FROM messages WHERE chat_jid = ? AND timestamp < ?`,
+			p.ChatJID, now.Add(-p.Keep()),
+		)
+		if err != nil {
+			return pruned, fmt.Errorf("pruning %s: %w", p.ChatJID, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return pruned, err
+		}
+		pruned += n
+	}
+	return pruned, nil
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetChatRetentionUpserts(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "1234@s.whatsapp.net"
+	require.NoError(t, store.StoreChat(chatJID, "John Doe", time.Now()))
+
+	require.NoError(t, store.SetChatRetention(chatJID, 24*time.Hour))
+	require.NoError(t, store.SetChatRetention(chatJID, 48*time.Hour))
+
+	policies, err := store.ListChatRetention()
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, chatJID, policies[0].ChatJID)
+	assert.Equal(t, "John Doe", policies[0].ChatName)
+	assert.Equal(t, 48*time.Hour, policies[0].Keep())
+
+	require.NoError(t, store.ClearChatRetention(chatJID))
+	policies, err = store.ListChatRetention()
+	require.NoError(t, err)
+	assert.Empty(t, policies)
+}
+
+func TestSetChatRetentionRejectsNonPositive(t *testing.T) {
+	store := setupTestDB(t)
+	assert.Error(t, store.SetChatRetention("1234@s.whatsapp.net", 0))
+}
+
+func TestPruneExpiredMessagesOnlyTouchesChatsWithOverrides(t *testing.T) {
+	store := setupTestDB(t)
+	now := time.Now()
+	short := "short@s.whatsapp.net"
+	full := "full@s.whatsapp.net"
+
+	for _, jid := range []string{short, full} {
+		require.NoError(t, store.StoreChat(jid, jid, now))
+		require.NoError(t, store.StoreMessage("old-"+jid, jid, "1", "old", now.Add(-72*time.Hour), false, "", "", "", "", "", nil, nil, nil, 0))
+		require.NoError(t, store.StoreMessage("new-"+jid, jid, "1", "new", now.Add(-time.Hour), false, "", "", "", "", "", nil, nil, nil, 0))
+	}
+	require.NoError(t, store.SetChatRetention(short, 24*time.Hour))
+
+	pruned, err := store.PruneExpiredMessages(now)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, pruned)
+
+	remaining, err := store.ListMessages(ListMessagesParams{ChatJID: &short, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, "new", remaining[0].Content)
+
+	kept, err := store.ListMessages(ListMessagesParams{ChatJID: &full, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, kept, 2)
+}
@@ -0,0 +1,25 @@
+package store
+
+// Stats summarizes the size of the message store.
+type Stats struct {
+	Chats           int64 `json:"chats"`
+	Messages        int64 `json:"messages"`
+	MediaDownloaded int64 `json:"media_downloaded"`
+	RawArchived     int64 `json:"raw_archived"`
+	OutboundFailed  int64 `json:"outbound_failed"`
+}
+
+// Stats counts chats, messages and related rows.
+func (s *MessageStore) Stats() (Stats, error) {
+	var st Stats
+	err := s.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM chats),
+			(SELECT COUNT(*) FROM messages),
+			(SELECT COUNT(*) FROM messages WHERE local_path IS NOT NULL),
+			(SELECT COUNT(*) FROM messages WHERE raw_proto IS NOT NULL),
+			(SELECT COUNT(*) FROM outbound_log WHERE status = ?)`,
+		OutboundFailed,
+	).Scan(&st.Chats, &st.Messages, &st.MediaDownloaded, &st.RawArchived, &st.OutboundFailed)
+	return st, err
+}
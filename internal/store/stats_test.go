@@ -0,0 +1,24 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	store := setupTestDB(t)
+	chatJID := "1234@s.whatsapp.net"
+	now := time.Now()
+	require.NoError(t, store.StoreChat(chatJID, "John Doe", now))
+	require.NoError(t, store.StoreMessage("a", chatJID, "1234", "hi", now, false, "", "", "", "", "", nil, nil, nil, 0))
+	require.NoError(t, store.StoreMessage("b", chatJID, "1234", "", now, false, "image", "", "", "/p", "image/jpeg", []byte{1}, nil, nil, 10))
+	require.NoError(t, store.MarkMediaDownloaded("b", chatJID, "/tmp/b.jpg", now))
+	require.NoError(t, store.StoreRawMessage("a", chatJID, []byte{1}, ""))
+
+	st, err := store.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Chats: 1, Messages: 2, MediaDownloaded: 1, RawArchived: 1}, st)
+}
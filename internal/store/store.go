@@ -2,6 +2,7 @@ package store
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/vicentereig/whatsapp-cli/internal/uri"
 )
 
 type Message struct {
@@ -20,6 +22,22 @@ type Message struct {
 	Timestamp time.Time `json:"timestamp"`
 	IsFromMe  bool      `json:"is_from_me"`
 	MediaType string    `json:"media_type,omitempty"`
+	ReplyToID string    `json:"reply_to_id,omitempty"`
+	// SenderName is the cached display name of a group participant; see
+	// StoreParticipantName.
+	SenderName string `json:"sender_name,omitempty"`
+	// Type, Amount, Currency, ItemCount and Status are set for business
+	// messages (order, invoice, product) and payments.
+	Type      string   `json:"type,omitempty"`
+	Amount    *float64 `json:"amount,omitempty"`
+	Currency  string   `json:"currency,omitempty"`
+	ItemCount *int     `json:"item_count,omitempty"`
+	Status    string   `json:"status,omitempty"`
+	// SelectedID is the button or list row picked in a button_reply or
+	// list_reply; ReplyToID is the interactive message answered.
+	SelectedID string `json:"selected_id,omitempty"`
+	// URI references the message durably; `open` resolves it.
+	URI string `json:"uri"`
 }
 
 type Chat struct {
@@ -29,6 +47,15 @@ type Chat struct {
 	LastMessage     *string   `json:"last_message,omitempty"`
 	LastSender      *string   `json:"last_sender,omitempty"`
 	LastIsFromMe    *bool     `json:"last_is_from_me,omitempty"`
+	// MessageCount and FirstMessageTime are kept up to date by triggers;
+	// see ensureChatCounters.
+	MessageCount     int        `json:"message_count"`
+	FirstMessageTime *time.Time `json:"first_message_time,omitempty"`
+	// Group is set for groups the sync daemon has refreshed.
+	Group *GroupInfo `json:"group,omitempty"`
+	// DeletedAt is set for chats deleted on the phone, which are only
+	// listed with IncludeDeleted.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 type Contact struct {
@@ -38,7 +65,8 @@ type Contact struct {
 }
 
 type MessageStore struct {
-	db *sql.DB
+	db           *sql.DB
+	allowedChats []string
 }
 
 type MessageDownloadInfo struct {
@@ -68,14 +96,19 @@ type ListMessagesParams struct {
 	Sender  *string
 	ChatJID *string
 	Query   *string
-	Limit   int
-	Page    int
+	// Type matches a business, payment or interactive message type (order,
+	// payment_request, button_reply, ...) or a media type (image, video, ...).
+	Type  *string
+	Limit int
+	Page  int
 }
 
 type ListChatsParams struct {
 	Query *string
 	Limit int
 	Page  int
+	// IncludeDeleted also lists chats deleted on the phone.
+	IncludeDeleted bool
 }
 
 func NewMessageStore(dbPath string) (*MessageStore, error) {
@@ -95,7 +128,10 @@ func NewMessageStore(dbPath string) (*MessageStore, error) {
 		CREATE TABLE IF NOT EXISTS chats (
 			jid TEXT PRIMARY KEY,
 			name TEXT,
-			last_message_time TIMESTAMP
+			last_message_time TIMESTAMP,
+			message_count INTEGER NOT NULL DEFAULT 0,
+			first_message_time TIMESTAMP,
+			deleted_at TIMESTAMP
 		);
 
 		CREATE TABLE IF NOT EXISTS messages (
@@ -116,9 +152,103 @@ func NewMessageStore(dbPath string) (*MessageStore, error) {
 			file_length INTEGER,
 			local_path TEXT,
 			downloaded_at TIMESTAMP,
+			read_marked_at TIMESTAMP,
+			reply_to_id TEXT,
+			raw_proto BLOB,
+			media_evicted_at TIMESTAMP,
+			message_type TEXT,
+			amount_1000 INTEGER,
+			currency TEXT,
+			item_count INTEGER,
+			status TEXT,
+			selected_id TEXT,
 			PRIMARY KEY (id, chat_jid),
 			FOREIGN KEY (chat_jid) REFERENCES chats(jid)
 		);
+
+		CREATE TABLE IF NOT EXISTS chat_retention (
+			chat_jid TEXT PRIMARY KEY,
+			keep_seconds INTEGER NOT NULL,
+			updated_at TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS chat_media_policy (
+			chat_jid TEXT PRIMARY KEY,
+			types TEXT NOT NULL DEFAULT '',
+			max_size INTEGER NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS outbound_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			message_id TEXT,
+			chat_jid TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			content TEXT,
+			attachment TEXT,
+			status TEXT NOT NULL,
+			error TEXT,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			sent_at TIMESTAMP,
+			delivered_at TIMESTAMP,
+			read_at TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_outbound_log_message_id ON outbound_log(message_id);
+
+		CREATE TABLE IF NOT EXISTS locations (
+			message_id TEXT NOT NULL,
+			chat_jid TEXT NOT NULL,
+			sender TEXT,
+			live BOOLEAN NOT NULL,
+			sequence INTEGER NOT NULL DEFAULT 0,
+			latitude REAL NOT NULL,
+			longitude REAL NOT NULL,
+			accuracy_m INTEGER,
+			speed_mps REAL,
+			heading INTEGER,
+			label TEXT,
+			timestamp TIMESTAMP NOT NULL,
+			UNIQUE (message_id, chat_jid, sequence, timestamp)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_locations_chat ON locations(chat_jid, timestamp);
+
+		CREATE TABLE IF NOT EXISTS group_participant_events (
+			chat_jid TEXT NOT NULL,
+			participant_jid TEXT NOT NULL,
+			action TEXT NOT NULL,
+			timestamp TIMESTAMP NOT NULL,
+			UNIQUE (chat_jid, participant_jid, action, timestamp)
+		);
+
+		CREATE TABLE IF NOT EXISTS group_participant_names (
+			chat_jid TEXT NOT NULL,
+			participant TEXT NOT NULL,
+			name TEXT NOT NULL,
+			source TEXT NOT NULL,
+			updated_at TIMESTAMP,
+			PRIMARY KEY (chat_jid, participant)
+		);
+
+		CREATE TABLE IF NOT EXISTS group_metadata (
+			chat_jid TEXT PRIMARY KEY,
+			topic TEXT,
+			participant_count INTEGER NOT NULL DEFAULT 0,
+			avatar_id TEXT,
+			avatar_path TEXT,
+			refreshed_at TIMESTAMP,
+			checked_at TIMESTAMP NOT NULL,
+			last_error TEXT
+		);
+
+		CREATE TABLE IF NOT EXISTS group_members (
+			chat_jid TEXT NOT NULL,
+			participant TEXT NOT NULL,
+			role TEXT NOT NULL,
+			PRIMARY KEY (chat_jid, participant)
+		);
 	`)
 	if err != nil {
 		db.Close()
@@ -129,16 +259,38 @@ func NewMessageStore(dbPath string) (*MessageStore, error) {
 		db.Close()
 		return nil, err
 	}
+	if err := ensureChatCounters(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := ensureChatDeletedAt(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := ensureChatNameSource(db); err != nil {
+		db.Close()
+		return nil, err
+	}
 
 	return &MessageStore{db: db}, nil
 }
 
 func ensureMessageColumns(db *sql.DB) error {
 	required := map[string]string{
-		"direct_path":   "TEXT",
-		"mime_type":     "TEXT",
-		"local_path":    "TEXT",
-		"downloaded_at": "TIMESTAMP",
+		"direct_path":      "TEXT",
+		"mime_type":        "TEXT",
+		"local_path":       "TEXT",
+		"downloaded_at":    "TIMESTAMP",
+		"read_marked_at":   "TIMESTAMP",
+		"reply_to_id":      "TEXT",
+		"raw_proto":        "BLOB",
+		"media_evicted_at": "TIMESTAMP",
+		"message_type":     "TEXT",
+		"amount_1000":      "INTEGER",
+		"currency":         "TEXT",
+		"item_count":       "INTEGER",
+		"status":           "TEXT",
+		"selected_id":      "TEXT",
 	}
 
 	for column, columnType := range required {
@@ -190,16 +342,68 @@ func (s *MessageStore) StoreChat(jid, name string, lastMessageTime time.Time) er
 		`INSERT INTO chats (jid, name, last_message_time) VALUES (?, ?, ?)
 		ON CONFLICT(jid) DO UPDATE SET
 			name = CASE
+				WHEN chats.name_source = 'external' THEN chats.name
 				WHEN excluded.name IS NOT NULL AND excluded.name != '' AND (excluded.name != chats.jid OR chats.name IS NULL OR chats.name = '' OR chats.name = chats.jid) THEN excluded.name
 				WHEN chats.name IS NULL OR chats.name = '' THEN excluded.name
 				ELSE chats.name
 			END,
-			last_message_time = excluded.last_message_time`,
+			last_message_time = excluded.last_message_time,
+			deleted_at = CASE WHEN excluded.last_message_time > chats.deleted_at THEN NULL ELSE chats.deleted_at END`,
 		jid, name, lastMessageTime,
 	)
 	return err
 }
 
+// GetChatName returns the stored friendly name for a chat, or "" when the
+// chat is unknown or only named by its JID.
+func (s *MessageStore) GetChatName(jid string) (string, error) {
+	var name sql.NullString
+	err := s.db.QueryRow(`SELECT name FROM chats WHERE jid = ?`, jid).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !name.Valid || name.String == jid {
+		return "", nil
+	}
+	return name.String, nil
+}
+
+// UpdateChatName overwrites a chat's name, reporting whether it changed.
+// Unknown chats are left alone.
+func (s *MessageStore) UpdateChatName(jid, name string) (bool, error) {
+	res, err := s.db.Exec(
+		`UPDATE chats SET name = ? WHERE jid = ? AND (name IS NULL OR name != ?)`,
+		name, jid, name,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListDirectChatJIDs returns the JIDs of all one-to-one chats.
+func (s *MessageStore) ListDirectChatJIDs() ([]string, error) {
+	rows, err := s.db.Query(`SELECT jid FROM chats WHERE jid LIKE '%@s.whatsapp.net' ORDER BY jid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jids []string
+	for rows.Next() {
+		var jid string
+		if err := rows.Scan(&jid); err != nil {
+			return nil, err
+		}
+		jids = append(jids, jid)
+	}
+	return jids, rows.Err()
+}
+
 func (s *MessageStore) StoreMessage(id, chatJID, sender, content string, timestamp time.Time, isFromMe bool,
 	mediaType, filename, url, directPath, mimeType string, mediaKey, fileSHA256, fileEncSHA256 []byte, fileLength uint64) error {
 	intFileLength := int64(0)
@@ -230,9 +434,16 @@ func (s *MessageStore) StoreMessage(id, chatJID, sender, content string, timesta
 	return err
 }
 
+// messageSelect reads the columns scanned by scanMessages.
+const messageSelect = `SELECT m.id, m.chat_jid, c.name, m.sender, m.content, m.timestamp, m.is_from_me, m.media_type, COALESCE(m.reply_to_id, ''),
+	          COALESCE(m.message_type, ''), m.amount_1000, COALESCE(m.currency, ''), m.item_count, COALESCE(m.status, ''), COALESCE(m.selected_id, ''),
+	          COALESCE(pn.name, '')
+	          FROM messages m JOIN chats c ON m.chat_jid = c.jid
+	          LEFT JOIN group_participant_names pn ON pn.chat_jid = m.chat_jid AND pn.participant = ` + senderUserSQL + `
+	          WHERE 1=1`
+
 func (s *MessageStore) ListMessages(params ListMessagesParams) ([]Message, error) {
-	query := `SELECT m.id, m.chat_jid, c.name, m.sender, m.content, m.timestamp, m.is_from_me, m.media_type
-	          FROM messages m JOIN chats c ON m.chat_jid = c.jid WHERE 1=1`
+	query := messageSelect
 	args := []interface{}{}
 
 	if params.After != nil {
@@ -255,10 +466,20 @@ func (s *MessageStore) ListMessages(params ListMessagesParams) ([]Message, error
 		query += " AND LOWER(m.content) LIKE LOWER(?)"
 		args = append(args, "%"+*params.Query+"%")
 	}
+	if params.Type != nil {
+		query += " AND (m.message_type = ? OR m.media_type = ?)"
+		args = append(args, *params.Type, *params.Type)
+	}
+	query, args = s.restrictChats(query, args, "m.chat_jid")
 
 	query += " ORDER BY m.timestamp DESC LIMIT ? OFFSET ?"
 	args = append(args, params.Limit, params.Page*params.Limit)
 
+	return s.queryMessages(query, args...)
+}
+
+// queryMessages runs a query built on messageSelect.
+func (s *MessageStore) queryMessages(query string, args ...interface{}) ([]Message, error) {
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, err
@@ -268,23 +489,33 @@ func (s *MessageStore) ListMessages(params ListMessagesParams) ([]Message, error
 	var messages []Message
 	for rows.Next() {
 		var m Message
-		err := rows.Scan(&m.ID, &m.ChatJID, &m.ChatName, &m.Sender, &m.Content, &m.Timestamp, &m.IsFromMe, &m.MediaType)
+		var amount1000, itemCount sql.NullInt64
+		err := rows.Scan(&m.ID, &m.ChatJID, &m.ChatName, &m.Sender, &m.Content, &m.Timestamp, &m.IsFromMe, &m.MediaType, &m.ReplyToID,
+			&m.Type, &amount1000, &m.Currency, &itemCount, &m.Status, &m.SelectedID, &m.SenderName)
 		if err != nil {
 			return nil, err
 		}
+		if amount1000.Valid {
+			amount := float64(amount1000.Int64) / 1000
+			m.Amount = &amount
+		}
+		if itemCount.Valid {
+			n := int(itemCount.Int64)
+			m.ItemCount = &n
+		}
+		m.URI = uri.Message(m.ChatJID, m.ID)
 		messages = append(messages, m)
 	}
 
-	return messages, nil
+	return messages, rows.Err()
 }
 
 func (s *MessageStore) SearchContacts(query string) ([]Contact, error) {
-	rows, err := s.db.Query(`
+	sqlQuery, args := s.restrictChats(`
 		SELECT jid, name FROM chats
 		WHERE (LOWER(name) LIKE LOWER(?) OR LOWER(jid) LIKE LOWER(?))
-		AND jid NOT LIKE '%@g.us'
-		ORDER BY name LIMIT 50
-	`, "%"+query+"%", "%"+query+"%")
+		AND jid NOT LIKE '%@g.us'`, []interface{}{"%" + query + "%", "%" + query + "%"}, "jid")
+	rows, err := s.db.Query(sqlQuery+" ORDER BY name LIMIT 50", args...)
 	if err != nil {
 		return nil, err
 	}
@@ -313,6 +544,16 @@ func (s *MessageStore) SearchContacts(query string) ([]Contact, error) {
 }
 
 func (s *MessageStore) GetMessageForDownload(id string, chatJID *string) (MessageDownloadInfo, error) {
+	return s.getMessageForDownload(id, chatJID, true)
+}
+
+// GetMediaForSync looks up a message whose media sync downloads in the
+// background. Unlike GetMessageForDownload it ignores the chat allowlist.
+func (s *MessageStore) GetMediaForSync(id, chatJID string) (MessageDownloadInfo, error) {
+	return s.getMessageForDownload(id, &chatJID, false)
+}
+
+func (s *MessageStore) getMessageForDownload(id string, chatJID *string, restrict bool) (MessageDownloadInfo, error) {
 	query := `
 		SELECT
 			m.id,
@@ -341,6 +582,9 @@ func (s *MessageStore) GetMessageForDownload(id string, chatJID *string) (Messag
 		query += " AND m.chat_jid = ?"
 		args = append(args, *chatJID)
 	}
+	if restrict {
+		query, args = s.restrictChats(query, args, "m.chat_jid")
+	}
 
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
@@ -410,7 +654,7 @@ func (s *MessageStore) GetMessageForDownload(id string, chatJID *string) (Messag
 func (s *MessageStore) MarkMediaDownloaded(id, chatJID, localPath string, downloadedAt time.Time) error {
 	_, err := s.db.Exec(
 		`UPDATE messages
-		 SET local_path = ?, downloaded_at = ?
+		 SET local_path = ?, downloaded_at = ?, media_evicted_at = NULL
 		 WHERE id = ? AND chat_jid = ?`,
 		localPath, downloadedAt, id, chatJID,
 	)
@@ -418,13 +662,20 @@ func (s *MessageStore) MarkMediaDownloaded(id, chatJID, localPath string, downlo
 }
 
 func (s *MessageStore) ListChats(params ListChatsParams) ([]Chat, error) {
-	query := "SELECT jid, name, last_message_time FROM chats WHERE 1=1"
+	query := `SELECT jid, name, last_message_time, message_count, first_message_time,
+		g.topic, g.participant_count, g.avatar_path, g.refreshed_at, deleted_at
+		FROM chats LEFT JOIN group_metadata g ON g.chat_jid = chats.jid WHERE 1=1`
 	args := []interface{}{}
 
+	if !params.IncludeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+
 	if params.Query != nil {
 		query += " AND (LOWER(name) LIKE LOWER(?) OR jid LIKE ?)"
 		args = append(args, "%"+*params.Query+"%", "%"+*params.Query+"%")
 	}
+	query, args = s.restrictChats(query, args, "jid")
 
 	query += " ORDER BY last_message_time DESC LIMIT ? OFFSET ?"
 	args = append(args, params.Limit, params.Page*params.Limit)
@@ -438,9 +689,27 @@ func (s *MessageStore) ListChats(params ListChatsParams) ([]Chat, error) {
 	var chats []Chat
 	for rows.Next() {
 		var c Chat
-		if err := rows.Scan(&c.JID, &c.Name, &c.LastMessageTime); err != nil {
+		var first, refreshed, deleted sql.NullTime
+		var topic, avatar sql.NullString
+		var participants sql.NullInt64
+		if err := rows.Scan(&c.JID, &c.Name, &c.LastMessageTime, &c.MessageCount, &first,
+			&topic, &participants, &avatar, &refreshed, &deleted); err != nil {
 			return nil, err
 		}
+		if first.Valid {
+			c.FirstMessageTime = &first.Time
+		}
+		if deleted.Valid {
+			c.DeletedAt = &deleted.Time
+		}
+		if refreshed.Valid {
+			c.Group = &GroupInfo{
+				Topic:            topic.String,
+				ParticipantCount: int(participants.Int64),
+				AvatarPath:       avatar.String,
+				RefreshedAt:      refreshed.Time,
+			}
+		}
 		chats = append(chats, c)
 	}
 
@@ -67,6 +67,50 @@ func TestStoreChatUpgradesNameFromJID(t *testing.T) {
 	assert.Equal(t, "Jane Smith", chats[0].Name)
 }
 
+func TestGetChatNameIgnoresJIDNames(t *testing.T) {
+	store := setupTestDB(t)
+	named := "1234@s.whatsapp.net"
+	unnamed := "5678@s.whatsapp.net"
+
+	require.NoError(t, store.StoreChat(named, "John Doe", time.Now()))
+	require.NoError(t, store.StoreChat(unnamed, unnamed, time.Now()))
+
+	name, err := store.GetChatName(named)
+	require.NoError(t, err)
+	assert.Equal(t, "John Doe", name)
+
+	name, err = store.GetChatName(unnamed)
+	require.NoError(t, err)
+	assert.Empty(t, name)
+
+	name, err = store.GetChatName("missing@s.whatsapp.net")
+	require.NoError(t, err)
+	assert.Empty(t, name)
+}
+
+func TestUpdateChatNameOverwritesAndReportsChange(t *testing.T) {
+	store := setupTestDB(t)
+	jid := "1234@s.whatsapp.net"
+	require.NoError(t, store.StoreChat(jid, "Push Name", time.Now()))
+	require.NoError(t, store.StoreChat("9999@g.us", "Group", time.Now()))
+
+	changed, err := store.UpdateChatName(jid, "Directory Name")
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	changed, err = store.UpdateChatName(jid, "Directory Name")
+	require.NoError(t, err)
+	assert.False(t, changed, "same name should not count as a change")
+
+	changed, err = store.UpdateChatName("missing@s.whatsapp.net", "Nobody")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	jids, err := store.ListDirectChatJIDs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{jid}, jids)
+}
+
 func TestStoreMessage(t *testing.T) {
 	store := setupTestDB(t)
 
@@ -0,0 +1,28 @@
+package types
+
+// Group member roles reported in GroupParticipant.Role.
+const (
+	RoleMember     = "member"
+	RoleAdmin      = "admin"
+	RoleSuperAdmin = "superadmin"
+)
+
+// GroupMetadata is a group's current name, topic, members and photo, as
+// fetched by the background group refresher during sync.
+type GroupMetadata struct {
+	JID          string
+	Name         string
+	Topic        string
+	Participants []GroupParticipant
+	// AvatarID identifies the group photo; empty when the group has none.
+	AvatarID string
+	// AvatarURL downloads the photo. It is empty when the photo is the one
+	// the caller already has.
+	AvatarURL string
+}
+
+// GroupParticipant is a current member of a group.
+type GroupParticipant struct {
+	JID  string
+	Role string
+}
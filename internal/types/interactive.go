@@ -0,0 +1,42 @@
+package types
+
+// Interactive message kinds accepted in InteractiveSpec.Type.
+const (
+	InteractiveButtons = "buttons"
+	InteractiveList    = "list"
+)
+
+// InteractiveSpec describes a reply-button or list message, as read from
+// the JSON file passed to `send interactive --spec`.
+type InteractiveSpec struct {
+	// Type is InteractiveButtons or InteractiveList.
+	Type   string `json:"type"`
+	Header string `json:"header,omitempty"`
+	Body   string `json:"body"`
+	Footer string `json:"footer,omitempty"`
+	// Buttons are the reply buttons of a buttons message.
+	Buttons []InteractiveButton `json:"buttons,omitempty"`
+	// ButtonText labels the button that opens a list message.
+	ButtonText string               `json:"button_text,omitempty"`
+	Sections   []InteractiveSection `json:"sections,omitempty"`
+}
+
+// InteractiveButton is one reply button. ID comes back in the response.
+type InteractiveButton struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// InteractiveSection groups the rows of a list message.
+type InteractiveSection struct {
+	Title string           `json:"title,omitempty"`
+	Rows  []InteractiveRow `json:"rows"`
+}
+
+// InteractiveRow is one selectable list entry. ID comes back in the
+// response.
+type InteractiveRow struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
@@ -0,0 +1,48 @@
+// Package uri builds and parses whatsapp-cli:// URIs, the stable references
+// to messages that every message output carries and `open` accepts. A URI
+// stays valid for as long as the message is in the store, so notes apps and
+// ticketing systems can link to a message and resolve it later:
+//
+//	whatsapp-cli://chat/<chat JID>/message/<message ID>
+package uri
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Scheme is the URI scheme of message references.
+const Scheme = "whatsapp-cli"
+
+// Ref is the message a URI points at.
+type Ref struct {
+	ChatJID   string
+	MessageID string
+}
+
+// Message returns the URI of a message.
+func Message(chatJID, messageID string) string {
+	return fmt.Sprintf("%s://chat/%s/message/%s", Scheme, url.PathEscape(chatJID), url.PathEscape(messageID))
+}
+
+// Parse reads a URI produced by Message.
+func Parse(s string) (Ref, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(s), Scheme+"://")
+	if !ok {
+		return Ref{}, fmt.Errorf("%q is not a %s:// URI", s, Scheme)
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) != 4 || parts[0] != "chat" || parts[2] != "message" || parts[1] == "" || parts[3] == "" {
+		return Ref{}, fmt.Errorf("%q is not a message URI (expected %s://chat/<jid>/message/<id>)", s, Scheme)
+	}
+	chatJID, err := url.PathUnescape(parts[1])
+	if err != nil {
+		return Ref{}, fmt.Errorf("invalid chat in %q: %w", s, err)
+	}
+	messageID, err := url.PathUnescape(parts[3])
+	if err != nil {
+		return Ref{}, fmt.Errorf("invalid message ID in %q: %w", s, err)
+	}
+	return Ref{ChatJID: chatJID, MessageID: messageID}, nil
+}
@@ -0,0 +1,38 @@
+package uri
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage_RoundTrips(t *testing.T) {
+	cases := []Ref{
+		{ChatJID: "120363000000000000@g.us", MessageID: "3EB0C767D26A1B2C"},
+		{ChatJID: "34612345678@s.whatsapp.net", MessageID: "import-1"},
+		// IDs from imports and bridges may contain path characters.
+		{ChatJID: "34612345678@s.whatsapp.net", MessageID: "a/b?c#d e"},
+	}
+	for _, ref := range cases {
+		got, err := Parse(Message(ref.ChatJID, ref.MessageID))
+		require.NoError(t, err)
+		require.Equal(t, ref, got)
+	}
+	require.Equal(t, "whatsapp-cli://chat/120363000000000000@g.us/message/3EB0C767D26A1B2C",
+		Message("120363000000000000@g.us", "3EB0C767D26A1B2C"))
+}
+
+func TestParse_RejectsOtherURIs(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"https://example.com/chat/1@g.us/message/M1",
+		"whatsapp-cli://chat/1@g.us",
+		"whatsapp-cli://chat//message/M1",
+		"whatsapp-cli://chat/1@g.us/message/",
+		"whatsapp-cli://chat/1@g.us/message/M1/extra",
+		"whatsapp-cli://chat/1@g.us/message/%zz",
+	} {
+		_, err := Parse(s)
+		require.Error(t, err, s)
+	}
+}
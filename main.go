@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -12,6 +13,8 @@ import (
 	"time"
 
 	"github.com/vicentereig/whatsapp-cli/internal/commands"
+	"github.com/vicentereig/whatsapp-cli/internal/config"
+	"github.com/vicentereig/whatsapp-cli/internal/output"
 )
 
 var (
@@ -40,18 +43,48 @@ Usage:
 
 Commands:
   auth                              Authenticate with WhatsApp (scan QR code)
-  sync                              Sync messages continuously (run until Ctrl+C)
-  messages list [--chat JID]        List messages
+  sync [--daemon] [--media-quota 20GB] [--media-policy skip|evict]  Sync messages continuously (run until Ctrl+C)
+  enrich [--media]                  Resolve chat names (and media) deferred during sync
+  messages list [--chat JID] [--type order]  List messages
   messages search --query TEXT      Search messages
+  messages location-track --id MSGID [--chat JID] [--format json|geojson] [--output PATH]  Export a live location path
   contacts search --query TEXT      Search contacts
-  chats list                        List chats
-  send --to RECIPIENT --message TEXT                     Send a text message
+  contacts sync-external --carddav-url URL [--user U] [--interval 1h]  Name chats from a CardDAV address book
+  chats list [--include-deleted]    List chats
+  chats retention set --chat JID --keep 30d              Keep only recent history for a chat
+  chats retention clear --chat JID                       Remove a chat's retention override
+  chats retention list                                   List retention overrides
+  chats media-policy set --chat JID [--types image,document] [--max-size 5MB]  Limit media auto-download for a chat
+  chats media-policy clear --chat JID                    Remove a chat's media policy
+  chats media-policy list                                List media policies
+  chats mark-read --chat JID | --auto on|off             Send read receipts / toggle automatic receipts
+  chats snapshot --chat JID --as-of 2023-12-31           Show a chat as it was at a point in time
+  send --to RECIPIENT --message TEXT [--no-read-receipt-request]  Send a text message
   send --to RECIPIENT --image PATH [--caption TEXT]      Send an image
+  send interactive --to RECIPIENT --spec flow.json       Send a reply-button or list message
+  chat NAME|PHONE|JID [--history N]                      Show a conversation and send messages interactively
   media download --message-id ID [--chat JID] [--output PATH]   Download media for a message
+  open URI [--context N]                                 Show the message a whatsapp-cli:// URI points at
+  outbound list [--failed] [--status S] [--limit N]      List CLI-initiated sends and their delivery status
+  stats delivery [--chat JID] [--since 7d] [--by-chat]   Delivery and read latency of CLI-initiated sends
+  export locations --chat JID [--format geojson|kml] [--output PATH]  Export every location shared in a chat
+  export matrix --user @me:example.org [--chat JID] [--output PATH]  Export chats as Matrix room imports
+  import backup --file msgstore.db.crypt15 [--key KEY]  Merge history from an Android or iOS phone backup
+  store reprocess                   Re-extract message content from archived raw protos
+  store migrate [--from ./store] [--to DIR]              Move a store to the default location
+  service install|uninstall|status                       Run sync --daemon as a systemd, launchd or Windows service
+  fixtures generate --store DIR [--chats 5] [--messages 1000] [--seed 42]  Fill a new store with synthetic chats for development
+  schema print --command messages.list                   Print the JSON Schema of a command's output
+  schema list                                            List commands with an output schema
   version                           Print CLI version information
 
 Global Options:
-  --store DIR    Storage directory (default: ./store)
+  --store DIR    Storage directory (default: $WHATSAPP_CLI_STORE, else
+                 $XDG_DATA_HOME/whatsapp-cli or ~/.local/share/whatsapp-cli)
+  --quiet        Suppress progress and status output on stderr
+  --no-emoji     Print stderr output without emoji
+  --consistent   Wait until a running sync has stored everything it received
+                 before the command started (read-your-writes for scripts)
 
 Examples:
   whatsapp-cli auth
@@ -63,30 +96,198 @@ Examples:
   whatsapp-cli send --to 1234567890@g.us --message "Hello group"
 `
 
-// extractGlobalFlags pulls --store from anywhere in the arg list,
-// returning the store directory and remaining args.
-func extractGlobalFlags(args []string) (string, []string) {
-	storeDir := "./store"
+// globalOptions are flags accepted anywhere on the command line.
+type globalOptions struct {
+	storeDir   string
+	quiet      bool
+	noEmoji    bool
+	consistent bool
+}
+
+// extractGlobalFlags pulls --store, --quiet, --no-emoji and --consistent
+// from anywhere in the arg list, returning them and the remaining args.
+// storeDir is empty unless --store was given; see resolveStoreDir.
+func extractGlobalFlags(args []string) (globalOptions, []string) {
+	var opts globalOptions
 	var remaining []string
 	for i := 0; i < len(args); i++ {
 		switch {
 		case args[i] == "--store" && i+1 < len(args):
-			storeDir = args[i+1]
+			opts.storeDir = args[i+1]
 			i++ // skip value
 		case strings.HasPrefix(args[i], "--store="):
-			storeDir = strings.TrimPrefix(args[i], "--store=")
+			opts.storeDir = strings.TrimPrefix(args[i], "--store=")
+		case args[i] == "--quiet":
+			opts.quiet = true
+		case args[i] == "--no-emoji":
+			opts.noEmoji = true
+		case args[i] == "--consistent":
+			opts.consistent = true
 		default:
 			remaining = append(remaining, args[i])
 		}
 	}
-	return storeDir, remaining
+	return opts, remaining
+}
+
+// resolveStoreDir picks the store directory: --store, then
+// WHATSAPP_CLI_STORE or the XDG data directory. A store left in ./store by
+// an older version keeps being used, with a warning, until `store migrate`
+// moves it, so upgrading never strands a logged-in session.
+func resolveStoreDir(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	dir, err := config.DefaultStoreDir()
+	if os.Getenv(config.StoreDirEnv) == "" && config.HasStore(config.LegacyStoreDir) && (err != nil || !config.HasStore(dir)) {
+		fmt.Fprintf(output.Stderr, "⚠️  Using the store in ./%s; run `whatsapp-cli store migrate` to move it to %s\n", config.LegacyStoreDir, dir)
+		return config.LegacyStoreDir, nil
+	}
+	return dir, err
+}
+
+// runStoreMigrate handles "store migrate", which must run before the App
+// opens (and so creates) a store at the destination.
+func runStoreMigrate(args []string) string {
+	migrateCmd := newFlagSet("store migrate")
+	from := migrateCmd.String("from", config.LegacyStoreDir, "store directory to move")
+	to := migrateCmd.String("to", "", "destination (default: the default store location)")
+	parseFlags(migrateCmd, args[2:])
+	if *to == "" {
+		dir, err := config.DefaultStoreDir()
+		if err != nil {
+			exitJSON(err.Error())
+		}
+		*to = dir
+	}
+	return commands.MigrateStore(*from, *to)
+}
+
+// runSchema handles "schema print|list", which read only the schemas
+// embedded in the binary.
+func runSchema(args []string) string {
+	sub := requireSubcommand(args, "schema", []string{"print", "list"})
+	if sub == "list" {
+		return commands.ListSchemas()
+	}
+	printCmd := newFlagSet("schema print")
+	command := printCmd.String("command", "", `command whose output to describe, e.g. messages.list or "messages list"`)
+	parseFlags(printCmd, args[2:])
+	if *command == "" {
+		exitJSON("schema print requires --command")
+	}
+	return commands.PrintSchema(*command)
+}
+
+// runFixtures handles "fixtures generate", which writes a new store and so
+// must run before the App opens one. It needs an explicit --store so fake
+// data never lands in the default location a real login would use.
+func runFixtures(args []string, storeDir string) string {
+	requireSubcommand(args, "fixtures", []string{"generate"})
+	if storeDir == "" {
+		exitJSON("fixtures generate requires --store DIR (a new directory for the generated store)")
+	}
+	absStoreDir, err := filepath.Abs(storeDir)
+	if err != nil {
+		exitJSON(fmt.Sprintf("invalid store path: %v", err))
+	}
+	genCmd := newFlagSet("fixtures generate")
+	chats := genCmd.Int("chats", 5, "number of chats")
+	messages := genCmd.Int("messages", 1000, "number of messages")
+	seed := genCmd.Int64("seed", 1, "random seed; the same seed and --end give the same store")
+	end := genCmd.String("end", "", "time of the newest message, RFC 3339 or YYYY-MM-DD (default: start of today, UTC)")
+	parseFlags(genCmd, args[2:])
+
+	opts := commands.FixtureOptions{Chats: *chats, Messages: *messages, Seed: *seed}
+	if *end != "" {
+		t, err := time.Parse(time.RFC3339, *end)
+		if err != nil {
+			if t, err = time.Parse("2006-01-02", *end); err != nil {
+				exitJSON(fmt.Sprintf("invalid --end %q: use RFC 3339 or YYYY-MM-DD", *end))
+			}
+		}
+		opts.End = t
+	}
+	return commands.GenerateFixtures(absStoreDir, opts)
 }
 
+// runService handles "service install|uninstall|status", which manage the
+// service manager's registration rather than the store.
+func runService(args []string, storeDir string) string {
+	sub := requireSubcommand(args, "service", []string{"install", "uninstall", "status"})
+	serviceCmd := newFlagSet("service " + sub)
+	password := serviceCmd.String("password", "", "Windows only: your account password, so the service runs as you (or WHATSAPP_CLI_SERVICE_PASSWORD)")
+	parseFlags(serviceCmd, args[2:])
+	if *password == "" {
+		*password = os.Getenv("WHATSAPP_CLI_SERVICE_PASSWORD")
+	}
+	switch sub {
+	case "install":
+		dir, err := resolveStoreDir(storeDir)
+		if err != nil {
+			exitJSON(err.Error())
+		}
+		absStoreDir, err := filepath.Abs(dir)
+		if err != nil {
+			exitJSON(fmt.Sprintf("invalid store path: %v", err))
+		}
+		return commands.InstallService(absStoreDir, *password)
+	case "uninstall":
+		return commands.UninstallService()
+	}
+	return commands.ServiceStatus()
+}
+
+// isLongRunning reports whether a command runs until interrupted and must
+// not be bound by defaultTimeout.
+func isLongRunning(args []string) bool {
+	switch args[0] {
+	case "sync", "chat":
+		return true
+	case "contacts":
+		// Only periodic syncs run until interrupted; each pull is bounded
+		// by commands.ExternalSyncTimeout either way.
+		if len(args) < 2 || args[1] != "sync-external" {
+			return false
+		}
+		for _, arg := range args[2:] {
+			if arg == "--interval" || arg == "-interval" || strings.HasPrefix(arg, "--interval=") || strings.HasPrefix(arg, "-interval=") {
+				return true
+			}
+		}
+		return false
+	case "import":
+		// Large backups can take longer than defaultTimeout, and an
+		// interrupt must stop cleanly so the decrypted copy is removed.
+		return true
+	case "store":
+		// Reprocessing a large store can take longer than defaultTimeout.
+		return len(args) > 1 && args[1] == "reprocess"
+	}
+	return false
+}
+
+// exitJSON reports a usage error as the command's single JSON result on
+// stdout and exits non-zero.
 func exitJSON(msg string) {
-	fmt.Fprintf(os.Stderr, `{"success":false,"data":null,"error":"%s"}`+"\n", msg)
+	fmt.Println(output.Error(errors.New(msg)))
 	os.Exit(1)
 }
 
+// newFlagSet returns a flag set whose parse errors are reported through
+// parseFlags as JSON instead of flag's default exit.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output.Stderr)
+	return fs
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) {
+	if err := fs.Parse(args); err != nil {
+		exitJSON(err.Error())
+	}
+}
+
 func requireSubcommand(args []string, command string, valid []string) string {
 	if len(args) < 2 {
 		exitJSON(fmt.Sprintf("%s requires a subcommand: %s", command, strings.Join(valid, ", ")))
@@ -101,48 +302,136 @@ func requireSubcommand(args []string, command string, valid []string) string {
 	return "" // unreachable
 }
 
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprint(os.Stderr, usage)
-		os.Exit(1)
+// runChatRetention handles "chats retention set|clear|list".
+func runChatRetention(app *commands.App, args []string) string {
+	action := requireSubcommand(args[1:], "chats retention", []string{"set", "clear", "list"})
+	retentionCmd := newFlagSet("chats retention")
+	chatJID := retentionCmd.String("chat", "", "chat JID")
+	keep := retentionCmd.String("keep", "", "how long to keep messages (e.g. 30d, 2w, 12h)")
+	parseFlags(retentionCmd, args[3:])
+
+	switch action {
+	case "set":
+		if *chatJID == "" || *keep == "" {
+			exitJSON("chats retention set requires --chat and --keep")
+		}
+		return app.SetChatRetention(*chatJID, *keep)
+	case "clear":
+		if *chatJID == "" {
+			exitJSON("chats retention clear requires --chat")
+		}
+		return app.ClearChatRetention(*chatJID)
+	default:
+		return app.ListChatRetention()
 	}
+}
 
-	// Extract --store from anywhere in args,
+// runChatMediaPolicy handles "chats media-policy set|clear|list".
+func runChatMediaPolicy(app *commands.App, args []string) string {
+	action := requireSubcommand(args[1:], "chats media-policy", []string{"set", "clear", "list"})
+	policyCmd := newFlagSet("chats media-policy")
+	chatJID := policyCmd.String("chat", "", "chat JID")
+	types := policyCmd.String("types", "", "media types to auto-download: image,video,audio,document,sticker, all or none")
+	maxSize := policyCmd.String("max-size", "", "largest file to auto-download (e.g. 5MB)")
+	parseFlags(policyCmd, args[3:])
+
+	switch action {
+	case "set":
+		if *chatJID == "" || (*types == "" && *maxSize == "") {
+			exitJSON("chats media-policy set requires --chat and --types or --max-size")
+		}
+		return app.SetChatMediaPolicy(*chatJID, *types, *maxSize)
+	case "clear":
+		if *chatJID == "" {
+			exitJSON("chats media-policy clear requires --chat")
+		}
+		return app.ClearChatMediaPolicy(*chatJID)
+	default:
+		return app.ListChatMediaPolicies()
+	}
+}
+
+// runMarkRead handles "chats mark-read --chat JID" and "chats mark-read --auto on|off".
+func runMarkRead(ctx context.Context, app *commands.App, args []string) string {
+	markCmd := newFlagSet("chats mark-read")
+	chatJID := markCmd.String("chat", "", "chat JID to mark as read")
+	auto := markCmd.String("auto", "", "automatically mark incoming messages as read: on or off")
+	parseFlags(markCmd, args[2:])
+
+	switch {
+	case *auto != "":
+		switch *auto {
+		case "on":
+			return app.SetAutoMarkRead(true)
+		case "off":
+			return app.SetAutoMarkRead(false)
+		}
+		exitJSON("--auto must be on or off")
+	case *chatJID != "":
+		return app.MarkChatRead(ctx, *chatJID)
+	}
+	exitJSON("chats mark-read requires --chat or --auto")
+	return "" // unreachable
+}
+
+func main() {
+	// Extract global flags from anywhere in args,
 	// so "whatsapp-cli contacts search --store /tmp" works.
-	storeDir, args := extractGlobalFlags(os.Args[1:])
+	opts, args := extractGlobalFlags(os.Args[1:])
+	output.ConfigureStderr(opts.quiet, opts.noEmoji)
 
 	if len(args) == 0 {
-		fmt.Fprint(os.Stderr, usage)
-		os.Exit(1)
+		fmt.Fprint(output.Stderr, usage)
+		exitJSON("no command given")
 	}
 
 	command := args[0]
 
 	if command == "version" {
-		fmt.Printf(`{"success":true,"data":{"version":"%s"},"error":null}
-`, version)
+		fmt.Println(output.Success(map[string]string{"version": version}))
+		return
+	}
+
+	if command == "store" && len(args) > 1 && args[1] == "migrate" {
+		fmt.Println(runStoreMigrate(args))
+		return
+	}
+
+	if command == "schema" {
+		fmt.Println(runSchema(args))
+		return
+	}
+
+	if command == "fixtures" {
+		fmt.Println(runFixtures(args, opts.storeDir))
+		return
+	}
+
+	if command == "service" {
+		fmt.Println(runService(args, opts.storeDir))
 		return
 	}
 
 	// Create app
+	storeDir, err := resolveStoreDir(opts.storeDir)
+	if err != nil {
+		exitJSON(err.Error())
+	}
 	absStoreDir, err := filepath.Abs(storeDir)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, `{"success":false,"data":null,"error":"invalid store path: %v"}`+"\n", err)
-		os.Exit(1)
+		exitJSON(fmt.Sprintf("invalid store path: %v", err))
 	}
 	app, err := commands.NewApp(absStoreDir, version)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, `{"success":false,"data":null,"error":"Failed to initialize: %v"}
-`, err)
-		os.Exit(1)
+		exitJSON(fmt.Sprintf("Failed to initialize: %v", err))
 	}
 	defer app.Close()
 
 	// Use different timeout for sync command
 	var ctx context.Context
 	var cancel context.CancelFunc
-	if command == "sync" {
-		// For sync, use signal-based cancellation
+	if isLongRunning(args) {
+		// For long-running commands, use signal-based cancellation
 		ctx, cancel = context.WithCancel(context.Background())
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -150,11 +439,21 @@ func main() {
 			<-sigChan
 			cancel()
 		}()
+		// Under the Windows service manager, a stop request cancels instead.
+		if finish := commands.StartServiceHost(cancel); finish != nil {
+			defer finish()
+		}
 	} else {
 		ctx, cancel = context.WithTimeout(context.Background(), defaultTimeout)
 	}
 	defer cancel()
 
+	if opts.consistent && !isLongRunning(args) {
+		if err := app.WaitForSync(ctx, time.Now(), commands.ConsistentTimeout); err != nil {
+			exitJSON(err.Error())
+		}
+	}
+
 	var result string
 
 	switch command {
@@ -162,19 +461,43 @@ func main() {
 		result = app.Auth(ctx)
 
 	case "sync":
-		result = app.Sync(ctx)
+		syncCmd := newFlagSet("sync")
+		daemon := syncCmd.Bool("daemon", false, "run background maintenance (retention pruning)")
+		mediaQuota := syncCmd.String("media-quota", "", "maximum size of auto-downloaded media (e.g. 20GB)")
+		mediaMinFree := syncCmd.String("media-min-free", "", "free disk space to leave (e.g. 5GB)")
+		mediaPolicy := syncCmd.String("media-policy", "", "when a media limit is hit: skip or evict")
+		parseFlags(syncCmd, args[1:])
+
+		result = app.Sync(ctx, commands.SyncOptions{
+			Daemon:       *daemon,
+			MediaQuota:   *mediaQuota,
+			MediaMinFree: *mediaMinFree,
+			MediaPolicy:  *mediaPolicy,
+		})
+
+	case "enrich":
+		enrichCmd := newFlagSet("enrich")
+		media := enrichCmd.Bool("media", false, "also download media that was never fetched")
+		mediaLimit := enrichCmd.Int("media-limit", 100, "maximum media files to download")
+		parseFlags(enrichCmd, args[1:])
+
+		result = app.Enrich(ctx, commands.EnrichOptions{Media: *media, MediaLimit: *mediaLimit})
 
 	case "messages":
-		subcommand := requireSubcommand(args, "messages", []string{"list", "search"})
-		messagesCmd := flag.NewFlagSet("messages", flag.ExitOnError)
+		subcommand := requireSubcommand(args, "messages", []string{"list", "search", "location-track"})
+		messagesCmd := newFlagSet("messages")
 		chatJID := messagesCmd.String("chat", "", "chat JID")
 		query := messagesCmd.String("query", "", "search query")
 		limit := messagesCmd.Int("limit", 20, "limit")
 		page := messagesCmd.Int("page", 0, "page")
+		msgType := messagesCmd.String("type", "", "message type: order, invoice, product, or a media type such as image")
+		messageID := messagesCmd.String("id", "", "message ID (location-track)")
+		format := messagesCmd.String("format", "json", "location-track output format: json or geojson")
+		outputPath := messagesCmd.String("output", "", "write the location-track export to this file")
 		// Parse from args[2:] to skip subcommand ("list"/"search") —
 		// Go's flag parser stops at the first non-flag argument.
 		if len(args) > 2 {
-			messagesCmd.Parse(args[2:])
+			parseFlags(messagesCmd, args[2:])
 		}
 
 		switch subcommand {
@@ -182,19 +505,42 @@ func main() {
 			if *query == "" {
 				exitJSON("messages search requires --query")
 			}
-			result = app.ListMessages(nil, query, *limit, *page)
+			result = app.ListMessages(nil, query, optionalStr(*msgType), *limit, *page)
 		case "list":
-			result = app.ListMessages(optionalStr(*chatJID), nil, *limit, *page)
+			result = app.ListMessages(optionalStr(*chatJID), nil, optionalStr(*msgType), *limit, *page)
+		case "location-track":
+			if *messageID == "" {
+				exitJSON("messages location-track requires --id")
+			}
+			result = app.LocationTrack(*messageID, optionalStr(*chatJID), *format, *outputPath)
 		}
 
 	case "contacts":
-		requireSubcommand(args, "contacts", []string{"search"})
-		contactsCmd := flag.NewFlagSet("contacts", flag.ExitOnError)
+		subcommand := requireSubcommand(args, "contacts", []string{"search", "sync-external"})
+		contactsCmd := newFlagSet("contacts")
 		query := contactsCmd.String("query", "", "search query")
+		davURL := contactsCmd.String("carddav-url", "", "CardDAV address book URL")
+		davUser := contactsCmd.String("user", "", "CardDAV username")
+		davPassword := contactsCmd.String("password", "", "CardDAV password (or WHATSAPP_CLI_CARDDAV_PASSWORD)")
+		davToken := contactsCmd.String("token", "", "Bearer token instead of username/password")
+		interval := contactsCmd.Duration("interval", 0, "repeat the sync at this interval until interrupted")
 		// Parse from args[2:] to skip subcommand ("search") —
 		// Go's flag parser stops at the first non-flag argument.
 		if len(args) > 2 {
-			contactsCmd.Parse(args[2:])
+			parseFlags(contactsCmd, args[2:])
+		}
+
+		if subcommand == "sync-external" {
+			result = app.SyncExternalContacts(ctx, commands.ExternalContactsOptions{
+				CardDAV: config.CardDAVConfig{
+					URL:      *davURL,
+					Username: *davUser,
+					Password: *davPassword,
+					Token:    *davToken,
+				},
+				Interval: *interval,
+			})
+			break
 		}
 
 		if *query == "" {
@@ -203,26 +549,85 @@ func main() {
 		result = app.SearchContacts(*query)
 
 	case "chats":
-		requireSubcommand(args, "chats", []string{"list"})
-		chatsCmd := flag.NewFlagSet("chats", flag.ExitOnError)
+		subcommand := requireSubcommand(args, "chats", []string{"list", "retention", "media-policy", "mark-read", "snapshot"})
+		if subcommand == "retention" {
+			result = runChatRetention(app, args)
+			break
+		}
+		if subcommand == "media-policy" {
+			result = runChatMediaPolicy(app, args)
+			break
+		}
+		if subcommand == "mark-read" {
+			result = runMarkRead(ctx, app, args)
+			break
+		}
+		if subcommand == "snapshot" {
+			snapshotCmd := newFlagSet("chats snapshot")
+			chatJID := snapshotCmd.String("chat", "", "chat JID")
+			asOf := snapshotCmd.String("as-of", "", "point in time (date or timestamp)")
+			parseFlags(snapshotCmd, args[2:])
+			if *chatJID == "" || *asOf == "" {
+				exitJSON("chats snapshot requires --chat and --as-of")
+			}
+			result = app.ChatSnapshot(*chatJID, *asOf)
+			break
+		}
+		chatsCmd := newFlagSet("chats")
 		query := chatsCmd.String("query", "", "search query")
 		limit := chatsCmd.Int("limit", 20, "limit")
 		page := chatsCmd.Int("page", 0, "page")
+		includeDeleted := chatsCmd.Bool("include-deleted", false, "also list chats deleted on the phone")
 		// Parse from args[2:] to skip subcommand ("list") —
 		// Go's flag parser stops at the first non-flag argument.
 		if len(args) > 2 {
-			chatsCmd.Parse(args[2:])
+			parseFlags(chatsCmd, args[2:])
 		}
 
-		result = app.ListChats(optionalStr(*query), *limit, *page)
+		result = app.ListChats(optionalStr(*query), *limit, *page, *includeDeleted)
+
+	case "chat":
+		// Accept the name before or after the flags.
+		chatCmd := newFlagSet("chat")
+		history := chatCmd.Int("history", 20, "number of recent messages to show")
+		noReadReceipt := chatCmd.Bool("no-read-receipt-request", false, "do not mark the chat as read before sending")
+		var query string
+		if len(args) > 1 && !strings.HasPrefix(args[1], "-") {
+			query = args[1]
+			parseFlags(chatCmd, args[2:])
+		} else {
+			parseFlags(chatCmd, args[1:])
+			query = chatCmd.Arg(0)
+		}
+		if query == "" {
+			exitJSON("chat requires a contact name, phone number or JID")
+		}
+		result = app.Chat(ctx, query, os.Stdin, output.Stderr, commands.ChatOptions{
+			History: *history,
+			Send:    commands.SendOptions{NoReadReceipt: *noReadReceipt},
+		})
 
 	case "send":
-		sendCmd := flag.NewFlagSet("send", flag.ExitOnError)
+		if len(args) > 1 && args[1] == "interactive" {
+			interactiveCmd := newFlagSet("send interactive")
+			to := interactiveCmd.String("to", "", "recipient")
+			spec := interactiveCmd.String("spec", "", "JSON file describing the buttons or list message")
+			noReadReceipt := interactiveCmd.Bool("no-read-receipt-request", false, "do not mark the chat as read before sending")
+			parseFlags(interactiveCmd, args[2:])
+			if *to == "" || *spec == "" {
+				exitJSON("send interactive requires --to and --spec")
+			}
+			result = app.SendInteractive(ctx, *to, *spec, commands.SendOptions{NoReadReceipt: *noReadReceipt})
+			break
+		}
+		sendCmd := newFlagSet("send")
 		to := sendCmd.String("to", "", "recipient")
 		message := sendCmd.String("message", "", "message text")
 		image := sendCmd.String("image", "", "image file path")
 		caption := sendCmd.String("caption", "", "image caption")
-		sendCmd.Parse(args[1:])
+		noReadReceipt := sendCmd.Bool("no-read-receipt-request", false, "do not mark the chat as read before sending")
+		parseFlags(sendCmd, args[1:])
+		sendOpts := commands.SendOptions{NoReadReceipt: *noReadReceipt}
 
 		if *to == "" {
 			exitJSON(`--to is required`)
@@ -231,30 +636,107 @@ func main() {
 			exitJSON(`--message and --image are mutually exclusive`)
 		}
 		if *image != "" {
-			result = app.SendImage(ctx, *to, *image, *caption)
+			result = app.SendImage(ctx, *to, *image, *caption, sendOpts)
 		} else if *message != "" {
-			result = app.SendMessage(ctx, *to, *message)
+			result = app.SendMessage(ctx, *to, *message, sendOpts)
 		} else {
 			exitJSON(`--message or --image required`)
 		}
 
+	case "outbound":
+		requireSubcommand(args, "outbound", []string{"list"})
+		outboundCmd := newFlagSet("outbound list")
+		failed := outboundCmd.Bool("failed", false, "only sends that failed")
+		status := outboundCmd.String("status", "", "filter by status (queued, sent, delivered, read, failed)")
+		limit := outboundCmd.Int("limit", 50, "limit")
+		parseFlags(outboundCmd, args[2:])
+
+		if *failed {
+			*status = "failed"
+		}
+		result = app.ListOutbound(*status, *limit)
+
+	case "stats":
+		requireSubcommand(args, "stats", []string{"delivery"})
+		statsCmd := newFlagSet("stats delivery")
+		chatJID := statsCmd.String("chat", "", "only messages sent to this chat")
+		since := statsCmd.String("since", "", "only messages sent within this window (e.g. 7d, 24h)")
+		byChat := statsCmd.Bool("by-chat", false, "add a per-chat breakdown")
+		parseFlags(statsCmd, args[2:])
+		result = app.DeliveryStats(commands.DeliveryStatsOptions{ChatJID: *chatJID, Since: *since, ByChat: *byChat})
+
+	case "export":
+		sub := requireSubcommand(args, "export", []string{"locations", "matrix"})
+		if sub == "matrix" {
+			matrixCmd := newFlagSet("export matrix")
+			chatJID := matrixCmd.String("chat", "", "chat JID (default: every chat)")
+			userID := matrixCmd.String("user", "", "your Matrix ID, e.g. @me:example.org")
+			server := matrixCmd.String("server", "", "homeserver of the bridge puppets (default: from --user)")
+			outputPath := matrixCmd.String("output", "", "output file, or directory when exporting every chat")
+			parseFlags(matrixCmd, args[2:])
+			if *userID == "" {
+				exitJSON("export matrix requires --user")
+			}
+			result = app.ExportMatrix(commands.MatrixExportOptions{ChatJID: *chatJID, UserID: *userID, Server: *server, OutputPath: *outputPath})
+			break
+		}
+		exportCmd := newFlagSet("export locations")
+		chatJID := exportCmd.String("chat", "", "chat JID")
+		format := exportCmd.String("format", "geojson", "geojson or kml")
+		outputPath := exportCmd.String("output", "", "write the export to this file (required for kml)")
+		parseFlags(exportCmd, args[2:])
+		if *chatJID == "" {
+			exitJSON("export locations requires --chat")
+		}
+		result = app.ExportLocations(*chatJID, *format, *outputPath)
+
+	case "import":
+		requireSubcommand(args, "import", []string{"backup"})
+		importCmd := newFlagSet("import backup")
+		file := importCmd.String("file", "", "msgstore.db(.crypt15) or ChatStorage.sqlite")
+		key := importCmd.String("key", "", "crypt15 key file, or the 64-digit key itself")
+		parseFlags(importCmd, args[2:])
+		if *file == "" {
+			exitJSON("import backup requires --file")
+		}
+		result = app.ImportBackup(ctx, *file, *key)
+
+	case "store":
+		requireSubcommand(args, "store", []string{"reprocess", "migrate"})
+		result = app.ReprocessStore(ctx)
+
 	case "media":
 		requireSubcommand(args, "media", []string{"download"})
-		downCmd := flag.NewFlagSet("media download", flag.ExitOnError)
+		downCmd := newFlagSet("media download")
 		messageID := downCmd.String("message-id", "", "message identifier")
 		chatJID := downCmd.String("chat", "", "chat JID (optional)")
 		outputPath := downCmd.String("output", "", "output file or directory")
-		downCmd.Parse(args[2:])
+		parseFlags(downCmd, args[2:])
 
 		if *messageID == "" {
 			exitJSON("--message-id required")
 		}
 		result = app.DownloadMedia(ctx, *messageID, optionalStr(*chatJID), *outputPath)
 
+	case "open":
+		// Accept the URI before or after the flags.
+		openCmd := newFlagSet("open")
+		contextSize := openCmd.Int("context", 0, "messages to show on each side")
+		var messageURI string
+		if len(args) > 1 && !strings.HasPrefix(args[1], "-") {
+			messageURI = args[1]
+			parseFlags(openCmd, args[2:])
+		} else {
+			parseFlags(openCmd, args[1:])
+			messageURI = openCmd.Arg(0)
+		}
+		if messageURI == "" {
+			exitJSON("open requires a whatsapp-cli:// message URI")
+		}
+		result = app.Open(messageURI, *contextSize)
+
 	default:
-		fmt.Fprintf(os.Stderr, `{"success":false,"data":null,"error":"Unknown command: %s"}
-`, command)
-		os.Exit(1)
+		exitJSON(fmt.Sprintf("Unknown command: %s", command))
 	}
 
 	fmt.Println(result)
@@ -2,6 +2,8 @@ package main
 
 import (
 	"flag"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -155,3 +157,64 @@ func TestSubcommandArgSkipping(t *testing.T) {
 		})
 	}
 }
+
+// TestExtractGlobalFlags verifies global flags are accepted anywhere and
+// removed before command parsing.
+func TestExtractGlobalFlags(t *testing.T) {
+	opts, rest := extractGlobalFlags([]string{"--quiet", "chats", "list", "--store=/tmp/wa", "--no-emoji", "--limit", "5"})
+	require.Equal(t, globalOptions{storeDir: "/tmp/wa", quiet: true, noEmoji: true}, opts)
+	require.Equal(t, []string{"chats", "list", "--limit", "5"}, rest)
+
+	opts, rest = extractGlobalFlags([]string{"messages", "list", "--consistent"})
+	require.Equal(t, globalOptions{consistent: true}, opts)
+	require.Equal(t, []string{"messages", "list"}, rest)
+
+	opts, rest = extractGlobalFlags([]string{"sync"})
+	require.Equal(t, globalOptions{}, opts)
+	require.Equal(t, []string{"sync"}, rest)
+}
+
+// TestIsLongRunning verifies one-shot external contact syncs stay bound by
+// the command timeout while periodic ones run until interrupted.
+func TestIsLongRunning(t *testing.T) {
+	require.True(t, isLongRunning([]string{"sync"}))
+	require.False(t, isLongRunning([]string{"contacts", "sync-external", "--carddav-url", "https://dav"}))
+	require.True(t, isLongRunning([]string{"contacts", "sync-external", "--interval", "1h"}))
+	require.True(t, isLongRunning([]string{"contacts", "sync-external", "--interval=1h"}))
+	require.False(t, isLongRunning([]string{"contacts", "list"}))
+}
+
+// TestResolveStoreDir verifies --store wins, the XDG directory is the
+// default, and a legacy ./store keeps being used until it is migrated.
+func TestResolveStoreDir(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Setenv("WHATSAPP_CLI_STORE", "")
+	t.Chdir(t.TempDir())
+	xdgStore := filepath.Join(dataHome, "whatsapp-cli")
+
+	dir, err := resolveStoreDir("/tmp/wa")
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/wa", dir)
+
+	dir, err = resolveStoreDir("")
+	require.NoError(t, err)
+	require.Equal(t, xdgStore, dir)
+
+	require.NoError(t, os.MkdirAll("store", 0700))
+	require.NoError(t, os.WriteFile(filepath.Join("store", "whatsapp.db"), nil, 0600))
+	dir, err = resolveStoreDir("")
+	require.NoError(t, err)
+	require.Equal(t, "store", dir, "legacy store is used until migrated")
+
+	require.NoError(t, os.MkdirAll(xdgStore, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(xdgStore, "messages.db"), nil, 0600))
+	dir, err = resolveStoreDir("")
+	require.NoError(t, err)
+	require.Equal(t, xdgStore, dir, "an existing default store wins")
+
+	t.Setenv("WHATSAPP_CLI_STORE", "/srv/wa")
+	dir, err = resolveStoreDir("")
+	require.NoError(t, err)
+	require.Equal(t, "/srv/wa", dir)
+}